@@ -0,0 +1,443 @@
+package bitmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parser for .bdf bitmap fonts
+
+// see https://www.x.org/docs/BDF/bdf.pdf
+// and freetype/src/bdf/bdflib.c
+
+const bdfHeader = "STARTFONT"
+
+// the bitmap format used for BDF fonts:
+// most significant bit and byte first, rows padded to one byte
+const bdfBitmapFormat = byteMask | bitMask
+
+type bdfGlyph struct {
+	name     string
+	encoding int32 // -1 for unencoded glyphs
+	sWidth   uint32
+	metric   metric
+	bitmap   []byte // rows padded to one byte
+}
+
+type bdfParser struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// next returns the next non empty, non comment line, split in fields
+func (pr *bdfParser) next() ([]string, error) {
+	for pr.scanner.Scan() {
+		pr.line++
+		line := strings.TrimSpace(pr.scanner.Text())
+		if line == "" || strings.HasPrefix(line, "COMMENT") {
+			continue
+		}
+		return strings.Fields(line), nil
+	}
+	if err := pr.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("invalid BDF file (EOF)")
+}
+
+func (pr *bdfParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid BDF file (line %d): %s", pr.line, fmt.Sprintf(format, args...))
+}
+
+// ints parses all the fields after the keyword
+func (pr *bdfParser) ints(fields []string, expected int) ([]int32, error) {
+	if len(fields) < expected+1 {
+		return nil, pr.errorf("expected %d values for %s", expected, fields[0])
+	}
+	out := make([]int32, expected)
+	for i := range out {
+		v, err := strconv.ParseInt(fields[i+1], 10, 32)
+		if err != nil {
+			return nil, pr.errorf("invalid value for %s: %s", fields[0], err)
+		}
+		out[i] = int32(v)
+	}
+	return out, nil
+}
+
+// parseBDFProperty returns an Int for numbers and
+// an Atom for quoted strings (or other invalid values)
+func parseBDFProperty(value string) Property {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return Atom(strings.ReplaceAll(value[1:len(value)-1], `""`, `"`))
+	}
+	if v, err := strconv.ParseInt(value, 10, 32); err == nil {
+		return Int(v)
+	}
+	return Atom(value)
+}
+
+func parseBDF(data []byte) (*Font, error) {
+	pr := bdfParser{scanner: bufio.NewScanner(bytes.NewReader(data))}
+	pr.scanner.Buffer(nil, 1<<20)
+
+	fields, err := pr.next()
+	if err != nil {
+		return nil, err
+	}
+	if fields[0] != bdfHeader {
+		return nil, errors.New("not a BDF file")
+	}
+
+	var (
+		out         Font
+		fontName    string
+		size        []int32 // point size, x and y resolutions
+		boundingBox []int32
+		glyphs      []bdfGlyph
+	)
+	out.properties = make(propertiesTable)
+
+header:
+	for {
+		fields, err = pr.next()
+		if err != nil {
+			return nil, err
+		}
+		switch fields[0] {
+		case "FONT":
+			if len(fields) < 2 {
+				return nil, pr.errorf("missing font name")
+			}
+			fontName = strings.Join(fields[1:], " ")
+		case "SIZE":
+			size, err = pr.ints(fields, 3)
+		case "FONTBOUNDINGBOX":
+			boundingBox, err = pr.ints(fields, 4)
+		case "STARTPROPERTIES":
+			err = pr.properties(out.properties)
+		case "CHARS":
+			var count []int32
+			count, err = pr.ints(fields, 1)
+			if err != nil {
+				return nil, err
+			}
+			if count[0] < 0 || count[0] > nbMetricsMax {
+				return nil, fmt.Errorf("number of glyphs (%d) exceeds implementation limit (%d)",
+					count[0], nbMetricsMax)
+			}
+			glyphs = make([]bdfGlyph, 0, count[0])
+			break header
+		case "ENDFONT":
+			return nil, pr.errorf("missing CHARS")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		fields, err = pr.next()
+		if err != nil {
+			return nil, err
+		}
+		if fields[0] == "ENDFONT" {
+			break
+		}
+		if fields[0] != "STARTCHAR" {
+			return nil, pr.errorf("expected STARTCHAR, got %s", fields[0])
+		}
+		if len(glyphs) >= nbMetricsMax {
+			return nil, fmt.Errorf("number of glyphs exceeds implementation limit (%d)", nbMetricsMax)
+		}
+		glyph, err := pr.glyph(strings.Join(fields[1:], " "))
+		if err != nil {
+			return nil, err
+		}
+		glyphs = append(glyphs, glyph)
+	}
+
+	// the font name and size are also exposed as properties
+	if _, has := out.properties["FONT"]; !has && fontName != "" {
+		out.properties["FONT"] = Atom(fontName)
+	}
+	if size != nil {
+		if _, has := out.properties["POINT_SIZE"]; !has {
+			out.properties["POINT_SIZE"] = Int(size[0] * 10)
+		}
+		if _, has := out.properties["RESOLUTION_X"]; !has {
+			out.properties["RESOLUTION_X"] = Int(size[1])
+		}
+		if _, has := out.properties["RESOLUTION_Y"]; !has {
+			out.properties["RESOLUTION_Y"] = Int(size[2])
+		}
+	}
+
+	out.metrics = make(metricsTable, len(glyphs))
+	out.scalableWidths = make(scalableWidthsTable, len(glyphs))
+	out.names = make(namesTable, len(glyphs))
+	out.bitmap = bitmapTable{offsets: make([]uint32, len(glyphs)), format: bdfBitmapFormat}
+	for i, glyph := range glyphs {
+		out.metrics[i] = glyph.metric
+		out.scalableWidths[i] = glyph.sWidth
+		out.names[i] = glyph.name
+		out.bitmap.offsets[i] = uint32(len(out.bitmap.data))
+		out.bitmap.data = append(out.bitmap.data, glyph.bitmap...)
+	}
+
+	out.cmap = buildEncodingTable(glyphs, out.properties)
+
+	var fontAscent, fontDescent int32
+	if boundingBox != nil {
+		fontAscent, fontDescent = boundingBox[1]+boundingBox[3], -boundingBox[3]
+	}
+	if v, ok := out.properties["FONT_ASCENT"].(Int); ok {
+		fontAscent = int32(v)
+	}
+	if v, ok := out.properties["FONT_DESCENT"].(Int); ok {
+		fontDescent = int32(v)
+	}
+	out.accelerator = synthesizeAccelerator(out.metrics, fontAscent, fontDescent)
+
+	err = out.concludeParsing(out.cmap)
+	return &out, err
+}
+
+// properties reads until ENDPROPERTIES
+func (pr *bdfParser) properties(dst propertiesTable) error {
+	for {
+		if !pr.scanner.Scan() {
+			return pr.errorf("missing ENDPROPERTIES")
+		}
+		pr.line++
+		line := strings.TrimSpace(pr.scanner.Text())
+		if line == "" || strings.HasPrefix(line, "COMMENT") {
+			continue
+		}
+		if line == "ENDPROPERTIES" {
+			return nil
+		}
+		if len(dst) >= nbPropertiesMax {
+			return fmt.Errorf("number of properties exceeds implementation limit (%d)", nbPropertiesMax)
+		}
+		name, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i != -1 {
+			name, value = line[:i], strings.TrimSpace(line[i:])
+		}
+		dst[name] = parseBDFProperty(value)
+	}
+}
+
+// glyph reads until ENDCHAR
+func (pr *bdfParser) glyph(name string) (bdfGlyph, error) {
+	out := bdfGlyph{name: name, encoding: -1}
+	var (
+		bbx      []int32
+		hasWidth bool
+	)
+	for {
+		fields, err := pr.next()
+		if err != nil {
+			return out, err
+		}
+		switch fields[0] {
+		case "ENCODING":
+			var enc []int32
+			enc, err = pr.ints(fields, 1)
+			// non standard encodings, given as "ENCODING -1 code",
+			// are considered as unencoded
+			if err == nil {
+				out.encoding = enc[0]
+			}
+		case "SWIDTH":
+			var sw []int32
+			sw, err = pr.ints(fields, 1)
+			if err == nil {
+				out.sWidth = uint32(sw[0])
+			}
+		case "DWIDTH":
+			var dw []int32
+			dw, err = pr.ints(fields, 1)
+			if err == nil {
+				out.metric.characterWidth = int16(dw[0])
+				hasWidth = true
+			}
+		case "BBX":
+			bbx, err = pr.ints(fields, 4)
+		case "BITMAP":
+			if bbx == nil {
+				return out, pr.errorf("missing BBX for glyph %s", name)
+			}
+			out.bitmap, err = pr.bitmap(int(bbx[0]), int(bbx[1]))
+			if err != nil {
+				return out, err
+			}
+		case "ENDCHAR":
+			if bbx == nil {
+				return out, pr.errorf("missing BBX for glyph %s", name)
+			}
+			if out.bitmap == nil { // no BITMAP section
+				out.bitmap = make([]byte, int(bbx[1])*((int(bbx[0])+7)/8))
+			}
+			width, height, xOff, yOff := bbx[0], bbx[1], bbx[2], bbx[3]
+			out.metric.leftSideBearing = int16(xOff)
+			out.metric.rightSideBearing = int16(xOff + width)
+			out.metric.characterAscent = int16(yOff + height)
+			out.metric.characterDescent = int16(-yOff)
+			if !hasWidth {
+				out.metric.characterWidth = int16(width)
+			}
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
+
+// bitmap reads `height` hexadecimal rows, normalizing
+// their length to (width+7)/8 bytes
+func (pr *bdfParser) bitmap(width, height int) ([]byte, error) {
+	if width < 0 || height < 0 || width > 0xFFFF || height > 0xFFFF {
+		return nil, pr.errorf("invalid glyph size %dx%d", width, height)
+	}
+	rowLength := (width + 7) / 8
+	out := make([]byte, rowLength*height)
+	for y := 0; y < height; y++ {
+		fields, err := pr.next()
+		if err != nil {
+			return nil, err
+		}
+		if fields[0] == "ENDCHAR" {
+			return nil, pr.errorf("not enough bitmap rows")
+		}
+		row := fields[0]
+		if len(row)%2 == 1 {
+			row += "0"
+		}
+		decoded, err := hex.DecodeString(row)
+		if err != nil {
+			return nil, pr.errorf("invalid bitmap row: %s", err)
+		}
+		copy(out[y*rowLength:(y+1)*rowLength], decoded)
+	}
+	return out, nil
+}
+
+// buildEncodingTable returns the table mapping the encoded glyphs,
+// whose codes are restricted to 2 bytes
+func buildEncodingTable(glyphs []bdfGlyph, props propertiesTable) encodingTable {
+	var out encodingTable
+	out.minByte, out.minChar = 0xFF, 0xFF
+	hasGlyph := false
+	for _, glyph := range glyphs {
+		if glyph.encoding < 0 || glyph.encoding > 0xFFFF {
+			continue
+		}
+		hasGlyph = true
+		b1, b2 := byte(glyph.encoding>>8), byte(glyph.encoding)
+		if b1 < out.minByte {
+			out.minByte = b1
+		}
+		if b1 > out.maxByte {
+			out.maxByte = b1
+		}
+		if b2 < out.minChar {
+			out.minChar = b2
+		}
+		if b2 > out.maxChar {
+			out.maxChar = b2
+		}
+	}
+	if !hasGlyph {
+		out.minByte, out.minChar = 0, 0
+	}
+
+	L := int(out.maxChar-out.minChar) + 1
+	out.values = make([]gid, int(out.maxByte-out.minByte+1)*L)
+	for i := range out.values {
+		out.values[i] = 0xFFFF
+	}
+	for i, glyph := range glyphs {
+		if glyph.encoding < 0 || glyph.encoding > 0xFFFF {
+			continue
+		}
+		b1, b2 := byte(glyph.encoding>>8), byte(glyph.encoding)
+		index := int(b1-out.minByte)*L + int(b2-out.minChar)
+		if out.values[index] == 0xFFFF { // keep the first glyph
+			out.values[index] = gid(i)
+		}
+	}
+
+	if v, ok := props["DEFAULT_CHAR"].(Int); ok {
+		out.defaultChar = gid(v)
+	}
+	return out
+}
+
+// synthesizeAccelerator computes the accelerator table from the
+// glyphs metrics, as done by bdftopcf
+func synthesizeAccelerator(metrics metricsTable, fontAscent, fontDescent int32) *acceleratorTable {
+	out := acceleratorTable{
+		fontAscent:  fontAscent,
+		fontDescent: fontDescent,
+	}
+	if len(metrics) == 0 {
+		return &out
+	}
+
+	out.minbounds, out.maxbounds = metrics[0], metrics[0]
+	out.maxOverlap = int32(metrics[0].rightSideBearing - metrics[0].characterWidth)
+	out.constantMetrics, out.inkInside = true, true
+	for _, m := range metrics {
+		if m != metrics[0] {
+			out.constantMetrics = false
+		}
+		if overlap := int32(m.rightSideBearing - m.characterWidth); overlap > out.maxOverlap {
+			out.maxOverlap = overlap
+		}
+		if m.leftSideBearing < 0 || m.rightSideBearing > m.characterWidth ||
+			int32(m.characterAscent) > fontAscent || int32(m.characterDescent) > fontDescent {
+			out.inkInside = false
+		}
+		out.minbounds.leftSideBearing = min16(out.minbounds.leftSideBearing, m.leftSideBearing)
+		out.minbounds.rightSideBearing = min16(out.minbounds.rightSideBearing, m.rightSideBearing)
+		out.minbounds.characterWidth = min16(out.minbounds.characterWidth, m.characterWidth)
+		out.minbounds.characterAscent = min16(out.minbounds.characterAscent, m.characterAscent)
+		out.minbounds.characterDescent = min16(out.minbounds.characterDescent, m.characterDescent)
+		out.maxbounds.leftSideBearing = max16(out.maxbounds.leftSideBearing, m.leftSideBearing)
+		out.maxbounds.rightSideBearing = max16(out.maxbounds.rightSideBearing, m.rightSideBearing)
+		out.maxbounds.characterWidth = max16(out.maxbounds.characterWidth, m.characterWidth)
+		out.maxbounds.characterAscent = max16(out.maxbounds.characterAscent, m.characterAscent)
+		out.maxbounds.characterDescent = max16(out.maxbounds.characterDescent, m.characterDescent)
+	}
+	out.minbounds.characterAttributes, out.maxbounds.characterAttributes = 0, 0
+
+	out.noOverlap = out.maxOverlap <= int32(out.minbounds.leftSideBearing)
+	out.constantWidth = out.minbounds.characterWidth == out.maxbounds.characterWidth
+	first := metrics[0]
+	out.terminalFont = out.constantMetrics && first.leftSideBearing == 0 &&
+		first.rightSideBearing == first.characterWidth &&
+		int32(first.characterAscent) == fontAscent && int32(first.characterDescent) == fontDescent
+	out.inkMinbounds, out.inkMaxbounds = out.minbounds, out.maxbounds
+	return &out
+}
+
+func min16(a, b int16) int16 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max16(a, b int16) int16 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,227 @@
+package bitmap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/bitmap"
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+const sampleBDF = `STARTFONT 2.1
+COMMENT a minimal font
+FONT -Misc-Sample-Medium-R-Normal--8-80-75-75-C-40-ISO10646-1
+SIZE 8 75 75
+FONTBOUNDINGBOX 4 8 0 -2
+STARTPROPERTIES 6
+FAMILY_NAME "Sample"
+FOUNDRY "Misc"
+WEIGHT_NAME "Bold"
+CHARSET_REGISTRY "ISO10646"
+CHARSET_ENCODING "1"
+COPYRIGHT "a ""quoted"" notice"
+ENDPROPERTIES
+CHARS 3
+STARTCHAR space
+ENCODING 32
+SWIDTH 500 0
+DWIDTH 4 0
+BBX 4 8 0 -2
+BITMAP
+00
+00
+00
+00
+00
+00
+00
+00
+ENDCHAR
+STARTCHAR A
+ENCODING 65
+SWIDTH 500 0
+DWIDTH 4 0
+BBX 3 5 0 0
+BITMAP
+40
+A0
+E0
+A0
+A0
+ENDCHAR
+STARTCHAR unencoded
+ENCODING -1
+SWIDTH 500 0
+DWIDTH 4 0
+BBX 2 2 1 -1
+BITMAP
+C0
+C0
+ENDCHAR
+ENDFONT
+`
+
+func TestParseBDF(t *testing.T) {
+	font, err := Parse(bytes.NewReader([]byte(sampleBDF)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(font.metrics) != 3 {
+		t.Fatalf("expected 3 glyphs, got %d", len(font.metrics))
+	}
+	if exp := (metric{leftSideBearing: 0, rightSideBearing: 3, characterWidth: 4, characterAscent: 5}); font.metrics[1] != exp {
+		t.Fatalf("expected %v, got %v", exp, font.metrics[1])
+	}
+	if exp := (metric{leftSideBearing: 1, rightSideBearing: 3, characterWidth: 4, characterAscent: 1, characterDescent: 1}); font.metrics[2] != exp {
+		t.Fatalf("expected %v, got %v", exp, font.metrics[2])
+	}
+
+	if p := font.GetBDFProperty("COPYRIGHT"); p != Atom(`a "quoted" notice`) {
+		t.Fatalf("unexpected property %v", p)
+	}
+	if p := font.GetBDFProperty("POINT_SIZE"); p != Int(80) {
+		t.Fatalf("unexpected property %v", p)
+	}
+	if p := font.GetBDFProperty("FONT"); p != Atom("-Misc-Sample-Medium-R-Normal--8-80-75-75-C-40-ISO10646-1") {
+		t.Fatalf("unexpected property %v", p)
+	}
+
+	if _, enc := font.Cmap(); enc != fonts.EncUnicode {
+		t.Fatal("expected Unicode cmap")
+	}
+	if gid, ok := font.NominalGlyph('A'); !ok || gid != 1 {
+		t.Fatalf("unexpected glyph %d", gid)
+	}
+	if _, ok := font.NominalGlyph('B'); ok {
+		t.Fatal("unexpected glyph for 'B'")
+	}
+	if name := font.GlyphName(2); name != "unencoded" {
+		t.Fatalf("unexpected name %s", name)
+	}
+
+	data := font.GlyphData(1, 0, 0).(fonts.GlyphBitmap)
+	if exp := []byte{0x40, 0xA0, 0xE0, 0xA0, 0xA0}; !bytes.Equal(data.Data, exp) {
+		t.Fatalf("expected %v, got %v", exp, data.Data)
+	}
+
+	acc := font.accelerator
+	if acc.fontAscent != 6 || acc.fontDescent != 2 {
+		t.Fatalf("unexpected accelerator %v", acc)
+	}
+	if !acc.constantWidth || acc.constantMetrics || !acc.inkInside {
+		t.Fatalf("unexpected accelerator %v", acc)
+	}
+
+	summary, _ := font.LoadSummary()
+	if !summary.IsBold || summary.Familly != "Misc Sample" {
+		t.Fatalf("unexpected summary %v", summary)
+	}
+
+	if _, err = Parse(bytes.NewReader([]byte("STARTFONT 2.1\nCHARS 1\nSTARTCHAR A\nENDFONT\n"))); err == nil {
+		t.Fatal("expected error for invalid file")
+	}
+}
+
+func TestScanBDF(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(sampleBDF))
+	w.Close()
+
+	fs, err := Load(bytes.NewReader(buf.Bytes()))
+	if err != nil || len(fs) != 1 {
+		t.Fatal(err)
+	}
+
+	l, err := ScanFont(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if family := l[0].Family(); family != "Misc Sample" {
+		t.Fatalf("unexpected family %s", family)
+	}
+	cmap, err := l[0].LoadCmap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gid, _ := cmap.Lookup('A'); gid != 1 {
+		t.Fatalf("unexpected glyph %d", gid)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, file := range files {
+		fi, err := testdata.Files.ReadFile(file)
+		if err != nil {
+			t.Fatal("can't read test file", err)
+		}
+		font, err := Parse(bytes.NewReader(fi))
+		if err != nil {
+			t.Fatal(file, err)
+		}
+
+		// PCF -> BDF
+		var bdf bytes.Buffer
+		if err = font.WriteBDF(&bdf); err != nil {
+			t.Fatal(err)
+		}
+		fromBDF, err := Parse(bytes.NewReader(bdf.Bytes()))
+		if err != nil {
+			t.Fatal(file, err)
+		}
+
+		if !reflect.DeepEqual(font.metrics, fromBDF.metrics) {
+			t.Fatalf("%s: inconsistent metrics", file)
+		}
+		for name, prop := range font.properties {
+			if fromBDF.properties[name] != prop {
+				t.Fatalf("%s: property %s: expected %v, got %v", file, name, prop, fromBDF.properties[name])
+			}
+		}
+		for i := range font.metrics {
+			if font.GlyphName(fonts.GID(i)) != "" && font.GlyphName(fonts.GID(i)) != fromBDF.GlyphName(fonts.GID(i)) {
+				t.Fatalf("%s: inconsistent glyph name", file)
+			}
+			if exp, got := font.glyphBitmap(gid(i)), fromBDF.glyphBitmap(gid(i)); !bytes.Equal(exp, got) {
+				t.Fatalf("%s: glyph %d: expected bitmap %v, got %v", file, i, exp, got)
+			}
+		}
+		for g, code := range font.glyphCodes() {
+			if code == -1 {
+				continue
+			}
+			if got, _ := fromBDF.NominalGlyph(rune(code)); got != fonts.GID(g) {
+				t.Fatalf("%s: code %d: expected glyph %d, got %d", file, code, g, got)
+			}
+		}
+
+		// BDF -> PCF
+		var pcf bytes.Buffer
+		if err = fromBDF.WritePCF(&pcf); err != nil {
+			t.Fatal(err)
+		}
+		fromPCF, err := Parse(bytes.NewReader(pcf.Bytes()))
+		if err != nil {
+			t.Fatal(file, err)
+		}
+		if !reflect.DeepEqual(fromBDF, fromPCF) {
+			t.Fatalf("%s: inconsistent PCF round trip", file)
+		}
+
+		// PCF -> PCF, keeping the original bitmap format
+		pcf.Reset()
+		if err = font.WritePCF(&pcf); err != nil {
+			t.Fatal(err)
+		}
+		fromPCF, err = Parse(bytes.NewReader(pcf.Bytes()))
+		if err != nil {
+			t.Fatal(file, err)
+		}
+		if !bytes.Equal(font.bitmap.data, fromPCF.bitmap.data) || !reflect.DeepEqual(font.cmap, fromPCF.cmap) {
+			t.Fatalf("%s: inconsistent PCF round trip", file)
+		}
+	}
+}
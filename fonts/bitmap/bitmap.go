@@ -1,5 +1,5 @@
 // Pacakge bitmap provides support for bitmap fonts
-// found in .pcf files.
+// found in .pcf and .bdf files.
 package bitmap
 
 import (
@@ -336,6 +336,10 @@ type fontDescriptor struct {
 	src          io.Reader
 	cmapTocEntry tocEntry // offset relative to the start of `src`
 
+	// for BDF files, which are parsed in one pass,
+	// the cmap is directly available
+	cmap *encodingTable
+
 	properties propertiesTable // required for Family
 }
 
@@ -358,7 +362,23 @@ func readSection(src io.Reader, offset, size uint32) ([]byte, error) {
 // ScanFont lazily parse `file` to extract the information about the font.
 // If no error occurs, the returned slice has always length 1.
 func ScanFont(file fonts.Resource) ([]fonts.FontDescriptor, error) {
-	r, tocEntries, err := newParser(file)
+	src, isBDF, err := openFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if isBDF {
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return nil, err
+		}
+		font, err := parseBDF(data)
+		if err != nil {
+			return nil, err
+		}
+		return []fonts.FontDescriptor{fontDescriptor{properties: font.properties, cmap: &font.cmap}}, nil
+	}
+
+	r, tocEntries, err := newParser(src)
 	if err != nil {
 		return nil, err
 	}
@@ -514,6 +534,13 @@ func stretchFromBFD(value Int) fonts.Stretch {
 }
 
 func (fd fontDescriptor) LoadCmap() (fonts.Cmap, error) {
+	if fd.cmap != nil {
+		if !fd.properties.isCmapUnicode() {
+			return nil, fmt.Errorf("not a Unicode cmap")
+		}
+		return fd.cmap, nil
+	}
+
 	data, err := readSection(fd.src, fd.cmapTocEntry.offset, fd.cmapTocEntry.size)
 	if err != nil {
 		return nil, err
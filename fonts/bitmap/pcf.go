@@ -1,6 +1,7 @@
 package bitmap
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"encoding/binary"
@@ -237,6 +238,7 @@ func (pr *parser) propertiesTable() (propertiesTable, error) {
 type bitmapTable struct {
 	offsets []uint32
 	data    []byte
+	format  uint32 // padding and bit order of the data
 }
 
 func (p *parser) bitmap() (bitmapTable, error) {
@@ -292,7 +294,7 @@ func (p *parser) bitmap() (bitmapTable, error) {
 	data := p.data[p.pos : p.pos+bitmapLength]
 	p.pos += bitmapLength
 
-	return bitmapTable{data: data, offsets: offsets}, nil
+	return bitmapTable{data: data, offsets: offsets, format: format}, nil
 }
 
 // we use int16 even for compressed for simplicity
@@ -608,20 +610,28 @@ func (f *Font) validate() error {
 	return nil
 }
 
-func newParser(file fonts.Resource) (io.Reader, []tocEntry, error) {
+// openFile returns a reader for the (possibly compressed) content of `file`,
+// and reports whether it starts as a BDF file.
+func openFile(file fonts.Resource) (*bufio.Reader, bool, error) {
 	_, err := file.Seek(0, io.SeekStart) // file might have been used before
 	if err != nil {
-		return nil, nil, err
+		return nil, false, err
 	}
 
 	var r io.Reader
-	// pcf file are often compressed so we try gzip
+	// pcf and bdf file are often compressed so we try gzip
 	r, err = gzip.NewReader(file)
 	if err != nil { // not a gzip file: read from the plain file
 		// gzip has read some bytes
 		_, _ = file.Seek(0, io.SeekStart)
 		r = file
 	}
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(len(bdfHeader))
+	return br, string(header) == bdfHeader, nil
+}
+
+func newParser(r io.Reader) (io.Reader, []tocEntry, error) {
 	// check the start of the file before reading all
 	var headerBuf [4]byte
 	if io.ReadFull(r, headerBuf[:]); string(headerBuf[:]) != pcfHeader {
@@ -636,9 +646,22 @@ func newParser(file fonts.Resource) (io.Reader, []tocEntry, error) {
 	return r, toc, nil
 }
 
-// Parse parse a .pcf font file.
+// Parse parse a .pcf or a .bdf font file, which may be
+// gzip compressed.
 func Parse(file fonts.Resource) (*Font, error) {
-	r, tocEntries, err := newParser(file)
+	src, isBDF, err := openFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if isBDF {
+		data, err := ioutil.ReadAll(src)
+		if err != nil {
+			return nil, fmt.Errorf("can't load font file: %s", err)
+		}
+		return parseBDF(data)
+	}
+
+	r, tocEntries, err := newParser(src)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,397 @@
+package bitmap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// writers for .bdf and .pcf files, mainly used for tests
+
+// glyphSize returns the size of the glyph bitmap, in pixels
+func (m metric) glyphSize() (width, height int) {
+	return int(m.rightSideBearing - m.leftSideBearing), int(m.characterAscent + m.characterDescent)
+}
+
+// rowStride returns the number of bytes used by a row of `width` pixels
+// for the given bitmap format
+func rowStride(width int, format uint32) int {
+	pad := 1 << (format & glyphPadMask)
+	return (width + 8*pad - 1) / (8 * pad) * pad
+}
+
+// glyphBitmap returns the bitmap of the glyph, with the most significant
+// bit first and rows padded to one byte.
+func (f *Font) glyphBitmap(g gid) []byte {
+	width, height := f.metrics[g].glyphSize()
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	format := f.bitmap.format
+	stride := rowStride(width, format)
+	scanUnit := 1 << ((format & scanUnitMask) >> 4)
+	rowLength := (width + 7) / 8
+
+	start := int(f.bitmap.offsets[g])
+	out := make([]byte, rowLength*height)
+	row := make([]byte, stride)
+	for y := 0; y < height; y++ {
+		// missing data is treated as blank
+		for i := range row {
+			row[i] = 0
+		}
+		if rowStart := start + y*stride; rowStart < len(f.bitmap.data) {
+			copy(row, f.bitmap.data[rowStart:])
+		}
+		// bytes are swapped in each scan unit when the byte order differs from the bit order
+		if (format&byteMask == 0) != (format&bitMask == 0) && scanUnit > 1 {
+			for i := 0; i+scanUnit <= len(row); i += scanUnit {
+				unit := row[i : i+scanUnit]
+				for a, b := 0, len(unit)-1; a < b; a, b = a+1, b-1 {
+					unit[a], unit[b] = unit[b], unit[a]
+				}
+			}
+		}
+		if format&bitMask == 0 {
+			for i, v := range row {
+				row[i] = bits.Reverse8(v)
+			}
+		}
+		copy(out[y*rowLength:], row[:rowLength])
+	}
+	return out
+}
+
+// glyphCodes returns the first character code of each glyph, or -1
+func (f *Font) glyphCodes() []int32 {
+	out := make([]int32, len(f.metrics))
+	for i := range out {
+		out[i] = -1
+	}
+	enc := &f.cmap
+	L := int(enc.maxChar-enc.minChar) + 1
+	for index, g := range enc.values {
+		if g == 0xFFFF || int(g) >= len(out) || out[g] != -1 {
+			continue
+		}
+		j := index % L
+		i := index / L
+		out[g] = int32(enc.minByte+byte(i))<<8 | int32(enc.minChar) + int32(j)
+	}
+	return out
+}
+
+func (f *Font) sortedPropertyNames() []string {
+	names := make([]string, 0, len(f.properties))
+	for name := range f.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteBDF writes the font in the BDF format.
+// Since a glyph may only have one code in a BDF file,
+// glyphs mapped by several character codes are only
+// written with the first one.
+func (f *Font) WriteBDF(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	fontName, _ := f.properties["FONT"].(Atom)
+	if fontName == "" {
+		fontName = "unknown"
+	}
+	pointSize, _ := f.properties["POINT_SIZE"].(Int)
+	resX, _ := f.properties["RESOLUTION_X"].(Int)
+	resY, _ := f.properties["RESOLUTION_Y"].(Int)
+	if resX == 0 {
+		resX = 75
+	}
+	if resY == 0 {
+		resY = resX
+	}
+	if pointSize == 0 {
+		pointSize = Int(f.accelerator.fontAscent+f.accelerator.fontDescent) * 10
+	}
+
+	fmt.Fprintln(out, "STARTFONT 2.1")
+	fmt.Fprintf(out, "FONT %s\n", fontName)
+	fmt.Fprintf(out, "SIZE %d %d %d\n", (pointSize+5)/10, resX, resY)
+	minB, maxB := f.accelerator.minbounds, f.accelerator.maxbounds
+	fmt.Fprintf(out, "FONTBOUNDINGBOX %d %d %d %d\n", maxB.rightSideBearing-minB.leftSideBearing,
+		maxB.characterAscent+maxB.characterDescent, minB.leftSideBearing, -maxB.characterDescent)
+
+	names := f.sortedPropertyNames()
+	props := make([]string, 0, len(names))
+	for _, name := range names {
+		switch value := f.properties[name].(type) {
+		case Atom:
+			if name == "FONT" { // already written
+				continue
+			}
+			props = append(props, fmt.Sprintf("%s \"%s\"", name, strings.ReplaceAll(string(value), `"`, `""`)))
+		case Int:
+			props = append(props, fmt.Sprintf("%s %d", name, value))
+		}
+	}
+	fmt.Fprintf(out, "STARTPROPERTIES %d\n", len(props))
+	for _, prop := range props {
+		fmt.Fprintln(out, prop)
+	}
+	fmt.Fprintln(out, "ENDPROPERTIES")
+
+	codes := f.glyphCodes()
+	fmt.Fprintf(out, "CHARS %d\n", len(f.metrics))
+	for i, m := range f.metrics {
+		name := f.GlyphName(fonts.GID(i))
+		if name == "" {
+			name = fmt.Sprintf("glyph%d", i)
+		}
+		var sWidth uint32
+		if i < len(f.scalableWidths) {
+			sWidth = f.scalableWidths[i]
+		} else if pointSize != 0 {
+			// SWIDTH = DWIDTH / (points/1000 * resolution/72)
+			sWidth = uint32(int32(m.characterWidth) * 72000 * 10 / (int32(pointSize) * int32(resX)))
+		}
+		width, height := m.glyphSize()
+
+		fmt.Fprintf(out, "STARTCHAR %s\n", name)
+		fmt.Fprintf(out, "ENCODING %d\n", codes[i])
+		fmt.Fprintf(out, "SWIDTH %d 0\n", sWidth)
+		fmt.Fprintf(out, "DWIDTH %d 0\n", m.characterWidth)
+		fmt.Fprintf(out, "BBX %d %d %d %d\n", width, height, m.leftSideBearing, -m.characterDescent)
+		fmt.Fprintln(out, "BITMAP")
+		if data := f.glyphBitmap(gid(i)); len(data) != 0 {
+			rowLength := len(data) / height
+			for y := 0; y < height; y++ {
+				fmt.Fprintln(out, strings.ToUpper(hex.EncodeToString(data[y*rowLength:(y+1)*rowLength])))
+			}
+		}
+		fmt.Fprintln(out, "ENDCHAR")
+	}
+	fmt.Fprintln(out, "ENDFONT")
+
+	return out.Flush()
+}
+
+// pcfWriter serializes tables, always using the big endian
+// byte order and the most significant bit first
+type pcfWriter struct {
+	buf []byte
+}
+
+const pcfWriterFormat = byteMask | bitMask
+
+func appendU32(buf []byte, order binary.ByteOrder, v uint32) []byte {
+	var tmp [4]byte
+	order.PutUint32(tmp[:], v)
+	return append(buf, tmp[:]...)
+}
+
+func (w *pcfWriter) u32(v uint32) { w.buf = appendU32(w.buf, binary.BigEndian, v) }
+
+func (w *pcfWriter) u16(v uint16) { w.buf = append(w.buf, byte(v>>8), byte(v)) }
+
+// format is always little endian
+func (w *pcfWriter) format(format uint32) { w.buf = appendU32(w.buf, binary.LittleEndian, format) }
+
+func (w *pcfWriter) metric(m metric) {
+	w.u16(uint16(m.leftSideBearing))
+	w.u16(uint16(m.rightSideBearing))
+	w.u16(uint16(m.characterWidth))
+	w.u16(uint16(m.characterAscent))
+	w.u16(uint16(m.characterDescent))
+	w.u16(m.characterAttributes)
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (w *pcfWriter) properties(props propertiesTable, names []string) {
+	w.format(pcfWriterFormat)
+	w.u32(uint32(len(names)))
+	var stringData []byte
+	addString := func(s string) uint32 {
+		offset := len(stringData)
+		stringData = append(stringData, s...)
+		stringData = append(stringData, 0)
+		return uint32(offset)
+	}
+	for _, name := range names {
+		w.u32(addString(name))
+		switch value := props[name].(type) {
+		case Atom:
+			w.buf = append(w.buf, 1)
+			w.u32(addString(string(value)))
+		case Int:
+			w.buf = append(w.buf, 0)
+			w.u32(uint32(value))
+		}
+	}
+	if padding := len(names) & 3; padding != 0 {
+		w.buf = append(w.buf, make([]byte, 4-padding)...)
+	}
+	w.u32(uint32(len(stringData)))
+	w.buf = append(w.buf, stringData...)
+}
+
+func (w *pcfWriter) accelerator(acc *acceleratorTable, withInk bool) {
+	format := uint32(pcfWriterFormat)
+	if withInk {
+		format |= accelWInkbounds
+	}
+	w.format(format)
+	w.buf = append(w.buf, boolByte(acc.noOverlap), boolByte(acc.constantMetrics), boolByte(acc.terminalFont),
+		boolByte(acc.constantWidth), boolByte(acc.inkInside), boolByte(acc.inkMetrics), boolByte(acc.drawDirectionRTL), 0)
+	w.u32(uint32(acc.fontAscent))
+	w.u32(uint32(acc.fontDescent))
+	w.u32(uint32(acc.maxOverlap))
+	w.metric(acc.minbounds)
+	w.metric(acc.maxbounds)
+	if withInk {
+		w.metric(acc.inkMinbounds)
+		w.metric(acc.inkMaxbounds)
+	}
+}
+
+func (w *pcfWriter) metrics(metrics metricsTable) {
+	w.format(pcfWriterFormat)
+	w.u32(uint32(len(metrics)))
+	for _, m := range metrics {
+		w.metric(m)
+	}
+}
+
+// bitmaps writes the data in its original format
+func (w *pcfWriter) bitmaps(f *Font) {
+	format := f.bitmap.format & 0xFF
+	w.format(format)
+	order := getOrder(format)
+	w.buf = appendU32(w.buf, order, uint32(len(f.bitmap.offsets)))
+	for _, offset := range f.bitmap.offsets {
+		w.buf = appendU32(w.buf, order, offset)
+	}
+	// sizes of the data for each padding
+	for pad := uint32(0); pad < 4; pad++ {
+		var size int
+		if pad == format&glyphPadMask {
+			size = len(f.bitmap.data)
+		} else {
+			for _, m := range f.metrics {
+				width, height := m.glyphSize()
+				if width > 0 && height > 0 {
+					size += rowStride(width, pad) * height
+				}
+			}
+		}
+		w.buf = appendU32(w.buf, order, uint32(size))
+	}
+	w.buf = append(w.buf, f.bitmap.data...)
+}
+
+func (w *pcfWriter) encodings(enc encodingTable) {
+	w.format(pcfWriterFormat)
+	w.u16(uint16(enc.minChar))
+	w.u16(uint16(enc.maxChar))
+	w.u16(uint16(enc.minByte))
+	w.u16(uint16(enc.maxByte))
+	w.u16(enc.defaultChar)
+	for _, v := range enc.values {
+		w.u16(v)
+	}
+}
+
+func (w *pcfWriter) scalableWidths(widths scalableWidthsTable) {
+	w.format(pcfWriterFormat)
+	w.u32(uint32(len(widths)))
+	for _, v := range widths {
+		w.u32(v)
+	}
+}
+
+func (w *pcfWriter) names(names namesTable) {
+	w.format(pcfWriterFormat)
+	w.u32(uint32(len(names)))
+	var stringData []byte
+	for _, name := range names {
+		w.u32(uint32(len(stringData)))
+		stringData = append(stringData, name...)
+		stringData = append(stringData, 0)
+	}
+	w.u32(uint32(len(stringData)))
+	w.buf = append(w.buf, stringData...)
+}
+
+// WritePCF writes the font in the (uncompressed) PCF format.
+// The bitmaps are written with their original padding and bit order.
+func (f *Font) WritePCF(w io.Writer) error {
+	type table struct {
+		kind uint32
+		data []byte
+	}
+	var tables []table
+	add := func(kind uint32, write func(w *pcfWriter)) {
+		var tw pcfWriter
+		write(&tw)
+		tables = append(tables, table{kind: kind, data: tw.buf})
+	}
+
+	withInk := f.inkMetrics != nil
+	add(properties, func(w *pcfWriter) { w.properties(f.properties, f.sortedPropertyNames()) })
+	add(accelerators, func(w *pcfWriter) { w.accelerator(f.accelerator, withInk) })
+	add(metrics, func(w *pcfWriter) { w.metrics(f.metrics) })
+	add(bitmaps, func(w *pcfWriter) { w.bitmaps(f) })
+	if withInk {
+		add(inkMetrics, func(w *pcfWriter) { w.metrics(f.inkMetrics) })
+	}
+	add(bdfEncodings, func(w *pcfWriter) { w.encodings(f.cmap) })
+	if f.scalableWidths != nil {
+		add(sWidths, func(w *pcfWriter) { w.scalableWidths(f.scalableWidths) })
+	}
+	if f.names != nil {
+		add(glyphNames, func(w *pcfWriter) { w.names(f.names) })
+	}
+	add(bdfAccelerators, func(w *pcfWriter) { w.accelerator(f.accelerator, withInk) })
+
+	// header and table of contents
+	header := []byte(pcfHeader)
+	header = appendU32(header, binary.LittleEndian, uint32(len(tables)))
+	offset := uint32(len(header) + 16*len(tables))
+	for i, t := range tables {
+		// tables are aligned on 4 bytes
+		if padding := len(t.data) & 3; padding != 0 {
+			tables[i].data = append(t.data, make([]byte, 4-padding)...)
+		}
+		var format uint32
+		if len(t.data) >= 4 {
+			format = binary.LittleEndian.Uint32(t.data)
+		}
+		header = appendU32(header, binary.LittleEndian, t.kind)
+		header = appendU32(header, binary.LittleEndian, format)
+		header = appendU32(header, binary.LittleEndian, uint32(len(t.data)))
+		header = appendU32(header, binary.LittleEndian, offset)
+		offset += uint32(len(tables[i].data))
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	for _, t := range tables {
+		if _, err := w.Write(t.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package fonts
+
+import "sort"
+
+// CmapRuneRanger is an optional interface implemented by the cmaps
+// storing their mappings as ranges, which may thus provide
+// their runes without iterating over each of them.
+type CmapRuneRanger interface {
+	// RuneRanges appends to `buf` the inclusive ranges [start, end]
+	// of the runes mapped by the cmap, sorted and not overlapping, and returns it.
+	// A rune is mapped if the Lookup method returns true, but implementations
+	// may ignore the runes only mapped for technical reasons (like the
+	// terminating U+FFFF segment of TrueType format 4 cmaps).
+	RuneRanges(buf [][2]rune) [][2]rune
+}
+
+// CmapRunIter iterates over the runs of consecutive runes
+// mapped by a cmap, in increasing order.
+type CmapRunIter struct {
+	ranges [][2]rune
+	pos    int
+}
+
+// NewCmapRunIter returns an iterator over the runs of `cmap`.
+// If `cmap` implements CmapRuneRanger, its ranges are used directly.
+// Otherwise, the runes of the cmap are iterated and merged, ignoring
+// the ones mapped to the missing glyph (0).
+func NewCmapRunIter(cmap Cmap) *CmapRunIter {
+	if ranger, ok := cmap.(CmapRuneRanger); ok {
+		return &CmapRunIter{ranges: ranger.RuneRanges(nil)}
+	}
+
+	var runes []rune
+	for iter := cmap.Iter(); iter.Next(); {
+		r, glyph := iter.Char()
+		if glyph != 0 {
+			runes = append(runes, r)
+		}
+	}
+	// some cmaps (like CmapSimple) are not iterated in order
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	var out CmapRunIter
+	for _, r := range runes {
+		if L := len(out.ranges); L != 0 && out.ranges[L-1][1] >= r-1 {
+			if r > out.ranges[L-1][1] {
+				out.ranges[L-1][1] = r
+			}
+		} else {
+			out.ranges = append(out.ranges, [2]rune{r, r})
+		}
+	}
+	return &out
+}
+
+// Next returns true if the iterator still has runs to yield.
+func (it *CmapRunIter) Next() bool { return it.pos < len(it.ranges) }
+
+// Run must be called only when `Next` has returned `true`.
+// It returns the inclusive range [start, end] of the current run,
+// and advances.
+func (it *CmapRunIter) Run() (start, end rune) {
+	ra := it.ranges[it.pos]
+	it.pos++
+	return ra[0], ra[1]
+}
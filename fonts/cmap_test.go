@@ -0,0 +1,41 @@
+package fonts_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+type rangesCmap struct {
+	fonts.CmapSimple
+	ranges [][2]rune
+}
+
+func (cm rangesCmap) RuneRanges(buf [][2]rune) [][2]rune { return append(buf, cm.ranges...) }
+
+func collectRuns(cmap fonts.Cmap) [][2]rune {
+	var out [][2]rune
+	for iter := fonts.NewCmapRunIter(cmap); iter.Next(); {
+		start, end := iter.Run()
+		out = append(out, [2]rune{start, end})
+	}
+	return out
+}
+
+func TestCmapRunIter(t *testing.T) {
+	cmap := fonts.CmapSimple{'z': 1, 'a': 2, 'c': 3, 'b': 4, 0x0628: 5, 'e': 6, 'f': 0}
+	if exp, got := [][2]rune{{'a', 'c'}, {'e', 'e'}, {'z', 'z'}, {0x0628, 0x0628}}, collectRuns(cmap); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+
+	if got := collectRuns(fonts.CmapSimple{}); len(got) != 0 {
+		t.Fatalf("expected no runs, got %v", got)
+	}
+
+	// ranges are used directly
+	ranger := rangesCmap{ranges: [][2]rune{{'a', 'z'}, {0x0600, 0x06FF}}}
+	if exp, got := ranger.ranges, collectRuns(ranger); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+}
@@ -2,7 +2,9 @@
 // several font formats (postscript, bitmap and truetype)
 // and provides a common API, inspired by freetype.
 //
-// It does not currently support CIDType1 fonts.
+// CID-keyed fonts (CIDFontType 0 and CFF CIDFonts) are supported,
+// but since they have no builtin character map, one must be supplied
+// with a CmapCID to use them for text.
 package fonts
 
 import "math"
@@ -165,6 +167,36 @@ func (s CmapSimple) Lookup(r rune) (GID, bool) {
 	return v, ok
 }
 
+// CID is a character identifier, used to select glyphs in CID-keyed fonts.
+type CID uint16
+
+// CIDSystemInfo identifies the character collection
+// (the set of CIDs) used by a CID-keyed font.
+type CIDSystemInfo struct {
+	Registry   string
+	Ordering   string
+	Supplement int
+}
+
+// CmapCID maps runes to CIDs. CID-keyed fonts have no builtin character
+// map, so that it must be provided externally, usually from a
+// CMap resource matching the CIDSystemInfo of the font.
+type CmapCID map[rune]CID
+
+// FaceCID is implemented by CID-keyed fonts, whose glyphs
+// are selected by CIDs instead of glyph names.
+type FaceCID interface {
+	// CIDSystemInfo returns the character collection of the font,
+	// or false if the font is not CID-keyed.
+	CIDSystemInfo() (CIDSystemInfo, bool)
+	// CIDToGID returns the glyph selected by `cid`, or false.
+	CIDToGID(cid CID) (GID, bool)
+	// GIDToCID is the inverse of CIDToGID.
+	GIDToCID(gid GID) (CID, bool)
+	// SetCIDCmap sets the mapping used by NominalGlyph.
+	SetCIDCmap(cmap CmapCID)
+}
+
 // FontExtents exposes font-wide extent values, measured in font units.
 // Note that typically ascender is positive and descender negative in coordinate systems that grow up.
 type FontExtents struct {
@@ -271,6 +303,28 @@ type FaceRenderer interface {
 	GlyphData(gid GID, xPpem, yPpem uint16) GlyphData
 }
 
+// Pen receives the drawing commands describing a glyph outline,
+// with coordinates expressed in font units (the Y axis increasing up).
+// Each contour starts with MoveTo and ends with Close. Outlines sent by
+// this package explicitly return to the first point of their contours,
+// so that Close does not need to add a segment.
+// *GlyphOutline implements Pen, storing the segments.
+type Pen interface {
+	MoveTo(to SegmentPoint)
+	LineTo(to SegmentPoint)
+	QuadTo(ctrl, to SegmentPoint)
+	CubeTo(ctrl1, ctrl2, to SegmentPoint)
+	Close()
+}
+
+// FaceOutliner is implemented by the faces providing vector outlines,
+// which may be streamed into a Pen, avoiding intermediate allocations.
+type FaceOutliner interface {
+	// DrawGlyph sends the outline of the glyph to `pen`.
+	// An error is returned for invalid glyphs or for glyphs without outlines.
+	DrawGlyph(gid GID, pen Pen) error
+}
+
 // GlyphData describe how to graw a glyph.
 // It is either an GlyphOutline, GlyphSVG or GlyphBitmap.
 type GlyphData interface {
@@ -0,0 +1,96 @@
+package fontscan
+
+import (
+	"io/fs"
+	"math/bits"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// fileStamp is used to detect the modifications
+// of a font file since its last scan.
+type fileStamp struct {
+	size    int64
+	modTime int64 // Unix time in nanoseconds
+}
+
+func newFileStamp(info fs.FileInfo) fileStamp {
+	return fileStamp{size: info.Size(), modTime: info.ModTime().UnixNano()}
+}
+
+// Rescan walks the given directories as ScanDirectories does, but
+// only parses the font files which are not in the index, or whose size or
+// modification time has changed since they were scanned. The footprints of
+// the other files are reused.
+// The returned index only contains the fonts found in `dirs`, so that
+// removed files are dropped.
+//
+// A typical usage is to serialize the index (see Index.Serialize), and
+// to call Rescan on the index read at the next start of the program.
+func (idx Index) Rescan(dirs ...string) (Index, error) {
+	cache := make(map[string][]Footprint)
+	for _, fp := range idx {
+		cache[fp.Location.File] = append(cache[fp.Location.File], fp)
+	}
+	return scanDirectories(dirs, cache)
+}
+
+// Descriptor returns a fonts.FontDescriptor built from the footprint,
+// without reading the font file.
+// The Cmap returned by its LoadCmap method has invalid glyph indices (always 1),
+// but its runes are correct, which is enough to check the coverage of the font.
+func (fp *Footprint) Descriptor() fonts.FontDescriptor { return footprintDescriptor{fp} }
+
+type footprintDescriptor struct {
+	fp *Footprint
+}
+
+func (fd footprintDescriptor) Family() string { return fd.fp.Family }
+
+func (fd footprintDescriptor) Aspect() (fonts.Style, fonts.Weight, fonts.Stretch) {
+	return fd.fp.Aspect.Style, fd.fp.Aspect.Weight, fd.fp.Aspect.Stretch
+}
+
+func (fd footprintDescriptor) AdditionalStyle() string { return fd.fp.AdditionalStyle }
+
+func (fd footprintDescriptor) LoadCmap() (fonts.Cmap, error) { return runeSetCmap(fd.fp.Runes), nil }
+
+// runeSetCmap implements fonts.Cmap, mapping all the runes to glyph 1
+type runeSetCmap RuneSet
+
+func (cm runeSetCmap) Iter() fonts.CmapIter { return &runeSetIter{set: RuneSet(cm)} }
+
+func (cm runeSetCmap) Lookup(r rune) (fonts.GID, bool) {
+	if RuneSet(cm).Contains(r) {
+		return 1, true
+	}
+	return 0, false
+}
+
+type runeSetIter struct {
+	set       RuneSet
+	page      int    // index of the current page
+	word      int    // index of the current word in the page
+	remaining uint32 // the remaining bits of the current word
+	r         rune
+}
+
+func (it *runeSetIter) Next() bool {
+	for it.remaining == 0 {
+		if it.page >= len(it.set) {
+			return false
+		}
+		it.remaining = it.set[it.page].set[it.word]
+		it.r = rune(it.set[it.page].ref)<<8 | rune(it.word<<5)
+		it.word++
+		if it.word == len(it.set[it.page].set) {
+			it.page, it.word = it.page+1, 0
+		}
+	}
+	bit := bits.TrailingZeros32(it.remaining)
+	it.remaining &= it.remaining - 1
+	it.r = it.r&^31 | rune(bit)
+	return true
+}
+
+func (it *runeSetIter) Char() (rune, fonts.GID) { return it.r, 1 }
@@ -0,0 +1,149 @@
+package fontscan
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ttruetype "github.com/benoitkugler/textlayout-testdata/truetype"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+func TestRescan(t *testing.T) {
+	dir := setupFontDirectory(t)
+	index, err := ScanDirectories(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mark the footprints to detect which ones are reused
+	for i := range index {
+		index[i].Family = "cached"
+	}
+
+	truetypeDir := filepath.Join(dir, "truetype")
+	// removed file
+	if err = os.Remove(filepath.Join(truetypeDir, "DejaVuSerif.ttf")); err != nil {
+		t.Fatal(err)
+	}
+	// modified file
+	roboto, err := ttruetype.Files.ReadFile("Roboto-BoldItalic.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(truetypeDir, "Castoro-Italic.ttf"), roboto, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// touched file
+	later := time.Now().Add(time.Hour)
+	if err = os.Chtimes(filepath.Join(truetypeDir, "Castoro-Regular.ttf"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	// new file
+	if err = os.WriteFile(filepath.Join(truetypeDir, "new.ttf"), roboto, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	rescanned, err := index.Rescan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rescanned) != len(index) {
+		t.Fatalf("expected %d fonts, got %d", len(index), len(rescanned))
+	}
+	families := map[string]string{}
+	for _, fp := range rescanned {
+		families[filepath.Base(fp.Location.File)] = fp.Family
+	}
+	for file, exp := range map[string]string{
+		"Castoro-Italic.ttf":    "Roboto",
+		"Castoro-Regular.ttf":   "Castoro",
+		"new.ttf":               "Roboto",
+		"NotoSansArabic.ttf":    "cached",
+		"Roboto-BoldItalic.ttf": "cached",
+		"ToyTTC.ttc":            "cached",
+		"helvB18.pcf.gz":        "cached",
+	} {
+		if got := families[file]; got != exp {
+			t.Fatalf("for %s, expected family %s, got %s", file, exp, got)
+		}
+	}
+	if _, has := families["DejaVuSerif.ttf"]; has {
+		t.Fatal("removed file should be dropped")
+	}
+
+	// the stamps are preserved by serialization
+	var buf bytes.Buffer
+	if err = rescanned.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	index, err = ReadIndex(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range index {
+		index[i].Family = "cached"
+	}
+	rescanned, err = index.Rescan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fp := range rescanned {
+		if fp.Family != "cached" {
+			t.Fatalf("unexpected parsing of %s", fp.Location.File)
+		}
+	}
+}
+
+func TestFootprintDescriptor(t *testing.T) {
+	data, err := ttruetype.Files.ReadFile("DejaVuSerif.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptors, err := truetype.ScanFont(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := descriptors[0]
+	fp := newFootprint(fd, Location{})
+	cached := fp.Descriptor()
+
+	if cached.Family() != fd.Family() || cached.AdditionalStyle() != fd.AdditionalStyle() {
+		t.Fatalf("unexpected descriptor %s %s", cached.Family(), cached.AdditionalStyle())
+	}
+	if style, weight, _ := cached.Aspect(); style != fp.Aspect.Style || weight != fp.Aspect.Weight {
+		t.Fatalf("unexpected aspect %d %f", style, weight)
+	}
+
+	expected, err := fd.LoadCmap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := cached.LoadCmap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRunes := map[rune]bool{}
+	for iter := expected.Iter(); iter.Next(); {
+		r, _ := iter.Char()
+		expectedRunes[r] = true
+	}
+	var count int
+	previous := rune(-1)
+	for iter := got.Iter(); iter.Next(); {
+		r, _ := iter.Char()
+		if !expectedRunes[r] || r <= previous {
+			t.Fatalf("unexpected rune %d", r)
+		}
+		if _, ok := got.Lookup(r); !ok {
+			t.Fatalf("missing rune %d", r)
+		}
+		previous = r
+		count++
+	}
+	if count != len(expectedRunes) {
+		t.Fatalf("expected %d runes, got %d", len(expectedRunes), count)
+	}
+}
@@ -0,0 +1,342 @@
+// Package fontscan provides a font database, built by scanning
+// font directories, which may be persisted and queried to select
+// the fonts used to display a text, in the spirit of fontconfig.
+//
+// The scan relies on the lightweight `ScanFont` functions of the
+// font loaders: TrueType and OpenType (including collections, WOFF and dfont),
+// bitmap (PCF and BDF) and Type1 fonts are supported.
+package fontscan
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/bitmap"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/fonts/type1"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+// Location identifies a font in the file system.
+type Location struct {
+	File string // the path of the font file
+	// Index is the index of the font in a collection,
+	// or 0 for simple font files.
+	Index uint16
+}
+
+// Aspect stores the visual properties of a font, as defined by the CSS
+// properties 'font-style', 'font-weight' and 'font-stretch'.
+type Aspect struct {
+	Style   fonts.Style
+	Weight  fonts.Weight
+	Stretch fonts.Stretch
+}
+
+// setDefaults replaces the zero (unknown) values by the normal ones.
+func (as *Aspect) setDefaults() {
+	if as.Style == 0 {
+		as.Style = fonts.StyleNormal
+	}
+	if as.Weight == 0 {
+		as.Weight = fonts.WeightNormal
+	}
+	if as.Stretch == 0 {
+		as.Stretch = fonts.StretchNormal
+	}
+}
+
+// VariationAxis describes a variation axis of a variable font,
+// with the values in design units.
+type VariationAxis struct {
+	Tag                       truetype.Tag
+	Minimum, Default, Maximum float32
+}
+
+// Footprint is a condensed summary of the main information
+// about a font, serving as a lightweight surrogate
+// for the original font file.
+type Footprint struct {
+	Location Location
+
+	// Family is the family name of the font,
+	// as returned by fonts.FontDescriptor
+	Family string
+
+	// Aspect is the aspect of the font (of its default instance for variable fonts).
+	// The values not provided by the font are set to the normal ones.
+	Aspect Aspect
+
+	// AdditionalStyle is the style description
+	// returned by fonts.FontDescriptor
+	AdditionalStyle string
+
+	// Scripts are the scripts of the runes supported by the font,
+	// sorted and without the Common and Inherited pseudo-scripts.
+	Scripts []language.Script
+
+	// Axes is empty for non variable fonts.
+	Axes []VariationAxis
+
+	// Runes are the runes supported by the font.
+	Runes RuneSet
+
+	stamp fileStamp // the state of the file when it was scanned
+}
+
+// HasRune returns true if the font has a glyph for `r`.
+func (fp *Footprint) HasRune(r rune) bool { return fp.Runes.Contains(r) }
+
+// axis returns the variation axis `tag`, if any
+func (fp *Footprint) axis(tag truetype.Tag) (VariationAxis, bool) {
+	for _, axis := range fp.Axes {
+		if axis.Tag == tag {
+			return axis, true
+		}
+	}
+	return VariationAxis{}, false
+}
+
+// the interface implemented by the descriptors of variable fonts
+type variableDescriptor interface {
+	Variations() []truetype.VarAxis
+}
+
+func newFootprint(fd fonts.FontDescriptor, location Location) Footprint {
+	out := Footprint{Location: location, Family: fd.Family(), AdditionalStyle: fd.AdditionalStyle()}
+
+	out.Aspect.Style, out.Aspect.Weight, out.Aspect.Stretch = fd.Aspect()
+	out.Aspect.setDefaults()
+
+	// an invalid cmap is treated as empty
+	if cmap, err := fd.LoadCmap(); err == nil && cmap != nil {
+		out.Runes = NewRuneSetFromCmap(cmap)
+		out.Scripts = out.Runes.scripts()
+	}
+
+	if vd, ok := fd.(variableDescriptor); ok {
+		for _, axis := range vd.Variations() {
+			out.Axes = append(out.Axes, VariationAxis{
+				Tag:     axis.Tag,
+				Minimum: axis.Minimum,
+				Default: axis.Default,
+				Maximum: axis.Maximum,
+			})
+		}
+	}
+
+	return out
+}
+
+// scripts returns the real scripts of the runes in the set.
+func (rs RuneSet) scripts() []language.Script {
+	seen := map[language.Script]bool{}
+	rs.Runes(func(r rune) {
+		if script := language.LookupScript(r); script.IsRealScript() {
+			seen[script] = true
+		}
+	})
+	if len(seen) == 0 {
+		return nil
+	}
+	out := make([]language.Script, 0, len(seen))
+	for script := range seen {
+		out = append(out, script)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+type fontScanner = func(file fonts.Resource) ([]fonts.FontDescriptor, error)
+
+// returns the scanner used for `path`, based on its extension,
+// or nil if the file is not a supported font file
+func scannerFor(path string) fontScanner {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".ttf", ".otf", ".ttc", ".otc", ".woff", ".dfont", ".otb":
+		return truetype.ScanFont
+	case ".pcf", ".bdf":
+		return bitmap.ScanFont
+	case ".gz": // compressed bitmap fonts
+		if ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path)))); ext == ".pcf" || ext == ".bdf" {
+			return bitmap.ScanFont
+		}
+		return nil
+	case ".pfb", ".pfa", ".t1":
+		return type1.ScanFont
+	default:
+		return nil
+	}
+}
+
+// ScanFile returns the footprints of the fonts in the file at `path`.
+// An error is returned if the file is not a supported font file.
+func ScanFile(path string) ([]Footprint, error) {
+	scanner := scannerFor(path)
+	if scanner == nil {
+		return nil, errors.New("unsupported font file extension")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// descriptors lazily read the file, which must be kept open
+	descriptors, err := scanner(file)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Footprint, len(descriptors))
+	for i, fd := range descriptors {
+		out[i] = newFootprint(fd, Location{File: path, Index: uint16(i)})
+		out[i].stamp = newFileStamp(info)
+	}
+	return out, nil
+}
+
+// ScanDirectories walks the given directories, including their sub-directories,
+// and returns the footprints of the fonts found.
+// Missing directories, unreadable entries, files with unsupported extensions
+// and invalid font files are ignored.
+// Symbolic links are followed, and each directory is only scanned once.
+// See Index.Rescan to avoid parsing again the files already scanned.
+func ScanDirectories(dirs ...string) (Index, error) {
+	return scanDirectories(dirs, nil)
+}
+
+// scanDirectories implements ScanDirectories, reusing the footprints from
+// `cache` (indexed by file path) for the files which have not been modified
+func scanDirectories(dirs []string, cache map[string][]Footprint) (Index, error) {
+	sc := directoryScanner{cache: cache, visited: make(map[string]bool)}
+	for _, dir := range dirs {
+		err := sc.walk(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return sc.out, nil
+}
+
+// directoryScanner accumulates the footprints of the fonts found
+// in directories, following the symbolic links to directories.
+type directoryScanner struct {
+	cache   map[string][]Footprint
+	visited map[string]bool // real paths of the directories already walked, to avoid cycles
+	out     Index
+}
+
+// walk scans the directory `dir`, after resolving its symbolic links,
+// so that the files are reported with their real path.
+func (sc *directoryScanner) walk(dir string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// entries below the root which can't be read are skipped
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			// since `root` is resolved and WalkDir does not follow
+			// symbolic links, `path` is a real path
+			if sc.visited[path] {
+				return fs.SkipDir
+			}
+			sc.visited[path] = true
+			return nil
+		}
+
+		var info fs.FileInfo
+		if d.Type()&fs.ModeSymlink != 0 {
+			info, err = os.Stat(path)
+			if err != nil { // broken link
+				return nil
+			}
+			if info.IsDir() {
+				// the errors of the linked directory are ignored, as for sub-directories
+				_ = sc.walk(path)
+				return nil
+			}
+		}
+
+		if scannerFor(path) == nil {
+			return nil
+		}
+		if cached, has := sc.cache[path]; has {
+			if info == nil {
+				info, err = d.Info()
+			}
+			if err == nil && cached[0].stamp == newFileStamp(info) {
+				sc.out = append(sc.out, cached...)
+				return nil
+			}
+		}
+		footprints, err := ScanFile(path)
+		if err != nil { // not a valid font file: skip it
+			return nil
+		}
+		sc.out = append(sc.out, footprints...)
+		return nil
+	})
+}
+
+// DefaultFontDirectories returns the usual directories
+// where fonts are installed, for the current operating system.
+// The directories may not exist.
+func DefaultFontDirectories() []string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		windir := os.Getenv("WINDIR")
+		if windir == "" {
+			windir = `C:\Windows`
+		}
+		return []string{
+			filepath.Join(windir, "Fonts"),
+			filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "Fonts"),
+		}
+	case "darwin", "ios":
+		return []string{
+			filepath.Join(home, "Library", "Fonts"),
+			"/Library/Fonts",
+			"/System/Library/Fonts",
+			"/Network/Library/Fonts",
+		}
+	case "android":
+		return []string{"/system/fonts", "/system/font", "/data/fonts"}
+	default:
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		return []string{
+			filepath.Join(home, ".fonts"),
+			filepath.Join(dataHome, "fonts"),
+			"/usr/local/share/fonts",
+			"/usr/share/fonts",
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package fontscan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tbitmap "github.com/benoitkugler/textlayout-testdata/bitmap"
+	ttruetype "github.com/benoitkugler/textlayout-testdata/truetype"
+	ttype1 "github.com/benoitkugler/textlayout-testdata/type1"
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+// copies some test fonts into a temporary directory
+func setupFontDirectory(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	copyFile := func(files interface{ ReadFile(string) ([]byte, error) }, name, dst string) {
+		data, err := files.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(dst, data, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{
+		"Castoro-Regular.ttf",
+		"Castoro-Italic.ttf",
+		"Roboto-BoldItalic.ttf",
+		"NotoSansArabic.ttf",
+		"DejaVuSerif.ttf",
+		"Commissioner-VF.ttf",
+		"ToyTTC.ttc",
+	} {
+		copyFile(ttruetype.Files, file, filepath.Join(dir, "truetype", file))
+	}
+	copyFile(tbitmap.Files, "helvB18.pcf.gz", filepath.Join(dir, "bitmap", "helvB18.pcf.gz"))
+	copyFile(ttype1.Files, "CalligrapherRegular.pfb", filepath.Join(dir, "type1", "CalligrapherRegular.pfb"))
+
+	// not a font file
+	copyFile(ttype1.Files, "Times-Bold.afm", filepath.Join(dir, "type1", "Times-Bold.afm"))
+	// invalid font file
+	if err := os.WriteFile(filepath.Join(dir, "invalid.ttf"), []byte("not a font"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestScanDirectories(t *testing.T) {
+	dir := setupFontDirectory(t)
+
+	index, err := ScanDirectories(dir, filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the collection has 2 fonts; invalid and unsupported files are ignored
+	if len(index) != 10 {
+		t.Fatalf("expected 10 fonts, got %d", len(index))
+	}
+
+	byFile := map[string]Footprint{}
+	for _, fp := range index {
+		if fp.Location.Index == 0 {
+			byFile[filepath.Base(fp.Location.File)] = fp
+		}
+	}
+
+	roboto := byFile["Roboto-BoldItalic.ttf"]
+	if exp := (Aspect{fonts.StyleItalic, fonts.WeightBold, fonts.StretchNormal}); roboto.Family != "Roboto" || roboto.Aspect != exp {
+		t.Fatalf("unexpected footprint %s %v", roboto.Family, roboto.Aspect)
+	}
+	if !roboto.HasRune('a') || roboto.HasRune(0x0628) {
+		t.Fatal("invalid coverage for Roboto")
+	}
+
+	arabic := byFile["NotoSansArabic.ttf"]
+	if len(arabic.Scripts) != 1 || arabic.Scripts[0] != language.Arabic {
+		t.Fatalf("unexpected scripts %v", arabic.Scripts)
+	}
+	if len(arabic.Axes) != 2 || arabic.Axes[0] != (VariationAxis{tagWght, 100, 400, 900}) {
+		t.Fatalf("unexpected axes %v", arabic.Axes)
+	}
+
+	if helv := byFile["helvB18.pcf.gz"]; helv.Family != "Adobe Helvetica" || helv.Aspect.Weight != fonts.WeightBold || !helv.HasRune('A') {
+		t.Fatalf("unexpected bitmap footprint %v", helv)
+	}
+	if calli := byFile["CalligrapherRegular.pfb"]; calli.Family != "Calligrapher" || !calli.HasRune('A') {
+		t.Fatalf("unexpected Type1 footprint %v", calli)
+	}
+}
+
+func TestScanSymlinks(t *testing.T) {
+	dir := setupFontDirectory(t)
+	other := t.TempDir()
+	for target, link := range map[string]string{
+		filepath.Join(dir, "truetype"): filepath.Join(other, "fonts"),
+		other:                          filepath.Join(other, "loop"),
+		dir:                            filepath.Join(dir, "truetype", "parent"),
+		filepath.Join(dir, "missing"):  filepath.Join(other, "broken"),
+	} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skip("symbolic links not supported:", err)
+		}
+	}
+
+	// the linked directory is followed, the cycles are ignored
+	index, err := ScanDirectories(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 10 {
+		t.Fatalf("expected 10 fonts, got %d", len(index))
+	}
+
+	// each directory is only scanned once
+	index, err = ScanDirectories(dir, other, filepath.Join(other, "fonts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 10 {
+		t.Fatalf("expected 10 fonts, got %d", len(index))
+	}
+}
+
+func TestScanFile(t *testing.T) {
+	dir := setupFontDirectory(t)
+
+	footprints, err := ScanFile(filepath.Join(dir, "truetype", "ToyTTC.ttc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(footprints) != 2 || footprints[1].Location.Index != 1 {
+		t.Fatalf("unexpected collection footprints %v", footprints)
+	}
+
+	if _, err = ScanFile(filepath.Join(dir, "type1", "Times-Bold.afm")); err == nil {
+		t.Fatal("expected error for unsupported file")
+	}
+	if _, err = ScanFile(filepath.Join(dir, "invalid.ttf")); err == nil {
+		t.Fatal("expected error for invalid file")
+	}
+}
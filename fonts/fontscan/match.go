@@ -0,0 +1,287 @@
+package fontscan
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// Index is a list of font footprints, usually obtained with ScanDirectories
+// or ReadIndex, which may be queried to select fonts.
+type Index []Footprint
+
+// GenericFamilies maps the CSS generic families to a list of
+// common families, used as substitutes by the queries.
+// It may be customized to match the fonts of a system.
+var GenericFamilies = map[string][]string{
+	"serif": {
+		"DejaVu Serif", "Noto Serif", "Liberation Serif", "Times New Roman", "Times", "FreeSerif",
+	},
+	"sans-serif": {
+		"DejaVu Sans", "Noto Sans", "Liberation Sans", "Arial", "Helvetica", "Roboto", "FreeSans",
+	},
+	"monospace": {
+		"DejaVu Sans Mono", "Noto Sans Mono", "Liberation Mono", "Courier New", "Courier", "FreeMono",
+	},
+	"cursive": {
+		"Comic Sans MS", "URW Chancery L", "Z003", "Apple Chancery",
+	},
+	"fantasy": {
+		"Impact", "Papyrus", "Luminari",
+	},
+}
+
+// Query describes the requested fonts, with the semantics
+// of the CSS 'font-family', 'font-style', 'font-weight' and 'font-stretch' properties.
+type Query struct {
+	// Families is the list of the family names, in order of preference.
+	// Generic families (like "serif" or "monospace") are replaced
+	// by their substitutes from GenericFamilies.
+	Families []string
+
+	// Aspect is the requested aspect. Zero values are replaced by the normal ones.
+	Aspect Aspect
+}
+
+// normalizes a family name: matching is done
+// ignoring case, spaces, hyphens and underscores, as fontconfig does
+func normalizeFamily(family string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		default:
+			return r
+		}
+	}, strings.ToLower(family))
+}
+
+// returns the normalized families, with generic families expanded,
+// mapped to their rank
+func (q Query) familyRanks() map[string]int {
+	out := map[string]int{}
+	addFamily := func(family string) {
+		if _, has := out[family]; !has {
+			out[family] = len(out)
+		}
+	}
+	for _, family := range q.Families {
+		if substitutes, isGeneric := GenericFamilies[strings.ToLower(family)]; isGeneric {
+			for _, substitute := range substitutes {
+				addFamily(normalizeFamily(substitute))
+			}
+		} else {
+			addFamily(normalizeFamily(family))
+		}
+	}
+	return out
+}
+
+var (
+	tagWght = truetype.MustNewTag("wght")
+	tagWdth = truetype.MustNewTag("wdth")
+	tagItal = truetype.MustNewTag("ital")
+	tagSlnt = truetype.MustNewTag("slnt")
+)
+
+// returns the weights supported by the font,
+// using the 'wght' axis for variable fonts
+func (fp *Footprint) weightRange() (min, max float32) {
+	if axis, has := fp.axis(tagWght); has {
+		return axis.Minimum, axis.Maximum
+	}
+	return float32(fp.Aspect.Weight), float32(fp.Aspect.Weight)
+}
+
+// returns the stretches supported by the font,
+// using the 'wdth' axis (expressed in percent) for variable fonts
+func (fp *Footprint) stretchRange() (min, max float32) {
+	if axis, has := fp.axis(tagWdth); has {
+		return axis.Minimum / 100, axis.Maximum / 100
+	}
+	return float32(fp.Aspect.Stretch), float32(fp.Aspect.Stretch)
+}
+
+// returns the styles supported by the font,
+// using the 'ital' and 'slnt' axis for variable fonts
+func (fp *Footprint) styles() []fonts.Style {
+	out := []fonts.Style{fp.Aspect.Style}
+	if axis, has := fp.axis(tagItal); has && axis.Maximum >= 1 {
+		out = append(out, fonts.StyleItalic)
+	}
+	if axis, has := fp.axis(tagSlnt); has && axis.Minimum != axis.Maximum {
+		out = append(out, fonts.StyleOblique)
+	}
+	return out
+}
+
+// distanceInRange returns the distance between `value` and the range [min, max],
+// adding `penalty` if the range is on the non preferred side of `value`.
+func distanceInRange(value, min, max float32, preferLower bool, penalty float32) float32 {
+	switch {
+	case min <= value && value <= max:
+		return 0
+	case max < value: // the range is lower
+		if preferLower {
+			return value - max
+		}
+		return value - max + penalty
+	default: // the range is higher
+		if preferLower {
+			return min - value + penalty
+		}
+		return min - value
+	}
+}
+
+// stretchDistance implements the CSS rule for 'font-stretch':
+// narrower widths are preferred for requested widths up to normal,
+// wider widths otherwise.
+func (fp *Footprint) stretchDistance(stretch fonts.Stretch) float32 {
+	min, max := fp.stretchRange()
+	return distanceInRange(float32(stretch), min, max, stretch <= fonts.StretchNormal, 10)
+}
+
+// styleDistance implements the CSS rule for 'font-style':
+// italic prefers oblique to normal, oblique prefers italic to normal,
+// and normal prefers oblique to italic.
+func (fp *Footprint) styleDistance(style fonts.Style) int {
+	var preferences [3]fonts.Style
+	switch style {
+	case fonts.StyleItalic:
+		preferences = [3]fonts.Style{fonts.StyleItalic, fonts.StyleOblique, fonts.StyleNormal}
+	case fonts.StyleOblique:
+		preferences = [3]fonts.Style{fonts.StyleOblique, fonts.StyleItalic, fonts.StyleNormal}
+	default:
+		preferences = [3]fonts.Style{fonts.StyleNormal, fonts.StyleOblique, fonts.StyleItalic}
+	}
+	best := len(preferences)
+	for _, available := range fp.styles() {
+		for i, pref := range preferences {
+			if available == pref && i < best {
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+// weightDistance implements the CSS rule for 'font-weight':
+//   - for a requested weight between 400 and 500, the weights up to 500 are tried
+//     in ascending order, then the lower weights in descending order,
+//     then the weights greater than 500 in ascending order
+//   - for a requested weight less than 400, the lower weights are preferred
+//   - for a requested weight greater than 500, the greater weights are preferred
+func (fp *Footprint) weightDistance(weight fonts.Weight) float32 {
+	const penalty = 1000
+	min, max := fp.weightRange()
+	w := float32(weight)
+	if fonts.WeightNormal <= weight && weight <= fonts.WeightMedium {
+		switch {
+		case min <= w && w <= max:
+			return 0
+		case w < min && min <= float32(fonts.WeightMedium):
+			return min - w
+		case max < w:
+			return w - max + penalty
+		default:
+			return min - w + 2*penalty
+		}
+	}
+	return distanceInRange(w, min, max, weight < fonts.WeightNormal, penalty)
+}
+
+// matchScore is compared in lexicographic order, lower is better
+type matchScore struct {
+	family  int
+	stretch float32
+	style   int
+	weight  float32
+}
+
+func (s matchScore) isBetter(other matchScore) bool {
+	if s.family != other.family {
+		return s.family < other.family
+	}
+	if s.stretch != other.stretch {
+		return s.stretch < other.stretch
+	}
+	if s.style != other.style {
+		return s.style < other.style
+	}
+	return s.weight < other.weight
+}
+
+// Sort returns the fonts of the index, sorted by decreasing preference for `query`,
+// similarly to fontconfig's FcFontSort.
+// The fonts of the requested families come first, in the order of `query.Families`,
+// followed by the other fonts. Fonts with the same family rank are sorted
+// according to the CSS font matching algorithm, applied successively on
+// the stretch, the style and the weight.
+func (idx Index) Sort(query Query) Index {
+	query.Aspect.setDefaults()
+	ranks := query.familyRanks()
+
+	scores := make([]matchScore, len(idx))
+	for i := range idx {
+		fp := &idx[i]
+		rank, has := ranks[normalizeFamily(fp.Family)]
+		if !has {
+			rank = len(ranks)
+		}
+		scores[i] = matchScore{
+			family:  rank,
+			stretch: fp.stretchDistance(query.Aspect.Stretch),
+			style:   fp.styleDistance(query.Aspect.Style),
+			weight:  fp.weightDistance(query.Aspect.Weight),
+		}
+	}
+
+	permutation := make([]int, len(idx))
+	for i := range permutation {
+		permutation[i] = i
+	}
+	sort.SliceStable(permutation, func(i, j int) bool {
+		return scores[permutation[i]].isBetter(scores[permutation[j]])
+	})
+
+	out := make(Index, len(idx))
+	for i, index := range permutation {
+		out[i] = idx[index]
+	}
+	return out
+}
+
+// Match returns the font of the index best matching `query`,
+// similarly to fontconfig's FcFontMatch. If no font of the requested families
+// is found, the best font of the other families is returned.
+// It returns false only for an empty index.
+func (idx Index) Match(query Query) (Footprint, bool) {
+	sorted := idx.Sort(query)
+	if len(sorted) == 0 {
+		return Footprint{}, false
+	}
+	return sorted[0], true
+}
+
+// Supporting returns the fonts of the index with a glyph for `r`,
+// in the same order.
+func (idx Index) Supporting(r rune) Index {
+	var out Index
+	for i := range idx {
+		if idx[i].HasRune(r) {
+			out = append(out, idx[i])
+		}
+	}
+	return out
+}
+
+// FallbackChain returns the fonts with a glyph for `r`,
+// sorted by decreasing preference for `query` (see Sort).
+// To query several runes, it is more efficient to call Sort once,
+// then Supporting for each rune.
+func (idx Index) FallbackChain(r rune, query Query) Index {
+	return idx.Supporting(r).Sort(query)
+}
@@ -0,0 +1,141 @@
+package fontscan
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+func newTestFootprint(family string, aspect Aspect, runes ...rune) Footprint {
+	out := Footprint{Family: family, Aspect: aspect}
+	for _, r := range runes {
+		out.Runes.Add(r)
+	}
+	return out
+}
+
+// returns the families of the sorted index
+func sortedFamilies(idx Index, query Query) []string {
+	var out []string
+	for _, fp := range idx.Sort(query) {
+		out = append(out, fp.Family)
+	}
+	return out
+}
+
+func assertFamilies(t *testing.T, got []string, expected ...string) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestSortWeight(t *testing.T) {
+	weights := func(ws ...fonts.Weight) Index {
+		var out Index
+		for _, w := range ws {
+			out = append(out, newTestFootprint(strconv.Itoa(int(w)), Aspect{fonts.StyleNormal, w, fonts.StretchNormal}))
+		}
+		return out
+	}
+
+	idx := weights(300, 450, 500, 600)
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Weight: 400}}), "450", "500", "300", "600")
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Weight: 475}}), "500", "450", "300", "600")
+
+	idx = weights(200, 400, 600)
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Weight: 300}}), "200", "400", "600")
+
+	idx = weights(400, 600, 800)
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Weight: 700}}), "800", "600", "400")
+}
+
+func TestSortStyleStretch(t *testing.T) {
+	idx := Index{
+		newTestFootprint("normal", Aspect{fonts.StyleNormal, 400, 1}),
+		newTestFootprint("oblique", Aspect{fonts.StyleOblique, 400, 1}),
+		newTestFootprint("italic", Aspect{fonts.StyleItalic, 400, 1}),
+	}
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Style: fonts.StyleItalic}}), "italic", "oblique", "normal")
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Style: fonts.StyleOblique}}), "oblique", "italic", "normal")
+	assertFamilies(t, sortedFamilies(idx, Query{}), "normal", "oblique", "italic")
+
+	idx = Index{
+		newTestFootprint("expanded", Aspect{fonts.StyleNormal, 400, fonts.StretchExpanded}),
+		newTestFootprint("normal", Aspect{fonts.StyleItalic, 400, fonts.StretchNormal}),
+		newTestFootprint("ultra-condensed", Aspect{fonts.StyleNormal, 400, fonts.StretchUltraCondensed}),
+	}
+	// stretch has precedence over style
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Stretch: fonts.StretchCondensed}}), "ultra-condensed", "normal", "expanded")
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Stretch: fonts.StretchSemiExpanded}}), "expanded", "normal", "ultra-condensed")
+}
+
+func TestSortVariable(t *testing.T) {
+	variable := newTestFootprint("variable", Aspect{fonts.StyleNormal, 400, 1})
+	variable.Axes = []VariationAxis{{Tag: tagWght, Minimum: 100, Default: 400, Maximum: 900}}
+	idx := Index{
+		newTestFootprint("static", Aspect{fonts.StyleNormal, 400, 1}),
+		variable,
+	}
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Weight: 800}}), "variable", "static")
+	// stable order for equivalent fonts
+	assertFamilies(t, sortedFamilies(idx, Query{Aspect: Aspect{Weight: 400}}), "static", "variable")
+}
+
+func TestSortFamilies(t *testing.T) {
+	idx := Index{
+		newTestFootprint("Arial", Aspect{}),
+		newTestFootprint("DejaVu Sans Mono", Aspect{}),
+		newTestFootprint("Other", Aspect{}),
+		newTestFootprint("Noto Serif", Aspect{}),
+	}
+	assertFamilies(t, sortedFamilies(idx, Query{Families: []string{"noto-serif", "monospace"}}),
+		"Noto Serif", "DejaVu Sans Mono", "Arial", "Other")
+	assertFamilies(t, sortedFamilies(idx, Query{Families: []string{"sans-serif", "serif"}}),
+		"Arial", "Noto Serif", "DejaVu Sans Mono", "Other")
+
+	// family has precedence over aspect
+	idx = Index{
+		newTestFootprint("A", Aspect{fonts.StyleNormal, fonts.WeightBold, 1}),
+		newTestFootprint("B", Aspect{fonts.StyleNormal, fonts.WeightBold, 1}),
+		newTestFootprint("B", Aspect{fonts.StyleNormal, fonts.WeightNormal, 1}),
+	}
+	match, ok := idx.Match(Query{Families: []string{"B"}, Aspect: Aspect{Weight: fonts.WeightBold}})
+	if !ok || match.Family != "B" || match.Aspect.Weight != fonts.WeightBold {
+		t.Fatalf("unexpected match %v", match)
+	}
+
+	if _, ok = Index(nil).Match(Query{}); ok {
+		t.Fatal("expected no match for an empty index")
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	idx := Index{
+		newTestFootprint("Latin", Aspect{}, 'a', 'b'),
+		newTestFootprint("Arabic", Aspect{}, 'a', 0x0628),
+		newTestFootprint("Arabic Bold", Aspect{Weight: fonts.WeightBold}, 0x0628),
+	}
+	query := Query{Families: []string{"Latin"}, Aspect: Aspect{Weight: fonts.WeightBold}}
+	var got []string
+	for _, fp := range idx.FallbackChain('a', query) {
+		got = append(got, fp.Family)
+	}
+	assertFamilies(t, got, "Latin", "Arabic")
+
+	got = got[:0]
+	for _, fp := range idx.FallbackChain(0x0628, query) {
+		got = append(got, fp.Family)
+	}
+	assertFamilies(t, got, "Arabic Bold", "Arabic")
+
+	if chain := idx.FallbackChain('c', query); len(chain) != 0 {
+		t.Fatalf("unexpected fallback %v", chain)
+	}
+}
@@ -0,0 +1,240 @@
+package fontscan
+
+import (
+	"math/bits"
+	"sort"
+	"unicode"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// RuneSet is a compact set of runes, stored as a sorted list
+// of pages of 256 bits, as fontconfig's FcCharSet.
+// It is typically used to store the runes supported by a font.
+// The zero value is an empty set, ready to use.
+type RuneSet []runePage
+
+type runePage struct {
+	ref uint16    // the high bits of the runes of the page (r >> 8)
+	set [8]uint32 // bit i is set if r & 0xFF == i
+}
+
+func (p *runePage) isEmpty() bool { return p.set == [8]uint32{} }
+
+// NewRuneSet returns a set containing `runes`.
+func NewRuneSet(runes ...rune) RuneSet {
+	var out RuneSet
+	for _, r := range runes {
+		out.Add(r)
+	}
+	return out
+}
+
+// NewRuneSetFromCmap returns the set of the runes mapped by `cmap`.
+// The ranges of the cmaps implementing fonts.CmapRuneRanger
+// (like the TrueType cmaps with format 4 or 12) are added
+// without iterating over each rune.
+func NewRuneSetFromCmap(cmap fonts.Cmap) RuneSet {
+	var out RuneSet
+	for iter := fonts.NewCmapRunIter(cmap); iter.Next(); {
+		out.AddRange(iter.Run())
+	}
+	return out
+}
+
+// returns the index of the page `ref`, or the index
+// where it should be inserted
+func (rs RuneSet) findPage(ref uint16) (int, bool) {
+	i := sort.Search(len(rs), func(i int) bool { return rs[i].ref >= ref })
+	return i, i < len(rs) && rs[i].ref == ref
+}
+
+// returns the page `ref`, inserting it if needed
+func (rs *RuneSet) page(ref uint16) *runePage {
+	// runes are usually added in increasing order
+	if L := len(*rs); L != 0 && (*rs)[L-1].ref == ref {
+		return &(*rs)[L-1]
+	}
+	index, found := rs.findPage(ref)
+	if !found {
+		*rs = append(*rs, runePage{})
+		copy((*rs)[index+1:], (*rs)[index:])
+		(*rs)[index] = runePage{ref: ref}
+	}
+	return &(*rs)[index]
+}
+
+// Add adds `r` to the set. Invalid runes are ignored.
+func (rs *RuneSet) Add(r rune) {
+	if r < 0 || r > unicode.MaxRune {
+		return
+	}
+	page := rs.page(uint16(r >> 8))
+	low := r & 0xFF
+	page.set[low>>5] |= 1 << (low & 31)
+}
+
+// AddRange adds the runes of the inclusive range [start, end] to the set,
+// clamped to valid runes.
+func (rs *RuneSet) AddRange(start, end rune) {
+	if start < 0 {
+		start = 0
+	}
+	if end > unicode.MaxRune {
+		end = unicode.MaxRune
+	}
+	for start <= end {
+		ref := start >> 8
+		pageEnd := ref<<8 | 0xFF
+		if pageEnd > end {
+			pageEnd = end
+		}
+		page := rs.page(uint16(ref))
+		// set the bits [lo, hi] of the page, word by word
+		lo, hi := start&0xFF, pageEnd&0xFF
+		for w := lo >> 5; w <= hi>>5; w++ {
+			wordLo, wordHi := rune(0), rune(31)
+			if lo > w<<5 {
+				wordLo = lo - w<<5
+			}
+			if hi < w<<5|31 {
+				wordHi = hi - w<<5
+			}
+			page.set[w] |= (^uint32(0) >> (31 - uint(wordHi-wordLo))) << uint(wordLo)
+		}
+		start = pageEnd + 1
+	}
+}
+
+// Contains returns true if `r` is in the set.
+func (rs RuneSet) Contains(r rune) bool {
+	if r < 0 || r > unicode.MaxRune {
+		return false
+	}
+	index, found := rs.findPage(uint16(r >> 8))
+	if !found {
+		return false
+	}
+	low := r & 0xFF
+	return rs[index].set[low>>5]&(1<<(low&31)) != 0
+}
+
+// Len returns the number of runes in the set.
+func (rs RuneSet) Len() int {
+	out := 0
+	for _, page := range rs {
+		for _, word := range page.set {
+			out += bits.OnesCount32(word)
+		}
+	}
+	return out
+}
+
+// Runes calls `fn` for each rune of the set, in increasing order.
+func (rs RuneSet) Runes(fn func(r rune)) {
+	for _, page := range rs {
+		for i, word := range page.set {
+			for word != 0 {
+				bit := bits.TrailingZeros32(word)
+				fn(rune(page.ref)<<8 | rune(i<<5|bit))
+				word &= word - 1
+			}
+		}
+	}
+}
+
+// Ranges returns the runes of the set, as a sorted list
+// of inclusive ranges [start, end] of consecutive runes.
+func (rs RuneSet) Ranges() [][2]rune {
+	var out [][2]rune
+	rs.Runes(func(r rune) {
+		if L := len(out); L != 0 && out[L-1][1] == r-1 {
+			out[L-1][1] = r
+		} else {
+			out = append(out, [2]rune{r, r})
+		}
+	})
+	return out
+}
+
+// newRuneSetFromRanges returns the set containing the
+// inclusive ranges [start, end] of `ranges`.
+func newRuneSetFromRanges(ranges [][2]rune) RuneSet {
+	var out RuneSet
+	for _, ra := range ranges {
+		out.AddRange(ra[0], ra[1])
+	}
+	return out
+}
+
+// merge returns the pages of `rs` and `other`, combining the
+// words of the pages with the same ref using `op`. The pages only in `rs` (resp. `other`)
+// are kept if `keepOwn` (resp. `keepOther`) is true. Empty pages are removed.
+func (rs RuneSet) merge(other RuneSet, op func(a, b uint32) uint32, keepOwn, keepOther bool) RuneSet {
+	var out RuneSet
+	i, j := 0, 0
+	for i < len(rs) || j < len(other) {
+		var page runePage
+		switch {
+		case j == len(other) || (i < len(rs) && rs[i].ref < other[j].ref):
+			page = rs[i]
+			i++
+			if !keepOwn {
+				continue
+			}
+		case i == len(rs) || other[j].ref < rs[i].ref:
+			page = other[j]
+			j++
+			if !keepOther {
+				continue
+			}
+		default: // same page
+			page.ref = rs[i].ref
+			for w := range page.set {
+				page.set[w] = op(rs[i].set[w], other[j].set[w])
+			}
+			i++
+			j++
+		}
+		if !page.isEmpty() {
+			out = append(out, page)
+		}
+	}
+	return out
+}
+
+// Union returns a new set with the runes in `rs` or in `other`.
+func (rs RuneSet) Union(other RuneSet) RuneSet {
+	return rs.merge(other, func(a, b uint32) uint32 { return a | b }, true, true)
+}
+
+// Intersection returns a new set with the runes both in `rs` and `other`.
+func (rs RuneSet) Intersection(other RuneSet) RuneSet {
+	return rs.merge(other, func(a, b uint32) uint32 { return a & b }, false, false)
+}
+
+// Difference returns a new set with the runes in `rs` but not in `other`.
+func (rs RuneSet) Difference(other RuneSet) RuneSet {
+	return rs.merge(other, func(a, b uint32) uint32 { return a &^ b }, true, false)
+}
+
+// Covers returns true if all the runes of `text` are in the set.
+func (rs RuneSet) Covers(text []rune) bool {
+	for _, r := range text {
+		if !rs.Contains(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// CoveredCount returns the number of runes of `text` in the set.
+func (rs RuneSet) CoveredCount(text []rune) int {
+	out := 0
+	for _, r := range text {
+		if rs.Contains(r) {
+			out++
+		}
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package fontscan
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	ttruetype "github.com/benoitkugler/textlayout-testdata/truetype"
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+func TestRuneSet(t *testing.T) {
+	var rs RuneSet
+	runes := []rune{0x10FFFF, 'b', 'a', 0x0628, 0x1F600, 'a', 0xFF, 0x100}
+	for _, r := range runes {
+		rs.Add(r)
+	}
+	if rs.Len() != 7 {
+		t.Fatalf("expected 7 runes, got %d", rs.Len())
+	}
+	for _, r := range runes {
+		if !rs.Contains(r) {
+			t.Fatalf("missing rune %d", r)
+		}
+	}
+	for _, r := range []rune{-1, 'c', 0x0629, 0x110000} {
+		if rs.Contains(r) {
+			t.Fatalf("unexpected rune %d", r)
+		}
+	}
+
+	var got []rune
+	rs.Runes(func(r rune) { got = append(got, r) })
+	if exp := []rune{'a', 'b', 0xFF, 0x100, 0x0628, 0x1F600, 0x10FFFF}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+}
+
+func TestRuneSetRanges(t *testing.T) {
+	var rs RuneSet
+	for _, r := range []rune{'a', 'b', 'c', 0xFF, 0x100, 0x101, 0x0628, 0x10FFFF} {
+		rs.Add(r)
+	}
+	ranges := rs.Ranges()
+	if exp := [][2]rune{{'a', 'c'}, {0xFF, 0x101}, {0x0628, 0x0628}, {0x10FFFF, 0x10FFFF}}; !reflect.DeepEqual(ranges, exp) {
+		t.Fatalf("expected %v, got %v", exp, ranges)
+	}
+	if got := newRuneSetFromRanges(ranges); !reflect.DeepEqual(got, rs) {
+		t.Fatalf("expected %v, got %v", rs, got)
+	}
+}
+
+// returns the set built by adding the runes one by one
+func naiveRuneSet(start, end rune) RuneSet {
+	var out RuneSet
+	for r := start; r <= end; r++ {
+		out.Add(r)
+	}
+	return out
+}
+
+func TestRuneSetAddRange(t *testing.T) {
+	for _, ra := range [][2]rune{
+		{'a', 'a'}, {0, 31}, {0, 32}, {5, 255}, {31, 33}, {200, 300},
+		{0xFF, 0x100}, {0x0600, 0x08FF}, {0x10FF00, 0x10FFFF},
+	} {
+		var got RuneSet
+		got.AddRange(ra[0], ra[1])
+		if exp := naiveRuneSet(ra[0], ra[1]); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("range %v: expected %v, got %v", ra, exp, got)
+		}
+	}
+
+	var rs RuneSet
+	rs.AddRange(-10, 2)
+	rs.AddRange(0x10FFFE, 0x110005)
+	if exp := [][2]rune{{0, 2}, {0x10FFFE, 0x10FFFF}}; !reflect.DeepEqual(rs.Ranges(), exp) {
+		t.Fatalf("expected %v, got %v", exp, rs.Ranges())
+	}
+}
+
+func TestRuneSetOperations(t *testing.T) {
+	a := newRuneSetFromRanges([][2]rune{{'a', 'z'}, {0x0600, 0x06FF}})
+	b := newRuneSetFromRanges([][2]rune{{'x', 0x100}, {0x06FF, 0x0700}, {0x1F600, 0x1F600}})
+
+	if exp := [][2]rune{{'a', 0x100}, {0x0600, 0x0700}, {0x1F600, 0x1F600}}; !reflect.DeepEqual(a.Union(b).Ranges(), exp) {
+		t.Fatalf("union: expected %v, got %v", exp, a.Union(b).Ranges())
+	}
+	if exp := [][2]rune{{'x', 'z'}, {0x06FF, 0x06FF}}; !reflect.DeepEqual(a.Intersection(b).Ranges(), exp) {
+		t.Fatalf("intersection: expected %v, got %v", exp, a.Intersection(b).Ranges())
+	}
+	if exp := [][2]rune{{'a', 'w'}, {0x0600, 0x06FE}}; !reflect.DeepEqual(a.Difference(b).Ranges(), exp) {
+		t.Fatalf("difference: expected %v, got %v", exp, a.Difference(b).Ranges())
+	}
+
+	// empty pages are removed
+	if inter := a.Intersection(NewRuneSet('A', 0x0500)); len(inter) != 0 {
+		t.Fatalf("expected empty set, got %v", inter)
+	}
+	if diff := a.Difference(a); len(diff) != 0 {
+		t.Fatalf("expected empty set, got %v", diff)
+	}
+	if union := a.Union(nil); !reflect.DeepEqual(union, a) {
+		t.Fatalf("expected %v, got %v", a, union)
+	}
+}
+
+func TestRuneSetCovers(t *testing.T) {
+	rs := newRuneSetFromRanges([][2]rune{{'a', 'z'}, {' ', ' '}})
+	if !rs.Covers([]rune("hello world")) || !rs.Covers(nil) {
+		t.Fatal("expected text to be covered")
+	}
+	text := []rune("Hello World!")
+	if rs.Covers(text) {
+		t.Fatal("expected text not to be covered")
+	}
+	if got := rs.CoveredCount(text); got != 9 {
+		t.Fatalf("expected 9 covered runes, got %d", got)
+	}
+}
+
+func TestNewRuneSetFromCmap(t *testing.T) {
+	for _, file := range []string{"DejaVuSerif.ttf", "ToyCMAP12.otf", "NotoSansArabic.ttf"} {
+		data, err := ttruetype.Files.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		font, err := truetype.Parse(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmap, _ := font.Cmap()
+		if _, ok := cmap.(fonts.CmapRuneRanger); !ok {
+			t.Fatalf("%s: expected range based cmap", file)
+		}
+
+		var exp RuneSet
+		for iter := cmap.Iter(); iter.Next(); {
+			// the iterator also returns some unmapped runes
+			if r, _ := iter.Char(); r != 0xFFFF {
+				if _, ok := cmap.Lookup(r); ok {
+					exp.Add(r)
+				}
+			}
+		}
+		if got := NewRuneSetFromCmap(cmap); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("%s: expected %d runes, got %d", file, exp.Len(), got.Len())
+		}
+	}
+
+	// generic cmaps are iterated
+	cmap := fonts.CmapSimple{'c': 1, 'a': 2, 'b': 3, 0x0600: 4}
+	if exp := [][2]rune{{'a', 'c'}, {0x0600, 0x0600}}; !reflect.DeepEqual(NewRuneSetFromCmap(cmap).Ranges(), exp) {
+		t.Fatalf("expected %v, got %v", exp, NewRuneSetFromCmap(cmap).Ranges())
+	}
+}
@@ -0,0 +1,274 @@
+package fontscan
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unicode"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/binaryreader"
+	"github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+// The binary format of an index (big endian) starts with the magic tag "FSCN",
+// the format version (uint16) and the number of footprints (uint32).
+// Each footprint is then stored as :
+//   - the file path (string), the index in the file (uint16),
+//     the size (int64) and modification time (int64, Unix nanoseconds) of the file
+//   - the family and additional style (string)
+//   - the style (uint8), weight and stretch (float32)
+//   - the number of scripts (uint16), followed by the scripts (uint32)
+//   - the number of axes (uint16), followed by the axes : tag (uint32), minimum, default and maximum (float32)
+//   - the number of rune ranges (uvarint), followed by, for each sorted range [start, end],
+//     start - previous end (uvarint) and end - start (uvarint)
+//
+// where strings are stored as their length (uint16) followed by their bytes.
+//
+// The version is incremented when the format changes, so that
+// outdated indexes are detected.
+const (
+	indexMagic   = "FSCN"
+	indexVersion = 2
+)
+
+// Serialize writes a binary representation of the index to `w`,
+// which may be read back with ReadIndex.
+func (idx Index) Serialize(w io.Writer) error {
+	out := make([]byte, 0, 1024)
+	out = append(out, indexMagic...)
+	out = appendUint16(out, indexVersion)
+	out = appendUint32(out, uint32(len(idx)))
+	for i := range idx {
+		var err error
+		out, err = idx[i].serializeTo(out)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(out)
+	return err
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendFloat32(b []byte, v float32) []byte {
+	return appendUint32(b, math.Float32bits(v))
+}
+
+func appendString(b []byte, s string) ([]byte, error) {
+	if len(s) > math.MaxUint16 {
+		return nil, fmt.Errorf("string too long for serialization: %s", s)
+	}
+	b = appendUint16(b, uint16(len(s)))
+	return append(b, s...), nil
+}
+
+func (fp *Footprint) serializeTo(out []byte) ([]byte, error) {
+	var err error
+	out, err = appendString(out, fp.Location.File)
+	if err != nil {
+		return nil, err
+	}
+	out = appendUint16(out, fp.Location.Index)
+	out = appendUint64(out, uint64(fp.stamp.size))
+	out = appendUint64(out, uint64(fp.stamp.modTime))
+	out, err = appendString(out, fp.Family)
+	if err != nil {
+		return nil, err
+	}
+	out, err = appendString(out, fp.AdditionalStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	out = append(out, byte(fp.Aspect.Style))
+	out = appendFloat32(out, float32(fp.Aspect.Weight))
+	out = appendFloat32(out, float32(fp.Aspect.Stretch))
+
+	out = appendUint16(out, uint16(len(fp.Scripts)))
+	for _, script := range fp.Scripts {
+		out = appendUint32(out, uint32(script))
+	}
+
+	out = appendUint16(out, uint16(len(fp.Axes)))
+	for _, axis := range fp.Axes {
+		out = appendUint32(out, uint32(axis.Tag))
+		out = appendFloat32(out, axis.Minimum)
+		out = appendFloat32(out, axis.Default)
+		out = appendFloat32(out, axis.Maximum)
+	}
+
+	ranges := fp.Runes.Ranges()
+	out = appendUvarint(out, uint64(len(ranges)))
+	var previousEnd rune
+	for _, ra := range ranges {
+		out = appendUvarint(out, uint64(ra[0]-previousEnd))
+		out = appendUvarint(out, uint64(ra[1]-ra[0]))
+		previousEnd = ra[1]
+	}
+	return out, nil
+}
+
+// ReadIndex reads an index serialized with Index.Serialize.
+func ReadIndex(r io.Reader) (Index, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(indexMagic)+6 || string(data[:len(indexMagic)]) != indexMagic {
+		return nil, errors.New("invalid font index header")
+	}
+	data = data[len(indexMagic):]
+	if version := binary.BigEndian.Uint16(data); version != indexVersion {
+		return nil, fmt.Errorf("unsupported font index version %d", version)
+	}
+	count := binary.BigEndian.Uint32(data[2:])
+	// sanitize before allocating: a footprint takes at least 38 bytes
+	if int(count) > len(data)/38 {
+		return nil, errors.New("invalid font index (EOF)")
+	}
+
+	src := indexReader{Reader: binaryreader.NewReader(data[6:])}
+	out := make(Index, count)
+	for i := range out {
+		out[i] = src.footprint()
+	}
+	if src.err != nil {
+		return nil, fmt.Errorf("invalid font index: %s", src.err)
+	}
+	return out, nil
+}
+
+// indexReader wraps a binaryreader.Reader, keeping
+// the first error encountered
+type indexReader struct {
+	*binaryreader.Reader
+	err error
+}
+
+func (r *indexReader) uint8() uint8 {
+	v, err := r.Byte()
+	if r.err == nil {
+		r.err = err
+	}
+	return v
+}
+
+func (r *indexReader) uint16() uint16 {
+	v, err := r.Uint16()
+	if r.err == nil {
+		r.err = err
+	}
+	return v
+}
+
+func (r *indexReader) uint32() uint32 {
+	v, err := r.Uint32()
+	if r.err == nil {
+		r.err = err
+	}
+	return v
+}
+
+func (r *indexReader) uint64() uint64 {
+	return uint64(r.uint32())<<32 | uint64(r.uint32())
+}
+
+func (r *indexReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.Data())
+	if n <= 0 {
+		if r.err == nil {
+			r.err = errors.New("invalid uvarint")
+		}
+		return 0
+	}
+	r.Skip(n)
+	return v
+}
+
+func (r *indexReader) float32() float32 { return math.Float32frombits(r.uint32()) }
+
+func (r *indexReader) string() string {
+	length := int(r.uint16())
+	b, err := r.FixedSizes(length, 1)
+	if r.err == nil {
+		r.err = err
+	}
+	return string(b)
+}
+
+func (r *indexReader) footprint() (out Footprint) {
+	out.Location.File = r.string()
+	out.Location.Index = r.uint16()
+	out.stamp.size = int64(r.uint64())
+	out.stamp.modTime = int64(r.uint64())
+	out.Family = r.string()
+	out.AdditionalStyle = r.string()
+
+	out.Aspect.Style = fonts.Style(r.uint8())
+	out.Aspect.Weight = fonts.Weight(r.float32())
+	out.Aspect.Stretch = fonts.Stretch(r.float32())
+
+	if count := int(r.uint16()); count != 0 && r.err == nil {
+		out.Scripts = make([]language.Script, count)
+		for i := range out.Scripts {
+			out.Scripts[i] = language.Script(r.uint32())
+		}
+	}
+
+	if count := int(r.uint16()); count != 0 && r.err == nil {
+		out.Axes = make([]VariationAxis, count)
+		for i := range out.Axes {
+			out.Axes[i].Tag = truetype.Tag(r.uint32())
+			out.Axes[i].Minimum = r.float32()
+			out.Axes[i].Default = r.float32()
+			out.Axes[i].Maximum = r.float32()
+		}
+	}
+
+	count := r.uvarint()
+	if r.err != nil || count == 0 {
+		return out
+	}
+	// sanitize before allocating: a range takes at least 2 bytes
+	if count > uint64(len(r.Data())/2) {
+		r.err = errors.New("EOF")
+		return out
+	}
+	ranges := make([][2]rune, count)
+	var previousEnd uint64
+	for i := range ranges {
+		start := previousEnd + r.uvarint()
+		end := start + r.uvarint()
+		if end > unicode.MaxRune || (i != 0 && start <= previousEnd) {
+			if r.err == nil {
+				r.err = errors.New("invalid rune range")
+			}
+			return out
+		}
+		ranges[i] = [2]rune{rune(start), rune(end)}
+		previousEnd = end
+	}
+	out.Runes = newRuneSetFromRanges(ranges)
+	return out
+}
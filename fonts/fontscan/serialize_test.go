@@ -0,0 +1,41 @@
+package fontscan
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSerializeIndex(t *testing.T) {
+	dir := setupFontDirectory(t)
+	index, err := ScanDirectories(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = index.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	got, err := ReadIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(index, got) {
+		t.Fatal("index modified by serialization")
+	}
+
+	// corrupted inputs
+	for _, input := range [][]byte{
+		nil,
+		[]byte("FSCN"),
+		append([]byte("FSCN\x00\x09"), data[6:]...), // unknown version
+		data[:len(data)-10],
+	} {
+		if _, err = ReadIndex(bytes.NewReader(input)); err == nil {
+			t.Fatal("expected error for invalid index")
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package fonts
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// this file implements Pen for GlyphOutline, and
+// provides geometric utilities on glyph outlines
+
+// MoveTo implements Pen.
+func (o *GlyphOutline) MoveTo(to SegmentPoint) {
+	o.Segments = append(o.Segments, Segment{Op: SegmentOpMoveTo, Args: [3]SegmentPoint{to}})
+}
+
+// LineTo implements Pen.
+func (o *GlyphOutline) LineTo(to SegmentPoint) {
+	o.Segments = append(o.Segments, Segment{Op: SegmentOpLineTo, Args: [3]SegmentPoint{to}})
+}
+
+// QuadTo implements Pen.
+func (o *GlyphOutline) QuadTo(ctrl, to SegmentPoint) {
+	o.Segments = append(o.Segments, Segment{Op: SegmentOpQuadTo, Args: [3]SegmentPoint{ctrl, to}})
+}
+
+// CubeTo implements Pen.
+func (o *GlyphOutline) CubeTo(ctrl1, ctrl2, to SegmentPoint) {
+	o.Segments = append(o.Segments, Segment{Op: SegmentOpCubeTo, Args: [3]SegmentPoint{ctrl1, ctrl2, to}})
+}
+
+// Close implements Pen. It does nothing, since contours
+// are implicitly closed by the next MoveTo.
+func (o *GlyphOutline) Close() {}
+
+// Draw sends the segments of the outline to `pen`,
+// closing each contour.
+func (o GlyphOutline) Draw(pen Pen) {
+	for i, seg := range o.Segments {
+		switch seg.Op {
+		case SegmentOpMoveTo:
+			if i != 0 {
+				pen.Close()
+			}
+			pen.MoveTo(seg.Args[0])
+		case SegmentOpLineTo:
+			pen.LineTo(seg.Args[0])
+		case SegmentOpQuadTo:
+			pen.QuadTo(seg.Args[0], seg.Args[1])
+		case SegmentOpCubeTo:
+			pen.CubeTo(seg.Args[0], seg.Args[1], seg.Args[2])
+		}
+	}
+	if len(o.Segments) != 0 {
+		pen.Close()
+	}
+}
+
+// Rect is an axis aligned rectangle, in font units.
+type Rect struct {
+	XMin, YMin, XMax, YMax float32
+}
+
+// ToExtents converts the rectangle to glyph extents.
+func (r Rect) ToExtents() GlyphExtents {
+	return GlyphExtents{
+		XBearing: r.XMin,
+		YBearing: r.YMax,
+		Width:    r.XMax - r.XMin,
+		Height:   r.YMin - r.YMax,
+	}
+}
+
+func (r *Rect) add(pt SegmentPoint) {
+	if pt.X < r.XMin {
+		r.XMin = pt.X
+	}
+	if pt.X > r.XMax {
+		r.XMax = pt.X
+	}
+	if pt.Y < r.YMin {
+		r.YMin = pt.Y
+	}
+	if pt.Y > r.YMax {
+		r.YMax = pt.Y
+	}
+}
+
+// Matrix is an affine transformation [a b c d e f],
+// mapping (x, y) to (a*x + c*y + e, b*x + d*y + f), as
+// the PostScript FontMatrix.
+type Matrix [6]float32
+
+// Identity is the identity transformation.
+var Identity = Matrix{1, 0, 0, 1, 0, 0}
+
+// Apply returns the transformed point.
+func (m Matrix) Apply(pt SegmentPoint) SegmentPoint {
+	return SegmentPoint{
+		X: m[0]*pt.X + m[2]*pt.Y + m[4],
+		Y: m[1]*pt.X + m[3]*pt.Y + m[5],
+	}
+}
+
+// Mul returns the transformation applying `m`, then `other`.
+func (m Matrix) Mul(other Matrix) Matrix {
+	return Matrix{
+		m[0]*other[0] + m[1]*other[2],
+		m[0]*other[1] + m[1]*other[3],
+		m[2]*other[0] + m[3]*other[2],
+		m[2]*other[1] + m[3]*other[3],
+		m[4]*other[0] + m[5]*other[2] + other[4],
+		m[4]*other[1] + m[5]*other[3] + other[5],
+	}
+}
+
+// end returns the last point of the segment
+func (s *Segment) end() SegmentPoint {
+	args := s.ArgsSlice()
+	return args[len(args)-1]
+}
+
+// point operations, in float64 for precision
+
+type vec struct{ x, y float64 }
+
+func toVec(pt SegmentPoint) vec { return vec{float64(pt.X), float64(pt.Y)} }
+
+func (v vec) toPoint() SegmentPoint { return SegmentPoint{float32(v.x), float32(v.y)} }
+
+func (v vec) add(u vec) vec { return vec{v.x + u.x, v.y + u.y} }
+
+func (v vec) sub(u vec) vec { return vec{v.x - u.x, v.y - u.y} }
+
+func (v vec) scale(s float64) vec { return vec{v.x * s, v.y * s} }
+
+func (v vec) norm() float64 { return math.Hypot(v.x, v.y) }
+
+func lerp(a, b vec, t float64) vec { return a.add(b.sub(a).scale(t)) }
+
+func quadAt(p0, p1, p2 vec, t float64) vec {
+	return lerp(lerp(p0, p1, t), lerp(p1, p2, t), t)
+}
+
+func cubeAt(p0, p1, p2, p3 vec, t float64) vec {
+	return quadAt(lerp(p0, p1, t), lerp(p1, p2, t), lerp(p2, p3, t), t)
+}
+
+// splitCube splits the cubic Bézier curve at t = 0.5 (de Casteljau algorithm)
+func splitCube(p0, p1, p2, p3 vec) (left, right [4]vec) {
+	p01, p12, p23 := lerp(p0, p1, 0.5), lerp(p1, p2, 0.5), lerp(p2, p3, 0.5)
+	p012, p123 := lerp(p01, p12, 0.5), lerp(p12, p23, 0.5)
+	mid := lerp(p012, p123, 0.5)
+	return [4]vec{p0, p01, p012, mid}, [4]vec{mid, p123, p23, p3}
+}
+
+// quadExtremum returns the parameter t in (0, 1) where the derivative
+// of the quadratic Bézier (1D) vanishes, if any.
+func quadExtremum(p0, p1, p2 float64) (float64, bool) {
+	den := p0 - 2*p1 + p2
+	if den == 0 {
+		return 0, false
+	}
+	t := (p0 - p1) / den
+	return t, 0 < t && t < 1
+}
+
+// cubeExtrema returns the parameters in (0, 1) where the derivative
+// of the cubic Bézier (1D) vanishes.
+func cubeExtrema(p0, p1, p2, p3 float64) []float64 {
+	// B'(t) / 3 = a t^2 + b t + c
+	a := p3 - 3*p2 + 3*p1 - p0
+	b := 2 * (p2 - 2*p1 + p0)
+	c := p1 - p0
+	var roots []float64
+	const epsilon = 1e-12
+	if math.Abs(a) < epsilon {
+		if math.Abs(b) >= epsilon {
+			roots = append(roots, -c/b)
+		}
+	} else if delta := b*b - 4*a*c; delta >= 0 {
+		sq := math.Sqrt(delta)
+		roots = append(roots, (-b+sq)/(2*a), (-b-sq)/(2*a))
+	}
+	out := roots[:0]
+	for _, t := range roots {
+		if 0 < t && t < 1 {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Bounds returns the tight bounding box of the outline, taking into
+// account the exact extrema of the Bézier curves (and not their control points).
+// An empty outline has empty bounds.
+func (o GlyphOutline) Bounds() Rect {
+	if len(o.Segments) == 0 {
+		return Rect{}
+	}
+	first := o.Segments[0].Args[0]
+	bounds := Rect{XMin: first.X, XMax: first.X, YMin: first.Y, YMax: first.Y}
+	var current SegmentPoint
+	for _, seg := range o.Segments {
+		switch seg.Op {
+		case SegmentOpMoveTo, SegmentOpLineTo:
+			bounds.add(seg.Args[0])
+		case SegmentOpQuadTo:
+			p0, p1, p2 := toVec(current), toVec(seg.Args[0]), toVec(seg.Args[1])
+			bounds.add(seg.Args[1])
+			if t, ok := quadExtremum(p0.x, p1.x, p2.x); ok {
+				bounds.add(quadAt(p0, p1, p2, t).toPoint())
+			}
+			if t, ok := quadExtremum(p0.y, p1.y, p2.y); ok {
+				bounds.add(quadAt(p0, p1, p2, t).toPoint())
+			}
+		case SegmentOpCubeTo:
+			p0, p1, p2, p3 := toVec(current), toVec(seg.Args[0]), toVec(seg.Args[1]), toVec(seg.Args[2])
+			bounds.add(seg.Args[2])
+			for _, t := range cubeExtrema(p0.x, p1.x, p2.x, p3.x) {
+				bounds.add(cubeAt(p0, p1, p2, p3, t).toPoint())
+			}
+			for _, t := range cubeExtrema(p0.y, p1.y, p2.y, p3.y) {
+				bounds.add(cubeAt(p0, p1, p2, p3, t).toPoint())
+			}
+		}
+		current = seg.end()
+	}
+	return bounds
+}
+
+// Transform returns a copy of the outline, transformed by `m`.
+func (o GlyphOutline) Transform(m Matrix) GlyphOutline {
+	out := make([]Segment, len(o.Segments))
+	for i, seg := range o.Segments {
+		out[i].Op = seg.Op
+		for j, pt := range seg.ArgsSlice() {
+			out[i].Args[j] = m.Apply(pt)
+		}
+	}
+	return GlyphOutline{Segments: out}
+}
+
+// maximum number of subdivisions of one cubic curve
+// (giving at most 2^maxCubicSplits quadratic curves)
+const maxCubicSplits = 10
+
+// CubicsToQuads returns a copy of the outline where cubic Bézier curves are
+// approximated by quadratic ones (as required by TrueType outlines),
+// with a maximum distance of `tolerance` (in font units) to the original curves.
+func (o GlyphOutline) CubicsToQuads(tolerance float32) GlyphOutline {
+	out := make([]Segment, 0, len(o.Segments))
+	var current SegmentPoint
+	for _, seg := range o.Segments {
+		if seg.Op == SegmentOpCubeTo {
+			out = appendQuads(out, toVec(current), toVec(seg.Args[0]), toVec(seg.Args[1]), toVec(seg.Args[2]),
+				float64(tolerance), 0)
+		} else {
+			out = append(out, seg)
+		}
+		current = seg.end()
+	}
+	return GlyphOutline{Segments: out}
+}
+
+func appendQuads(out []Segment, p0, p1, p2, p3 vec, tolerance float64, depth int) []Segment {
+	// the distance between the cubic curve and the quadratic curve
+	// with control point (3(p1 + p2) - p0 - p3) / 4 is at most
+	// sqrt(3) / 36 * |p3 - 3p2 + 3p1 - p0|
+	err := math.Sqrt(3) / 36 * p3.sub(p2.scale(3)).add(p1.scale(3)).sub(p0).norm()
+	if err <= tolerance || depth == maxCubicSplits {
+		ctrl := p1.add(p2).scale(3).sub(p0).sub(p3).scale(0.25)
+		return append(out, Segment{Op: SegmentOpQuadTo, Args: [3]SegmentPoint{ctrl.toPoint(), p3.toPoint()}})
+	}
+	left, right := splitCube(p0, p1, p2, p3)
+	out = appendQuads(out, left[0], left[1], left[2], left[3], tolerance, depth+1)
+	return appendQuads(out, right[0], right[1], right[2], right[3], tolerance, depth+1)
+}
+
+// maximum number of lines used to flatten one curve
+const maxFlattenLines = 1000
+
+// linesCount returns the number of uniform subdivisions of a curve
+// whose second derivative is bounded by `maxDD`, so that the distance
+// between the lines and the curve is at most `tolerance`.
+func linesCount(maxDD, tolerance float64) int {
+	if tolerance <= 0 {
+		return maxFlattenLines
+	}
+	n := int(math.Ceil(math.Sqrt(maxDD / (8 * tolerance))))
+	if n < 1 {
+		return 1
+	}
+	if n > maxFlattenLines {
+		return maxFlattenLines
+	}
+	return n
+}
+
+// Flatten returns a copy of the outline where Bézier curves are
+// replaced by lines, with a maximum distance of `tolerance` (in font units) to the original curves.
+func (o GlyphOutline) Flatten(tolerance float32) GlyphOutline {
+	out := make([]Segment, 0, len(o.Segments))
+	appendLine := func(pt vec) {
+		out = append(out, Segment{Op: SegmentOpLineTo, Args: [3]SegmentPoint{pt.toPoint()}})
+	}
+	var current SegmentPoint
+	for _, seg := range o.Segments {
+		switch seg.Op {
+		case SegmentOpQuadTo:
+			p0, p1, p2 := toVec(current), toVec(seg.Args[0]), toVec(seg.Args[1])
+			n := linesCount(2*p0.sub(p1.scale(2)).add(p2).norm(), float64(tolerance))
+			for i := 1; i < n; i++ {
+				appendLine(quadAt(p0, p1, p2, float64(i)/float64(n)))
+			}
+			out = append(out, Segment{Op: SegmentOpLineTo, Args: [3]SegmentPoint{seg.Args[1]}})
+		case SegmentOpCubeTo:
+			p0, p1, p2, p3 := toVec(current), toVec(seg.Args[0]), toVec(seg.Args[1]), toVec(seg.Args[2])
+			dd := math.Max(p0.sub(p1.scale(2)).add(p2).norm(), p1.sub(p2.scale(2)).add(p3).norm())
+			n := linesCount(6*dd, float64(tolerance))
+			for i := 1; i < n; i++ {
+				appendLine(cubeAt(p0, p1, p2, p3, float64(i)/float64(n)))
+			}
+			out = append(out, Segment{Op: SegmentOpLineTo, Args: [3]SegmentPoint{seg.Args[2]}})
+		default:
+			out = append(out, seg)
+		}
+		current = seg.end()
+	}
+	return GlyphOutline{Segments: out}
+}
+
+// Reverse returns a copy of the outline where the direction
+// of each contour is reversed. Contours are implicitly closed, and
+// the reversed contours start at the last point of the original ones.
+func (o GlyphOutline) Reverse() GlyphOutline {
+	out := make([]Segment, 0, len(o.Segments))
+	for start := 0; start < len(o.Segments); {
+		end := start + 1
+		for end < len(o.Segments) && o.Segments[end].Op != SegmentOpMoveTo {
+			end++
+		}
+		out = appendReversedContour(out, o.Segments[start:end])
+		start = end
+	}
+	return GlyphOutline{Segments: out}
+}
+
+// contour starts with a move, except maybe for the first one
+func appendReversedContour(out []Segment, contour []Segment) []Segment {
+	last := contour[len(contour)-1].end()
+	out = append(out, Segment{Op: SegmentOpMoveTo, Args: [3]SegmentPoint{last}})
+	for i := len(contour) - 1; i >= 1; i-- {
+		seg, previous := contour[i], contour[i-1].end()
+		reversed := Segment{Op: seg.Op}
+		switch seg.Op {
+		case SegmentOpLineTo:
+			reversed.Args[0] = previous
+		case SegmentOpQuadTo:
+			reversed.Args[0], reversed.Args[1] = seg.Args[0], previous
+		case SegmentOpCubeTo:
+			reversed.Args[0], reversed.Args[1], reversed.Args[2] = seg.Args[1], seg.Args[0], previous
+		default: // should not happen
+			continue
+		}
+		out = append(out, reversed)
+	}
+	return out
+}
+
+func formatFloat(v float32) string { return strconv.FormatFloat(float64(v), 'f', -1, 32) }
+
+// SVGPath returns the outline as the content of the 'd' attribute of an SVG <path> element.
+// Since the SVG Y axis increases down, outlines should usually be transformed
+// by Matrix{1, 0, 0, -1, 0, 0} (that is, flipped) beforehand.
+func (o GlyphOutline) SVGPath() string {
+	var sb strings.Builder
+	for i, seg := range o.Segments {
+		if seg.Op == SegmentOpMoveTo && i != 0 {
+			sb.WriteString("Z ")
+		}
+		switch seg.Op {
+		case SegmentOpMoveTo:
+			sb.WriteByte('M')
+		case SegmentOpLineTo:
+			sb.WriteByte('L')
+		case SegmentOpQuadTo:
+			sb.WriteByte('Q')
+		case SegmentOpCubeTo:
+			sb.WriteByte('C')
+		}
+		for j, pt := range seg.ArgsSlice() {
+			if j != 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(formatFloat(pt.X))
+			sb.WriteByte(' ')
+			sb.WriteString(formatFloat(pt.Y))
+		}
+		sb.WriteByte(' ')
+	}
+	if len(o.Segments) != 0 {
+		sb.WriteByte('Z')
+	}
+	return sb.String()
+}
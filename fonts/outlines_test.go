@@ -0,0 +1,204 @@
+package fonts_test
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	testdatatt "github.com/benoitkugler/textlayout-testdata/truetype"
+	testdatacff "github.com/benoitkugler/textlayout-testdata/type1C"
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+	type1c "github.com/benoitkugler/textlayout/fonts/type1C"
+)
+
+// testOutlines returns the outlines of a TrueType (quadratic) and a CFF (cubic) font.
+func testOutlines(t *testing.T) []fonts.GlyphOutline {
+	var out []fonts.GlyphOutline
+
+	b, err := testdatatt.Files.ReadFile("Roboto-BoldItalic.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ttf, err := tt.Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for gid := 0; gid < ttf.NumGlyphs; gid += 7 {
+		if outline, ok := ttf.GlyphData(fonts.GID(gid), 0, 0).(fonts.GlyphOutline); ok {
+			out = append(out, outline)
+		}
+	}
+
+	b, err = testdatacff.Files.ReadFile("AAAPKB+SourceSansPro-Bold.cff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cff, err := type1c.Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for gid := 0; gid < cff.NumGlyphs(); gid++ {
+		out = append(out, cff.GlyphData(fonts.GID(gid), 0, 0).(fonts.GlyphOutline))
+	}
+	return out
+}
+
+// controlBounds returns the bounds of all the points, including control points
+func controlBounds(o fonts.GlyphOutline) fonts.Rect {
+	r := fonts.Rect{XMin: math.MaxFloat32, YMin: math.MaxFloat32, XMax: -math.MaxFloat32, YMax: -math.MaxFloat32}
+	for _, seg := range o.Segments {
+		for _, pt := range seg.ArgsSlice() {
+			r.XMin, r.XMax = float32(math.Min(float64(r.XMin), float64(pt.X))), float32(math.Max(float64(r.XMax), float64(pt.X)))
+			r.YMin, r.YMax = float32(math.Min(float64(r.YMin), float64(pt.Y))), float32(math.Max(float64(r.YMax), float64(pt.Y)))
+		}
+	}
+	return r
+}
+
+func rectsClose(r1, r2 fonts.Rect, tolerance float32) bool {
+	abs := func(v float32) float32 { return float32(math.Abs(float64(v))) }
+	return abs(r1.XMin-r2.XMin) <= tolerance && abs(r1.XMax-r2.XMax) <= tolerance &&
+		abs(r1.YMin-r2.YMin) <= tolerance && abs(r1.YMax-r2.YMax) <= tolerance
+}
+
+// signedArea uses the shoelace formula on a flattened outline
+func signedArea(o fonts.GlyphOutline) float64 {
+	var (
+		area         float64
+		start, prev  fonts.SegmentPoint
+		closeContour = func() {
+			area += float64(prev.X*start.Y - start.X*prev.Y)
+		}
+	)
+	for _, seg := range o.Flatten(0.1).Segments {
+		pt := seg.Args[0]
+		if seg.Op == fonts.SegmentOpMoveTo {
+			closeContour()
+			start = pt
+		} else {
+			area += float64(prev.X*pt.Y - pt.X*prev.Y)
+		}
+		prev = pt
+	}
+	closeContour()
+	return area / 2
+}
+
+func TestBounds(t *testing.T) {
+	// a cubic curve whose extremum is not a control point
+	outline := fonts.GlyphOutline{Segments: []fonts.Segment{
+		{Op: fonts.SegmentOpMoveTo, Args: [3]fonts.SegmentPoint{{X: 0, Y: 0}}},
+		{Op: fonts.SegmentOpCubeTo, Args: [3]fonts.SegmentPoint{{X: 0, Y: 100}, {X: 100, Y: 100}, {X: 100, Y: 0}}},
+		{Op: fonts.SegmentOpQuadTo, Args: [3]fonts.SegmentPoint{{X: 50, Y: -100}, {X: 0, Y: 0}}},
+	}}
+	if exp := (fonts.Rect{XMin: 0, YMin: -50, XMax: 100, YMax: 75}); outline.Bounds() != exp {
+		t.Fatalf("expected %v, got %v", exp, outline.Bounds())
+	}
+	if (fonts.GlyphOutline{}).Bounds() != (fonts.Rect{}) {
+		t.Fatal("expected empty bounds")
+	}
+
+	for _, outline := range testOutlines(t) {
+		if len(outline.Segments) == 0 {
+			continue
+		}
+		bounds, control := outline.Bounds(), controlBounds(outline)
+		if bounds.XMin < control.XMin || bounds.YMin < control.YMin || bounds.XMax > control.XMax || bounds.YMax > control.YMax {
+			t.Fatalf("bounds %v should be inside the control box %v", bounds, control)
+		}
+		// compare with a fine approximation
+		if flat := outline.Flatten(0.01).Bounds(); !rectsClose(flat, bounds, 0.02) {
+			t.Fatalf("expected %v, got %v", flat, bounds)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	for _, outline := range testOutlines(t) {
+		if len(outline.Segments) == 0 {
+			continue
+		}
+		bounds := outline.Bounds()
+		moved := outline.Transform(fonts.Matrix{2, 0, 0, 2, 10, -20}).Bounds()
+		exp := fonts.Rect{XMin: 2*bounds.XMin + 10, YMin: 2*bounds.YMin - 20, XMax: 2*bounds.XMax + 10, YMax: 2*bounds.YMax - 20}
+		if !rectsClose(moved, exp, 0.01) {
+			t.Fatalf("expected %v, got %v", exp, moved)
+		}
+
+		// flipping the outline reverses the orientation
+		flip := fonts.Matrix{1, 0, 0, -1, 0, 0}
+		area, flipped := signedArea(outline), signedArea(outline.Transform(flip))
+		if math.Abs(area+flipped) > 1e-3*math.Abs(area)+1 {
+			t.Fatalf("unexpected area %f %f", area, flipped)
+		}
+		if back := outline.Transform(flip.Mul(flip)); !rectsClose(back.Bounds(), bounds, 0) {
+			t.Fatal("flipping twice should be the identity")
+		}
+	}
+}
+
+func TestReverse(t *testing.T) {
+	for _, outline := range testOutlines(t) {
+		reversed := outline.Reverse()
+		area, reversedArea := signedArea(outline), signedArea(reversed)
+		if math.Abs(area+reversedArea) > 1e-3*math.Abs(area)+1 {
+			t.Fatalf("unexpected area %f %f", area, reversedArea)
+		}
+		if !rectsClose(reversed.Bounds(), outline.Bounds(), 0.01) {
+			t.Fatal("reversing should not change the bounds")
+		}
+		if twice := reversed.Reverse(); len(outline.Segments) != 0 && !reflect.DeepEqual(twice.Segments, outline.Segments) {
+			t.Fatal("reversing twice should be the identity")
+		}
+	}
+}
+
+func TestCubicsToQuads(t *testing.T) {
+	for _, outline := range testOutlines(t) {
+		quads := outline.CubicsToQuads(0.5)
+		for _, seg := range quads.Segments {
+			if seg.Op == fonts.SegmentOpCubeTo {
+				t.Fatal("unexpected cubic segment")
+			}
+		}
+		if !rectsClose(quads.Bounds(), outline.Bounds(), 0.5) {
+			t.Fatalf("expected %v, got %v", outline.Bounds(), quads.Bounds())
+		}
+		area, quadsArea := signedArea(outline), signedArea(quads)
+		if math.Abs(area-quadsArea) > 1e-2*math.Abs(area)+1 {
+			t.Fatalf("unexpected area %f %f", area, quadsArea)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	for _, outline := range testOutlines(t) {
+		flat := outline.Flatten(1)
+		for _, seg := range flat.Segments {
+			if seg.Op != fonts.SegmentOpMoveTo && seg.Op != fonts.SegmentOpLineTo {
+				t.Fatal("unexpected curve segment")
+			}
+		}
+		if !rectsClose(flat.Bounds(), outline.Bounds(), 1) {
+			t.Fatalf("expected %v, got %v", outline.Bounds(), flat.Bounds())
+		}
+	}
+}
+
+func TestSVGPath(t *testing.T) {
+	outline := fonts.GlyphOutline{Segments: []fonts.Segment{
+		{Op: fonts.SegmentOpMoveTo, Args: [3]fonts.SegmentPoint{{X: 0, Y: 0}}},
+		{Op: fonts.SegmentOpLineTo, Args: [3]fonts.SegmentPoint{{X: 10.5, Y: 0}}},
+		{Op: fonts.SegmentOpQuadTo, Args: [3]fonts.SegmentPoint{{X: 20, Y: 10}, {X: 10, Y: 20}}},
+		{Op: fonts.SegmentOpMoveTo, Args: [3]fonts.SegmentPoint{{X: -5, Y: 5}}},
+		{Op: fonts.SegmentOpCubeTo, Args: [3]fonts.SegmentPoint{{X: 1, Y: 2}, {X: 3, Y: 4}, {X: 5, Y: 6}}},
+	}}
+	if exp, got := "M0 0 L10.5 0 Q20 10 10 20 Z M-5 5 C1 2 3 4 5 6 Z", outline.SVGPath(); got != exp {
+		t.Fatalf("expected %s, got %s", exp, got)
+	}
+	if (fonts.GlyphOutline{}).SVGPath() != "" {
+		t.Fatal("expected empty path")
+	}
+}
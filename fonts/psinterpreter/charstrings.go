@@ -55,10 +55,15 @@ func (p Point) toSP() fonts.SegmentPoint {
 // CharstringReader provides implementation
 // of the operators found in a font charstring.
 type CharstringReader struct {
+	// Pen, if not nil, receives the glyph outlines,
+	// which are then not stored in Segments
+	Pen fonts.Pen
 	// Acumulated segments for the glyph outlines
 	Segments []fonts.Segment
 	// Acumulated bounds for the glyph outlines
 	Bounds PathBounds
+	// Acumulated stem hints
+	Hints GlyphHints
 
 	vstemCount   int32
 	hstemCount   int32
@@ -67,8 +72,11 @@ type CharstringReader struct {
 	CurrentPoint Point
 	firstPoint   Point // first point in path, required to check if a path is closed
 	isPathOpen   bool
+	contourOpen  bool // a move has been emitted, and the contour is not closed yet
+	nbSegments   int  // number of segments emitted
 
-	seenHintmask bool
+	seenHintmask  bool
+	type1NewHints bool // see Type1HintReplacement
 
 	// bounds for an empty path is {0,0,0,0}
 	// however, for the first point in the path,
@@ -86,34 +94,70 @@ func (out *CharstringReader) updateBounds(pt Point) {
 	out.Bounds.Enlarge(pt)
 }
 
+// Hstem reads the horizontal stems of a Type2 charstring.
 func (out *CharstringReader) Hstem(state *Machine) {
 	out.hstemCount += state.ArgStack.Top / 2
+	out.Hints.HStems = appendType2Stems(out.Hints.HStems, &state.ArgStack)
 }
 
+// Vstem reads the vertical stems of a Type2 charstring.
 func (out *CharstringReader) Vstem(state *Machine) {
 	out.vstemCount += state.ArgStack.Top / 2
+	out.Hints.VStems = appendType2Stems(out.Hints.VStems, &state.ArgStack)
 }
 
 func (out *CharstringReader) determineHintmaskSize(state *Machine) {
 	if !out.seenHintmask {
+		// implicit vstem
 		out.vstemCount += state.ArgStack.Top / 2
+		out.Hints.VStems = appendType2Stems(out.Hints.VStems, &state.ArgStack)
 		out.hintmaskSize = (out.hstemCount + out.vstemCount + 7) >> 3
 		out.seenHintmask = true
 	}
 }
 
+// Hintmask reads the hint mask following the hintmask operator
+// in a Type2 charstring.
 func (out *CharstringReader) Hintmask(state *Machine) {
 	out.determineHintmaskSize(state)
-	state.SkipBytes(out.hintmaskSize)
+	mask := out.decodeMask(state.SkipBytes(out.hintmaskSize))
+	masks := &out.Hints.HintMasks
+	if n := len(*masks); n != 0 && (*masks)[n-1].SegmentIndex == mask.SegmentIndex {
+		(*masks)[n-1] = mask // the previous mask has not been used
+	} else {
+		*masks = append(*masks, mask)
+	}
+}
+
+// emit sends the segment to Pen, or stores it
+func (out *CharstringReader) emit(seg fonts.Segment) {
+	out.nbSegments++
+	if out.Pen == nil {
+		out.Segments = append(out.Segments, seg)
+		return
+	}
+	switch seg.Op {
+	case fonts.SegmentOpMoveTo:
+		out.Pen.MoveTo(seg.Args[0])
+	case fonts.SegmentOpLineTo:
+		out.Pen.LineTo(seg.Args[0])
+	case fonts.SegmentOpCubeTo:
+		out.Pen.CubeTo(seg.Args[0], seg.Args[1], seg.Args[2])
+	}
 }
 
 func (out *CharstringReader) move(pt Point) {
-	out.ensureClosePath()
+	out.closeContour()
 
 	out.CurrentPoint.Move(pt.X, pt.Y)
 	out.isPathOpen = false
+	out.startContour()
+}
+
+func (out *CharstringReader) startContour() {
 	out.firstPoint = out.CurrentPoint
-	out.Segments = append(out.Segments, fonts.Segment{
+	out.contourOpen = true
+	out.emit(fonts.Segment{
 		Op:   fonts.SegmentOpMoveTo,
 		Args: [3]fonts.SegmentPoint{out.CurrentPoint.toSP()},
 	})
@@ -121,19 +165,25 @@ func (out *CharstringReader) move(pt Point) {
 
 // pt is in absolute coordinates
 func (out *CharstringReader) line(pt Point) {
+	if !out.contourOpen { // missing move
+		out.startContour()
+	}
 	if !out.isPathOpen {
 		out.isPathOpen = true
 		out.updateBounds(out.CurrentPoint)
 	}
 	out.CurrentPoint = pt
 	out.updateBounds(pt)
-	out.Segments = append(out.Segments, fonts.Segment{
+	out.emit(fonts.Segment{
 		Op:   fonts.SegmentOpLineTo,
 		Args: [3]fonts.SegmentPoint{pt.toSP()},
 	})
 }
 
 func (out *CharstringReader) curve(pt1, pt2, pt3 Point) {
+	if !out.contourOpen { // missing move
+		out.startContour()
+	}
 	if !out.isPathOpen {
 		out.isPathOpen = true
 		out.updateBounds(out.CurrentPoint)
@@ -143,7 +193,7 @@ func (out *CharstringReader) curve(pt1, pt2, pt3 Point) {
 	out.updateBounds(pt2)
 	out.CurrentPoint = pt3
 	out.updateBounds(pt3)
-	out.Segments = append(out.Segments, fonts.Segment{
+	out.emit(fonts.Segment{
 		Op:   fonts.SegmentOpCubeTo,
 		Args: [3]fonts.SegmentPoint{pt1.toSP(), pt2.toSP(), pt3.toSP()},
 	})
@@ -154,13 +204,22 @@ func (out *CharstringReader) doubleCurve(pt1, pt2, pt3, pt4, pt5, pt6 Point) {
 	out.curve(pt4, pt5, pt6)
 }
 
-func (out *CharstringReader) ensureClosePath() {
+// closeContour adds a segment to the first point of the
+// current contour if needed.
+func (out *CharstringReader) closeContour() {
+	if !out.contourOpen {
+		return
+	}
 	if out.firstPoint != out.CurrentPoint {
-		out.Segments = append(out.Segments, fonts.Segment{
+		out.emit(fonts.Segment{
 			Op:   fonts.SegmentOpLineTo,
 			Args: [3]fonts.SegmentPoint{out.firstPoint.toSP()},
 		})
 	}
+	if out.Pen != nil {
+		out.Pen.Close()
+	}
+	out.contourOpen = false
 }
 
 func abs(x int32) int32 {
@@ -193,7 +252,7 @@ func GlobalSubr(state *Machine) error {
 // ClosePath closes the current contour, adding
 // a segment to the first point if needed.
 func (out *CharstringReader) ClosePath() {
-	out.ensureClosePath()
+	out.closeContour()
 	out.isPathOpen = false
 }
 
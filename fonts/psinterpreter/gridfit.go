@@ -0,0 +1,283 @@
+package psinterpreter
+
+import (
+	"math"
+	"sort"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// HintingParams are the font-wide hinting values stored
+// in the Private dict of Type1 and CFF fonts, in font units.
+type HintingParams struct {
+	// Pairs of alignment zones : the first one is the baseline zone,
+	// the other ones are top zones.
+	BlueValues []float32
+	// Pairs of bottom alignment zones (descenders)
+	OtherBlues []float32
+
+	StdHW, StdVW         float32 // dominant stem widths
+	StemSnapH, StemSnapV []float32
+
+	BlueScale, BlueShift, BlueFuzz float32
+}
+
+type blueZone struct {
+	bottom, top float32 // enlarged by BlueFuzz
+	// flat position: the bottom of top zones,
+	// the top of bottom zones
+	ref   float32
+	isTop bool
+}
+
+// GridFitter aligns glyph outlines on the pixel grid, for a given size,
+// using the stem hints of Type1 and CFF charstrings and the font-wide
+// alignment zones.
+//
+// Stems are rounded to an integer number of pixels, after being snapped to the
+// standard widths; their edges are aligned on the blue zones if possible,
+// and on the pixel grid otherwise. The other points are interpolated between the edges.
+type GridFitter struct {
+	blues        []blueZone
+	stdHW, stdVW []float32
+	blueShift    float32
+	scale        float32 // pixels per font unit
+	noOvershoots bool
+}
+
+// NewGridFitter prepares the hinting of glyphs for a size of `ppem` pixels
+// per em, for a font with `upem` units per em.
+func NewGridFitter(params HintingParams, upem, ppem uint16) GridFitter {
+	if upem == 0 {
+		upem = 1000
+	}
+	gf := GridFitter{
+		scale:     float32(ppem) / float32(upem),
+		blueShift: params.BlueShift,
+		// BlueScale is expressed for a 1000 units em
+		noOvershoots: float32(ppem) < params.BlueScale*1000,
+	}
+	for i := 0; i+1 < len(params.BlueValues); i += 2 {
+		bottom, top := params.BlueValues[i], params.BlueValues[i+1]
+		zone := blueZone{bottom: bottom - params.BlueFuzz, top: top + params.BlueFuzz, ref: bottom, isTop: true}
+		if i == 0 { // baseline
+			zone.ref, zone.isTop = top, false
+		}
+		gf.blues = append(gf.blues, zone)
+	}
+	for i := 0; i+1 < len(params.OtherBlues); i += 2 {
+		bottom, top := params.OtherBlues[i], params.OtherBlues[i+1]
+		gf.blues = append(gf.blues, blueZone{bottom: bottom - params.BlueFuzz, top: top + params.BlueFuzz, ref: top})
+	}
+
+	gf.stdHW = standardWidths(params.StdHW, params.StemSnapH)
+	gf.stdVW = standardWidths(params.StdVW, params.StemSnapV)
+	return gf
+}
+
+func standardWidths(std float32, snaps []float32) []float32 {
+	var out []float32
+	if std > 0 {
+		out = append(out, std)
+	}
+	for _, w := range snaps {
+		if w > 0 {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func round(v float32) float32 { return float32(math.Round(float64(v))) }
+
+// edge maps an original coordinate (in font units)
+// to its fitted position (in pixels)
+type edge struct {
+	orig, fitted float32
+}
+
+// edges is sorted by original position
+type edges []edge
+
+// apply returns the fitted position (in font units)
+// of the coordinate `v`.
+func (es edges) apply(v, scale float32) float32 {
+	if len(es) == 0 {
+		return v
+	}
+	var px float32
+	if first := es[0]; v <= first.orig {
+		px = first.fitted + (v-first.orig)*scale
+	} else if last := es[len(es)-1]; v >= last.orig {
+		px = last.fitted + (v-last.orig)*scale
+	} else {
+		// first edge strictly above v
+		i := sort.Search(len(es), func(i int) bool { return es[i].orig > v })
+		e1, e2 := es[i-1], es[i]
+		t := (v - e1.orig) / (e2.orig - e1.orig)
+		px = e1.fitted + t*(e2.fitted-e1.fitted)
+	}
+	return px / scale
+}
+
+// snapWidth returns the standard width closest to `width`,
+// if the difference is less than half a pixel.
+func (gf GridFitter) snapWidth(width float32, std []float32) float32 {
+	best, bestDiff := width, float32(0.5)/gf.scale
+	for _, w := range std {
+		diff := w - width
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff < bestDiff {
+			best, bestDiff = w, diff
+		}
+	}
+	return best
+}
+
+// blueZone returns the zone containing `pos`, if any.
+func (gf GridFitter) blueZone(pos float32, isTop bool) (blueZone, bool) {
+	for _, zone := range gf.blues {
+		if zone.isTop == isTop && zone.bottom <= pos && pos <= zone.top {
+			return zone, true
+		}
+	}
+	return blueZone{}, false
+}
+
+// alignOnZone returns the fitted position (in pixels) of
+// the edge `pos`, which is inside `zone`.
+func (gf GridFitter) alignOnZone(pos float32, zone blueZone) float32 {
+	ref := round(zone.ref * gf.scale)
+	if gf.noOvershoots {
+		return ref
+	}
+	overshoot := pos - zone.ref
+	if !zone.isTop {
+		overshoot = -overshoot
+	}
+	if overshoot <= 0 {
+		return ref
+	}
+	delta := round(overshoot * gf.scale)
+	if delta < 1 && overshoot >= gf.blueShift {
+		delta = 1
+	}
+	if zone.isTop {
+		return ref + delta
+	}
+	return ref - delta
+}
+
+// fitStems computes the fitted positions of the edges of `stems`
+func (gf GridFitter) fitStems(stems []StemHint, std []float32, useBlues bool) edges {
+	var out edges
+	for _, stem := range stems {
+		low, high, hasLow, hasHigh := stem.edges()
+		l, h := float32(low), float32(high)
+		if !hasLow || !hasHigh { // ghost stem
+			pos, isTop := l, false
+			if hasHigh {
+				pos, isTop = h, true
+			}
+			fitted := round(pos * gf.scale)
+			if zone, ok := gf.blueZone(pos, isTop); useBlues && ok {
+				fitted = gf.alignOnZone(pos, zone)
+			}
+			out = append(out, edge{pos, fitted})
+			continue
+		}
+
+		width := round(gf.snapWidth(h-l, std) * gf.scale)
+		if width < 1 {
+			width = 1
+		}
+		var fLow, fHigh float32
+		if zone, ok := gf.blueZone(h, true); useBlues && ok {
+			fHigh = gf.alignOnZone(h, zone)
+			fLow = fHigh - width
+		} else if zone, ok := gf.blueZone(l, false); useBlues && ok {
+			fLow = gf.alignOnZone(l, zone)
+			fHigh = fLow + width
+		} else {
+			center := (l + h) / 2 * gf.scale
+			fLow = round(center - width/2)
+			fHigh = fLow + width
+		}
+		out = append(out, edge{l, fLow}, edge{h, fHigh})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].orig < out[j].orig })
+	// remove duplicates and make sure the edges do not cross
+	filtered := out[:0]
+	for _, e := range out {
+		if n := len(filtered); n != 0 {
+			prev := filtered[n-1]
+			if e.orig == prev.orig {
+				continue
+			}
+			if e.fitted < prev.fitted {
+				e.fitted = prev.fitted
+			}
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+func selectStems(stems []StemHint, indices []int) []StemHint {
+	out := make([]StemHint, 0, len(indices))
+	for _, i := range indices {
+		if i < len(stems) {
+			out = append(out, stems[i])
+		}
+	}
+	return out
+}
+
+// Fit returns a grid-fitted copy of the outline `segments`, using the
+// given glyph `hints`. The returned outline is still expressed in font units,
+// so that it may be rendered (at the hinting size) as any other outline.
+func (gf GridFitter) Fit(segments []fonts.Segment, hints GlyphHints) []fonts.Segment {
+	out := append([]fonts.Segment(nil), segments...)
+	if gf.scale <= 0 {
+		return out
+	}
+
+	masks := hints.HintMasks
+	if len(masks) == 0 {
+		all := HintMask{}
+		for i := range hints.HStems {
+			all.HStems = append(all.HStems, i)
+		}
+		for i := range hints.VStems {
+			all.VStems = append(all.VStems, i)
+		}
+		masks = []HintMask{all}
+	}
+
+	for i, mask := range masks {
+		start, end := mask.SegmentIndex, len(out)
+		if i == 0 { // the first mask also applies to the previous segments
+			start = 0
+		}
+		if i+1 < len(masks) && masks[i+1].SegmentIndex < end {
+			end = masks[i+1].SegmentIndex
+		}
+		if start >= end {
+			continue
+		}
+
+		yEdges := gf.fitStems(selectStems(hints.HStems, mask.HStems), gf.stdHW, true)
+		xEdges := gf.fitStems(selectStems(hints.VStems, mask.VStems), gf.stdVW, false)
+		for j := start; j < end; j++ {
+			args := out[j].ArgsSlice()
+			for k := range args {
+				args[k].X = xEdges.apply(args[k].X, gf.scale)
+				args[k].Y = yEdges.apply(args[k].Y, gf.scale)
+			}
+		}
+	}
+	return out
+}
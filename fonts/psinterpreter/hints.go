@@ -0,0 +1,136 @@
+package psinterpreter
+
+// StemHint is a horizontal or vertical stem hint, in font units.
+// For horizontal stems, Pos and Pos + Width are y coordinates,
+// for vertical stems they are x coordinates.
+// Edge (or ghost) hints have a width of -20 (top edge, located at Pos)
+// or -21 (bottom edge, located at Pos + Width).
+type StemHint struct {
+	Pos, Width int32
+}
+
+// edges returns the edges of the stem, with `low` <= `high`
+// For ghost hints, only one edge is valid, as indicated by the booleans.
+func (s StemHint) edges() (low, high int32, hasLow, hasHigh bool) {
+	switch s.Width {
+	case -20: // top edge
+		return 0, s.Pos, false, true
+	case -21: // bottom edge
+		return s.Pos + s.Width, 0, true, false
+	}
+	low, high = s.Pos, s.Pos+s.Width
+	if high < low {
+		low, high = high, low
+	}
+	return low, high, true, true
+}
+
+// HintMask selects the stem hints active for the segments
+// starting at Segments[SegmentIndex], until the next mask.
+type HintMask struct {
+	SegmentIndex int
+	// Indices into GlyphHints.HStems and GlyphHints.VStems
+	HStems, VStems []int
+}
+
+// GlyphHints stores the hints found in a glyph charstring.
+type GlyphHints struct {
+	HStems, VStems []StemHint
+	// HintMasks implements the hint replacement mechanism
+	// (hintmask operator in Type2 charstrings, othersubr 3 in Type1 charstrings).
+	// If it is empty, all the stems apply to the whole glyph.
+	HintMasks []HintMask
+	// CounterMasks are the groups of stems defined by the
+	// cntrmask operators in Type2 charstrings (SegmentIndex is then meaningless).
+	CounterMasks []HintMask
+}
+
+// appendType2Stems appends the stems defined by the arguments
+// of a Type2 charstring stem operator, ignoring the optional width.
+func appendType2Stems(stems []StemHint, args *ArgStack) []StemHint {
+	var edge int32
+	for i := args.Top & 1; i+2 <= args.Top; i += 2 {
+		stem := StemHint{Pos: edge + args.Vals[i], Width: args.Vals[i+1]}
+		stems = append(stems, stem)
+		edge = stem.Pos + stem.Width
+	}
+	return stems
+}
+
+// decodeMask interprets the bytes of a hintmask or cntrmask operator,
+// whose bits select the horizontal stems, then the vertical stems.
+func (out *CharstringReader) decodeMask(mask []byte) HintMask {
+	hm := HintMask{SegmentIndex: out.nbSegments}
+	nbH, nbV := len(out.Hints.HStems), len(out.Hints.VStems)
+	for i := 0; i < nbH+nbV && i/8 < len(mask); i++ {
+		if mask[i/8]&(0x80>>(i%8)) == 0 {
+			continue
+		}
+		if i < nbH {
+			hm.HStems = append(hm.HStems, i)
+		} else {
+			hm.VStems = append(hm.VStems, i-nbH)
+		}
+	}
+	return hm
+}
+
+// Cntrmask reads the counter mask following the cntrmask operator
+// in a Type2 charstring.
+func (out *CharstringReader) Cntrmask(state *Machine) {
+	out.determineHintmaskSize(state)
+	mask := state.SkipBytes(out.hintmaskSize)
+	out.Hints.CounterMasks = append(out.Hints.CounterMasks, out.decodeMask(mask))
+}
+
+// Type1Stem adds the stems defined by the Type1 hstem, vstem, hstem3 and vstem3
+// operators, whose arguments are pairs (position, width), positions being
+// relative to `origin` (the left side bearing).
+func (out *CharstringReader) Type1Stem(state *Machine, origin int32, horizontal bool) {
+	masks := &out.Hints.HintMasks
+	if n := len(*masks); n == 0 || out.type1NewHints {
+		if n != 0 && (*masks)[n-1].SegmentIndex == out.nbSegments {
+			// the previous hints have not been used
+			*masks = (*masks)[:n-1]
+		}
+		*masks = append(*masks, HintMask{SegmentIndex: out.nbSegments})
+		out.type1NewHints = false
+	}
+	current := &(*masks)[len(*masks)-1]
+
+	stems, indices := &out.Hints.VStems, &current.VStems
+	if horizontal {
+		stems, indices = &out.Hints.HStems, &current.HStems
+	}
+	for i := int32(0); i+2 <= state.ArgStack.Top; i += 2 {
+		stem := StemHint{Pos: origin + state.ArgStack.Vals[i], Width: state.ArgStack.Vals[i+1]}
+		index := -1
+		for j, other := range *stems {
+			if other == stem {
+				index = j
+				break
+			}
+		}
+		if index == -1 {
+			index = len(*stems)
+			*stems = append(*stems, stem)
+		}
+		if !containsIndex(*indices, index) {
+			*indices = append(*indices, index)
+		}
+	}
+}
+
+// Type1HintReplacement is called for the hint replacement
+// mechanism of Type1 charstrings (othersubr 3), so that the
+// following stems replace the current ones.
+func (out *CharstringReader) Type1HintReplacement() { out.type1NewHints = true }
+
+func containsIndex(indices []int, index int) bool {
+	for _, i := range indices {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
@@ -110,14 +110,17 @@ type Machine struct {
 	ctx            PsContext
 }
 
-// SkipBytes skips the next `count` bytes from the instructions, and clears the argument stack.
-// It does nothing if `count` exceed the length of the instructions.
-func (p *Machine) SkipBytes(count int32) {
+// SkipBytes skips the next `count` bytes from the instructions, clears the argument stack,
+// and returns the skipped bytes.
+// It does nothing (and returns nil) if `count` exceed the length of the instructions.
+func (p *Machine) SkipBytes(count int32) []byte {
 	if int(count) >= len(p.instructions) {
-		return
+		return nil
 	}
+	skipped := p.instructions[:count]
 	p.instructions = p.instructions[count:]
 	p.ArgStack.Clear()
+	return skipped
 }
 
 func (p *Machine) hasMoreInstructions() bool {
@@ -296,9 +299,9 @@ var nibbleDefs = [16]string{
 	0x0f: "",
 }
 
-// subrBias returns the subroutine index bias as per 5177.Type2.pdf section 4.7
+// SubrBias returns the subroutine index bias as per 5177.Type2.pdf section 4.7
 // "Subroutine Operators".
-func subrBias(numSubroutines int) int32 {
+func SubrBias(numSubroutines int) int32 {
 	if numSubroutines < 1240 {
 		return 107
 	}
@@ -320,7 +323,7 @@ func (p *Machine) CallSubroutine(index int32, isLocal bool) error {
 
 	// no bias in type1 fonts
 	if p.ctx == Type2Charstring {
-		index += subrBias(len(subrs))
+		index += SubrBias(len(subrs))
 	}
 
 	if index < 0 || int(index) >= len(subrs) {
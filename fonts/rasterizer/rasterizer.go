@@ -0,0 +1,225 @@
+// Package rasterizer renders the glyphs returned by
+// fonts.FaceRenderer into images.
+//
+// Outlines are drawn with an anti-aliased scanline rasterizer,
+// supporting both the non-zero and even-odd fill rules.
+// Bitmap glyphs (black and white, PNG, JPG or TIFF) are decoded
+// and scaled to the requested size.
+package rasterizer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"golang.org/x/image/tiff"
+)
+
+// FillRule specifies how the interior of an outline is determined.
+type FillRule uint8
+
+const (
+	// NonZero fills the points with a non zero winding number.
+	// It is the rule used by TrueType and CFF fonts.
+	NonZero FillRule = iota
+	// EvenOdd fills the points with an odd winding number.
+	EvenOdd
+)
+
+// Options controls the rendering of a glyph.
+type Options struct {
+	// XPpem and YPpem are the requested size, in pixels per em.
+	XPpem, YPpem float32
+
+	// Upem is the number of font units per em, used to scale outlines.
+	// If zero, 1000 is assumed.
+	Upem uint16
+
+	// StrikeXPpem and StrikeYPpem are the size of the bitmap strike
+	// the glyph comes from. If zero, bitmaps are not scaled.
+	StrikeXPpem, StrikeYPpem uint16
+
+	// OffsetX and OffsetY position the glyph origin inside the pixel grid,
+	// to render at subpixel positions. They are expressed in pixels,
+	// with the Y axis increasing down, and are usually in [0, 1).
+	OffsetX, OffsetY float32
+
+	// Fill is the rule used for outlines.
+	Fill FillRule
+
+	// Gamma, if not zero or one, is applied to the coverage
+	// of outlines, as coverage^(1/Gamma) : a value greater
+	// than one produces darker glyphs.
+	Gamma float32
+}
+
+// Render draws the glyph `data`.
+//
+// The bounds of the returned image are relative to the glyph origin,
+// with the Y axis increasing down, so that the image should be drawn
+// at the (integer) pen position, as with golang.org/x/image/font.Face masks.
+// Since bitmap glyphs carry no metrics, they are positioned
+// with their bottom left corner at the origin.
+//
+// Outlines are rendered into an *image.Alpha; bitmaps
+// are rendered into an *image.Alpha for black and white data, or
+// an *image.RGBA otherwise.
+// SVG glyphs are rendered using their fallback outline.
+func Render(data fonts.GlyphData, opts Options) (draw.Image, error) {
+	switch data := data.(type) {
+	case fonts.GlyphOutline:
+		return RenderOutline(data, opts), nil
+	case fonts.GlyphSVG:
+		return RenderOutline(data.Outline, opts), nil
+	case fonts.GlyphBitmap:
+		return RenderBitmap(data, opts)
+	case nil:
+		return nil, errors.New("missing glyph data")
+	default:
+		return nil, fmt.Errorf("unsupported glyph data %T", data)
+	}
+}
+
+// RenderOutline draws the outline into an anti-aliased mask.
+// See Render for the conventions used.
+func RenderOutline(outline fonts.GlyphOutline, opts Options) *image.Alpha {
+	upem := float32(opts.Upem)
+	if upem == 0 {
+		upem = 1000
+	}
+	tr := transform{
+		sx: opts.XPpem / upem, sy: opts.YPpem / upem,
+		dx: opts.OffsetX, dy: opts.OffsetY,
+	}
+
+	var r rasterizer
+	r.flatten(outline.Segments, tr)
+
+	bounds := r.bounds()
+	out := image.NewAlpha(bounds)
+	r.fill(out, opts.Fill)
+
+	if opts.Gamma != 0 && opts.Gamma != 1 {
+		applyGamma(out.Pix, opts.Gamma)
+	}
+	return out
+}
+
+// RenderBitmap decodes the bitmap and scales it from the strike
+// size to the requested size, using nearest neighbour sampling.
+// See Render for the conventions used.
+// The offsets of `opts` are rounded to whole pixels.
+//
+// BlackAndWhite data is expected with the most significant bit first.
+// Rows may be either padded (as in PCF fonts) or bit aligned (as in 'EBDT' tables).
+func RenderBitmap(bitmap fonts.GlyphBitmap, opts Options) (draw.Image, error) {
+	var (
+		img draw.Image
+		err error
+	)
+	switch bitmap.Format {
+	case fonts.BlackAndWhite:
+		img, err = expandBlackAndWhite(bitmap)
+	case fonts.PNG:
+		img, err = decodeImage(png.Decode, bitmap.Data)
+	case fonts.JPG:
+		img, err = decodeImage(jpeg.Decode, bitmap.Data)
+	case fonts.TIFF:
+		img, err = decodeImage(tiff.Decode, bitmap.Data)
+	default:
+		return nil, fmt.Errorf("unsupported bitmap format %d", bitmap.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sx, sy := float32(1), float32(1)
+	if opts.StrikeXPpem != 0 && opts.XPpem != 0 {
+		sx = opts.XPpem / float32(opts.StrikeXPpem)
+	}
+	if opts.StrikeYPpem != 0 && opts.YPpem != 0 {
+		sy = opts.YPpem / float32(opts.StrikeYPpem)
+	}
+	size := img.Bounds().Size()
+	width := int(math.Round(float64(float32(size.X) * sx)))
+	height := int(math.Round(float64(float32(size.Y) * sy)))
+	// bitmaps are not resampled at subpixel positions
+	dx, dy := int(math.Round(float64(opts.OffsetX))), int(math.Round(float64(opts.OffsetY)))
+	bounds := image.Rect(dx, dy-height, dx+width, dy)
+
+	var out draw.Image
+	if _, isAlpha := img.(*image.Alpha); isAlpha {
+		out = image.NewAlpha(bounds)
+	} else {
+		out = image.NewRGBA(bounds)
+	}
+	src := img.Bounds()
+	for y := 0; y < height; y++ {
+		srcY := src.Min.Y + y*size.Y/height
+		for x := 0; x < width; x++ {
+			srcX := src.Min.X + x*size.X/width
+			out.Set(dx+x, dy+y-height, img.At(srcX, srcY))
+		}
+	}
+	return out, nil
+}
+
+func decodeImage(decode func(r io.Reader) (image.Image, error), data []byte) (draw.Image, error) {
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bitmap glyph: %s", err)
+	}
+	out := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+	return out, nil
+}
+
+// expandBlackAndWhite returns a mask with one byte per pixel
+func expandBlackAndWhite(bitmap fonts.GlyphBitmap) (*image.Alpha, error) {
+	width, height := bitmap.Width, bitmap.Height
+	out := image.NewAlpha(image.Rect(0, 0, width, height))
+	if width <= 0 || height <= 0 {
+		return out, nil
+	}
+
+	// bit aligned rows have no padding; otherwise, rows are
+	// padded and the stride is deduced from the data length
+	strideBits := width
+	if rowBytes := (width + 7) / 8; len(bitmap.Data) >= rowBytes*height {
+		if stride := len(bitmap.Data) / height; len(bitmap.Data)%height == 0 && stride >= rowBytes {
+			strideBits = 8 * stride
+		} else {
+			strideBits = 8 * rowBytes
+		}
+	}
+	if len(bitmap.Data)*8 < strideBits*(height-1)+width {
+		return nil, errors.New("invalid black and white bitmap (EOF)")
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			bit := y*strideBits + x
+			if bitmap.Data[bit/8]&(0x80>>(bit%8)) != 0 {
+				out.Pix[y*out.Stride+x] = 0xFF
+			}
+		}
+	}
+	return out, nil
+}
+
+func applyGamma(pix []byte, gamma float32) {
+	var table [256]byte
+	for i := range table {
+		table[i] = byte(math.Round(255 * math.Pow(float64(i)/255, 1/float64(gamma))))
+	}
+	for i, v := range pix {
+		pix[i] = table[v]
+	}
+}
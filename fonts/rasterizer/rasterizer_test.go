@@ -0,0 +1,186 @@
+package rasterizer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+func square(x0, y0, x1, y1 float32, clockwise bool) []fonts.Segment {
+	pts := []fonts.SegmentPoint{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+	if clockwise {
+		pts[1], pts[3] = pts[3], pts[1]
+	}
+	out := []fonts.Segment{{Op: fonts.SegmentOpMoveTo, Args: [3]fonts.SegmentPoint{pts[0]}}}
+	for _, pt := range pts[1:] {
+		out = append(out, fonts.Segment{Op: fonts.SegmentOpLineTo, Args: [3]fonts.SegmentPoint{pt}})
+	}
+	return out
+}
+
+func TestSquare(t *testing.T) {
+	outline := fonts.GlyphOutline{Segments: square(0, 0, 1000, 500, false)}
+	img := RenderOutline(outline, Options{XPpem: 10, YPpem: 10})
+	if exp := image.Rect(0, -5, 10, 0); img.Bounds() != exp {
+		t.Fatalf("expected %v, got %v", exp, img.Bounds())
+	}
+	for _, v := range img.Pix {
+		if v != 0xFF {
+			t.Fatalf("expected full coverage, got %v", img.Pix)
+		}
+	}
+
+	// half pixel offset
+	img = RenderOutline(outline, Options{XPpem: 10, YPpem: 10, OffsetX: 0.5})
+	if exp := image.Rect(0, -5, 11, 0); img.Bounds() != exp {
+		t.Fatalf("expected %v, got %v", exp, img.Bounds())
+	}
+	if a := img.AlphaAt(0, -1).A; a != 0x80 {
+		t.Fatalf("expected half coverage, got %d", a)
+	}
+	if a := img.AlphaAt(5, -1).A; a != 0xFF {
+		t.Fatalf("expected full coverage, got %d", a)
+	}
+}
+
+func TestFillRules(t *testing.T) {
+	// two squares with the same orientation, one inside the other
+	segments := append(square(0, 0, 1000, 1000, false), square(250, 250, 750, 750, false)...)
+	outline := fonts.GlyphOutline{Segments: segments}
+
+	nonZero := RenderOutline(outline, Options{XPpem: 8, YPpem: 8})
+	evenOdd := RenderOutline(outline, Options{XPpem: 8, YPpem: 8, Fill: EvenOdd})
+	if a := nonZero.AlphaAt(4, -4).A; a != 0xFF {
+		t.Fatalf("expected filled center, got %d", a)
+	}
+	if a := evenOdd.AlphaAt(4, -4).A; a != 0 {
+		t.Fatalf("expected empty center, got %d", a)
+	}
+	if a := evenOdd.AlphaAt(0, -1).A; a != 0xFF {
+		t.Fatalf("expected filled border, got %d", a)
+	}
+}
+
+func TestGamma(t *testing.T) {
+	outline := fonts.GlyphOutline{Segments: square(0, 0, 250, 1000, false)}
+	linear := RenderOutline(outline, Options{XPpem: 2, YPpem: 2})
+	dark := RenderOutline(outline, Options{XPpem: 2, YPpem: 2, Gamma: 2.2})
+	if linear.Pix[0] != 0x80 {
+		t.Fatalf("expected half coverage, got %d", linear.Pix[0])
+	}
+	if dark.Pix[0] <= linear.Pix[0] {
+		t.Fatalf("expected darker coverage, got %d", dark.Pix[0])
+	}
+}
+
+func TestRenderFont(t *testing.T) {
+	file, err := testdata.Files.ReadFile("Roboto-BoldItalic.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := tt.Parse(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gid, _ := font.NominalGlyph('O')
+	extents, _ := font.GlyphExtents(gid, 0, 0)
+
+	img, err := Render(font.GlyphData(gid, 0, 0), Options{XPpem: 64, YPpem: 64, Upem: font.Upem()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale := 64 / float32(font.Upem())
+	bounds := img.Bounds()
+	if w := float32(bounds.Dx()); w < extents.Width*scale-2 || w > extents.Width*scale+2 {
+		t.Fatalf("unexpected width %v for extents %v", bounds, extents)
+	}
+	if h := float32(bounds.Dy()); h < -extents.Height*scale-2 || h > -extents.Height*scale+2 {
+		t.Fatalf("unexpected height %v for extents %v", bounds, extents)
+	}
+
+	// the center of the 'O' is empty, its border is filled
+	center := bounds.Min.Add(bounds.Max).Div(2)
+	if _, _, _, a := img.At(center.X, center.Y).RGBA(); a != 0 {
+		t.Fatalf("expected empty center, got %d", a)
+	}
+	var filled int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if _, _, _, a := img.At(center.X, y).RGBA(); a == 0xFFFF {
+			filled++
+		}
+	}
+	if filled == 0 {
+		t.Fatal("expected filled pixels")
+	}
+}
+
+func TestBlackAndWhite(t *testing.T) {
+	// a 3x2 checker, bit aligned and padded
+	for _, data := range [][]byte{
+		{0b10101000},
+		{0b10100000, 0b01000000},
+		{0b10100000, 0, 0, 0, 0b01000000, 0, 0, 0},
+	} {
+		img, err := RenderBitmap(fonts.GlyphBitmap{Data: data, Format: fonts.BlackAndWhite, Width: 3, Height: 2}, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		alpha := img.(*image.Alpha)
+		if exp := []byte{0xFF, 0, 0xFF, 0, 0xFF, 0}; !bytes.Equal(alpha.Pix, exp) {
+			t.Fatalf("expected %v, got %v", exp, alpha.Pix)
+		}
+	}
+
+	_, err := RenderBitmap(fonts.GlyphBitmap{Data: []byte{0}, Format: fonts.BlackAndWhite, Width: 8, Height: 2}, Options{})
+	if err == nil {
+		t.Fatal("expected error for invalid bitmap")
+	}
+}
+
+func TestScaleStrike(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.RGBA{R: 0xFF, A: 0xFF})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := RenderBitmap(fonts.GlyphBitmap{Data: buf.Bytes(), Format: fonts.PNG, Width: 2, Height: 2},
+		Options{XPpem: 20, YPpem: 20, StrikeXPpem: 10, StrikeYPpem: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := image.Rect(0, -4, 4, 0); img.Bounds() != exp {
+		t.Fatalf("expected %v, got %v", exp, img.Bounds())
+	}
+	if c := img.At(1, -3).(color.RGBA); c.R != 0xFF || c.A != 0xFF {
+		t.Fatalf("unexpected color %v", c)
+	}
+	if c := img.At(2, -3).(color.RGBA); c.A != 0 {
+		t.Fatalf("unexpected color %v", c)
+	}
+}
+
+func TestBitmapOffset(t *testing.T) {
+	data := []byte{0b10101000} // a 3x2 checker
+	img, err := RenderBitmap(fonts.GlyphBitmap{Data: data, Format: fonts.BlackAndWhite, Width: 3, Height: 2},
+		Options{OffsetX: 1.6, OffsetY: -0.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := image.Rect(2, -2, 5, 0); img.Bounds() != exp {
+		t.Fatalf("expected %v, got %v", exp, img.Bounds())
+	}
+	if a := img.(*image.Alpha).AlphaAt(2, -2).A; a != 0xFF {
+		t.Fatalf("unexpected alpha %d", a)
+	}
+	if a := img.(*image.Alpha).AlphaAt(3, -2).A; a != 0 {
+		t.Fatalf("unexpected alpha %d", a)
+	}
+}
@@ -0,0 +1,271 @@
+package rasterizer
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+const (
+	// number of samples per pixel row
+	subScanlines = 16
+	// maximum distance, in pixels, between a curve and its approximation
+	flatnessTolerance = 0.1
+	// protects against huge (or malicious) coordinates
+	maxCurveSegments = 100
+)
+
+// transform maps font units to pixels, flipping the Y axis
+type transform struct {
+	sx, sy, dx, dy float32
+}
+
+func (tr transform) apply(pt fonts.SegmentPoint) point {
+	return point{pt.X*tr.sx + tr.dx, -pt.Y*tr.sy + tr.dy}
+}
+
+type point struct{ x, y float32 }
+
+func (p point) sub(q point) point { return point{p.x - q.x, p.y - q.y} }
+
+func (p point) norm() float32 { return float32(math.Hypot(float64(p.x), float64(p.y))) }
+
+func lerp(t float32, p, q point) point { return point{p.x + t*(q.x-p.x), p.y + t*(q.y-p.y)} }
+
+// edge is a line segment, oriented with y0 < y1
+type edge struct {
+	x0, y0, x1, y1 float32
+	winding        int // +1 for downward edges, -1 for upward ones
+}
+
+// rasterizer accumulates the edges of an outline,
+// expressed in pixels
+type rasterizer struct {
+	edges            []edge
+	minX, minY       float32
+	maxX, maxY       float32
+	start, current   point
+	hasOpenedContour bool
+}
+
+func (r *rasterizer) flatten(segments []fonts.Segment, tr transform) {
+	r.minX, r.minY = float32(math.Inf(1)), float32(math.Inf(1))
+	r.maxX, r.maxY = float32(math.Inf(-1)), float32(math.Inf(-1))
+	for _, seg := range segments {
+		switch seg.Op {
+		case fonts.SegmentOpMoveTo:
+			r.closeContour()
+			r.start = tr.apply(seg.Args[0])
+			r.current = r.start
+			r.hasOpenedContour = true
+		case fonts.SegmentOpLineTo:
+			r.lineTo(tr.apply(seg.Args[0]))
+		case fonts.SegmentOpQuadTo:
+			p1, p2 := tr.apply(seg.Args[0]), tr.apply(seg.Args[1])
+			r.quadTo(p1, p2)
+		case fonts.SegmentOpCubeTo:
+			p1, p2, p3 := tr.apply(seg.Args[0]), tr.apply(seg.Args[1]), tr.apply(seg.Args[2])
+			r.cubeTo(p1, p2, p3)
+		}
+	}
+	r.closeContour()
+}
+
+func (r *rasterizer) closeContour() {
+	if r.hasOpenedContour && r.current != r.start {
+		r.lineTo(r.start)
+	}
+	r.hasOpenedContour = false
+}
+
+func (r *rasterizer) lineTo(p point) {
+	r.addEdge(r.current, p)
+	r.current = p
+}
+
+func (r *rasterizer) quadTo(p1, p2 point) {
+	p0 := r.current
+	dev := p0.sub(p1).sub(p1.sub(p2)).norm()
+	n := segmentsCount(dev / (8 * flatnessTolerance))
+	for i := 1; i <= n; i++ {
+		t := float32(i) / float32(n)
+		a, b := lerp(t, p0, p1), lerp(t, p1, p2)
+		r.lineTo(lerp(t, a, b))
+	}
+}
+
+func (r *rasterizer) cubeTo(p1, p2, p3 point) {
+	p0 := r.current
+	dev1 := p0.sub(p1).sub(p1.sub(p2)).norm()
+	dev2 := p1.sub(p2).sub(p2.sub(p3)).norm()
+	if dev2 > dev1 {
+		dev1 = dev2
+	}
+	n := segmentsCount(3 * dev1 / (4 * flatnessTolerance))
+	for i := 1; i <= n; i++ {
+		t := float32(i) / float32(n)
+		a, b, c := lerp(t, p0, p1), lerp(t, p1, p2), lerp(t, p2, p3)
+		d, e := lerp(t, a, b), lerp(t, b, c)
+		r.lineTo(lerp(t, d, e))
+	}
+}
+
+// returns the number of lines needed to approximate a curve,
+// given the squared number required
+func segmentsCount(squared float32) int {
+	n := int(math.Ceil(math.Sqrt(float64(squared))))
+	if n < 1 {
+		n = 1
+	} else if n > maxCurveSegments {
+		n = maxCurveSegments
+	}
+	return n
+}
+
+func (r *rasterizer) addEdge(p, q point) {
+	r.extend(p)
+	r.extend(q)
+	if p.y == q.y { // horizontal edges never cross a scanline
+		return
+	}
+	e := edge{x0: p.x, y0: p.y, x1: q.x, y1: q.y, winding: 1}
+	if p.y > q.y {
+		e = edge{x0: q.x, y0: q.y, x1: p.x, y1: p.y, winding: -1}
+	}
+	r.edges = append(r.edges, e)
+}
+
+func (r *rasterizer) extend(p point) {
+	if p.x < r.minX {
+		r.minX = p.x
+	}
+	if p.x > r.maxX {
+		r.maxX = p.x
+	}
+	if p.y < r.minY {
+		r.minY = p.y
+	}
+	if p.y > r.maxY {
+		r.maxY = p.y
+	}
+}
+
+// bounds returns the pixels touched by the outline,
+// or an empty rectangle
+func (r *rasterizer) bounds() image.Rectangle {
+	if len(r.edges) == 0 {
+		return image.Rectangle{}
+	}
+	return image.Rect(
+		int(math.Floor(float64(r.minX))), int(math.Floor(float64(r.minY))),
+		int(math.Ceil(float64(r.maxX))), int(math.Ceil(float64(r.maxY))),
+	)
+}
+
+type crossing struct {
+	x       float32
+	winding int
+}
+
+// fill computes the coverage of each pixel of `dst`, by sampling
+// `subScanlines` horizontal lines per row and computing
+// the exact horizontal coverage of the spans inside the outline
+func (r *rasterizer) fill(dst *image.Alpha, rule FillRule) {
+	bounds := dst.Bounds()
+	width := bounds.Dx()
+	if width == 0 || bounds.Dy() == 0 {
+		return
+	}
+
+	// sort the edges by their top, to only visit the active ones
+	sort.Slice(r.edges, func(i, j int) bool { return r.edges[i].y0 < r.edges[j].y0 })
+
+	var (
+		crossings []crossing
+		active    []edge
+		next      int // first edge not yet active
+	)
+	acc := make([]float32, width)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for i := range acc {
+			acc[i] = 0
+		}
+		for s := 0; s < subScanlines; s++ {
+			sampleY := float32(y) + (float32(s)+0.5)/subScanlines
+
+			// update the active edges
+			for ; next < len(r.edges) && r.edges[next].y0 <= sampleY; next++ {
+				active = append(active, r.edges[next])
+			}
+			kept := active[:0]
+			for _, e := range active {
+				if e.y1 > sampleY {
+					kept = append(kept, e)
+				}
+			}
+			active = kept
+
+			crossings = crossings[:0]
+			for _, e := range active {
+				if e.y0 > sampleY {
+					continue
+				}
+				x := e.x0 + (sampleY-e.y0)*(e.x1-e.x0)/(e.y1-e.y0)
+				crossings = append(crossings, crossing{x: x - float32(bounds.Min.X), winding: e.winding})
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding := 0
+			for i, c := range crossings {
+				winding += c.winding
+				if i+1 < len(crossings) && isInside(winding, rule) {
+					addSpan(acc, c.x, crossings[i+1].x)
+				}
+			}
+		}
+
+		row := dst.Pix[(y-bounds.Min.Y)*dst.Stride:]
+		for x, v := range acc {
+			cov := v / subScanlines
+			if cov > 1 {
+				cov = 1
+			}
+			row[x] = uint8(cov*0xFF + 0.5)
+		}
+	}
+}
+
+func isInside(winding int, rule FillRule) bool {
+	if rule == EvenOdd {
+		return winding&1 != 0
+	}
+	return winding != 0
+}
+
+// addSpan adds the coverage of the horizontal span [x0, x1]
+// to the pixels it crosses
+func addSpan(acc []float32, x0, x1 float32) {
+	if x0 < 0 {
+		x0 = 0
+	}
+	if max := float32(len(acc)); x1 > max {
+		x1 = max
+	}
+	if x1 <= x0 {
+		return
+	}
+	i0, i1 := int(x0), int(x1)
+	if i0 == i1 {
+		acc[i0] += x1 - x0
+		return
+	}
+	acc[i0] += float32(i0+1) - x0
+	for i := i0 + 1; i < i1; i++ {
+		acc[i] += 1
+	}
+	if i1 < len(acc) {
+		acc[i1] += x1 - float32(i1)
+	}
+}
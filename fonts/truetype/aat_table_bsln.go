@@ -0,0 +1,81 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// TableBsln is the AAT 'bsln' table, defining the
+// positions of the baselines (up to 32) used in the font.
+type TableBsln struct {
+	mapping Class // glyph -> baseline class, may be nil
+
+	// For the distance formats (0 and 1), the positions
+	// of the baselines, in font units, relative to the roman baseline.
+	// For the control point formats (2 and 3), the control points of StdGlyph
+	// defining the baselines positions (0xFFFF if not defined).
+	Values [32]int16
+
+	// StdGlyph is the glyph whose control points define the
+	// baselines, for the control point formats.
+	StdGlyph GID
+
+	// DefaultBaseline is the baseline class of the glyphs
+	// not found in the mapping.
+	DefaultBaseline uint16
+
+	// Format is 0 or 1 for distances, 2 or 3 for control points
+	Format uint16
+}
+
+// BaselineClass returns the baseline class of `glyph`, that is,
+// an index into Values.
+func (t TableBsln) BaselineClass(glyph GID) uint16 {
+	if t.mapping != nil {
+		if class, ok := t.mapping.ClassID(glyph); ok && class < 32 {
+			return uint16(class)
+		}
+	}
+	return t.DefaultBaseline
+}
+
+func parseTableBsln(data []byte, numGlyphs int) (out TableBsln, err error) {
+	if len(data) < 8 {
+		return out, errors.New("invalid 'bsln' table (EOF)")
+	}
+	// ignoring version
+	out.Format = binary.BigEndian.Uint16(data[4:])
+	out.DefaultBaseline = binary.BigEndian.Uint16(data[6:])
+	if out.DefaultBaseline >= 32 {
+		return out, fmt.Errorf("invalid 'bsln' default baseline: %d", out.DefaultBaseline)
+	}
+	data = data[8:]
+	var hasMapping bool
+	switch out.Format {
+	case 0, 1:
+		hasMapping = out.Format == 1
+	case 2, 3:
+		if len(data) < 2 {
+			return out, errors.New("invalid 'bsln' table (EOF)")
+		}
+		out.StdGlyph = GID(binary.BigEndian.Uint16(data))
+		data = data[2:]
+		hasMapping = out.Format == 3
+	default:
+		return out, fmt.Errorf("invalid 'bsln' table format: %d", out.Format)
+	}
+	if len(data) < 2*len(out.Values) {
+		return out, errors.New("invalid 'bsln' table (EOF)")
+	}
+	for i := range out.Values {
+		out.Values[i] = int16(binary.BigEndian.Uint16(data[2*i:]))
+	}
+	if hasMapping {
+		out.mapping, err = parseAATLookupTable(data, 2*uint32(len(out.Values)), numGlyphs, false)
+		if err != nil {
+			return out, fmt.Errorf("invalid 'bsln' table: %s", err)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,37 @@
+package truetype
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBsln(t *testing.T) {
+	data := deHexStr(
+		"0001 0000 " + //  0: Version=1.0
+			"0001 0000 " + //  4: Format=1 (distances with mapping), DefaultBaseline=0
+			"0000 FF9C " + //  8: Deltas[0]=0, Deltas[1]=-100
+			strings.Repeat("0000 ", 30) + // 12: Deltas[2..31]
+			"0006 0004 0001 " + // 72: Lookup format 6, UnitSize=4, NUnits=1
+			"0004 0000 0000 " + // 78: SearchRange, EntrySelector, RangeShift
+			"000A 0001") // 84: Glyph 10 -> baseline 1
+
+	bsln, err := parseTableBsln(data, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bsln.Format != 1 || bsln.Values[1] != -100 {
+		t.Fatalf("unexpected table %v", bsln)
+	}
+	if class := bsln.BaselineClass(10); class != 1 {
+		t.Fatalf("expected baseline 1, got %d", class)
+	}
+	if class := bsln.BaselineClass(11); class != 0 {
+		t.Fatalf("expected default baseline, got %d", class)
+	}
+
+	// invalid default baseline
+	data[7] = 32
+	if _, err = parseTableBsln(data, 20); err == nil {
+		t.Fatal("expected error for invalid default baseline")
+	}
+}
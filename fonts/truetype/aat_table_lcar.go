@@ -0,0 +1,60 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// TableLcar is the AAT 'lcar' table, storing the ligature
+// caret positions.
+type TableLcar struct {
+	class Class // glyph -> offset to the carets
+	data  []byte
+	// IsPointIndex is true if the carets are given by control point indices,
+	// false if they are distances (from the glyph origin), expressed in font units.
+	IsPointIndex bool
+}
+
+// GetCarets returns the carets of the ligature `glyph`, or nil if not found.
+// Control point indices should be interpreted as uint16.
+func (t TableLcar) GetCarets(glyph GID) []int16 {
+	if t.class == nil {
+		return nil
+	}
+	offset, ok := t.class.ClassID(glyph)
+	if !ok || len(t.data) < int(offset)+2 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint16(t.data[offset:]))
+	carets := t.data[offset+2:]
+	if len(carets) < 2*count {
+		return nil
+	}
+	out := make([]int16, count)
+	for i := range out {
+		out[i] = int16(binary.BigEndian.Uint16(carets[2*i:]))
+	}
+	return out
+}
+
+func parseTableLcar(data []byte, numGlyphs int) (out TableLcar, err error) {
+	if len(data) < 6 {
+		return out, errors.New("invalid 'lcar' table (EOF)")
+	}
+	// ignoring version
+	switch format := binary.BigEndian.Uint16(data[4:]); format {
+	case 0:
+	case 1:
+		out.IsPointIndex = true
+	default:
+		return out, fmt.Errorf("invalid 'lcar' table format: %d", format)
+	}
+	// the lookup values are offsets from the start of the table
+	out.class, err = parseAATLookupTable(data, 6, numGlyphs, false)
+	if err != nil {
+		return out, fmt.Errorf("invalid 'lcar' table: %s", err)
+	}
+	out.data = data
+	return out, nil
+}
@@ -0,0 +1,35 @@
+package truetype
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLcar(t *testing.T) {
+	data := deHexStr(
+		"0001 0000 " + //  0: Version=1.0
+			"0001 " + //  4: Format=1 (control points)
+			"0006 0004 0001 " + //  6: Lookup format 6, UnitSize=4, NUnits=1
+			"0004 0000 0000 " + // 12: SearchRange, EntrySelector, RangeShift
+			"000A 0016 " + // 18: Glyph 10 -> offset 22
+			"0002 0003 0007") // 22: Count=2, Points=3, 7
+
+	lcar, err := parseTableLcar(data, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lcar.IsPointIndex {
+		t.Fatal("expected point index format")
+	}
+	if carets, exp := lcar.GetCarets(10), []int16{3, 7}; !reflect.DeepEqual(carets, exp) {
+		t.Fatalf("expected %v, got %v", exp, carets)
+	}
+	if carets := lcar.GetCarets(11); carets != nil {
+		t.Fatalf("unexpected carets %v", carets)
+	}
+
+	// truncated caret list
+	if carets := (TableLcar{class: lcar.class, data: data[:26]}).GetCarets(10); carets != nil {
+		t.Fatalf("unexpected carets %v", carets)
+	}
+}
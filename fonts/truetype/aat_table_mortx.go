@@ -1,7 +1,8 @@
 package truetype
 
 // parser of Apple AAT layout tables
-// We dont support the deprecated 'mort' tables
+// The deprecated 'mort' tables are converted to the 'morx' model,
+// so that they are handled by the same layout engine.
 
 import (
 	"encoding/binary"
@@ -51,7 +52,7 @@ type MorxChain struct {
 func parseMorxChain(version uint16, data []byte, numGlyphs int) (out MorxChain, size int, err error) {
 	switch version {
 	case 1:
-		return out, 0, fmt.Errorf("deprecated mort tables are not supported")
+		return parseMortChain(data, numGlyphs)
 	case 2, 3:
 		return parseMorxChain23(data, numGlyphs)
 	default:
@@ -304,21 +305,9 @@ func parseLigatureSubtable(data []byte, numGlyphs int) (out MorxLigatureSubtable
 			maxIndex = index
 		}
 	}
-	// fetch the action table, up to the last entry
-	if len(data) < ligActionOffset+4*int(maxIndex+1) {
-		return out, errors.New("invalid morx ligature subtable (EOF)")
-	}
-	actionData := data[ligActionOffset:]
-	for len(actionData) >= 4 { // stop gracefully if the last action was not found
-		action := binary.BigEndian.Uint32(actionData)
-		// data is truncated to the end of the table,
-		// so the memory allocation is bounded by the table size
-		out.LigatureAction = append(out.LigatureAction, action)
-		actionData = actionData[4:]
-		// dont break before maxIndex
-		if len(out.LigatureAction) > maxIndex && action&MLActionLast != 0 {
-			break
-		}
+	out.LigatureAction, err = parseLigatureActions(data[ligActionOffset:], maxIndex)
+	if err != nil {
+		return out, err
 	}
 
 	componentCount := (ligatureOffset - componentOffset) / 2
@@ -334,6 +323,27 @@ func parseLigatureSubtable(data []byte, numGlyphs int) (out MorxLigatureSubtable
 	return out, nil
 }
 
+// parseLigatureActions fetches the action table, up to the last entry,
+// at least until `maxIndex`
+func parseLigatureActions(actionData []byte, maxIndex int) ([]uint32, error) {
+	if len(actionData) < 4*int(maxIndex+1) {
+		return nil, errors.New("invalid morx ligature subtable (EOF)")
+	}
+	var out []uint32
+	for len(actionData) >= 4 { // stop gracefully if the last action was not found
+		action := binary.BigEndian.Uint32(actionData)
+		// data is truncated to the end of the table,
+		// so the memory allocation is bounded by the table size
+		out = append(out, action)
+		actionData = actionData[4:]
+		// dont break before maxIndex
+		if len(out) > maxIndex && action&MLActionLast != 0 {
+			break
+		}
+	}
+	return out, nil
+}
+
 type MorxNonContextualSubtable struct {
 	Class // the lookup value is interpreted as a GlyphIndex
 }
@@ -455,3 +465,241 @@ func parseInsertionSubtable(data []byte, numGlyphs int) (out MorxInsertionSubtab
 
 	return out, nil
 }
+
+// 'mort' tables use 16-bit state tables, and store offsets
+// where 'morx' tables store indices: the following functions
+// resolve these offsets to fit into the 'morx' model.
+
+func parseMortChain(data []byte, numGlyphs int) (out MorxChain, size int, err error) {
+	if len(data) < 12 {
+		return out, 0, errors.New("invalid mort table (EOF)")
+	}
+	out.DefaultFlags = binary.BigEndian.Uint32(data)
+	size = int(binary.BigEndian.Uint32(data[4:]))
+	nFeatures := int(binary.BigEndian.Uint16(data[8:]))
+	nSubtables := int(binary.BigEndian.Uint16(data[10:]))
+
+	currentOffset := 12 + 12*nFeatures
+	// "sanitize" before allocating
+	if len(data) < currentOffset+8*nSubtables { // at least
+		return out, 0, errors.New("invalid mort table (EOF)")
+	}
+	out.Features = make([]AATFeature, nFeatures)
+	for i := range out.Features {
+		out.Features[i].Type = binary.BigEndian.Uint16(data[12+12*i:])
+		out.Features[i].Setting = binary.BigEndian.Uint16(data[12+12*i+2:])
+		out.Features[i].EnableFlags = binary.BigEndian.Uint32(data[12+12*i+4:])
+		out.Features[i].DisableFlags = binary.BigEndian.Uint32(data[12+12*i+8:])
+	}
+
+	out.Subtables = make([]MortxSubtable, nSubtables)
+	var subtableLength int
+	for i := range out.Subtables {
+		if len(data) < currentOffset {
+			return out, 0, errors.New("invalid mort table (EOF)")
+		}
+		out.Subtables[i], subtableLength, err = parseMortSubtable(data[currentOffset:], numGlyphs)
+		if err != nil {
+			return out, 0, err
+		}
+		currentOffset += subtableLength
+	}
+	return out, size, nil
+}
+
+// also returns the length of the subtable (in bytes)
+func parseMortSubtable(data []byte, numGlyphs int) (out MortxSubtable, length int, err error) {
+	if len(data) < 8 {
+		return out, 0, errors.New("invalid mort subtable (EOF)")
+	}
+	length = int(binary.BigEndian.Uint16(data))
+	if length < 8 || len(data) < length {
+		return out, 0, errors.New("invalid mort subtable (EOF)")
+	}
+	coverage := binary.BigEndian.Uint16(data[2:])
+	// the vertical, backwards and all directions bits match the 'morx' ones,
+	// but 'mort' tables have no logical order bit
+	out.Coverage = uint8(coverage>>8) & 0xE0
+	kind := MorxSubtableType(coverage & 0x0007)
+	out.Flags = binary.BigEndian.Uint32(data[4:])
+	data = data[8:length]
+	switch kind {
+	case MorxRearrangement:
+		var s AATStateTable
+		s, err = parseStateTable(data, 0, false, numGlyphs)
+		out.Data = MorxRearrangementSubtable(s)
+	case MorxContextual:
+		out.Data, err = parseMortContextualSubtable(data, numGlyphs)
+	case MorxLigature:
+		out.Data, err = parseMortLigatureSubtable(data, numGlyphs)
+	case MorxNonContextual:
+		out.Data, err = parseNonContextualSubtable(data, numGlyphs)
+	case MorxInsertion:
+		out.Data, err = parseMortInsertionSubtable(data, numGlyphs)
+	default:
+		return out, 0, fmt.Errorf("invalid mort subtable type: %d", kind)
+	}
+	return out, length, err
+}
+
+func parseMortContextualSubtable(data []byte, numGlyphs int) (out MorxContextualSubtable, err error) {
+	if len(data) < aatStateHeaderSize+2 {
+		return out, errors.New("invalid mort contextual subtable (EOF)")
+	}
+	subsOffset := int(binary.BigEndian.Uint16(data[aatStateHeaderSize:]))
+	if len(data) < subsOffset {
+		return out, errors.New("invalid mort contextual subtable (EOF)")
+	}
+	out.Machine, err = parseStateTable(data, 4, false, numGlyphs)
+	if err != nil {
+		return out, err
+	}
+
+	// the entries store offsets (in words, from the start of the subtable),
+	// which are added to the glyph index to find the substitution:
+	// we build one lookup for each offset, and store its index instead
+	lookups := make(map[uint16]uint16)
+	resolve := func(offset uint16) uint16 {
+		if offset == 0 { // no substitution
+			return 0xFFFF
+		}
+		index, ok := lookups[offset]
+		if !ok {
+			index = uint16(len(out.Substitutions))
+			out.Substitutions = append(out.Substitutions, mortSubstitutions(data, subsOffset, offset, numGlyphs))
+			lookups[offset] = index
+		}
+		return index
+	}
+	for i := range out.Machine.entries {
+		entry := &out.Machine.entries[i]
+		markOffset, currentOffset := entry.AsMorxContextual()
+		binary.BigEndian.PutUint16(entry.data[:], resolve(markOffset))
+		binary.BigEndian.PutUint16(entry.data[2:], resolve(currentOffset))
+	}
+	return out, nil
+}
+
+// mortSubstitutions returns the substitutions stored in the
+// substitution table (starting at `subsOffset`), for
+// the word offset `offset`.
+// Zero values are ignored, since they would map to the .notdef glyph.
+func mortSubstitutions(data []byte, subsOffset int, offset uint16, numGlyphs int) lookupFormat6 {
+	// the memory allocation is bounded by the table size
+	var out lookupFormat6
+	first := (subsOffset+1)/2 - int(offset)
+	if first < 0 {
+		first = 0
+	}
+	for gid := first; gid < numGlyphs; gid++ {
+		pos := 2 * (int(offset) + gid)
+		if pos+2 > len(data) {
+			break
+		}
+		if value := binary.BigEndian.Uint16(data[pos:]); value != 0 {
+			out = append(out, lookupFormat6{{gid: GID(gid), value: uint32(value)}}...)
+		}
+	}
+	return out
+}
+
+func parseMortLigatureSubtable(data []byte, numGlyphs int) (out MorxLigatureSubtable, err error) {
+	if len(data) < aatStateHeaderSize+6 {
+		return out, errors.New("invalid mort ligature subtable (EOF)")
+	}
+	ligActionOffset := int(binary.BigEndian.Uint16(data[aatStateHeaderSize:]))
+	componentOffset := int(binary.BigEndian.Uint16(data[aatStateHeaderSize+2:]))
+	ligatureOffset := int(binary.BigEndian.Uint16(data[aatStateHeaderSize+4:]))
+	// for now, we assume the offsets are actually sorted
+	if ligActionOffset > componentOffset || componentOffset > ligatureOffset || len(data) < ligatureOffset {
+		return out, errors.New("invalid mort ligature subtable (EOF)")
+	}
+	out.Machine, err = parseStateTable(data, 0, false, numGlyphs)
+	if err != nil {
+		return out, err
+	}
+
+	// the entries store the byte offset to the action list in their flags:
+	// resolve it to an index, and use the 'morx' flags
+	maxIndex := -1
+	for i := range out.Machine.entries {
+		entry := &out.Machine.entries[i]
+		offset := int(entry.Flags & MLOffset)
+		entry.Flags &= MLSetComponent | MLDontAdvance
+		if offset == 0 {
+			continue
+		}
+		if offset < ligActionOffset || (offset-ligActionOffset)%4 != 0 {
+			return out, fmt.Errorf("invalid mort ligature action offset %d", offset)
+		}
+		index := (offset - ligActionOffset) / 4
+		entry.Flags |= MLPerformAction
+		binary.BigEndian.PutUint16(entry.data[:], uint16(index))
+		if index > maxIndex {
+			maxIndex = index
+		}
+	}
+	out.LigatureAction, err = parseLigatureActions(data[ligActionOffset:], maxIndex)
+	if err != nil {
+		return out, err
+	}
+
+	// the actions store offsets to the components, in words, from the start of the subtable:
+	// we simply pad the component array
+	componentStart := componentOffset / 2
+	out.Component = make([]uint16, componentStart+(ligatureOffset-componentOffset)/2)
+	for i := range out.Component[componentStart:] {
+		out.Component[componentStart+i] = binary.BigEndian.Uint16(data[componentOffset+2*i:])
+	}
+
+	// the accumulated components are byte offsets from the start of the subtable,
+	// so that the ligatures are indexed by byte offsets
+	if ligatureOffset+2 <= len(data) {
+		out.Ligatures = make([]GID, len(data)-1)
+		for offset := ligatureOffset; offset < len(out.Ligatures); offset++ {
+			aligned := ligatureOffset + (offset-ligatureOffset)/2*2
+			out.Ligatures[offset] = GID(binary.BigEndian.Uint16(data[aligned:]))
+		}
+	}
+	return out, nil
+}
+
+func parseMortInsertionSubtable(data []byte, numGlyphs int) (out MorxInsertionSubtable, err error) {
+	out.Machine, err = parseStateTable(data, 4, false, numGlyphs)
+	if err != nil {
+		return out, err
+	}
+
+	// the entries store byte offsets from the start of the subtable
+	// to the insertion lists: resolve them to indices,
+	// and find the maximum index needed, taking into account the number of insertions
+	var maxi int
+	for i := range out.Machine.entries {
+		entry := &out.Machine.entries[i]
+		currentOffset, markedOffset := entry.AsMorxInsertion()
+		currentIndex, markedIndex := uint16(0xFFFF), uint16(0xFFFF)
+		if currentOffset != 0 {
+			currentIndex = currentOffset / 2
+			if indexEnd := int(currentIndex) + int(entry.Flags&MICurrentInsertCount)>>5; indexEnd > maxi {
+				maxi = indexEnd
+			}
+		}
+		if markedOffset != 0 {
+			markedIndex = markedOffset / 2
+			if indexEnd := int(markedIndex) + int(entry.Flags&MIMarkedInsertCount); indexEnd > maxi {
+				maxi = indexEnd
+			}
+		}
+		binary.BigEndian.PutUint16(entry.data[:], currentIndex)
+		binary.BigEndian.PutUint16(entry.data[2:], markedIndex)
+	}
+
+	if len(data) < 2*maxi {
+		return out, errors.New("invalid mort insertion subtable (EOF)")
+	}
+	out.Insertions = make([]GID, maxi)
+	for i := range out.Insertions {
+		out.Insertions[i] = GID(binary.BigEndian.Uint16(data[i*2:]))
+	}
+	return out, nil
+}
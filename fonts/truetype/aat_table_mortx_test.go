@@ -223,6 +223,99 @@ func TestParseMorxLigature(t *testing.T) {
 	}
 }
 
+func TestParseMort(t *testing.T) {
+	// a contextual subtable replacing glyph 10 by 20, and a ligature subtable
+	// replacing the glyphs 10, 11 by 100
+	mortData := deHexStr(
+		"0001 0000 " + //  0: Version=1.0
+			"0000 0001 " + //  4: MorphChainCount=1
+			"0000 0001 " + //  8: DefaultFlags=1
+			"0000 0088 " + // 12: ChainLength=136
+			"0000 0002 " + // 16: FeatureCount=0, SubtableCount=2
+
+			"0034 0001 " + // 20: Subtable[0].Length=52, Coverage=1/ContextualMorph
+			"0000 0001 " + // 24: Subtable[0].SubFeatureFlags=0x1
+			"0005 000A 0010 001A " + // 28: STHeader: ClassCount=5, ClassTable=10, StateArray=16, EntryTable=26
+			"002A " + // 36: SubstitutionTable=42
+			"000A 0001 04 00 " + // 38: ClassTable: FirstGlyph=10, GlyphCount=1, classes, padding
+			"00 00 00 00 01 " + // 44: State[0][0..4]
+			"00 00 00 00 01 " + // 49: State[1][0..4]
+			"0010 0000 0000 0000 " + // 54: Entries[0]: NewState=0, no substitution
+			"0010 0000 0000 000B " + // 62: Entries[1]: NewState=0, CurrentOffset=11 words
+			"0014 " + // 70: Substitution for glyph 10 at 2*(11+10)=42
+
+			"0048 0002 " + // 72: Subtable[1].Length=72, Coverage=2/LigatureMorph
+			"0000 0001 " + // 76: Subtable[1].SubFeatureFlags=0x1
+			"0006 000E 0014 0026 " + // 80: STHeader: ClassCount=6, ClassTable=14, StateArray=20, EntryTable=38
+			"0032 003A 003E " + // 88: LigActions=50, Components=58, Ligatures=62
+			"000A 0002 04 05 " + // 94: ClassTable: FirstGlyph=10, GlyphCount=2, classes
+			"00 00 00 00 01 00 " + // 100: State[0][0..5]
+			"00 00 00 00 01 00 " + // 106: State[1][0..5]
+			"00 00 00 00 01 02 " + // 112: State[2][0..5]
+			"0014 0000 " + // 118: Entries[0]: NewState=0
+			"0020 8000 " + // 122: Entries[1]: NewState=2, SetComponent
+			"0014 8032 " + // 126: Entries[2]: NewState=0, SetComponent, Action offset=50
+			"0000 0013 " + // 130: Action[0]: Offset=19
+			"C000 0013 " + // 134: Action[1]: Last, Store, Offset=19
+			"0038 0006 " + // 138: Components (words 29, 30)
+			"0064 ") // 142: Ligature
+
+	if len(mortData) != 144 {
+		t.Fatal()
+	}
+
+	out, err := parseTableMorx(mortData, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || len(out[0].Subtables) != 2 || out[0].DefaultFlags != 1 {
+		t.Fatalf("unexpected chains %v", out)
+	}
+
+	contextual, ok := out[0].Subtables[0].Data.(MorxContextualSubtable)
+	if !ok {
+		t.Fatalf("expected MorxContextualSubtable, got %T", out[0].Subtables[0].Data)
+	}
+	if len(contextual.Substitutions) != 1 {
+		t.Fatalf("unexpected substitutions %v", contextual.Substitutions)
+	}
+	if markIndex, currentIndex := contextual.Machine.entries[1].AsMorxContextual(); markIndex != 0xFFFF || currentIndex != 0 {
+		t.Fatalf("unexpected indices %d %d", markIndex, currentIndex)
+	}
+	if markIndex, currentIndex := contextual.Machine.entries[0].AsMorxContextual(); markIndex != 0xFFFF || currentIndex != 0xFFFF {
+		t.Fatalf("unexpected indices %d %d", markIndex, currentIndex)
+	}
+	if gid, ok := contextual.Substitutions[0].ClassID(10); !ok || gid != 20 {
+		t.Fatalf("unexpected substitution %d", gid)
+	}
+	if _, ok := contextual.Substitutions[0].ClassID(11); ok {
+		t.Fatal("unexpected substitution")
+	}
+
+	ligature, ok := out[0].Subtables[1].Data.(MorxLigatureSubtable)
+	if !ok {
+		t.Fatalf("expected MorxLigatureSubtable, got %T", out[0].Subtables[1].Data)
+	}
+	expEntries := []AATStateEntry{
+		{NewState: 0, Flags: 0},
+		{NewState: 2, Flags: MLSetComponent},
+		{NewState: 0, Flags: MLSetComponent | MLPerformAction},
+	}
+	if !reflect.DeepEqual(ligature.Machine.entries, expEntries) {
+		t.Fatalf("expected %v, got %v", expEntries, ligature.Machine.entries)
+	}
+	// run the actions as the layout engine does, popping the components
+	var ligatureIndex int
+	for i, glyph := range []GID{11, 10} {
+		action := ligature.LigatureAction[ligature.Machine.entries[2].AsMorxLigature()+uint16(i)]
+		componentIndex := int(glyph) + int(action&MLActionOffset)
+		ligatureIndex += int(ligature.Component[componentIndex])
+	}
+	if lig := ligature.Ligatures[ligatureIndex]; lig != 100 {
+		t.Fatalf("expected ligature 100, got %d", lig)
+	}
+}
+
 func TestMorxInsertion(t *testing.T) {
 	// imported from fonttools
 
@@ -0,0 +1,63 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// TableOpbd is the AAT 'opbd' table, storing the optical bounds of the glyphs.
+type TableOpbd struct {
+	class Class // glyph -> offset to the bounds
+	data  []byte
+	// IsPointIndex is true if the bounds are given by control point indices,
+	// false if they are distances, expressed in font units.
+	IsPointIndex bool
+}
+
+// AATOpticalBounds stores the optical bounds of a glyph.
+// For the distance format, each value is the delta (in font units, the Y axis
+// going up) added to the corresponding side of the advance box to get the optical edge.
+// For the control point format, each value is a control point index
+// (or -1 if there is no point), whose coordinate gives the edge.
+type AATOpticalBounds struct {
+	Left, Top, Right, Bottom int16
+}
+
+// GetBounds returns the optical bounds of `glyph`, or false if not found.
+func (t TableOpbd) GetBounds(glyph GID) (AATOpticalBounds, bool) {
+	if t.class == nil {
+		return AATOpticalBounds{}, false
+	}
+	offset, ok := t.class.ClassID(glyph)
+	if !ok || len(t.data) < int(offset)+8 {
+		return AATOpticalBounds{}, false
+	}
+	return AATOpticalBounds{
+		Left:   int16(binary.BigEndian.Uint16(t.data[offset:])),
+		Top:    int16(binary.BigEndian.Uint16(t.data[offset+2:])),
+		Right:  int16(binary.BigEndian.Uint16(t.data[offset+4:])),
+		Bottom: int16(binary.BigEndian.Uint16(t.data[offset+6:])),
+	}, true
+}
+
+func parseTableOpbd(data []byte, numGlyphs int) (out TableOpbd, err error) {
+	if len(data) < 6 {
+		return out, errors.New("invalid 'opbd' table (EOF)")
+	}
+	// ignoring version
+	switch format := binary.BigEndian.Uint16(data[4:]); format {
+	case 0:
+	case 1:
+		out.IsPointIndex = true
+	default:
+		return out, fmt.Errorf("invalid 'opbd' table format: %d", format)
+	}
+	// the lookup values are offsets from the start of the table
+	out.class, err = parseAATLookupTable(data, 6, numGlyphs, false)
+	if err != nil {
+		return out, fmt.Errorf("invalid 'opbd' table: %s", err)
+	}
+	out.data = data
+	return out, nil
+}
@@ -0,0 +1,32 @@
+package truetype
+
+import "testing"
+
+func TestParseOpbd(t *testing.T) {
+	data := deHexStr(
+		"0001 0000 " + //  0: Version=1.0
+			"0000 " + //  4: Format=0 (distances)
+			"0006 0004 0001 " + //  6: Lookup format 6, UnitSize=4, NUnits=1
+			"0004 0000 0000 " + // 12: SearchRange, EntrySelector, RangeShift
+			"000A 0016 " + // 18: Glyph 10 -> offset 22
+			"FFCE 0000 0032 0000") // 22: Left=-50, Top=0, Right=50, Bottom=0
+
+	opbd, err := parseTableOpbd(data, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opbd.IsPointIndex {
+		t.Fatal("unexpected point index format")
+	}
+	bounds, ok := opbd.GetBounds(10)
+	if exp := (AATOpticalBounds{Left: -50, Right: 50}); !ok || bounds != exp {
+		t.Fatalf("expected %v, got %v", exp, bounds)
+	}
+	if _, ok = opbd.GetBounds(11); ok {
+		t.Fatal("unexpected bounds")
+	}
+
+	if _, ok = (TableOpbd{}).GetBounds(10); ok {
+		t.Fatal("unexpected bounds for empty table")
+	}
+}
@@ -0,0 +1,68 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// TableProp is the AAT 'prop' table, storing the
+// glyph properties.
+type TableProp struct {
+	properties Class // may be nil
+	// DefaultProperties applies to the glyphs not found in the table.
+	DefaultProperties uint16
+}
+
+// glyph properties, see the 'prop' table
+const (
+	PropFloater                    = 0x8000 // The glyph is a floater.
+	PropHangsLeftTop               = 0x4000 // The glyph can hang off the left (top) edge of the text.
+	PropHangsRightBottom           = 0x2000 // The glyph can hang off the right (bottom) edge of the text.
+	PropUseComplementaryBracket    = 0x1000 // The glyph is mirrored in right to left text, see PropComplementaryBracketOffset.
+	PropComplementaryBracketOffset = 0x0F00 // Signed offset to the mirrored glyph.
+	PropAttachesOnRight            = 0x0080 // The glyph attaches on the right (version 2.0 and higher).
+	PropDirectionalityClass        = 0x001F // The bidirectional class of the glyph.
+)
+
+// Properties returns the properties of `glyph`, see the PropXXX constants.
+func (t TableProp) Properties(glyph GID) uint16 {
+	if t.properties != nil {
+		if props, ok := t.properties.ClassID(glyph); ok {
+			return uint16(props)
+		}
+	}
+	return t.DefaultProperties
+}
+
+// MirroredGlyph returns the complementary glyph of `glyph`, to
+// be used in right to left text, or false if it has none.
+func (t TableProp) MirroredGlyph(glyph GID) (GID, bool) {
+	props := t.Properties(glyph)
+	if props&PropUseComplementaryBracket == 0 {
+		return 0, false
+	}
+	// sign-extend the 4 bits value
+	offset := int(int8((props&PropComplementaryBracketOffset)>>4) >> 4)
+	return GID(int(glyph) + offset), true
+}
+
+func parseTableProp(data []byte, numGlyphs int) (out TableProp, err error) {
+	if len(data) < 8 {
+		return out, errors.New("invalid 'prop' table (EOF)")
+	}
+	// ignoring version
+	format := binary.BigEndian.Uint16(data[4:])
+	out.DefaultProperties = binary.BigEndian.Uint16(data[6:])
+	switch format {
+	case 0: // no lookup table
+	case 1:
+		out.properties, err = parseAATLookupTable(data, 8, numGlyphs, false)
+		if err != nil {
+			return out, fmt.Errorf("invalid 'prop' table: %s", err)
+		}
+	default:
+		return out, fmt.Errorf("invalid 'prop' table format: %d", format)
+	}
+	return out, nil
+}
@@ -0,0 +1,26 @@
+package truetype
+
+import "testing"
+
+func TestParseProp(t *testing.T) {
+	font := loadFont(t, "ToyKern1.ttf")
+	prop := font.layoutTables.Prop
+
+	if props := prop.Properties(11); props != 0x110b {
+		t.Fatalf("unexpected properties %x", props)
+	}
+	if props := prop.Properties(1000); props != prop.DefaultProperties {
+		t.Fatalf("unexpected properties %x", props)
+	}
+
+	// parenthesis are mirrored
+	if gid, ok := prop.MirroredGlyph(11); !ok || gid != 12 {
+		t.Fatalf("unexpected mirrored glyph %d", gid)
+	}
+	if gid, ok := prop.MirroredGlyph(12); !ok || gid != 11 {
+		t.Fatalf("unexpected mirrored glyph %d", gid)
+	}
+	if _, ok := prop.MirroredGlyph(3); ok {
+		t.Fatal("unexpected mirrored glyph")
+	}
+}
@@ -318,3 +318,10 @@ func (fd *fontDescriptor) LoadCmap() (Cmap, error) {
 	out, _ := cmap.BestEncoding()
 	return out, nil
 }
+
+// Variations returns the variation axes of the font,
+// or nil for non variable fonts. An invalid 'fvar' table is ignored.
+func (fd *fontDescriptor) Variations() []VarAxis {
+	fvar, _ := fd.FontParser.tryAndLoadFvarTable(fd.names)
+	return fvar.Axis
+}
@@ -71,6 +71,10 @@ type Font struct {
 	bitmap     bitmapTable // CBDT or EBLC or BLOC
 	sbix       tableSbix
 
+	hinting *hintingTables // optional, only for TrueType outlines
+
+	glyfPoints []contourPoint // storage reused by DrawGlyph
+
 	OS2 *TableOS2 // optional
 
 	// graphite font, optionnal
@@ -104,11 +108,17 @@ type LayoutTables struct {
 	Trak TableTrak
 	Ankr TableAnkr
 	Feat TableFeat
+	Opbd TableOpbd // An absent table has no entries
+	Lcar TableLcar // An absent table has no entries
+	Bsln TableBsln // An absent table has no mapping and zero values
+	Prop TableProp // An absent table has no entries
 	Morx TableMorx
 	Kern TableKernx
 	Kerx TableKernx
 	GSUB TableGSUB // An absent table has a nil slice of lookups
 	GPOS TableGPOS // An absent table has a nil slice of lookups
+	BASE TableBASE // An absent table has empty axis
+	JSTF TableJSTF // An absent table has a nil slice of scripts
 }
 
 // LayoutTables returns the valid advanced layout tables.
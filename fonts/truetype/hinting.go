@@ -0,0 +1,292 @@
+package truetype
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// Hinter executes the TrueType instructions of a font at a given size,
+// producing grid-fitted outlines.
+//
+// As the FreeType 'v40' interpreter, it is tuned for anti-aliased rendering:
+// unless the font opts out, the instructions only affect the vertical direction.
+//
+// A Hinter is not safe for concurrent use. It is bound to the
+// variation coordinates of the font at the time of its creation.
+type Hinter struct {
+	font *Font
+
+	interp interpreter
+
+	// state after the control value program, restored for each glyph
+	glyphGS  graphicsState
+	cvt      []int32
+	storage  []int32
+	twilight zone
+
+	ppem uint16
+	// hinting may be disabled by the control value program
+	disabled bool
+}
+
+// HintedGlyph is an outline returned by Hinter.
+type HintedGlyph struct {
+	// Outline is expressed in font units, so that it may be
+	// rendered (at the hinted size) as any other outline.
+	Outline fonts.GlyphOutline
+	// Advance is the hinted horizontal advance, in font units.
+	Advance float32
+}
+
+// NewHinter runs the font program and the control value program
+// (the 'fpgm' and 'prep' tables) for a size of `ppem` pixels per em.
+// An error is returned if the font has no TrueType outlines or if the programs fail.
+func (f *Font) NewHinter(ppem uint16) (*Hinter, error) {
+	if f.hinting == nil || len(f.Glyf) == 0 {
+		return nil, errors.New("no TrueType hinting tables")
+	}
+	if ppem == 0 || f.upem == 0 {
+		return nil, errors.New("invalid size for hinting")
+	}
+	tables := f.hinting
+
+	h := &Hinter{font: f, ppem: ppem}
+	m := &h.interp
+	m.functions = make(map[int32][]byte)
+	m.instructions = make(map[uint8][]byte)
+	m.maxStack = int(tables.maxp.maxStackElements) + 32 // some fonts underestimate the stack size
+	m.stack = make([]int32, 0, m.maxStack)
+	m.storage = make([]int32, tables.maxp.maxStorage)
+	m.ppem = int32(ppem)
+	m.scale = float64(ppem) * 64 / float64(f.upem)
+	if f.isVar() {
+		m.coords = f.varCoords
+	}
+
+	var cvt []float32
+	if f.isVar() {
+		cvt = tables.variedCvt(f.varCoords)
+	} else {
+		cvt = tables.variedCvt(nil)
+	}
+	m.cvt = make([]int32, len(cvt))
+	for i, v := range cvt {
+		m.cvt[i] = int32(math.Round(float64(v) * m.scale))
+	}
+
+	nbTwilight := int(tables.maxp.maxTwilightPoints)
+	m.zones[0] = zone{
+		org:   make([]vector, nbTwilight),
+		cur:   make([]vector, nbTwilight),
+		flags: make([]uint8, nbTwilight),
+	}
+
+	m.gs = defaultGraphicsState
+	if err := m.run(tables.fpgm, programFont); err != nil {
+		return nil, fmt.Errorf("invalid font program: %s", err)
+	}
+
+	m.gs = defaultGraphicsState
+	if err := m.run(tables.prep, programControlValue); err != nil {
+		return nil, fmt.Errorf("invalid control value program: %s", err)
+	}
+
+	h.disabled = m.gs.instructControl&1 != 0
+	if m.gs.instructControl&2 != 0 {
+		h.glyphGS = defaultGraphicsState
+	} else {
+		// the control value program is not allowed to modify
+		// the following values
+		h.glyphGS = m.gs
+		h.glyphGS.pv, h.glyphGS.fv, h.glyphGS.dv = defaultGraphicsState.pv, defaultGraphicsState.fv, defaultGraphicsState.dv
+		h.glyphGS.rp0, h.glyphGS.rp1, h.glyphGS.rp2 = 0, 0, 0
+		h.glyphGS.zp0, h.glyphGS.zp1, h.glyphGS.zp2 = 1, 1, 1
+		h.glyphGS.loop = 1
+	}
+	h.cvt = append([]int32(nil), m.cvt...)
+	h.storage = append([]int32(nil), m.storage...)
+	h.twilight = m.zones[0].copy()
+
+	return h, nil
+}
+
+// GlyphData returns the hinted outline of the glyph.
+// Errors in the glyph instructions are ignored, as FreeType does, so that
+// an error is only returned for invalid glyph indices.
+func (h *Hinter) GlyphData(gid GID) (HintedGlyph, error) {
+	if int(gid) >= len(h.font.Glyf) {
+		return HintedGlyph{}, fmt.Errorf("out of range glyph %d", gid)
+	}
+	z := h.loadGlyph(gid, 0)
+
+	n := len(z.cur)
+	origin := z.cur[n-phantomCount+phantomLeft].x
+	advance := roundPixel(z.cur[n-phantomCount+phantomRight].x - origin)
+
+	// go back to font units, with the origin at (0, 0)
+	toFUnits := float32(1 / h.interp.scale)
+	points := make([]contourPoint, n-phantomCount)
+	for i := range points {
+		points[i].X = float32(z.cur[i].x-origin) * toFUnits
+		points[i].Y = float32(z.cur[i].y) * toFUnits
+		points[i].isOnCurve = z.flags[i]&pointOnCurve != 0
+	}
+	for _, end := range z.ends {
+		points[end].isEndPoint = true
+	}
+
+	return HintedGlyph{
+		Outline: fonts.GlyphOutline{Segments: buildSegments(points)},
+		Advance: float32(advance) * toFUnits,
+	}, nil
+}
+
+// loadGlyph returns the hinted points of the glyph, in 26.6 units,
+// followed by its phantom points
+func (h *Hinter) loadGlyph(gid GID, depth int) zone {
+	font := h.font
+	if depth > maxCompositeNesting || int(gid) >= len(font.Glyf) {
+		return zone{
+			cur:   make([]vector, phantomCount),
+			org:   make([]vector, phantomCount),
+			flags: make([]uint8, phantomCount),
+		}
+	}
+
+	// variations are applied on the unscaled points
+	unscaled := font.glyphPointsWithPhantoms(gid, nil)
+
+	var (
+		z            zone
+		instructions []byte
+		isComposite  bool
+	)
+	switch data := font.Glyf[gid].data.(type) {
+	case compositeGlyphData:
+		z = h.loadComposite(data, unscaled, depth)
+		instructions, isComposite = data.instructions, true
+	default:
+		z.cur = make([]vector, len(unscaled))
+		z.flags = make([]uint8, len(unscaled))
+		for i, p := range unscaled {
+			z.cur[i] = h.scalePoint(p.X, p.Y)
+			if p.isOnCurve {
+				z.flags[i] = pointOnCurve
+			}
+			if p.isEndPoint {
+				z.ends = append(z.ends, i)
+			}
+		}
+		if data, ok := data.(simpleGlyphData); ok {
+			instructions = data.instructions
+		}
+	}
+
+	h.hint(&z, instructions, isComposite)
+	return z
+}
+
+// `unscaled` contains the component offsets and the phantom points
+func (h *Hinter) loadComposite(data compositeGlyphData, unscaled []contourPoint, depth int) zone {
+	const roundXYToGrid = 0x0004
+
+	phantoms := make([]vector, phantomCount)
+	for i, p := range unscaled[len(unscaled)-phantomCount:] {
+		phantoms[i] = h.scalePoint(p.X, p.Y)
+	}
+
+	var out zone
+	for compIndex, item := range data.glyphs {
+		comp := h.loadGlyph(item.glyphIndex, depth+1)
+		nbPoints := len(comp.cur) - phantomCount
+
+		if item.hasUseMyMetrics() {
+			copy(phantoms, comp.cur[nbPoints:])
+		}
+
+		points := comp.cur[:nbPoints]
+		if item.scale != [4]float32{1, 0, 0, 1} {
+			for i, p := range points {
+				x, y := float32(p.x), float32(p.y)
+				points[i] = vector{
+					int32(math.Round(float64(x*item.scale[0] + y*item.scale[2]))),
+					int32(math.Round(float64(x*item.scale[1] + y*item.scale[3]))),
+				}
+			}
+		}
+
+		var offset vector
+		if item.isAnchored() {
+			p1, p2 := item.argsAsIndices()
+			if p1 < len(out.cur) && p2 < nbPoints {
+				offset = out.cur[p1].sub(points[p2])
+			}
+		} else {
+			arg1, arg2 := item.argsAsTranslation()
+			// include the variations of the offset
+			dx := float32(arg1) + unscaled[compIndex].X
+			dy := float32(arg2) + unscaled[compIndex].Y
+			if item.isScaledOffsets() {
+				dx, dy = dx*item.scale[0]+dy*item.scale[2], dx*item.scale[1]+dy*item.scale[3]
+			}
+			offset = h.scalePoint(dx, dy)
+			if item.flags&roundXYToGrid != 0 {
+				offset = vector{roundPixel(offset.x), roundPixel(offset.y)}
+			}
+		}
+
+		start := len(out.cur)
+		for _, p := range points {
+			out.cur = append(out.cur, vector{p.x + offset.x, p.y + offset.y})
+		}
+		for _, flag := range comp.flags[:nbPoints] {
+			// the points touched by the component instructions are untouched
+			out.flags = append(out.flags, flag&pointOnCurve)
+		}
+		for _, end := range comp.ends {
+			out.ends = append(out.ends, start+end)
+		}
+	}
+
+	out.cur = append(out.cur, phantoms...)
+	out.flags = append(out.flags, make([]uint8, phantomCount)...)
+	return out
+}
+
+func (h *Hinter) scalePoint(x, y float32) vector {
+	return vector{
+		int32(math.Round(float64(x) * h.interp.scale)),
+		int32(math.Round(float64(y) * h.interp.scale)),
+	}
+}
+
+// hint executes the glyph instructions on the zone `z`,
+// whose current positions are the scaled (and for composite glyphs, hinted) points
+func (h *Hinter) hint(z *zone, instructions []byte, isComposite bool) {
+	z.org = append(z.org[:0], z.cur...)
+
+	n := len(z.cur)
+	z.cur[n-4].x = roundPixel(z.cur[n-4].x)
+	z.cur[n-3].x = roundPixel(z.cur[n-3].x)
+	z.cur[n-2].y = roundPixel(z.cur[n-2].y)
+	z.cur[n-1].y = roundPixel(z.cur[n-1].y)
+
+	if len(instructions) == 0 || h.disabled {
+		return
+	}
+
+	m := &h.interp
+	m.gs = h.glyphGS
+	m.cvt = append(m.cvt[:0], h.cvt...)
+	m.storage = append(m.storage[:0], h.storage...)
+	m.zones[0] = h.twilight.copy()
+	m.zones[1] = *z
+	m.isComposite = isComposite
+
+	// as FreeType, ignore errors and keep the points
+	// modified so far
+	_ = m.run(instructions, programGlyph)
+}
@@ -0,0 +1,1703 @@
+package truetype
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// this file implements the TrueType bytecode interpreter,
+// following the behavior of the FreeType 'v40' interpreter (see ttinterp.c),
+// where the glyph programs are not allowed to move points horizontally
+// ("backward compatibility"), unless the font explicitely opts out
+// with the INSTCTRL instruction.
+//
+// Coordinates and distances are 26.6 fixed point numbers,
+// unit vectors are 2.14 fixed point numbers.
+
+const (
+	// protects against infinite loops
+	maxInstructionsCount = 1 << 20
+	maxCallDepth         = 32
+)
+
+type vector struct{ x, y int32 }
+
+func (v vector) sub(w vector) vector { return vector{v.x - w.x, v.y - w.y} }
+
+const (
+	pointOnCurve uint8 = 1 << iota
+	pointTouchedX
+	pointTouchedY
+)
+
+// zone stores the points manipulated by the instructions,
+// either the twilight zone (0) or the glyph zone (1)
+type zone struct {
+	org, cur []vector
+	flags    []uint8
+	ends     []int // index of the last point of each contour
+}
+
+func (z zone) copy() zone {
+	return zone{
+		org:   append([]vector(nil), z.org...),
+		cur:   append([]vector(nil), z.cur...),
+		flags: append([]uint8(nil), z.flags...),
+		ends:  append([]int(nil), z.ends...),
+	}
+}
+
+type roundMode uint8
+
+const (
+	roundToHalfGrid roundMode = iota
+	roundToGrid
+	roundToDoubleGrid
+	roundDownToGrid
+	roundUpToGrid
+	roundOff
+	roundSuper
+	roundSuper45
+)
+
+type graphicsState struct {
+	// projection, freedom and dual projection vectors
+	pv, fv, dv vector
+	// reference points
+	rp0, rp1, rp2 int32
+	// zone pointers
+	zp0, zp1, zp2 int32
+	loop          int32
+
+	minDist                       int32
+	controlValueCutIn             int32
+	singleWidthCutIn, singleWidth int32
+	deltaBase, deltaShift         int32
+
+	roundMode                roundMode
+	period, phase, threshold int32 // for super rounding
+
+	instructControl int32
+	autoFlip        bool
+}
+
+var defaultGraphicsState = graphicsState{
+	pv:                vector{0x4000, 0},
+	fv:                vector{0x4000, 0},
+	dv:                vector{0x4000, 0},
+	zp0:               1,
+	zp1:               1,
+	zp2:               1,
+	loop:              1,
+	minDist:           64,
+	controlValueCutIn: 68, // 17/16 pixel
+	deltaBase:         9,
+	deltaShift:        3,
+	roundMode:         roundToGrid,
+	period:            64,
+	autoFlip:          true,
+}
+
+type programKind uint8
+
+const (
+	programFont programKind = iota
+	programControlValue
+	programGlyph
+)
+
+type interpreter struct {
+	functions    map[int32][]byte
+	instructions map[uint8][]byte // user defined opcodes
+
+	stack   []int32
+	storage []int32
+	cvt     []int32
+	zones   [2]zone // twilight and glyph zones
+
+	coords []float32 // normalized variation coordinates, may be empty
+
+	gs       graphicsState
+	maxStack int
+	budget   int // remaining instructions
+
+	ppem  int32
+	scale float64 // from font units to 26.6 pixels
+
+	kind                  programKind
+	isComposite           bool
+	backwardCompatibility bool
+	iupXCalled            bool
+	iupYCalled            bool
+}
+
+// run executes a whole program, starting with an empty stack
+func (m *interpreter) run(program []byte, kind programKind) error {
+	m.kind = kind
+	m.stack = m.stack[:0]
+	m.backwardCompatibility = m.gs.instructControl&4 == 0
+	m.iupXCalled, m.iupYCalled = false, false
+	m.budget = maxInstructionsCount
+	return m.execute(program, 0)
+}
+
+func (m *interpreter) execute(code []byte, depth int) error {
+	if depth > maxCallDepth {
+		return errors.New("too many nested calls")
+	}
+	for pc := 0; pc < len(code); {
+		m.budget--
+		if m.budget < 0 {
+			return errors.New("too many instructions")
+		}
+		next, err := m.step(code, pc, depth)
+		if err != nil {
+			return fmt.Errorf("opcode 0x%02x at %d: %s", code[pc], pc, err)
+		}
+		pc = next
+	}
+	return nil
+}
+
+// ------------------------------- stack -------------------------------
+
+var errStackUnderflow = errors.New("stack underflow")
+
+func (m *interpreter) push(v int32) error {
+	if len(m.stack) >= m.maxStack {
+		return errors.New("stack overflow")
+	}
+	m.stack = append(m.stack, v)
+	return nil
+}
+
+func (m *interpreter) pop() (int32, error) {
+	if len(m.stack) == 0 {
+		return 0, errStackUnderflow
+	}
+	v := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return v, nil
+}
+
+// opcodePops is the number of arguments popped by each opcode,
+// not counting the loop and delta instructions
+var opcodePops = [256]uint8{
+	0x06: 2, 0x07: 2, 0x08: 2, 0x09: 2, 0x0A: 2, 0x0B: 2, 0x0F: 5,
+	0x10: 1, 0x11: 1, 0x12: 1, 0x13: 1, 0x14: 1, 0x15: 1, 0x16: 1, 0x17: 1,
+	0x1A: 1, 0x1C: 1, 0x1D: 1, 0x1E: 1, 0x1F: 1,
+	0x20: 1, 0x21: 1, 0x23: 2, 0x25: 1, 0x26: 1, 0x27: 2, 0x29: 1, 0x2A: 2, 0x2B: 1, 0x2C: 1, 0x2E: 1, 0x2F: 1,
+	0x34: 1, 0x35: 1, 0x36: 1, 0x37: 1, 0x38: 1, 0x3A: 2, 0x3B: 2, 0x3E: 2, 0x3F: 2,
+	0x42: 2, 0x43: 1, 0x44: 2, 0x45: 1, 0x46: 1, 0x47: 1, 0x48: 2, 0x49: 2, 0x4A: 2, 0x4F: 1,
+	0x50: 2, 0x51: 2, 0x52: 2, 0x53: 2, 0x54: 2, 0x55: 2, 0x56: 1, 0x57: 1, 0x58: 1, 0x5A: 2, 0x5B: 2, 0x5C: 1,
+	0x5D: 1, 0x5E: 1, 0x5F: 1,
+	0x60: 2, 0x61: 2, 0x62: 2, 0x63: 2, 0x64: 1, 0x65: 1, 0x66: 1, 0x67: 1,
+	0x68: 1, 0x69: 1, 0x6A: 1, 0x6B: 1, 0x6C: 1, 0x6D: 1, 0x6E: 1, 0x6F: 1,
+	0x70: 2, 0x71: 1, 0x72: 1, 0x73: 1, 0x74: 1, 0x75: 1, 0x76: 1, 0x77: 1, 0x78: 2, 0x79: 2, 0x7E: 1, 0x7F: 1,
+	0x81: 2, 0x82: 2, 0x85: 1, 0x86: 2, 0x87: 2, 0x88: 1, 0x89: 1, 0x8A: 3, 0x8B: 2, 0x8C: 2, 0x8D: 1, 0x8E: 2,
+}
+
+func init() {
+	for op := 0xC0; op <= 0xFF; op++ { // MDRP and MIRP
+		opcodePops[op] = 1
+		if op >= 0xE0 {
+			opcodePops[op] = 2
+		}
+	}
+}
+
+// ---------------------------- instructions ----------------------------
+
+const (
+	opIF     = 0x58
+	opELSE   = 0x1B
+	opEIF    = 0x59
+	opFDEF   = 0x2C
+	opENDF   = 0x2D
+	opIDEF   = 0x89
+	opNPUSHB = 0x40
+	opNPUSHW = 0x41
+)
+
+// instructionLength returns the size of the instruction at `pc`,
+// including its inline data, or 0 if `code` is too short
+func instructionLength(code []byte, pc int) int {
+	n := 1
+	switch op := code[pc]; {
+	case op == opNPUSHB:
+		if pc+1 >= len(code) {
+			return 0
+		}
+		n = 2 + int(code[pc+1])
+	case op == opNPUSHW:
+		if pc+1 >= len(code) {
+			return 0
+		}
+		n = 2 + 2*int(code[pc+1])
+	case 0xB0 <= op && op <= 0xB7: // PUSHB
+		n = 1 + int(op-0xB0+1)
+	case 0xB8 <= op && op <= 0xBF: // PUSHW
+		n = 1 + 2*int(op-0xB8+1)
+	}
+	if pc+n > len(code) {
+		return 0
+	}
+	return n
+}
+
+// skipIf returns the position of the ELSE (if `stopAtElse` is true)
+// or EIF instruction matching the IF preceding `pc`
+func skipIf(code []byte, pc int, stopAtElse bool) (int, error) {
+	level := 1
+	for pc < len(code) {
+		switch code[pc] {
+		case opIF:
+			level++
+		case opELSE:
+			if level == 1 && stopAtElse {
+				return pc, nil
+			}
+		case opEIF:
+			level--
+			if level == 0 {
+				return pc, nil
+			}
+		}
+		n := instructionLength(code, pc)
+		if n == 0 {
+			break
+		}
+		pc += n
+	}
+	return 0, errors.New("unbalanced IF instruction")
+}
+
+// skipDefinition returns the position of the ENDF instruction ending
+// the definition starting at `pc`
+func skipDefinition(code []byte, pc int) (int, error) {
+	for pc < len(code) {
+		switch code[pc] {
+		case opENDF:
+			return pc, nil
+		case opFDEF, opIDEF:
+			return 0, errors.New("nested definition")
+		}
+		n := instructionLength(code, pc)
+		if n == 0 {
+			break
+		}
+		pc += n
+	}
+	return 0, errors.New("unterminated definition")
+}
+
+// step executes the instruction at `pc` and returns the position
+// of the next instruction
+func (m *interpreter) step(code []byte, pc, depth int) (int, error) {
+	op := code[pc]
+
+	// push instructions read their arguments inline
+	if op == opNPUSHB || op == opNPUSHW || (0xB0 <= op && op <= 0xBF) {
+		return m.pushInline(code, pc)
+	}
+
+	// pop the fixed arguments, which are ordered as pushed:
+	// the top of the stack is the last element
+	var args [5]int32
+	nArgs := int(opcodePops[op])
+	if len(m.stack) < nArgs {
+		return 0, errStackUnderflow
+	}
+	copy(args[:], m.stack[len(m.stack)-nArgs:])
+	m.stack = m.stack[:len(m.stack)-nArgs]
+
+	gs := &m.gs
+	next := pc + 1
+	var err error
+	switch op {
+	case 0x00, 0x01: // SVTCA
+		v := axisVector(op)
+		gs.pv, gs.fv, gs.dv = v, v, v
+	case 0x02, 0x03: // SPVTCA
+		gs.pv = axisVector(op)
+		gs.dv = gs.pv
+	case 0x04, 0x05: // SFVTCA
+		gs.fv = axisVector(op)
+	case 0x06, 0x07: // SPVTL
+		gs.pv, err = m.vectorToLine(args[1], args[0], op&1 != 0)
+		gs.dv = gs.pv
+	case 0x08, 0x09: // SFVTL
+		gs.fv, err = m.vectorToLine(args[1], args[0], op&1 != 0)
+	case 0x0A: // SPVFS
+		gs.pv = normalize(int32(int16(args[0])), int32(int16(args[1])))
+		gs.dv = gs.pv
+	case 0x0B: // SFVFS
+		gs.fv = normalize(int32(int16(args[0])), int32(int16(args[1])))
+	case 0x0C: // GPV
+		err = m.pushAll(gs.pv.x, gs.pv.y)
+	case 0x0D: // GFV
+		err = m.pushAll(gs.fv.x, gs.fv.y)
+	case 0x0E: // SFVTPV
+		gs.fv = gs.pv
+	case 0x0F: // ISECT
+		err = m.intersect(args[0], args[1], args[2], args[3], args[4])
+	case 0x10: // SRP0
+		gs.rp0 = args[0]
+	case 0x11: // SRP1
+		gs.rp1 = args[0]
+	case 0x12: // SRP2
+		gs.rp2 = args[0]
+	case 0x13, 0x14, 0x15, 0x16: // SZP0, SZP1, SZP2, SZPS
+		if args[0] != 0 && args[0] != 1 {
+			return 0, errors.New("invalid zone")
+		}
+		switch op {
+		case 0x13:
+			gs.zp0 = args[0]
+		case 0x14:
+			gs.zp1 = args[0]
+		case 0x15:
+			gs.zp2 = args[0]
+		default:
+			gs.zp0, gs.zp1, gs.zp2 = args[0], args[0], args[0]
+		}
+	case 0x17: // SLOOP
+		if args[0] < 0 {
+			return 0, errors.New("invalid loop counter")
+		}
+		gs.loop = args[0]
+		if gs.loop > 0xFFFF {
+			gs.loop = 0xFFFF
+		}
+	case 0x18: // RTG
+		gs.roundMode = roundToGrid
+	case 0x19: // RTHG
+		gs.roundMode = roundToHalfGrid
+	case 0x1A: // SMD
+		gs.minDist = args[0]
+	case opELSE: // reached at the end of a true IF branch
+		end, err := skipIf(code, pc+1, false)
+		if err != nil {
+			return 0, err
+		}
+		next = end + 1
+	case 0x1C: // JMPR
+		next, err = jump(code, pc, args[0])
+	case 0x1D: // SCVTCI
+		gs.controlValueCutIn = args[0]
+	case 0x1E: // SSWCI
+		gs.singleWidthCutIn = args[0]
+	case 0x1F: // SSW, in font units
+		gs.singleWidth = m.scaleFUnits(args[0])
+	case 0x20: // DUP
+		err = m.pushAll(args[0], args[0])
+	case 0x21: // POP
+	case 0x22: // CLEAR
+		m.stack = m.stack[:0]
+	case 0x23: // SWAP
+		err = m.pushAll(args[1], args[0])
+	case 0x24: // DEPTH
+		err = m.push(int32(len(m.stack)))
+	case 0x25, 0x26: // CINDEX, MINDEX
+		k := int(args[0])
+		if k <= 0 || k > len(m.stack) {
+			return 0, errors.New("invalid stack index")
+		}
+		index := len(m.stack) - k
+		v := m.stack[index]
+		if op == 0x26 {
+			copy(m.stack[index:], m.stack[index+1:])
+			m.stack = m.stack[:len(m.stack)-1]
+		}
+		err = m.push(v)
+	case 0x27: // ALIGNPTS
+		err = m.alignPoints(args[0], args[1])
+	case 0x29: // UTP
+		err = m.untouch(args[0])
+	case 0x2A: // LOOPCALL
+		err = m.call(args[1], args[0], depth)
+	case 0x2B: // CALL
+		err = m.call(args[0], 1, depth)
+	case opFDEF:
+		if m.kind == programGlyph {
+			return 0, errors.New("function definition in glyph program")
+		}
+		end, err := skipDefinition(code, pc+1)
+		if err != nil {
+			return 0, err
+		}
+		m.functions[args[0]] = code[pc+1 : end]
+		next = end + 1
+	case opENDF: // function bodies do not include ENDF
+		return 0, errors.New("unexpected ENDF")
+	case 0x2E, 0x2F: // MDAP
+		err = m.moveDirectAbsolute(args[0], op&1 != 0)
+	case 0x30, 0x31: // IUP
+		m.interpolateUntouched(op&1 != 0)
+	case 0x32, 0x33: // SHP
+		err = m.shiftPoints(op&1 != 0)
+	case 0x34, 0x35: // SHC
+		err = m.shiftContour(args[0], op&1 != 0)
+	case 0x36, 0x37: // SHZ
+		err = m.shiftZone(args[0], op&1 != 0)
+	case 0x38: // SHPIX
+		err = m.shiftPixels(args[0])
+	case 0x39: // IP
+		err = m.interpolatePoints()
+	case 0x3A, 0x3B: // MSIRP
+		err = m.moveStackIndirectRelative(args[0], args[1], op&1 != 0)
+	case 0x3C: // ALIGNRP
+		err = m.alignToReference()
+	case 0x3D: // RTDG
+		gs.roundMode = roundToDoubleGrid
+	case 0x3E, 0x3F: // MIAP
+		err = m.moveIndirectAbsolute(args[0], args[1], op&1 != 0)
+	case 0x42: // WS
+		if index := args[0]; 0 <= index && int(index) < len(m.storage) {
+			m.storage[index] = args[1]
+		}
+	case 0x43: // RS
+		var v int32
+		if index := args[0]; 0 <= index && int(index) < len(m.storage) {
+			v = m.storage[index]
+		}
+		err = m.push(v)
+	case 0x44: // WCVTP
+		m.writeCvt(args[0], args[1])
+	case 0x45: // RCVT
+		err = m.push(m.readCvt(args[0]))
+	case 0x46, 0x47: // GC
+		var p vector
+		p, err = m.point(gs.zp2, args[0], op == 0x47)
+		if err == nil {
+			if op == 0x46 {
+				err = m.push(dot14(p, gs.pv))
+			} else {
+				err = m.push(dot14(p, gs.dv))
+			}
+		}
+	case 0x48: // SCFS
+		err = m.setCoordinateFromStack(args[0], args[1])
+	case 0x49, 0x4A: // MD
+		err = m.measureDistance(args[0], args[1], op == 0x4A)
+	case 0x4B: // MPPEM
+		err = m.push(m.ppem)
+	case 0x4C: // MPS, at 72 dpi
+		err = m.push(m.ppem << 6)
+	case 0x4D: // FLIPON
+		gs.autoFlip = true
+	case 0x4E: // FLIPOFF
+		gs.autoFlip = false
+	case 0x4F: // DEBUG
+	case 0x50: // LT
+		err = m.push(boolToInt(args[0] < args[1]))
+	case 0x51: // LTEQ
+		err = m.push(boolToInt(args[0] <= args[1]))
+	case 0x52: // GT
+		err = m.push(boolToInt(args[0] > args[1]))
+	case 0x53: // GTEQ
+		err = m.push(boolToInt(args[0] >= args[1]))
+	case 0x54: // EQ
+		err = m.push(boolToInt(args[0] == args[1]))
+	case 0x55: // NEQ
+		err = m.push(boolToInt(args[0] != args[1]))
+	case 0x56: // ODD
+		err = m.push(boolToInt(m.round(args[0])&127 == 64))
+	case 0x57: // EVEN
+		err = m.push(boolToInt(m.round(args[0])&127 == 0))
+	case opIF:
+		if args[0] == 0 {
+			end, err := skipIf(code, pc+1, true)
+			if err != nil {
+				return 0, err
+			}
+			next = end + 1
+		}
+	case opEIF:
+	case 0x5A: // AND
+		err = m.push(boolToInt(args[0] != 0 && args[1] != 0))
+	case 0x5B: // OR
+		err = m.push(boolToInt(args[0] != 0 || args[1] != 0))
+	case 0x5C: // NOT
+		err = m.push(boolToInt(args[0] == 0))
+	case 0x5D, 0x71, 0x72: // DELTAP1, DELTAP2, DELTAP3
+		err = m.deltaPoints(args[0], op)
+	case 0x5E: // SDB
+		gs.deltaBase = args[0]
+	case 0x5F: // SDS
+		if args[0] < 0 || args[0] > 6 {
+			return 0, errors.New("invalid delta shift")
+		}
+		gs.deltaShift = args[0]
+	case 0x60: // ADD
+		err = m.push(args[0] + args[1])
+	case 0x61: // SUB
+		err = m.push(args[0] - args[1])
+	case 0x62: // DIV
+		if args[1] == 0 {
+			return 0, errors.New("division by zero")
+		}
+		err = m.push(mulDivNoRound26(args[0], 64, args[1]))
+	case 0x63: // MUL
+		err = m.push(mulDiv26(args[0], args[1], 64))
+	case 0x64: // ABS
+		if args[0] < 0 {
+			args[0] = -args[0]
+		}
+		err = m.push(args[0])
+	case 0x65: // NEG
+		err = m.push(-args[0])
+	case 0x66: // FLOOR
+		err = m.push(args[0] &^ 63)
+	case 0x67: // CEILING
+		err = m.push((args[0] + 63) &^ 63)
+	case 0x68, 0x69, 0x6A, 0x6B: // ROUND, without engine compensation
+		err = m.push(m.round(args[0]))
+	case 0x6C, 0x6D, 0x6E, 0x6F: // NROUND, without engine compensation
+		err = m.push(args[0])
+	case 0x70: // WCVTF, in font units
+		m.writeCvt(args[0], m.scaleFUnits(args[1]))
+	case 0x73, 0x74, 0x75: // DELTAC1, DELTAC2, DELTAC3
+		err = m.deltaCvt(args[0], op)
+	case 0x76: // SROUND
+		m.setSuperRound(0x4000, args[0])
+		gs.roundMode = roundSuper
+	case 0x77: // S45ROUND
+		m.setSuperRound(0x2D41, args[0])
+		gs.roundMode = roundSuper45
+	case 0x78: // JROT
+		if args[1] != 0 {
+			next, err = jump(code, pc, args[0])
+		}
+	case 0x79: // JROF
+		if args[1] == 0 {
+			next, err = jump(code, pc, args[0])
+		}
+	case 0x7A: // ROFF
+		gs.roundMode = roundOff
+	case 0x7C: // RUTG
+		gs.roundMode = roundUpToGrid
+	case 0x7D: // RDTG
+		gs.roundMode = roundDownToGrid
+	case 0x7E, 0x7F: // SANGW, AA (obsolete)
+	case 0x80: // FLIPPT
+		err = m.flipPoints()
+	case 0x81, 0x82: // FLIPRGON, FLIPRGOFF
+		err = m.flipRange(args[0], args[1], op == 0x81)
+	case 0x85, 0x8D: // SCANCTRL, SCANTYPE (no dropout control)
+	case 0x86, 0x87: // SDPVTL
+		err = m.setDualVectorToLine(args[1], args[0], op&1 != 0)
+	case 0x88: // GETINFO
+		err = m.push(m.getInfo(args[0]))
+	case opIDEF:
+		if m.kind == programGlyph {
+			return 0, errors.New("instruction definition in glyph program")
+		}
+		end, err := skipDefinition(code, pc+1)
+		if err != nil {
+			return 0, err
+		}
+		m.instructions[uint8(args[0])] = code[pc+1 : end]
+		next = end + 1
+	case 0x8A: // ROLL
+		err = m.pushAll(args[1], args[2], args[0])
+	case 0x8B: // MAX
+		if args[1] > args[0] {
+			args[0] = args[1]
+		}
+		err = m.push(args[0])
+	case 0x8C: // MIN
+		if args[1] < args[0] {
+			args[0] = args[1]
+		}
+		err = m.push(args[0])
+	case 0x8E: // INSTCTRL
+		err = m.instructionControl(args[1], args[0])
+	case 0x91: // GETVARIATION
+		if len(m.coords) == 0 {
+			return 0, errors.New("GETVARIATION in a non variable font")
+		}
+		for _, c := range m.coords {
+			if err = m.push(int32(math.Round(float64(c) * 0x4000))); err != nil {
+				break
+			}
+		}
+	case 0x92: // GETDATA
+		err = m.push(17)
+	default:
+		switch {
+		case 0xC0 <= op && op <= 0xDF: // MDRP
+			err = m.moveDirectRelative(args[0], op)
+		case 0xE0 <= op: // MIRP
+			err = m.moveIndirectRelative(args[0], args[1], op)
+		default:
+			def, ok := m.instructions[op]
+			if !ok {
+				return 0, errors.New("invalid opcode")
+			}
+			err = m.execute(def, depth+1)
+		}
+	}
+	return next, err
+}
+
+func (m *interpreter) pushInline(code []byte, pc int) (int, error) {
+	n := instructionLength(code, pc)
+	if n == 0 {
+		return 0, errors.New("missing inline data")
+	}
+	op := code[pc]
+	data := code[pc+1 : pc+n]
+	if op == opNPUSHB || op == opNPUSHW {
+		data = data[1:]
+	}
+	if op == opNPUSHB || (0xB0 <= op && op <= 0xB7) {
+		for _, b := range data {
+			if err := m.push(int32(b)); err != nil {
+				return 0, err
+			}
+		}
+	} else {
+		for i := 0; i+1 < len(data); i += 2 {
+			if err := m.push(int32(int16(uint16(data[i])<<8 | uint16(data[i+1])))); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return pc + n, nil
+}
+
+func (m *interpreter) pushAll(values ...int32) error {
+	for _, v := range values {
+		if err := m.push(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jump offsets are relative to the jump instruction
+func jump(code []byte, pc int, offset int32) (int, error) {
+	next := pc + int(offset)
+	if next < 0 || next > len(code) {
+		return 0, errors.New("invalid jump offset")
+	}
+	return next, nil
+}
+
+func (m *interpreter) call(function, count int32, depth int) error {
+	body, ok := m.functions[function]
+	if !ok {
+		return fmt.Errorf("undefined function %d", function)
+	}
+	for ; count > 0; count-- {
+		if err := m.execute(body, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func boolToInt(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ------------------------------ arithmetic ------------------------------
+
+// mulDiv26 returns a*b/c, rounded
+func mulDiv26(a, b, c int32) int32 {
+	if c == 0 {
+		return 0x7FFFFFFF
+	}
+	v := int64(a) * int64(b)
+	s := int64(c)
+	neg := (v < 0) != (s < 0)
+	if v < 0 {
+		v = -v
+	}
+	if s < 0 {
+		s = -s
+	}
+	r := (v + s/2) / s
+	if neg {
+		r = -r
+	}
+	return int32(r)
+}
+
+// mulDivNoRound26 returns a*b/c, truncated
+func mulDivNoRound26(a, b, c int32) int32 {
+	if c == 0 {
+		return 0x7FFFFFFF
+	}
+	return int32(int64(a) * int64(b) / int64(c))
+}
+
+// rounded (a*b) >> 14
+func mul14(a, b int32) int32 { return round14(int64(a) * int64(b)) }
+
+func round14(v int64) int32 {
+	if v < 0 {
+		return -int32((-v + 0x2000) >> 14)
+	}
+	return int32((v + 0x2000) >> 14)
+}
+
+// dot14 returns the scalar product of a 26.6 vector and a 2.14 unit vector
+func dot14(a, u vector) int32 {
+	return round14(int64(a.x)*int64(u.x) + int64(a.y)*int64(u.y))
+}
+
+func axisVector(op uint8) vector {
+	if op&1 != 0 {
+		return vector{0x4000, 0}
+	}
+	return vector{0, 0x4000}
+}
+
+// normalize returns the 2.14 unit vector with direction (x, y)
+func normalize(x, y int32) vector {
+	if x == 0 && y == 0 {
+		return vector{0x4000, 0}
+	}
+	fx, fy := float64(x), float64(y)
+	norm := math.Hypot(fx, fy)
+	return vector{int32(math.Round(fx / norm * 0x4000)), int32(math.Round(fy / norm * 0x4000))}
+}
+
+// round a 26.6 value to the pixel grid
+func roundPixel(v int32) int32 { return (v + 32) &^ 63 }
+
+// scaleFUnits converts font units to pixels
+func (m *interpreter) scaleFUnits(v int32) int32 {
+	return int32(math.Round(float64(v) * m.scale))
+}
+
+// ------------------------------- rounding -------------------------------
+
+func (m *interpreter) round(d int32) int32 {
+	gs := &m.gs
+	switch gs.roundMode {
+	case roundOff:
+		return d
+	case roundToGrid:
+		if d >= 0 {
+			return maxI32((d+32)&^63, 0)
+		}
+		return minI32(-((-d + 32) &^ 63), 0)
+	case roundToHalfGrid:
+		if d >= 0 {
+			return maxI32(d&^63+32, 0)
+		}
+		return minI32(-((-d)&^63 + 32), 0)
+	case roundToDoubleGrid:
+		if d >= 0 {
+			return maxI32((d+16)&^31, 0)
+		}
+		return minI32(-((-d + 16) &^ 31), 0)
+	case roundDownToGrid:
+		if d >= 0 {
+			return maxI32(d&^63, 0)
+		}
+		return minI32(-((-d) &^ 63), 0)
+	case roundUpToGrid:
+		if d >= 0 {
+			return maxI32((d+63)&^63, 0)
+		}
+		return minI32(-((-d + 63) &^ 63), 0)
+	case roundSuper:
+		if gs.period == 0 {
+			return d
+		}
+		if d >= 0 {
+			v := (d-gs.phase+gs.threshold)&-gs.period + gs.phase
+			if v < 0 {
+				v = gs.phase
+			}
+			return v
+		}
+		v := -((-d - gs.phase + gs.threshold) & -gs.period) - gs.phase
+		if v > 0 {
+			v = -gs.phase
+		}
+		return v
+	case roundSuper45:
+		if gs.period == 0 {
+			return d
+		}
+		if d >= 0 {
+			v := (d-gs.phase+gs.threshold)/gs.period*gs.period + gs.phase
+			if v < 0 {
+				v = gs.phase
+			}
+			return v
+		}
+		v := -((-d - gs.phase + gs.threshold) / gs.period * gs.period) - gs.phase
+		if v > 0 {
+			v = -gs.phase
+		}
+		return v
+	}
+	return d
+}
+
+// `gridPeriod` is a 2.14 value
+func (m *interpreter) setSuperRound(gridPeriod, selector int32) {
+	gs := &m.gs
+	switch selector & 0xC0 {
+	case 0:
+		gs.period = gridPeriod / 2
+	case 0x80:
+		gs.period = gridPeriod * 2
+	default: // 0x40 and the reserved 0xC0
+		gs.period = gridPeriod
+	}
+	switch selector & 0x30 {
+	case 0:
+		gs.phase = 0
+	case 0x10:
+		gs.phase = gs.period / 4
+	case 0x20:
+		gs.phase = gs.period / 2
+	case 0x30:
+		gs.phase = gs.period * 3 / 4
+	}
+	if selector&0x0F == 0 {
+		gs.threshold = gs.period - 1
+	} else {
+		gs.threshold = (selector&0x0F - 4) * gs.period / 8
+	}
+	// convert to 26.6
+	gs.period >>= 8
+	gs.phase >>= 8
+	gs.threshold >>= 8
+}
+
+// --------------------------------- points ---------------------------------
+
+func (m *interpreter) checkPoint(zoneIndex, p int32) (*zone, error) {
+	z := &m.zones[zoneIndex]
+	if p < 0 || int(p) >= len(z.cur) {
+		return nil, fmt.Errorf("invalid point %d in zone %d", p, zoneIndex)
+	}
+	return z, nil
+}
+
+// point returns the current or original position of the point
+func (m *interpreter) point(zoneIndex, p int32, original bool) (vector, error) {
+	z, err := m.checkPoint(zoneIndex, p)
+	if err != nil {
+		return vector{}, err
+	}
+	if original {
+		return z.org[p], nil
+	}
+	return z.cur[p], nil
+}
+
+// fDotP returns the scalar product of the freedom and projection vectors
+func (m *interpreter) fDotP() int32 {
+	v := int32((int64(m.gs.pv.x)*int64(m.gs.fv.x) + int64(m.gs.pv.y)*int64(m.gs.fv.y)) >> 14)
+	if -0x400 < v && v < 0x400 {
+		return 0x4000
+	}
+	return v
+}
+
+// movePoint shifts the current position of the point, for the axes
+// where the freedom vector is not null, applying the backward compatibility rules
+func (m *interpreter) movePoint(z *zone, p int32, dx, dy int32, touch bool) {
+	if m.gs.fv.x != 0 {
+		if !m.backwardCompatibility {
+			z.cur[p].x += dx
+		}
+		if touch {
+			z.flags[p] |= pointTouchedX
+		}
+	}
+	if m.gs.fv.y != 0 {
+		if !(m.backwardCompatibility && m.iupXCalled && m.iupYCalled) {
+			z.cur[p].y += dy
+		}
+		if touch {
+			z.flags[p] |= pointTouchedY
+		}
+	}
+}
+
+// moveDistance moves the point along the freedom vector,
+// so that its projection changes by `distance`, and touches it
+func (m *interpreter) moveDistance(z *zone, p int32, distance int32) {
+	fdotp := m.fDotP()
+	m.movePoint(z, p, mulDiv26(distance, m.gs.fv.x, fdotp), mulDiv26(distance, m.gs.fv.y, fdotp), true)
+}
+
+// moveOriginal is the same as moveDistance, for the original position
+func (m *interpreter) moveOriginal(z *zone, p int32, distance int32) {
+	fdotp := m.fDotP()
+	if m.gs.fv.x != 0 {
+		z.org[p].x += mulDiv26(distance, m.gs.fv.x, fdotp)
+	}
+	if m.gs.fv.y != 0 {
+		z.org[p].y += mulDiv26(distance, m.gs.fv.y, fdotp)
+	}
+}
+
+// loopPoints pops `gs.loop` points and calls `fn` on each of them,
+// then resets the loop counter
+func (m *interpreter) loopPoints(fn func(p int32) error) error {
+	for ; m.gs.loop > 0; m.gs.loop-- {
+		p, err := m.pop()
+		if err != nil {
+			m.gs.loop = 1
+			return err
+		}
+		if err := fn(p); err != nil {
+			m.gs.loop = 1
+			return err
+		}
+	}
+	m.gs.loop = 1
+	return nil
+}
+
+// vectorToLine returns the unit vector from p2 (in zp2) to p1 (in zp1),
+// rotated counter clockwise if `perpendicular` is true
+func (m *interpreter) vectorToLine(p2, p1 int32, perpendicular bool) (vector, error) {
+	a, err := m.point(m.gs.zp1, p1, false)
+	if err != nil {
+		return vector{}, err
+	}
+	b, err := m.point(m.gs.zp2, p2, false)
+	if err != nil {
+		return vector{}, err
+	}
+	return lineVector(a.sub(b), perpendicular), nil
+}
+
+func lineVector(d vector, perpendicular bool) vector {
+	if d.x == 0 && d.y == 0 {
+		return vector{0x4000, 0}
+	}
+	if perpendicular {
+		d = vector{-d.y, d.x}
+	}
+	return normalize(d.x, d.y)
+}
+
+func (m *interpreter) setDualVectorToLine(p2, p1 int32, perpendicular bool) error {
+	orgA, err := m.point(m.gs.zp1, p1, true)
+	if err != nil {
+		return err
+	}
+	orgB, err := m.point(m.gs.zp2, p2, true)
+	if err != nil {
+		return err
+	}
+	curA, _ := m.point(m.gs.zp1, p1, false)
+	curB, _ := m.point(m.gs.zp2, p2, false)
+	m.gs.dv = lineVector(orgA.sub(orgB), perpendicular)
+	m.gs.pv = lineVector(curA.sub(curB), perpendicular)
+	return nil
+}
+
+func (m *interpreter) intersect(p, a0, a1, b0, b1 int32) error {
+	gs := &m.gs
+	z, err := m.checkPoint(gs.zp2, p)
+	if err != nil {
+		return err
+	}
+	var pts [4]vector
+	for i, ref := range [4]struct{ zone, p int32 }{{gs.zp1, a0}, {gs.zp1, a1}, {gs.zp0, b0}, {gs.zp0, b1}} {
+		if pts[i], err = m.point(ref.zone, ref.p, false); err != nil {
+			return err
+		}
+	}
+	da, db := pts[1].sub(pts[0]), pts[3].sub(pts[2])
+	d := pts[2].sub(pts[0])
+
+	discriminant := mulDiv26(da.x, -db.y, 0x40) + mulDiv26(da.y, db.x, 0x40)
+	dotProduct := mulDiv26(da.x, db.x, 0x40) + mulDiv26(da.y, db.y, 0x40)
+	// the discriminant must be large enough compared to the dot product,
+	// that is, the lines must not be (nearly) parallel
+	if 19*absI32(discriminant) > absI32(dotProduct) {
+		v := mulDiv26(d.x, -db.y, 0x40) + mulDiv26(d.y, db.x, 0x40)
+		z.cur[p] = vector{
+			pts[0].x + mulDiv26(v, da.x, discriminant),
+			pts[0].y + mulDiv26(v, da.y, discriminant),
+		}
+	} else {
+		// use the middle of the middle points
+		z.cur[p] = vector{
+			(pts[0].x + pts[1].x + pts[2].x + pts[3].x) / 4,
+			(pts[0].y + pts[1].y + pts[2].y + pts[3].y) / 4,
+		}
+	}
+	z.flags[p] |= pointTouchedX | pointTouchedY
+	return nil
+}
+
+func (m *interpreter) alignPoints(p1, p2 int32) error {
+	gs := &m.gs
+	z1, err := m.checkPoint(gs.zp1, p1)
+	if err != nil {
+		return err
+	}
+	z0, err := m.checkPoint(gs.zp0, p2)
+	if err != nil {
+		return err
+	}
+	distance := dot14(z0.cur[p2].sub(z1.cur[p1]), gs.pv) / 2
+	m.moveDistance(z1, p1, distance)
+	m.moveDistance(z0, p2, -distance)
+	return nil
+}
+
+func (m *interpreter) untouch(p int32) error {
+	z, err := m.checkPoint(m.gs.zp0, p)
+	if err != nil {
+		return err
+	}
+	if m.gs.fv.x != 0 {
+		z.flags[p] &^= pointTouchedX
+	}
+	if m.gs.fv.y != 0 {
+		z.flags[p] &^= pointTouchedY
+	}
+	return nil
+}
+
+func (m *interpreter) moveDirectAbsolute(p int32, round bool) error {
+	gs := &m.gs
+	z, err := m.checkPoint(gs.zp0, p)
+	if err != nil {
+		return err
+	}
+	var distance int32
+	if round {
+		d := dot14(z.cur[p], gs.pv)
+		distance = m.round(d) - d
+	}
+	m.moveDistance(z, p, distance)
+	gs.rp0, gs.rp1 = p, p
+	return nil
+}
+
+func (m *interpreter) moveIndirectAbsolute(p, cvtIndex int32, round bool) error {
+	gs := &m.gs
+	z, err := m.checkPoint(gs.zp0, p)
+	if err != nil {
+		return err
+	}
+	distance := m.readCvt(cvtIndex)
+	if gs.zp0 == 0 { // twilight points are initialized from the CVT
+		z.org[p] = vector{mul14(distance, gs.fv.x), mul14(distance, gs.fv.y)}
+		z.cur[p] = z.org[p]
+	}
+	orgDist := dot14(z.cur[p], gs.pv)
+	if round {
+		if absI32(distance-orgDist) > gs.controlValueCutIn {
+			distance = orgDist
+		}
+		distance = m.round(distance)
+	}
+	m.moveDistance(z, p, distance-orgDist)
+	gs.rp0, gs.rp1 = p, p
+	return nil
+}
+
+func (m *interpreter) moveStackIndirectRelative(p, distance int32, setRP0 bool) error {
+	gs := &m.gs
+	z0, err := m.checkPoint(gs.zp0, gs.rp0)
+	if err != nil {
+		return err
+	}
+	z1, err := m.checkPoint(gs.zp1, p)
+	if err != nil {
+		return err
+	}
+	if gs.zp1 == 0 {
+		z1.org[p] = z0.org[gs.rp0]
+		m.moveOriginal(z1, p, distance)
+		z1.cur[p] = z1.org[p]
+	}
+	current := dot14(z1.cur[p].sub(z0.cur[gs.rp0]), gs.pv)
+	m.moveDistance(z1, p, distance-current)
+	gs.rp1 = gs.rp0
+	gs.rp2 = p
+	if setRP0 {
+		gs.rp0 = p
+	}
+	return nil
+}
+
+func (m *interpreter) moveDirectRelative(p int32, op uint8) error {
+	gs := &m.gs
+	z0, err := m.checkPoint(gs.zp0, gs.rp0)
+	if err != nil {
+		return err
+	}
+	z1, err := m.checkPoint(gs.zp1, p)
+	if err != nil {
+		return err
+	}
+
+	orgDist := dot14(z1.org[p].sub(z0.org[gs.rp0]), gs.dv)
+	if absI32(orgDist-gs.singleWidth) < gs.singleWidthCutIn {
+		if orgDist >= 0 {
+			orgDist = gs.singleWidth
+		} else {
+			orgDist = -gs.singleWidth
+		}
+	}
+	distance := orgDist
+	if op&4 != 0 {
+		distance = m.round(orgDist)
+	}
+	if op&8 != 0 {
+		distance = m.applyMinDist(orgDist, distance)
+	}
+
+	current := dot14(z1.cur[p].sub(z0.cur[gs.rp0]), gs.pv)
+	m.moveDistance(z1, p, distance-current)
+
+	gs.rp1 = gs.rp0
+	gs.rp2 = p
+	if op&16 != 0 {
+		gs.rp0 = p
+	}
+	return nil
+}
+
+func (m *interpreter) moveIndirectRelative(p, cvtIndex int32, op uint8) error {
+	gs := &m.gs
+	z0, err := m.checkPoint(gs.zp0, gs.rp0)
+	if err != nil {
+		return err
+	}
+	z1, err := m.checkPoint(gs.zp1, p)
+	if err != nil {
+		return err
+	}
+
+	var cvtDist int32 // an index of -1 means a null distance
+	if cvtIndex != -1 {
+		if cvtIndex < 0 || int(cvtIndex) >= len(m.cvt) {
+			return fmt.Errorf("invalid CVT index %d", cvtIndex)
+		}
+		cvtDist = m.cvt[cvtIndex]
+	}
+	if absI32(cvtDist-gs.singleWidth) < gs.singleWidthCutIn {
+		if cvtDist >= 0 {
+			cvtDist = gs.singleWidth
+		} else {
+			cvtDist = -gs.singleWidth
+		}
+	}
+
+	if gs.zp1 == 0 { // undocumented behavior for twilight points
+		z1.org[p] = vector{
+			z0.org[gs.rp0].x + mul14(cvtDist, gs.fv.x),
+			z0.org[gs.rp0].y + mul14(cvtDist, gs.fv.y),
+		}
+		z1.cur[p] = z1.org[p]
+	}
+
+	orgDist := dot14(z1.org[p].sub(z0.org[gs.rp0]), gs.dv)
+	current := dot14(z1.cur[p].sub(z0.cur[gs.rp0]), gs.pv)
+
+	if gs.autoFlip && (orgDist^cvtDist) < 0 {
+		cvtDist = -cvtDist
+	}
+
+	distance := cvtDist
+	if op&4 != 0 {
+		// the cut-in test is only performed when both points are in the same zone
+		if gs.zp0 == gs.zp1 && absI32(cvtDist-orgDist) > gs.controlValueCutIn {
+			cvtDist = orgDist
+		}
+		distance = m.round(cvtDist)
+	}
+	if op&8 != 0 {
+		distance = m.applyMinDist(orgDist, distance)
+	}
+
+	m.moveDistance(z1, p, distance-current)
+
+	gs.rp1 = gs.rp0
+	if op&16 != 0 {
+		gs.rp0 = p
+	}
+	gs.rp2 = p
+	return nil
+}
+
+func (m *interpreter) applyMinDist(orgDist, distance int32) int32 {
+	minDist := m.gs.minDist
+	if orgDist >= 0 {
+		if distance < minDist {
+			distance = minDist
+		}
+	} else if distance > -minDist {
+		distance = -minDist
+	}
+	return distance
+}
+
+func (m *interpreter) alignToReference() error {
+	gs := &m.gs
+	z0, err := m.checkPoint(gs.zp0, gs.rp0)
+	if err != nil {
+		return err
+	}
+	ref := z0.cur[gs.rp0]
+	return m.loopPoints(func(p int32) error {
+		z1, err := m.checkPoint(gs.zp1, p)
+		if err != nil {
+			return err
+		}
+		m.moveDistance(z1, p, -dot14(z1.cur[p].sub(ref), gs.pv))
+		return nil
+	})
+}
+
+// displacement returns the move of the reference point used by
+// SHP, SHC and SHZ, along the freedom vector
+func (m *interpreter) displacement(useRP1 bool) (ref *zone, refPoint int32, dx, dy int32, err error) {
+	gs := &m.gs
+	zoneIndex, refPoint := gs.zp1, gs.rp2
+	if useRP1 {
+		zoneIndex, refPoint = gs.zp0, gs.rp1
+	}
+	ref, err = m.checkPoint(zoneIndex, refPoint)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	d := dot14(ref.cur[refPoint].sub(ref.org[refPoint]), gs.pv)
+	fdotp := m.fDotP()
+	return ref, refPoint, mulDiv26(d, gs.fv.x, fdotp), mulDiv26(d, gs.fv.y, fdotp), nil
+}
+
+func (m *interpreter) shiftPoints(useRP1 bool) error {
+	_, _, dx, dy, err := m.displacement(useRP1)
+	if err != nil {
+		return err
+	}
+	return m.loopPoints(func(p int32) error {
+		z, err := m.checkPoint(m.gs.zp2, p)
+		if err != nil {
+			return err
+		}
+		m.movePoint(z, p, dx, dy, true)
+		return nil
+	})
+}
+
+func (m *interpreter) shiftContour(contour int32, useRP1 bool) error {
+	ref, refPoint, dx, dy, err := m.displacement(useRP1)
+	if err != nil {
+		return err
+	}
+	z := &m.zones[m.gs.zp2]
+	if contour < 0 || int(contour) >= len(z.ends) {
+		return fmt.Errorf("invalid contour %d", contour)
+	}
+	start := 0
+	if contour > 0 {
+		start = z.ends[contour-1] + 1
+	}
+	for p := start; p <= z.ends[contour]; p++ {
+		if ref != z || int32(p) != refPoint {
+			m.movePoint(z, int32(p), dx, dy, true)
+		}
+	}
+	return nil
+}
+
+func (m *interpreter) shiftZone(zoneIndex int32, useRP1 bool) error {
+	if zoneIndex != 0 && zoneIndex != 1 {
+		return errors.New("invalid zone")
+	}
+	ref, refPoint, dx, dy, err := m.displacement(useRP1)
+	if err != nil {
+		return err
+	}
+	// as FreeType, use zp2 and do not move the phantom points
+	z := &m.zones[m.gs.zp2]
+	limit := len(z.cur)
+	if m.gs.zp2 == 1 {
+		limit = 0
+		if len(z.ends) != 0 {
+			limit = z.ends[len(z.ends)-1] + 1
+		}
+	}
+	for p := 0; p < limit; p++ {
+		if ref != z || int32(p) != refPoint {
+			m.movePoint(z, int32(p), dx, dy, false)
+		}
+	}
+	return nil
+}
+
+func (m *interpreter) shiftPixels(amount int32) error {
+	gs := &m.gs
+	dx, dy := mul14(amount, gs.fv.x), mul14(amount, gs.fv.y)
+	return m.loopPoints(func(p int32) error {
+		z, err := m.checkPoint(gs.zp2, p)
+		if err != nil {
+			return err
+		}
+		if !m.backwardCompatibility {
+			m.movePoint(z, p, dx, dy, true)
+			return nil
+		}
+		// only allow vertical moves in the twilight zone, or
+		// on points already touched in Y, before the final IUP
+		inTwilight := gs.zp0 == 0 && gs.zp1 == 0 && gs.zp2 == 0
+		if inTwilight || (!(m.iupXCalled && m.iupYCalled) &&
+			((m.isComposite && gs.fv.y != 0) || z.flags[p]&pointTouchedY != 0)) {
+			m.movePoint(z, p, 0, dy, true)
+		}
+		return nil
+	})
+}
+
+func (m *interpreter) interpolatePoints() error {
+	gs := &m.gs
+	z0, err := m.checkPoint(gs.zp0, gs.rp1)
+	if err != nil {
+		return err
+	}
+	z1, err := m.checkPoint(gs.zp1, gs.rp2)
+	if err != nil {
+		return err
+	}
+	orgBase, curBase := z0.org[gs.rp1], z0.cur[gs.rp1]
+	oldRange := dot14(z1.org[gs.rp2].sub(orgBase), gs.dv)
+	curRange := dot14(z1.cur[gs.rp2].sub(curBase), gs.pv)
+
+	return m.loopPoints(func(p int32) error {
+		z, err := m.checkPoint(gs.zp2, p)
+		if err != nil {
+			return err
+		}
+		orgDist := dot14(z.org[p].sub(orgBase), gs.dv)
+		curDist := dot14(z.cur[p].sub(curBase), gs.pv)
+		var newDist int32
+		if orgDist != 0 {
+			newDist = curDist // invalid case: do not move
+			if oldRange != 0 {
+				newDist = mulDiv26(orgDist, curRange, oldRange)
+			}
+		}
+		m.moveDistance(z, p, newDist-curDist)
+		return nil
+	})
+}
+
+func (m *interpreter) setCoordinateFromStack(p, value int32) error {
+	z, err := m.checkPoint(m.gs.zp2, p)
+	if err != nil {
+		return err
+	}
+	current := dot14(z.cur[p], m.gs.pv)
+	m.moveDistance(z, p, value-current)
+	if m.gs.zp2 == 0 {
+		z.org[p] = z.cur[p]
+	}
+	return nil
+}
+
+// measureDistance pushes the distance between p1 (in zp0) and p2 (in zp1)
+func (m *interpreter) measureDistance(p1, p2 int32, original bool) error {
+	a, err := m.point(m.gs.zp0, p1, original)
+	if err != nil {
+		return err
+	}
+	b, err := m.point(m.gs.zp1, p2, original)
+	if err != nil {
+		return err
+	}
+	if original {
+		return m.push(dot14(a.sub(b), m.gs.dv))
+	}
+	return m.push(dot14(a.sub(b), m.gs.pv))
+}
+
+func (m *interpreter) flipPoints() error {
+	z := &m.zones[1]
+	return m.loopPoints(func(p int32) error {
+		if p < 0 || int(p) >= len(z.flags) {
+			return fmt.Errorf("invalid point %d", p)
+		}
+		z.flags[p] ^= pointOnCurve
+		return nil
+	})
+}
+
+func (m *interpreter) flipRange(low, high int32, on bool) error {
+	z := &m.zones[1]
+	if low < 0 || high < low || int(high) >= len(z.flags) {
+		return errors.New("invalid point range")
+	}
+	for p := low; p <= high; p++ {
+		if on {
+			z.flags[p] |= pointOnCurve
+		} else {
+			z.flags[p] &^= pointOnCurve
+		}
+	}
+	return nil
+}
+
+// --------------------------------- IUP ---------------------------------
+
+// interpolateUntouched implements IUP, on the glyph zone
+func (m *interpreter) interpolateUntouched(inX bool) {
+	if m.backwardCompatibility {
+		// only allow IUP until it has been called on both axes
+		if m.iupXCalled && m.iupYCalled {
+			return
+		}
+		if inX {
+			m.iupXCalled = true
+		} else {
+			m.iupYCalled = true
+		}
+	}
+
+	z := &m.zones[1]
+	touched := pointTouchedY
+	if inX {
+		touched = pointTouchedX
+	}
+	start := 0
+	for _, end := range z.ends {
+		first := -1
+		for p := start; p <= end; p++ {
+			if z.flags[p]&touched != 0 {
+				first = p
+				break
+			}
+		}
+		if first != -1 {
+			current := first
+			for p := first + 1; p <= end; p++ {
+				if z.flags[p]&touched != 0 {
+					if p-1 > current {
+						z.iupInterpolate(inX, current+1, p-1, current, p)
+					}
+					current = p
+				}
+			}
+			if current == first {
+				z.iupShift(inX, start, end, current)
+			} else {
+				z.iupInterpolate(inX, current+1, end, current, first)
+				if first > start {
+					z.iupInterpolate(inX, start, first-1, current, first)
+				}
+			}
+		}
+		start = end + 1
+	}
+}
+
+func coordinate(v *vector, inX bool) *int32 {
+	if inX {
+		return &v.x
+	}
+	return &v.y
+}
+
+func (z *zone) iupShift(inX bool, start, end, ref int) {
+	delta := *coordinate(&z.cur[ref], inX) - *coordinate(&z.org[ref], inX)
+	if delta == 0 {
+		return
+	}
+	for p := start; p <= end; p++ {
+		if p != ref {
+			*coordinate(&z.cur[p], inX) += delta
+		}
+	}
+}
+
+func (z *zone) iupInterpolate(inX bool, p1, p2, ref1, ref2 int) {
+	if p1 > p2 {
+		return
+	}
+	org1, org2 := *coordinate(&z.org[ref1], inX), *coordinate(&z.org[ref2], inX)
+	if org1 > org2 {
+		org1, org2 = org2, org1
+		ref1, ref2 = ref2, ref1
+	}
+	cur1, cur2 := *coordinate(&z.cur[ref1], inX), *coordinate(&z.cur[ref2], inX)
+	delta1, delta2 := cur1-org1, cur2-org2
+	for p := p1; p <= p2; p++ {
+		v := *coordinate(&z.org[p], inX)
+		switch {
+		case v <= org1:
+			v += delta1
+		case v >= org2:
+			v += delta2
+		default:
+			v = cur1 + mulDiv26(v-org1, cur2-cur1, org2-org1)
+		}
+		*coordinate(&z.cur[p], inX) = v
+	}
+}
+
+// -------------------------------- deltas --------------------------------
+
+// deltaArg returns the move encoded by the delta argument `arg`,
+// and false if it does not apply to the current ppem
+func (m *interpreter) deltaArg(arg int32, rangeStart int32) (int32, bool) {
+	ppem := (arg&0xF0)>>4 + rangeStart + m.gs.deltaBase
+	if ppem != m.ppem {
+		return 0, false
+	}
+	step := arg&0xF - 8
+	if step >= 0 {
+		step++
+	}
+	return step * (1 << (6 - m.gs.deltaShift)), true
+}
+
+func deltaRangeStart(op uint8) int32 {
+	switch op {
+	case 0x71, 0x74:
+		return 16
+	case 0x72, 0x75:
+		return 32
+	}
+	return 0
+}
+
+func (m *interpreter) deltaPoints(count int32, op uint8) error {
+	gs := &m.gs
+	for ; count > 0; count-- {
+		if len(m.stack) < 2 {
+			return errStackUnderflow
+		}
+		p, arg := m.stack[len(m.stack)-1], m.stack[len(m.stack)-2]
+		m.stack = m.stack[:len(m.stack)-2]
+
+		z := &m.zones[gs.zp0]
+		if p < 0 || int(p) >= len(z.cur) { // ignored, as FreeType does
+			continue
+		}
+		distance, ok := m.deltaArg(arg, deltaRangeStart(op))
+		if !ok {
+			continue
+		}
+		if m.backwardCompatibility {
+			if !(m.iupXCalled && m.iupYCalled) &&
+				((m.isComposite && gs.fv.y != 0) || z.flags[p]&pointTouchedY != 0) {
+				m.moveDistance(z, p, distance)
+			}
+		} else {
+			m.moveDistance(z, p, distance)
+		}
+	}
+	return nil
+}
+
+func (m *interpreter) deltaCvt(count int32, op uint8) error {
+	for ; count > 0; count-- {
+		if len(m.stack) < 2 {
+			return errStackUnderflow
+		}
+		index, arg := m.stack[len(m.stack)-1], m.stack[len(m.stack)-2]
+		m.stack = m.stack[:len(m.stack)-2]
+
+		if distance, ok := m.deltaArg(arg, deltaRangeStart(op)); ok {
+			m.writeCvt(index, m.readCvt(index)+distance)
+		}
+	}
+	return nil
+}
+
+// ---------------------------------- misc ----------------------------------
+
+// out of bounds accesses are ignored, as FreeType does
+func (m *interpreter) readCvt(index int32) int32 {
+	if index < 0 || int(index) >= len(m.cvt) {
+		return 0
+	}
+	return m.cvt[index]
+}
+
+func (m *interpreter) writeCvt(index, value int32) {
+	if index < 0 || int(index) >= len(m.cvt) {
+		return
+	}
+	m.cvt[index] = value
+}
+
+func (m *interpreter) getInfo(selector int32) int32 {
+	var out int32
+	if selector&1 != 0 {
+		out = 40 // interpreter version
+	}
+	if selector&8 != 0 && len(m.coords) != 0 {
+		out |= 1 << 10 // variable font
+	}
+	if selector&64 != 0 {
+		out |= 1 << 13 // subpixel hinting
+	}
+	if selector&1024 != 0 {
+		out |= 1 << 17 // subpixel positioned
+	}
+	if selector&2048 != 0 {
+		out |= 1 << 18 // symmetrical smoothing
+	}
+	if selector&4096 != 0 {
+		out |= 1 << 19 // ClearType hinting and grayscale rendering
+	}
+	return out
+}
+
+func (m *interpreter) instructionControl(selector, value int32) error {
+	if selector < 1 || selector > 3 { // ignored, as FreeType does
+		return nil
+	}
+	mask := int32(1) << (selector - 1)
+	if value != 0 {
+		value = mask
+	}
+	if selector == 3 {
+		// native ClearType fonts may opt out of backward compatibility,
+		// possibly for one glyph only
+		m.backwardCompatibility = value == 0
+	}
+	// the graphics state may only be changed by the control value program
+	if m.kind == programControlValue {
+		m.gs.instructControl = m.gs.instructControl&^mask | value
+	}
+	return nil
+}
+
+func absI32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func minI32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxI32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
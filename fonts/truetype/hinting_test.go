@@ -0,0 +1,162 @@
+package truetype
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+func newTestInterpreter() *interpreter {
+	return &interpreter{
+		functions:    map[int32][]byte{},
+		instructions: map[uint8][]byte{},
+		maxStack:     32,
+		storage:      make([]int32, 4),
+		gs:           defaultGraphicsState,
+		ppem:         12,
+		scale:        1,
+	}
+}
+
+func TestInterpreterArithmetic(t *testing.T) {
+	for _, tc := range []struct {
+		program  []byte
+		expected []int32
+	}{
+		{[]byte{0xB1, 2, 3, 0x60}, []int32{5}},                      // PUSHB[1] 2 3 ADD
+		{[]byte{0xB1, 2, 3, 0x61}, []int32{-1}},                     // SUB
+		{[]byte{0xB1, 128, 64, 0x63}, []int32{128}},                 // MUL (26.6)
+		{[]byte{0xB1, 128, 128, 0x62}, []int32{64}},                 // DIV (26.6)
+		{[]byte{0xB8, 0xFF, 0xF6, 0x64}, []int32{10}},               // PUSHW[0] -10 ABS
+		{[]byte{0xB0, 96, 0x68}, []int32{128}},                      // PUSHB[0] 96 ROUND[Grey]
+		{[]byte{0xB2, 1, 2, 3, 0x23}, []int32{1, 3, 2}},             // SWAP
+		{[]byte{0xB1, 2, 3, 0x50, 0x58, 0xB0, 7, 0x59}, []int32{7}}, // LT IF PUSHB 7 EIF
+		{[]byte{0xB1, 1, 42, 0x42, 0xB0, 1, 0x43}, []int32{42}},     // WS RS
+	} {
+		m := newTestInterpreter()
+		if err := m.run(tc.program, programGlyph); err != nil {
+			t.Fatalf("program %v: %s", tc.program, err)
+		}
+		if len(m.stack) != len(tc.expected) {
+			t.Fatalf("program %v: expected %v, got %v", tc.program, tc.expected, m.stack)
+		}
+		for i, v := range tc.expected {
+			if m.stack[i] != v {
+				t.Fatalf("program %v: expected %v, got %v", tc.program, tc.expected, m.stack)
+			}
+		}
+	}
+}
+
+func TestInterpreterFunctions(t *testing.T) {
+	m := newTestInterpreter()
+	// FDEF 0 : DUP ADD ; then CALL 0 on 21
+	program := []byte{0xB0, 0, 0x2C, 0x20, 0x60, 0x2D, 0xB1, 21, 0, 0x2B}
+	if err := m.run(program, programFont); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.stack) != 1 || m.stack[0] != 42 {
+		t.Fatalf("unexpected stack %v", m.stack)
+	}
+
+	// infinite recursion must be caught
+	m = newTestInterpreter()
+	program = []byte{0xB0, 0, 0x2C, 0xB0, 0, 0x2B, 0x2D, 0xB0, 0, 0x2B}
+	if err := m.run(program, programFont); err == nil {
+		t.Fatal("expected error for infinite recursion")
+	}
+
+	if err := newTestInterpreter().run([]byte{0x60}, programGlyph); err == nil {
+		t.Fatal("expected error for stack underflow")
+	}
+}
+
+func isPixel(v float32, scale float32) bool {
+	px := float64(v * scale)
+	return math.Abs(px-math.Round(px)) < 1e-3
+}
+
+func TestHinter(t *testing.T) {
+	file, err := testdata.Files.ReadFile("DejaVuSerif.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const ppem = 12
+	hinter, err := font.NewHinter(ppem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale := ppem / float32(font.Upem())
+
+	for _, r := range "HEILT" {
+		gid, _ := font.NominalGlyph(r)
+		glyph, err := hinter.GlyphData(gid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isPixel(glyph.Advance, scale) {
+			t.Fatalf("expected integer advance, got %v", glyph.Advance*scale)
+		}
+		unhinted := font.GlyphData(gid, 0, 0).(fonts.GlyphOutline)
+		if len(unhinted.Segments) != len(glyph.Outline.Segments) {
+			t.Fatalf("unexpected number of segments %d", len(glyph.Outline.Segments))
+		}
+		// horizontal stems are aligned on the pixel grid
+		for _, seg := range glyph.Outline.Segments {
+			if seg.Op != fonts.SegmentOpLineTo {
+				continue
+			}
+			if p := seg.Args[0]; !isPixel(p.Y, scale) {
+				t.Fatalf("rune %c: expected integer Y coordinate, got %v", r, p.Y*scale)
+			}
+		}
+	}
+
+	if _, err := hinter.GlyphData(GID(len(font.Glyf))); err == nil {
+		t.Fatal("expected error for invalid glyph")
+	}
+	if _, err := font.NewHinter(0); err == nil {
+		t.Fatal("expected error for invalid size")
+	}
+}
+
+func TestHinterFonts(t *testing.T) {
+	for _, filename := range []string{
+		"DejaVuSerif.ttf", "FreeSerif.ttf", "Roboto-BoldItalic.ttf",
+		"SelawikVar.ttf", "Commissioner-VF.ttf", "Castoro-Regular.ttf",
+	} {
+		file, err := testdata.Files.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		font, err := Parse(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if font.isVar() {
+			coords := make([]float32, len(font.fvar.Axis))
+			for i := range coords {
+				coords[i] = 0.7
+			}
+			font.SetVarCoordinates(coords)
+		}
+		for _, ppem := range []uint16{9, 16, 40} {
+			hinter, err := font.NewHinter(ppem)
+			if err != nil {
+				t.Fatalf("%s: %s", filename, err)
+			}
+			for gid := range font.Glyf {
+				if _, err := hinter.GlyphData(GID(gid)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package truetype
+
+import "sort"
+
+var (
+	_ PairKerns = Kern0{}
+	_ PairKerns = Kern2{}
+	_ PairKerns = Kern3{}
+	_ PairKerns = Kerx6{}
+)
+
+// PairKerns is implemented by the kerning subtables which store
+// their values for pairs of glyphs (or pairs of glyph classes),
+// and may thus be enumerated. The subtables based on
+// a state machine (Kern1 and Kerx4) apply contextual kerning and are not supported.
+type PairKerns interface {
+	SimpleKerns
+	// Pairs calls `fn` for each pair with a non zero kerning value,
+	// until `fn` returns false, in which case it also returns false.
+	// `numGlyphs` is used to enumerate the glyphs of class based subtables.
+	Pairs(numGlyphs int, fn func(KerningPair) bool) bool
+}
+
+func (k Kern0) Pairs(_ int, fn func(KerningPair) bool) bool {
+	for _, pair := range k {
+		if pair.Value == 0 {
+			continue
+		}
+		if !fn(pair) {
+			return false
+		}
+	}
+	return true
+}
+
+func (k Kern2) Pairs(numGlyphs int, fn func(KerningPair) bool) bool {
+	lefts := groupGlyphsByClass(numGlyphs, func(g GID) (uint32, bool) {
+		c, _ := k.left.ClassID(g)
+		return c, true
+	})
+	rights := groupGlyphsByClass(numGlyphs, func(g GID) (uint32, bool) {
+		c, _ := k.right.ClassID(g)
+		return c, true
+	})
+	return enumerateClassPairs(lefts, rights, k.KernPair, fn)
+}
+
+func (ks Kern3) Pairs(numGlyphs int, fn func(KerningPair) bool) bool {
+	lefts := groupGlyphsByClass(numGlyphs, func(g GID) (uint32, bool) {
+		if int(g) >= len(ks.leftClass) {
+			return 0, false
+		}
+		return uint32(ks.leftClass[g]), true
+	})
+	rights := groupGlyphsByClass(numGlyphs, func(g GID) (uint32, bool) {
+		if int(g) >= len(ks.rightClass) {
+			return 0, false
+		}
+		return uint32(ks.rightClass[g]), true
+	})
+	return enumerateClassPairs(lefts, rights, ks.KernPair, fn)
+}
+
+func (k Kerx6) Pairs(numGlyphs int, fn func(KerningPair) bool) bool {
+	lefts := groupGlyphsByClass(numGlyphs, func(g GID) (uint32, bool) {
+		c, _ := k.row.ClassID(g)
+		return c, true
+	})
+	rights := groupGlyphsByClass(numGlyphs, func(g GID) (uint32, bool) {
+		c, _ := k.column.ClassID(g)
+		return c, true
+	})
+	return enumerateClassPairs(lefts, rights, k.KernPair, fn)
+}
+
+// groups the glyphs [0, numGlyphs) accepted by `classID` by class,
+// sorted by their first glyph
+func groupGlyphsByClass(numGlyphs int, classID func(GID) (uint32, bool)) [][]GID {
+	var (
+		out     [][]GID
+		indices = map[uint32]int{} // class -> index into out
+	)
+	for g := 0; g < numGlyphs; g++ {
+		class, ok := classID(GID(g))
+		if !ok {
+			continue
+		}
+		index, has := indices[class]
+		if !has {
+			index = len(out)
+			indices[class] = index
+			out = append(out, nil)
+		}
+		out[index] = append(out[index], GID(g))
+	}
+	return out
+}
+
+// calls `fn` for each pair of glyphs from the classes `lefts` and `rights`,
+// whose kerning value (resolved using the first glyph of each class) is not zero
+func enumerateClassPairs(lefts, rights [][]GID, kernPair func(left, right GID) int16, fn func(KerningPair) bool) bool {
+	for _, leftGlyphs := range lefts {
+		for _, rightGlyphs := range rights {
+			value := kernPair(leftGlyphs[0], rightGlyphs[0])
+			if value == 0 {
+				continue
+			}
+			for _, left := range leftGlyphs {
+				for _, right := range rightGlyphs {
+					if !fn(KerningPair{Left: left, Right: right, Value: value}) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// KerningPairs calls `fn` for each pair of the subtables storing horizontal kerning values
+// (see PairKerns), until `fn` returns false, in which case it also returns false.
+// Cross-stream and variation subtables are ignored.
+// The values of a pair defined in several subtables add up.
+func (t TableKernx) KerningPairs(numGlyphs int, fn func(KerningPair) bool) bool {
+	for _, subtable := range t {
+		if !subtable.IsHorizontal() || subtable.IsCrossStream() || subtable.IsVariation() {
+			continue
+		}
+		pairs, ok := subtable.Data.(PairKerns)
+		if !ok {
+			continue
+		}
+		if !pairs.Pairs(numGlyphs, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the indices of the lookups used by the features with one of the given tags,
+// without duplicates and sorted in the order of application
+func (t *TableLayout) featuresLookups(features []Tag) []uint16 {
+	seen := map[uint16]bool{}
+	for _, record := range t.Features {
+		for _, tag := range features {
+			if record.Tag != tag {
+				continue
+			}
+			for _, index := range record.LookupIndices {
+				seen[index] = true
+			}
+		}
+	}
+	out := make([]uint16, 0, len(seen))
+	for index := range seen {
+		out = append(out, index)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// KerningPairs calls `fn` for each kerning pair defined by the pair adjustment lookups
+// (format 1 and class based format 2) used by one of the `features` (typically 'kern'),
+// until `fn` returns false, in which case it also returns false.
+// The features of all the scripts and languages are considered.
+//
+// The value of a pair is the horizontal advance adjustment of its first glyph:
+// the pairs which only adjust other values are ignored.
+// As during shaping, only the first subtable of a lookup defining a pair is used,
+// but a pair defined in several lookups is reported once per lookup, and the values add up.
+// `numGlyphs` is used to enumerate the glyphs of class based subtables.
+func (t *TableGPOS) KerningPairs(features []Tag, numGlyphs int, fn func(KerningPair) bool) bool {
+	for _, lookupIndex := range t.featuresLookups(features) {
+		if int(lookupIndex) >= len(t.Lookups) {
+			continue
+		}
+		seen := map[uint32]bool{} // pairs already defined in the lookup
+		for _, subtable := range t.Lookups[lookupIndex].Subtables {
+			if !gposSubtablePairs(subtable, numGlyphs, func(pair KerningPair) bool {
+				if seen[pair.key()] {
+					return true
+				}
+				seen[pair.key()] = true
+				return fn(pair)
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// enumerates the pairs of a pair adjustment subtable, or does nothing
+// for the other kinds of subtables
+func gposSubtablePairs(subtable GPOSSubtable, numGlyphs int, fn func(KerningPair) bool) bool {
+	switch data := subtable.Data.(type) {
+	case GPOSPair1:
+		if data.Formats[0]&XAdvance == 0 {
+			return true
+		}
+		for i, first := range coverageGlyphs(subtable.Coverage) {
+			if i >= len(data.Values) { // coverage might be corrupted
+				break
+			}
+			for _, record := range data.Values[i] {
+				value := record.Pos[0].XAdvance
+				if value == 0 {
+					continue
+				}
+				if !fn(KerningPair{Left: first, Right: record.SecondGlyph, Value: value}) {
+					return false
+				}
+			}
+		}
+	case GPOSPair2:
+		if data.Formats[0]&XAdvance == 0 {
+			return true
+		}
+		// the coverage restricts the first glyphs
+		lefts := groupGlyphsByClass(numGlyphs, func(g GID) (uint32, bool) {
+			if _, covered := subtable.Coverage.Index(g); !covered {
+				return 0, false
+			}
+			c, _ := data.First.ClassID(g)
+			return c, true
+		})
+		rights := groupGlyphsByClass(numGlyphs, func(g GID) (uint32, bool) {
+			c, _ := data.Second.ClassID(g)
+			return c, true
+		})
+		kernPair := func(left, right GID) int16 {
+			c1, _ := data.First.ClassID(left)
+			c2, _ := data.Second.ClassID(right)
+			if int(c1) >= len(data.Values) || int(c2) >= len(data.Values[c1]) {
+				return 0
+			}
+			return data.Values[c1][c2][0].XAdvance
+		}
+		return enumerateClassPairs(lefts, rights, kernPair, fn)
+	}
+	return true
+}
+
+// KerningPairs calls `fn` for each horizontal kerning pair of the font, until `fn` returns false.
+// As shaping engines usually apply only one of them, the pairs are read from the first
+// table providing kerning among :
+//   - the GPOS lookups used by the given `features` (typically 'kern'), see TableGPOS.KerningPairs
+//   - the 'kerx' table, then the 'kern' table, see TableKernx.KerningPairs
+//
+// A pair may be reported several times, in which case the values add up.
+func (font *Font) KerningPairs(features []Tag, fn func(KerningPair) bool) {
+	tables := &font.layoutTables
+	if len(tables.GPOS.featuresLookups(features)) != 0 {
+		tables.GPOS.KerningPairs(features, font.NumGlyphs, fn)
+	} else if len(tables.Kerx) != 0 {
+		tables.Kerx.KerningPairs(font.NumGlyphs, fn)
+	} else {
+		tables.Kern.KerningPairs(font.NumGlyphs, fn)
+	}
+}
@@ -0,0 +1,144 @@
+package truetype
+
+import (
+	"bytes"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+)
+
+// checks that the pairs reported by `pairs` are exactly the
+// non zero pairs of `kerns`
+func assertKerningPairs(t *testing.T, kerns SimpleKerns, numGlyphs int, pairs func(fn func(KerningPair) bool) bool) {
+	t.Helper()
+
+	reported := map[uint32]int16{}
+	pairs(func(pair KerningPair) bool {
+		if _, has := reported[pair.key()]; has {
+			t.Fatalf("pair (%d, %d) reported twice", pair.Left, pair.Right)
+		}
+		reported[pair.key()] = pair.Value
+		return true
+	})
+
+	count := 0
+	for left := GID(0); left < GID(numGlyphs); left++ {
+		for right := GID(0); right < GID(numGlyphs); right++ {
+			exp := kerns.KernPair(left, right)
+			if exp == 0 {
+				continue
+			}
+			count++
+			if got := reported[uint32(left)<<16|uint32(right)]; got != exp {
+				t.Fatalf("for (%d, %d), expected %d, got %d", left, right, exp, got)
+			}
+		}
+	}
+	if count != len(reported) {
+		t.Fatalf("expected %d pairs, got %d", count, len(reported))
+	}
+}
+
+func TestKernPairs(t *testing.T) {
+	f, err := testdata.Files.ReadFile("ToyKern1.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kern := font.LayoutTables().Kern
+	for _, subtable := range kern {
+		pairs, ok := subtable.Data.(PairKerns)
+		if !ok {
+			continue
+		}
+		assertKerningPairs(t, pairs, font.NumGlyphs, func(fn func(KerningPair) bool) bool {
+			return pairs.Pairs(font.NumGlyphs, fn)
+		})
+	}
+
+	total := 0
+	font.KerningPairs([]Tag{MustNewTag("kern")}, func(pair KerningPair) bool {
+		total++
+		return true
+	})
+	if total != 349 {
+		t.Fatalf("expected 349 pairs, got %d", total)
+	}
+
+	// early stop
+	total = 0
+	completed := kern.KerningPairs(font.NumGlyphs, func(pair KerningPair) bool {
+		total++
+		return total < 10
+	})
+	if completed || total != 10 {
+		t.Fatalf("unexpected enumeration stop: %v %d", completed, total)
+	}
+}
+
+func TestKerx6Pairs(t *testing.T) {
+	data, err := testdata.Files.ReadFile("kerxSubtable6.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kerx, err := parseKerxSubtable6(data, 2775, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reported := map[uint32]int16{}
+	kerx.Pairs(2775, func(pair KerningPair) bool {
+		if exp := kerx.KernPair(pair.Left, pair.Right); exp != pair.Value {
+			t.Fatalf("for (%d, %d), expected %d, got %d", pair.Left, pair.Right, exp, pair.Value)
+		}
+		reported[pair.key()] = pair.Value
+		return true
+	})
+
+	for _, exp := range []KerningPair{
+		{283, 659, -270},
+		{4, 333, -130},
+		{283, 815, -230},
+		{333, 573, -150},
+		{815, 283, -170},
+	} {
+		if got := reported[exp.key()]; got != exp.Value {
+			t.Fatalf("for (%d, %d), expected %d, got %d", exp.Left, exp.Right, exp.Value, got)
+		}
+	}
+}
+
+func TestGPOSKerningPairs(t *testing.T) {
+	f, err := testdata.Files.ReadFile("Castoro-Regular.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gpos := font.LayoutTables().GPOS
+	kerns, err := gpos.horizontalKerning()
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := []Tag{MustNewTag("kern")}
+	assertKerningPairs(t, kerns, font.NumGlyphs, func(fn func(KerningPair) bool) bool {
+		return gpos.KerningPairs(features, font.NumGlyphs, fn)
+	})
+
+	// no lookup for the feature
+	total := 0
+	gpos.KerningPairs([]Tag{MustNewTag("dist")}, font.NumGlyphs, func(pair KerningPair) bool {
+		total++
+		return true
+	})
+	if total != 0 {
+		t.Fatalf("expected no pairs, got %d", total)
+	}
+}
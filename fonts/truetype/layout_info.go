@@ -0,0 +1,239 @@
+package truetype
+
+import "sort"
+
+// LayoutInfo lists the layout features found in a font,
+// for both OpenType ('GSUB' and 'GPOS') and AAT ('morx' and 'feat') tables.
+// It is intended to be used by user interfaces.
+type LayoutInfo struct {
+	GSUB, GPOS LayoutTableInfo
+	// AAT lists the features described by the 'feat' table or,
+	// if the font has none, the feature types found in the 'morx' table,
+	// without names.
+	AAT []AATFeatureInfo
+}
+
+// LayoutTableInfo describes the scripts, languages and features
+// of a GSUB or GPOS table.
+type LayoutTableInfo struct {
+	Scripts []LayoutScriptInfo
+	// Features has the same length as the Features slice of the table.
+	Features []LayoutFeatureInfo
+}
+
+// LayoutScriptInfo describes a script of a GSUB or GPOS table.
+type LayoutScriptInfo struct {
+	// Languages starts with the default language system (with tag 'dflt'), if any.
+	Languages []LayoutLanguageInfo
+	Tag       Tag
+}
+
+// LayoutLanguageInfo describes a language system of a GSUB or GPOS table.
+type LayoutLanguageInfo struct {
+	// Features are indices into the `LayoutTableInfo.Features` slice,
+	// starting with the required feature, if any.
+	Features []uint16
+	Tag      Tag
+	// HasRequiredFeature is true if the first element
+	// of Features is required.
+	HasRequiredFeature bool
+}
+
+// LayoutFeatureInfo describes an OpenType feature.
+// The names are resolved using the 'name' table, and are empty if not found.
+type LayoutFeatureInfo struct {
+	// Params is the raw feature parameters, which may be nil.
+	Params FeatureParams
+
+	// Label is the name of the feature, for 'ssXX' and 'cvXX' features.
+	Label string
+	// Tooltip and SampleText are only provided by 'cvXX' features.
+	Tooltip, SampleText string
+	// ParamLabels are the names of the glyph variants, for 'cvXX' features.
+	ParamLabels []string
+	// Characters are the characters with variants, for 'cvXX' features.
+	Characters []rune
+
+	// Alternates maps the glyphs having alternates to the number of alternates
+	// provided by the feature. It is only filled for GSUB features
+	// using Alternate Substitution lookups.
+	Alternates map[GID]int
+
+	Tag Tag
+}
+
+// AATFeatureInfo describes an AAT feature type.
+type AATFeatureInfo struct {
+	Label     string
+	Selectors []AATSelectorInfo
+	// DefaultIndex is the index of the selector enabled by default for exclusive
+	// features, or 0xFFFF for non exclusive features.
+	DefaultIndex uint16
+	Type         uint16
+}
+
+// AATSelectorInfo describes a setting of an AAT feature.
+type AATSelectorInfo struct {
+	Label string
+	AATFeatureSelector
+}
+
+// LayoutInfo enumerates the scripts, languages and features
+// of the layout tables of the font.
+func (font *Font) LayoutInfo() LayoutInfo {
+	var out LayoutInfo
+	out.GSUB = font.layoutTableInfo(&font.layoutTables.GSUB.TableLayout, font.layoutTables.GSUB.Lookups)
+	out.GPOS = font.layoutTableInfo(&font.layoutTables.GPOS.TableLayout, nil)
+	out.AAT = font.aatFeaturesInfo()
+	return out
+}
+
+// `lookups` is only used for GSUB
+func (font *Font) layoutTableInfo(table *TableLayout, lookups []LookupGSUB) LayoutTableInfo {
+	var out LayoutTableInfo
+
+	out.Scripts = make([]LayoutScriptInfo, len(table.Scripts))
+	for i, script := range table.Scripts {
+		info := &out.Scripts[i]
+		info.Tag = script.Tag
+		if script.DefaultLanguage != nil {
+			ls := newLayoutLanguageInfo(*script.DefaultLanguage)
+			ls.Tag = tagDefaultLanguage
+			info.Languages = append(info.Languages, ls)
+		}
+		for _, lang := range script.Languages {
+			info.Languages = append(info.Languages, newLayoutLanguageInfo(lang))
+		}
+	}
+
+	out.Features = make([]LayoutFeatureInfo, len(table.Features))
+	for i, feature := range table.Features {
+		info := &out.Features[i]
+		info.Tag = feature.Tag
+		info.Params = feature.Params
+		switch params := feature.Params.(type) {
+		case FeatureParamsStylisticSet:
+			info.Label = font.Names.uiName(params.UINameID)
+		case FeatureParamsCharacterVariants:
+			info.Label = font.Names.uiName(params.FeatUILabelNameID)
+			info.Tooltip = font.Names.uiName(params.FeatUITooltipTextNameID)
+			info.SampleText = font.Names.uiName(params.SampleTextNameID)
+			info.Characters = params.Characters
+			if params.NumNamedParameters != 0 {
+				info.ParamLabels = make([]string, params.NumNamedParameters)
+				for j := range info.ParamLabels {
+					info.ParamLabels[j] = font.Names.uiName(params.FirstParamUILabelNameID + NameID(j))
+				}
+			}
+		}
+		info.Alternates = countAlternates(lookups, feature.LookupIndices)
+	}
+
+	return out
+}
+
+func newLayoutLanguageInfo(ls LangSys) LayoutLanguageInfo {
+	out := LayoutLanguageInfo{Tag: ls.Tag}
+	if ls.RequiredFeatureIndex != 0xFFFF {
+		out.HasRequiredFeature = true
+		out.Features = append(out.Features, ls.RequiredFeatureIndex)
+	}
+	out.Features = append(out.Features, ls.Features...)
+	return out
+}
+
+// returns the maximum number of alternates for each glyph,
+// or nil if the lookups have no Alternate Substitution
+func countAlternates(lookups []LookupGSUB, indices []uint16) map[GID]int {
+	var out map[GID]int
+	for _, index := range indices {
+		if int(index) >= len(lookups) {
+			continue
+		}
+		for _, subtable := range lookups[index].Subtables {
+			alternates, ok := subtable.Data.(GSUBAlternate1)
+			if !ok {
+				continue
+			}
+			if out == nil {
+				out = make(map[GID]int)
+			}
+			for coverageIndex, glyph := range coverageGlyphs(subtable.Coverage) {
+				if coverageIndex >= len(alternates) {
+					break
+				}
+				if n := len(alternates[coverageIndex]); n > out[glyph] {
+					out[glyph] = n
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (font *Font) aatFeaturesInfo() []AATFeatureInfo {
+	feat := font.layoutTables.Feat
+	if len(feat) == 0 {
+		return aatFeaturesFromMorx(font.layoutTables.Morx)
+	}
+
+	out := make([]AATFeatureInfo, len(feat))
+	for i, feature := range feat {
+		selectors, defaultIndex := feature.GetSelectorInfos()
+		out[i] = AATFeatureInfo{
+			Type:         feature.Feature,
+			Label:        font.Names.uiName(feature.NameIndex),
+			DefaultIndex: defaultIndex,
+			Selectors:    make([]AATSelectorInfo, len(selectors)),
+		}
+		for j, selector := range selectors {
+			out[i].Selectors[j] = AATSelectorInfo{
+				AATFeatureSelector: selector,
+				Label:              font.Names.uiName(selector.Name),
+			}
+		}
+	}
+	return out
+}
+
+// list the feature types used by the chains, sorted by type;
+// since the exclusivity of the features is unknown, each setting
+// is returned as a selector, with an unknown (0xFFFF) Disable value
+func aatFeaturesFromMorx(morx TableMorx) []AATFeatureInfo {
+	var out []AATFeatureInfo
+	for _, chain := range morx {
+		for _, feature := range chain.Features {
+			index := len(out)
+			for i, f := range out {
+				if f.Type == feature.Type {
+					index = i
+					break
+				}
+			}
+			if index == len(out) {
+				out = append(out, AATFeatureInfo{Type: feature.Type, DefaultIndex: 0xFFFF})
+			}
+			info := &out[index]
+			hasSetting := false
+			for _, selector := range info.Selectors {
+				hasSetting = hasSetting || selector.Enable == feature.Setting
+			}
+			if !hasSetting {
+				info.Selectors = append(info.Selectors, AATSelectorInfo{
+					AATFeatureSelector: AATFeatureSelector{Enable: feature.Setting, Disable: 0xFFFF},
+				})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
+
+// returns the name for the given ID, which is
+// considered absent if zero
+func (names TableName) uiName(name NameID) string {
+	if name == 0 {
+		return ""
+	}
+	return names.getName(name)
+}
@@ -0,0 +1,134 @@
+package truetype
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	hbtestdata "github.com/benoitkugler/textlayout-testdata/harfbuzz"
+)
+
+func TestFeatureParams(t *testing.T) {
+	font := loadFont(t, "TestCFF2VF.otf")
+	var found bool
+	for _, feature := range font.LayoutTables().GPOS.Features {
+		if feature.Tag == tagSize {
+			found = true
+			if exp := (FeatureParamsSize{DesignSize: 100}); feature.Params != exp {
+				t.Fatalf("expected %v, got %v", exp, feature.Params)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("missing size feature")
+	}
+
+	// stylistic set
+	params, err := parseFeatureParams([]byte{0, 0, 1, 2}, 0, MustNewTag("ss05"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (FeatureParamsStylisticSet{UINameID: 258}); params != exp {
+		t.Fatalf("expected %v, got %v", exp, params)
+	}
+
+	// unsupported feature
+	if params, _ = parseFeatureParams([]byte{0, 0, 1, 2}, 0, MustNewTag("liga")); params != nil {
+		t.Fatalf("expected nil params, got %v", params)
+	}
+
+	if _, err = parseFeatureParams([]byte{0, 1, 0}, 0, MustNewTag("cv01")); err == nil {
+		t.Fatal("expected error on invalid params")
+	}
+}
+
+func TestLayoutInfoCharacterVariant(t *testing.T) {
+	f, err := hbtestdata.Files.ReadFile("fonts/cv01.otf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := font.LayoutInfo()
+	if len(info.GPOS.Scripts) != 0 || len(info.AAT) != 0 {
+		t.Fatal("unexpected features")
+	}
+
+	expScripts := []LayoutScriptInfo{
+		{Tag: MustNewTag("DFLT"), Languages: []LayoutLanguageInfo{{Tag: MustNewTag("dflt"), Features: []uint16{0}}}},
+	}
+	if !reflect.DeepEqual(info.GSUB.Scripts, expScripts) {
+		t.Fatalf("expected %v, got %v", expScripts, info.GSUB.Scripts)
+	}
+
+	if len(info.GSUB.Features) != 1 {
+		t.Fatalf("expected one feature, got %d", len(info.GSUB.Features))
+	}
+	feature := info.GSUB.Features[0]
+	if feature.Tag != MustNewTag("cv01") {
+		t.Fatalf("unexpected tag %s", feature.Tag)
+	}
+	if feature.Label != "uilabel simple a" || feature.Tooltip != "tool tip simple a" || feature.SampleText != "sample text simple a" {
+		t.Fatalf("unexpected names %v", feature)
+	}
+	if exp := []string{"param1 text simple a", "param2 text simple a"}; !reflect.DeepEqual(feature.ParamLabels, exp) {
+		t.Fatalf("expected %v, got %v", exp, feature.ParamLabels)
+	}
+	if exp := []rune{10, 24030}; !reflect.DeepEqual(feature.Characters, exp) {
+		t.Fatalf("expected %v, got %v", exp, feature.Characters)
+	}
+}
+
+func TestLayoutInfoAlternates(t *testing.T) {
+	font := loadFont(t, "Raleway-v4020-Regular.otf")
+	info := font.LayoutInfo()
+	for _, feature := range info.GSUB.Features {
+		if feature.Tag != MustNewTag("aalt") {
+			continue
+		}
+		if len(feature.Alternates) != 27 {
+			t.Fatalf("expected 27 glyphs with alternates, got %d", len(feature.Alternates))
+		}
+		for glyph, count := range feature.Alternates {
+			if count <= 0 {
+				t.Fatalf("invalid alternates count for glyph %d", glyph)
+			}
+		}
+	}
+}
+
+func TestLayoutInfoAAT(t *testing.T) {
+	font := loadFont(t, "ToyFeat.ttf")
+	info := font.LayoutInfo()
+	if len(info.AAT) != 11 {
+		t.Fatalf("expected 11 features, got %d", len(info.AAT))
+	}
+	feature := info.AAT[1]
+	if feature.Type != 3 || feature.DefaultIndex != 0 || len(feature.Selectors) != 3 {
+		t.Fatalf("unexpected feature %v", feature)
+	}
+	if exp := (AATFeatureSelector{Name: 263, Enable: 1, Disable: 0}); feature.Selectors[1].AATFeatureSelector != exp {
+		t.Fatalf("expected %v, got %v", exp, feature.Selectors[1])
+	}
+}
+
+func TestLayoutInfoMorx(t *testing.T) {
+	// no 'feat' table
+	morx := TableMorx{
+		{Features: []AATFeature{{Type: 4, Setting: 0}, {Type: 1, Setting: 2}, {Type: 4, Setting: 0}}},
+		{Features: []AATFeature{{Type: 4, Setting: 1}}},
+	}
+	exp := []AATFeatureInfo{
+		{Type: 1, DefaultIndex: 0xFFFF, Selectors: []AATSelectorInfo{{AATFeatureSelector: AATFeatureSelector{Enable: 2, Disable: 0xFFFF}}}},
+		{Type: 4, DefaultIndex: 0xFFFF, Selectors: []AATSelectorInfo{
+			{AATFeatureSelector: AATFeatureSelector{Enable: 0, Disable: 0xFFFF}},
+			{AATFeatureSelector: AATFeatureSelector{Enable: 1, Disable: 0xFFFF}},
+		}},
+	}
+	if got := aatFeaturesFromMorx(morx); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+}
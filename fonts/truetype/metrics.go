@@ -205,34 +205,22 @@ func (f *Font) getPointsForGlyph(gid GID, currentDepth int, allPoints *[]contour
 	}
 	g := f.Glyf[gid]
 
-	var points []contourPoint
-	if data, ok := g.data.(simpleGlyphData); ok {
-		points = data.getContourPoints() // fetch the "real" points
-	} else { // zeros values are enough
-		points = make([]contourPoint, g.pointNumbersCount())
-	}
-
-	// init phantom point
-	points = append(points, make([]contourPoint, phantomCount)...)
+	// the points are directly stored in `allPoints`, which is
+	// enough for simple glyphs
+	start := len(*allPoints)
+	*allPoints = f.glyphPointsWithPhantoms(gid, *allPoints)
+	points := (*allPoints)[start:]
 	phantoms := points[len(points)-phantomCount:]
 
-	hDelta := float32(g.Xmin - f.Hmtx.getSideBearing(gid))
-	vOrig := float32(g.Ymax + f.vmtx.getSideBearing(gid))
-	hAdv := float32(f.getBaseAdvance(gid, f.Hmtx))
-	vAdv := float32(f.getBaseAdvance(gid, f.vmtx))
-	phantoms[phantomLeft].X = hDelta
-	phantoms[phantomRight].X = hAdv + hDelta
-	phantoms[phantomTop].Y = vOrig
-	phantoms[phantomBottom].Y = vOrig - vAdv
-
-	if f.isVar() {
-		f.gvar.applyDeltasToPoints(gid, f.varCoords, points)
-	}
-
 	switch data := g.data.(type) {
 	case simpleGlyphData:
-		*allPoints = append(*allPoints, points...)
 	case compositeGlyphData:
+		// `points` holds one point per component: copy it since
+		// `allPoints` will be overwritten by the components
+		points = append([]contourPoint(nil), points...)
+		phantoms = points[len(points)-phantomCount:]
+		*allPoints = (*allPoints)[:start]
+
 		for compIndex, item := range data.glyphs {
 			// recurse on component
 			var compPoints []contourPoint
@@ -272,8 +260,7 @@ func (f *Font) getPointsForGlyph(gid GID, currentDepth int, allPoints *[]contour
 		}
 
 		*allPoints = append(*allPoints, phantoms...)
-	default: // no data for the glyph
-		*allPoints = append(*allPoints, phantoms...)
+	default: // no data for the glyph: only the phantoms are stored
 	}
 
 	// apply at top level
@@ -287,6 +274,42 @@ func (f *Font) getPointsForGlyph(gid GID, currentDepth int, allPoints *[]contour
 	}
 }
 
+// returns the contour points of a simple glyph, or one point per component
+// for a composite glyph, followed by the phantom points, with variations applied
+func (f *Font) glyphPointsWithPhantoms(gid GID, buffer []contourPoint) []contourPoint {
+	g := f.Glyf[gid]
+
+	start := len(buffer)
+	if data, ok := g.data.(simpleGlyphData); ok {
+		buffer = data.getContourPoints(buffer) // fetch the "real" points
+	} else { // zeros values are enough
+		for i := g.pointNumbersCount(); i > 0; i-- {
+			buffer = append(buffer, contourPoint{})
+		}
+	}
+
+	// init phantom point
+	for i := 0; i < phantomCount; i++ {
+		buffer = append(buffer, contourPoint{})
+	}
+	points := buffer[start:]
+	phantoms := points[len(points)-phantomCount:]
+
+	hDelta := float32(g.Xmin - f.Hmtx.getSideBearing(gid))
+	vOrig := float32(g.Ymax + f.vmtx.getSideBearing(gid))
+	hAdv := float32(f.getBaseAdvance(gid, f.Hmtx))
+	vAdv := float32(f.getBaseAdvance(gid, f.vmtx))
+	phantoms[phantomLeft].X = hDelta
+	phantoms[phantomRight].X = hAdv + hDelta
+	phantoms[phantomTop].Y = vOrig
+	phantoms[phantomBottom].Y = vOrig - vAdv
+
+	if f.isVar() {
+		f.gvar.applyDeltasToPoints(gid, f.varCoords, points)
+	}
+	return buffer
+}
+
 func extentsFromPoints(allPoints []contourPoint) (ext fonts.GlyphExtents) {
 	truePoints := allPoints[:len(allPoints)-phantomCount]
 	if len(truePoints) == 0 {
@@ -361,6 +384,10 @@ func (f *Font) isVar() bool {
 	return len(f.varCoords) != 0 && len(f.varCoords) == len(f.fvar.Axis)
 }
 
+// HasVerticalMetrics returns true if the font provides
+// vertical advances, that is if it has a 'vmtx' table.
+func (f *Font) HasVerticalMetrics() bool { return len(f.vmtx) != 0 }
+
 func (f *Font) VerticalAdvance(gid GID) float32 {
 	// return the opposite of the advance from the font
 	advance := f.getBaseAdvance(gid, f.vmtx)
@@ -324,6 +324,26 @@ func (pr *FontParser) GDEFTable(nbAxis int) (TableGDEF, error) {
 	return parseTableGdef(buf, nbAxis)
 }
 
+// BASETable returns the Baseline table identified with the 'BASE' tag.
+func (pr *FontParser) BASETable(nbAxis int) (TableBASE, error) {
+	buf, err := pr.GetRawTable(TagBase)
+	if err != nil {
+		return TableBASE{}, err
+	}
+
+	return parseTableBASE(buf, nbAxis)
+}
+
+// JSTFTable returns the Justification table identified with the 'JSTF' tag.
+func (pr *FontParser) JSTFTable() (TableJSTF, error) {
+	buf, err := pr.GetRawTable(TagJstf)
+	if err != nil {
+		return TableJSTF{}, err
+	}
+
+	return parseTableJSTF(buf)
+}
+
 func (pr *FontParser) CmapTable() (TableCmap, error) {
 	s, found := pr.tables[tagCmap]
 	if !found {
@@ -410,9 +430,13 @@ func (pr *FontParser) KernTable(numGlyphs int) (TableKernx, error) {
 	return parseKernTable(buf, numGlyphs)
 }
 
-// MorxTable parse the AAT 'morx' table.
+// MorxTable parse the AAT 'morx' table, or the
+// deprecated 'mort' table if the font has no 'morx' table.
 func (pr *FontParser) MorxTable(numGlyphs int) (TableMorx, error) {
 	buf, err := pr.GetRawTable(tagMorx)
+	if err != nil {
+		buf, err = pr.GetRawTable(tagMort)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -450,6 +474,46 @@ func (pr *FontParser) TrakTable() (TableTrak, error) {
 	return parseTrakTable(buf)
 }
 
+// OpbdTable parse the AAT 'opbd' table.
+func (pr *FontParser) OpbdTable(numGlyphs int) (TableOpbd, error) {
+	buf, err := pr.GetRawTable(tagOpbd)
+	if err != nil {
+		return TableOpbd{}, err
+	}
+
+	return parseTableOpbd(buf, numGlyphs)
+}
+
+// LcarTable parse the AAT 'lcar' table.
+func (pr *FontParser) LcarTable(numGlyphs int) (TableLcar, error) {
+	buf, err := pr.GetRawTable(tagLcar)
+	if err != nil {
+		return TableLcar{}, err
+	}
+
+	return parseTableLcar(buf, numGlyphs)
+}
+
+// BslnTable parse the AAT 'bsln' table.
+func (pr *FontParser) BslnTable(numGlyphs int) (TableBsln, error) {
+	buf, err := pr.GetRawTable(tagBsln)
+	if err != nil {
+		return TableBsln{}, err
+	}
+
+	return parseTableBsln(buf, numGlyphs)
+}
+
+// PropTable parse the AAT 'prop' table.
+func (pr *FontParser) PropTable(numGlyphs int) (TableProp, error) {
+	buf, err := pr.GetRawTable(tagProp)
+	if err != nil {
+		return TableProp{}, err
+	}
+
+	return parseTableProp(buf, numGlyphs)
+}
+
 // FeatTable parse the AAT 'feat' table.
 func (pr *FontParser) FeatTable() (TableFeat, error) {
 	buf, err := pr.GetRawTable(tagFeat)
@@ -546,6 +610,12 @@ func (pr *FontParser) loadLayoutTables(numGlyphs int, fvar TableFvar) (out Layou
 	if tb, err := pr.GPOSTable(); err == nil {
 		out.GPOS = tb
 	}
+	if tb, err := pr.BASETable(len(fvar.Axis)); err == nil {
+		out.BASE = tb
+	}
+	if tb, err := pr.JSTFTable(); err == nil {
+		out.JSTF = tb
+	}
 
 	if tb, err := pr.MorxTable(numGlyphs); err == nil {
 		out.Morx = tb
@@ -565,6 +635,18 @@ func (pr *FontParser) loadLayoutTables(numGlyphs int, fvar TableFvar) (out Layou
 	if tb, err := pr.FeatTable(); err == nil {
 		out.Feat = tb
 	}
+	if tb, err := pr.OpbdTable(numGlyphs); err == nil {
+		out.Opbd = tb
+	}
+	if tb, err := pr.LcarTable(numGlyphs); err == nil {
+		out.Lcar = tb
+	}
+	if tb, err := pr.BslnTable(numGlyphs); err == nil {
+		out.Bsln = tb
+	}
+	if tb, err := pr.PropTable(numGlyphs); err == nil {
+		out.Prop = tb
+	}
 
 	return out
 }
@@ -730,6 +812,11 @@ func (pr *FontParser) loadTables() (*Font, error) {
 	if pr.HasTable(TagPrep) {
 		out.HasHint = true
 	}
+	if out.Glyf != nil {
+		if ht, err := pr.hintingTables(out.fvar); err == nil {
+			out.hinting = &ht
+		}
+	}
 
 	err = pr.loadSummary(&out)
 	if err != nil {
@@ -62,17 +62,11 @@ func (colorBitmap bitmapTable) glyphData(gid GID, xPpem, yPpem uint16) (fonts.Gl
 
 // look for data in 'glyf' and 'cff' tables
 func (f *Font) outlineGlyphData(gid GID) (fonts.GlyphOutline, bool) {
-	out, err := f.glyphDataFromCFF1(gid)
-	if err == nil {
-		return out, true
+	var out fonts.GlyphOutline
+	if err := f.DrawGlyph(gid, &out); err != nil {
+		return fonts.GlyphOutline{}, false
 	}
-
-	out, err = f.glyphDataFromGlyf(gid)
-	if err == nil {
-		return out, true
-	}
-
-	return fonts.GlyphOutline{}, false
+	return out, true
 }
 
 func (f *Font) GlyphData(gid GID, xPpem, yPpem uint16) fonts.GlyphData {
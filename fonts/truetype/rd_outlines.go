@@ -21,10 +21,16 @@ func midPoint(p, q fonts.SegmentPoint) fonts.SegmentPoint {
 
 // build the segments from the resolved contour points
 func buildSegments(points []contourPoint) []fonts.Segment {
+	var out fonts.GlyphOutline
+	drawContours(points, &out)
+	return out.Segments
+}
+
+// drawContours sends the resolved contour points to `pen`
+func drawContours(points []contourPoint, pen fonts.Pen) {
 	var (
 		firstOnCurveValid, firstOffCurveValid, lastOffCurveValid bool
 		firstOnCurve, firstOffCurve, lastOffCurve                fonts.SegmentPoint
-		out                                                      []fonts.Segment
 	)
 
 	for _, point := range points {
@@ -33,10 +39,7 @@ func buildSegments(points []contourPoint) []fonts.Segment {
 			if point.isOnCurve {
 				firstOnCurve = p
 				firstOnCurveValid = true
-				out = append(out, fonts.Segment{
-					Op:   fonts.SegmentOpMoveTo,
-					Args: [3]fonts.SegmentPoint{p},
-				})
+				pen.MoveTo(p)
 			} else if !firstOffCurveValid {
 				firstOffCurve = p
 				firstOffCurveValid = true
@@ -49,10 +52,7 @@ func buildSegments(points []contourPoint) []fonts.Segment {
 				firstOnCurveValid = true
 				lastOffCurve = p
 				lastOffCurveValid = true
-				out = append(out, fonts.Segment{
-					Op:   fonts.SegmentOpMoveTo,
-					Args: [3]fonts.SegmentPoint{firstOnCurve},
-				})
+				pen.MoveTo(firstOnCurve)
 			}
 		} else if !lastOffCurveValid {
 			if !point.isOnCurve {
@@ -63,27 +63,15 @@ func buildSegments(points []contourPoint) []fonts.Segment {
 					continue
 				}
 			} else {
-				out = append(out, fonts.Segment{
-					Op:   fonts.SegmentOpLineTo,
-					Args: [3]fonts.SegmentPoint{p},
-				})
+				pen.LineTo(p)
 			}
 		} else {
 			if !point.isOnCurve {
-				out = append(out, fonts.Segment{
-					Op: fonts.SegmentOpQuadTo,
-					Args: [3]fonts.SegmentPoint{
-						lastOffCurve,
-						midPoint(lastOffCurve, p),
-					},
-				})
+				pen.QuadTo(lastOffCurve, midPoint(lastOffCurve, p))
 				lastOffCurve = p
 				lastOffCurveValid = true
 			} else {
-				out = append(out, fonts.Segment{
-					Op:   fonts.SegmentOpQuadTo,
-					Args: [3]fonts.SegmentPoint{lastOffCurve, p},
-				})
+				pen.QuadTo(lastOffCurve, p)
 				lastOffCurveValid = false
 			}
 		}
@@ -92,62 +80,50 @@ func buildSegments(points []contourPoint) []fonts.Segment {
 			// closing the contour
 			switch {
 			case !firstOffCurveValid && !lastOffCurveValid:
-				out = append(out, fonts.Segment{
-					Op:   fonts.SegmentOpLineTo,
-					Args: [3]fonts.SegmentPoint{firstOnCurve},
-				})
+				pen.LineTo(firstOnCurve)
 			case !firstOffCurveValid && lastOffCurveValid:
-				out = append(out, fonts.Segment{
-					Op:   fonts.SegmentOpQuadTo,
-					Args: [3]fonts.SegmentPoint{lastOffCurve, firstOnCurve},
-				})
+				pen.QuadTo(lastOffCurve, firstOnCurve)
 			case firstOffCurveValid && !lastOffCurveValid:
-				out = append(out, fonts.Segment{
-					Op:   fonts.SegmentOpQuadTo,
-					Args: [3]fonts.SegmentPoint{firstOffCurve, firstOnCurve},
-				})
+				pen.QuadTo(firstOffCurve, firstOnCurve)
 			case firstOffCurveValid && lastOffCurveValid:
-				out = append(out, fonts.Segment{
-					Op: fonts.SegmentOpQuadTo,
-					Args: [3]fonts.SegmentPoint{
-						lastOffCurve,
-						midPoint(lastOffCurve, firstOffCurve),
-					},
-				},
-					fonts.Segment{
-						Op:   fonts.SegmentOpQuadTo,
-						Args: [3]fonts.SegmentPoint{firstOffCurve, firstOnCurve},
-					},
-				)
+				pen.QuadTo(lastOffCurve, midPoint(lastOffCurve, firstOffCurve))
+				pen.QuadTo(firstOffCurve, firstOnCurve)
 			}
+			pen.Close()
 
 			firstOnCurveValid = false
 			firstOffCurveValid = false
 			lastOffCurveValid = false
 		}
 	}
-
-	return out
 }
 
 // apply variation when needed
-func (f *Font) glyphDataFromGlyf(glyph GID) (fonts.GlyphOutline, error) {
+func (f *Font) drawGlyf(glyph GID, pen fonts.Pen) error {
 	if int(glyph) >= len(f.Glyf) {
-		return fonts.GlyphOutline{}, fmt.Errorf("out of range glyph %d", glyph)
+		return fmt.Errorf("out of range glyph %d", glyph)
 	}
-	var points []contourPoint
+	// the points are only needed while drawing: reuse the storage
+	points := f.glyfPoints[:0]
 	f.getPointsForGlyph(glyph, 0, &points)
-	segments := buildSegments(points[:len(points)-phantomCount])
-	return fonts.GlyphOutline{Segments: segments}, nil
+	f.glyfPoints = points
+	drawContours(points[:len(points)-phantomCount], pen)
+	return nil
 }
 
-func (f *Font) glyphDataFromCFF1(glyph GID) (fonts.GlyphOutline, error) {
-	if f.cff == nil {
-		return fonts.GlyphOutline{}, errors.New("no CFF table")
+var _ fonts.FaceOutliner = (*Font)(nil)
+
+// DrawGlyph sends the outlines of the given glyph to `pen`,
+// looking for data in the 'CFF ' and 'glyf' tables.
+// Variations are applied for 'glyf' outlines.
+// Since the storage of the points is reused between calls, DrawGlyph
+// is not safe for concurrent use.
+func (f *Font) DrawGlyph(gid GID, pen fonts.Pen) error {
+	if f.cff != nil {
+		return f.cff.DrawGlyph(gid, pen)
 	}
-	segments, _, err := f.cff.LoadGlyph(glyph)
-	if err != nil {
-		return fonts.GlyphOutline{}, err
+	if f.Glyf == nil {
+		return errors.New("no outline table")
 	}
-	return fonts.GlyphOutline{Segments: segments}, nil
+	return f.drawGlyf(gid, pen)
 }
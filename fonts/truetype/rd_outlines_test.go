@@ -1,6 +1,7 @@
 package truetype
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -471,7 +472,8 @@ func TestCFFSegments(t *testing.T) {
 	font := loadFont(t, "CFFTest.otf")
 
 	for i, expected := range expecteds {
-		got, err := font.glyphDataFromCFF1(fonts.GID(i))
+		var got fonts.GlyphOutline
+		err := font.DrawGlyph(fonts.GID(i), &got)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -480,3 +482,71 @@ func TestCFFSegments(t *testing.T) {
 		}
 	}
 }
+
+// contourPen checks that every contour is closed
+type contourPen struct {
+	nbSegments, nbContours int
+	open                   bool
+	err                    error
+}
+
+func (p *contourPen) MoveTo(fonts.SegmentPoint) {
+	if p.open {
+		p.err = errors.New("unclosed contour")
+	}
+	p.open = true
+	p.nbSegments++
+}
+
+func (p *contourPen) checkOpen() {
+	if !p.open {
+		p.err = errors.New("segment outside of a contour")
+	}
+	p.nbSegments++
+}
+
+func (p *contourPen) LineTo(fonts.SegmentPoint)         { p.checkOpen() }
+func (p *contourPen) QuadTo(_, _ fonts.SegmentPoint)    { p.checkOpen() }
+func (p *contourPen) CubeTo(_, _, _ fonts.SegmentPoint) { p.checkOpen() }
+func (p *contourPen) Close()                            { p.open = false; p.nbContours++ }
+
+func TestDrawGlyph(t *testing.T) {
+	for _, filename := range []string{"Roboto-BoldItalic.ttf", "CFFTest.otf", "SourceSansVariable-Roman.anchor.ttf"} {
+		font := loadFont(t, filename)
+		for i := 0; i < font.NumGlyphs; i++ {
+			gid := fonts.GID(i)
+			var pen contourPen
+			if err := font.DrawGlyph(gid, &pen); err != nil {
+				t.Fatal(err)
+			}
+			if pen.err != nil || pen.open {
+				t.Fatalf("glyph %d: invalid contours", gid)
+			}
+			outline, _ := font.GlyphData(gid, 0, 0).(fonts.GlyphOutline)
+			if len(outline.Segments) != pen.nbSegments {
+				t.Fatalf("glyph %d: expected %d segments, got %d", gid, len(outline.Segments), pen.nbSegments)
+			}
+		}
+	}
+}
+
+func TestDrawGlyphAllocs(t *testing.T) {
+	font := loadFont(t, "Roboto-BoldItalic.ttf")
+	gid, _ := font.NominalGlyph('a')
+	if _, isSimple := font.Glyf[gid].data.(simpleGlyphData); !isSimple {
+		t.Fatal("expected a simple glyph")
+	}
+
+	var pen contourPen
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := font.DrawGlyph(gid, &pen); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocation, got %f", allocs)
+	}
+	if pen.err != nil || pen.nbContours == 0 {
+		t.Fatal("invalid contours")
+	}
+}
@@ -23,6 +23,10 @@ var (
 	TagGsub = MustNewTag("GSUB")
 	// TagGdef represents the 'GDEF' table, which contains various Glyph Definitions
 	TagGdef = MustNewTag("GDEF")
+	// TagBase represents the 'BASE' table, which contains baseline data
+	TagBase = MustNewTag("BASE")
+	// TagJstf represents the 'JSTF' table, which contains justification data
+	TagJstf = MustNewTag("JSTF")
 
 	tagCmap = MustNewTag("cmap")
 	tagKern = MustNewTag("kern")
@@ -56,6 +60,10 @@ var (
 	tagKerx = MustNewTag("kerx")
 	tagAnkr = MustNewTag("ankr")
 	tagTrak = MustNewTag("trak")
+	tagOpbd = MustNewTag("opbd")
+	tagLcar = MustNewTag("lcar")
+	tagBsln = MustNewTag("bsln")
+	tagProp = MustNewTag("prop")
 
 	// TypeTrueType is the first four bytes of an OpenType file containing a TrueType font
 	TypeTrueType = Tag(0x00010000)
@@ -0,0 +1,442 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var tagDefaultScript = MustNewTag("DFLT")
+
+// TableBASE is the Baseline table, which provides information
+// used to align glyphs of different scripts and sizes in a line of text.
+// See https://docs.microsoft.com/en-us/typography/opentype/spec/base
+type TableBASE struct {
+	Horizontal, Vertical BaseAxis       // may be empty
+	VariationStore       VariationStore // for variable fonts, may be empty
+}
+
+// BaseAxis stores the baseline information for one text direction.
+type BaseAxis struct {
+	// BaselineTags lists the baselines supported by the scripts of the axis,
+	// sorted in ascending order.
+	BaselineTags []Tag
+	// Scripts is sorted by tag.
+	Scripts []BaseScript
+}
+
+// IsEmpty returns `true` if the axis has no script records.
+func (axis BaseAxis) IsEmpty() bool { return len(axis.Scripts) == 0 }
+
+// FindBaselineTag looks for `baseline` and returns its index into the BaselineTags slice,
+// or -1 if the tag is not found.
+func (axis BaseAxis) FindBaselineTag(baseline Tag) int {
+	// BaselineTags is sorted: binary search
+	low, high := 0, len(axis.BaselineTags)
+	for low < high {
+		mid := low + (high-low)/2 // avoid overflow when computing mid
+		p := axis.BaselineTags[mid]
+		if baseline < p {
+			high = mid
+		} else if baseline > p {
+			low = mid + 1
+		} else {
+			return mid
+		}
+	}
+	return -1
+}
+
+// FindScript looks for `script` and returns the corresponding record,
+// defaulting to the 'DFLT' script. It returns nil if none is found.
+func (axis BaseAxis) FindScript(script Tag) *BaseScript {
+	if i := axis.findScript(script); i != -1 {
+		return &axis.Scripts[i]
+	}
+	if i := axis.findScript(tagDefaultScript); i != -1 {
+		return &axis.Scripts[i]
+	}
+	return nil
+}
+
+func (axis BaseAxis) findScript(script Tag) int {
+	// Scripts is sorted: binary search
+	low, high := 0, len(axis.Scripts)
+	for low < high {
+		mid := low + (high-low)/2 // avoid overflow when computing mid
+		p := axis.Scripts[mid].Tag
+		if script < p {
+			high = mid
+		} else if script > p {
+			low = mid + 1
+		} else {
+			return mid
+		}
+	}
+	return -1
+}
+
+// Baseline returns the coordinate of the baseline `baseline` for the given
+// script, or nil if not found.
+func (axis BaseAxis) Baseline(baseline, script Tag) BaseCoord {
+	bs := axis.FindScript(script)
+	if bs == nil {
+		return nil
+	}
+	index := axis.FindBaselineTag(baseline)
+	if index == -1 {
+		return nil
+	}
+	if index >= len(bs.BaseCoords) {
+		return nil
+	}
+	return bs.BaseCoords[index]
+}
+
+// MinMax returns the extents for the given script, language and feature.
+// It returns false if the script (or the 'DFLT' script) is not found
+// or if it does not provide any extent.
+func (axis BaseAxis) MinMax(script, language, feature Tag) (min, max BaseCoord, ok bool) {
+	bs := axis.FindScript(script)
+	if bs == nil {
+		return nil, nil, false
+	}
+	minMax := bs.DefaultMinMax
+	if i := bs.findLanguage(language); i != -1 {
+		minMax = &bs.Languages[i].MinMax
+	}
+	if minMax == nil {
+		return nil, nil, false
+	}
+	min, max = minMax.Min, minMax.Max
+	for _, feat := range minMax.Features {
+		if feat.Tag == feature {
+			min, max = feat.Min, feat.Max
+			break
+		}
+	}
+	return min, max, true
+}
+
+// BaseScript stores the baseline values and
+// the extents of one script.
+type BaseScript struct {
+	// DefaultMinMax is the extent for the script, used
+	// when no specific language record matches. It may be nil.
+	DefaultMinMax *BaseMinMax
+	// BaseCoords is either empty or has the same length
+	// as the BaselineTags of the parent axis.
+	// Items may be nil.
+	BaseCoords []BaseCoord
+	// Languages is sorted by tag.
+	Languages []BaseLangSys
+	Tag       Tag
+	// DefaultBaselineIndex is the index of the default baseline
+	// for this script, into the BaselineTags of the parent axis.
+	DefaultBaselineIndex uint16
+}
+
+func (bs BaseScript) findLanguage(language Tag) int {
+	// Languages is sorted: binary search
+	low, high := 0, len(bs.Languages)
+	for low < high {
+		mid := low + (high-low)/2 // avoid overflow when computing mid
+		p := bs.Languages[mid].Tag
+		if language < p {
+			high = mid
+		} else if language > p {
+			low = mid + 1
+		} else {
+			return mid
+		}
+	}
+	return -1
+}
+
+// BaseLangSys stores the extents for one language.
+type BaseLangSys struct {
+	MinMax BaseMinMax
+	Tag    Tag
+}
+
+// BaseMinMax defines the minimum and maximum extents
+// for a script or language, optionally refined for some features.
+type BaseMinMax struct {
+	Min, Max BaseCoord // may be nil
+	// Features is sorted by tag.
+	Features []BaseFeatMinMax
+}
+
+// BaseFeatMinMax overrides the extents when a feature
+// is enabled.
+type BaseFeatMinMax struct {
+	Min, Max BaseCoord // may be nil
+	Tag      Tag
+}
+
+// BaseCoord is either BaseCoordFormat1, BaseCoordFormat2 or BaseCoordFormat3.
+type BaseCoord interface {
+	// Coordinate returns the value, in design units,
+	// without any device or contour point adjustment.
+	Coordinate() int16
+}
+
+// BaseCoordFormat1 is a simple X or Y value, in design units.
+type BaseCoordFormat1 int16
+
+// BaseCoordFormat2 is a X or Y value, in design units,
+// which may be adjusted by the position of a contour point
+// in the reference glyph.
+type BaseCoordFormat2 struct {
+	Value          int16
+	ReferenceGlyph GID
+	BaseCoordPoint uint16
+}
+
+// BaseCoordFormat3 is a X or Y value, in design units, adjusted by a device
+// table (or variations).
+type BaseCoordFormat3 struct {
+	Device DeviceTable // may be nil
+	Value  int16
+}
+
+func (c BaseCoordFormat1) Coordinate() int16 { return int16(c) }
+func (c BaseCoordFormat2) Coordinate() int16 { return c.Value }
+func (c BaseCoordFormat3) Coordinate() int16 { return c.Value }
+
+func parseTableBASE(data []byte, axisCount int) (out TableBASE, err error) {
+	if len(data) < 8 {
+		return out, errors.New("invalid BASE table (EOF)")
+	}
+	major := binary.BigEndian.Uint16(data)
+	minor := binary.BigEndian.Uint16(data[2:])
+	horizOffset := binary.BigEndian.Uint16(data[4:])
+	vertOffset := binary.BigEndian.Uint16(data[6:])
+
+	if major != 1 {
+		return out, fmt.Errorf("unsupported BASE table version %d.%d", major, minor)
+	}
+
+	if horizOffset != 0 {
+		out.Horizontal, err = parseBaseAxis(data, horizOffset)
+		if err != nil {
+			return out, err
+		}
+	}
+	if vertOffset != 0 {
+		out.Vertical, err = parseBaseAxis(data, vertOffset)
+		if err != nil {
+			return out, err
+		}
+	}
+
+	if minor >= 1 {
+		if len(data) < 12 {
+			return out, errors.New("invalid BASE table (EOF)")
+		}
+		itemVarStoreOffset := binary.BigEndian.Uint32(data[8:])
+		if itemVarStoreOffset != 0 {
+			out.VariationStore, err = parseVariationStore(data, itemVarStoreOffset, axisCount)
+			if err != nil {
+				return out, err
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func parseBaseAxis(data []byte, offset uint16) (out BaseAxis, err error) {
+	if len(data) < int(offset)+4 {
+		return out, errors.New("invalid BASE axis table (EOF)")
+	}
+	data = data[offset:]
+	baseTagListOffset := binary.BigEndian.Uint16(data)
+	baseScriptListOffset := binary.BigEndian.Uint16(data[2:])
+
+	if baseTagListOffset != 0 {
+		if len(data) < int(baseTagListOffset)+2 {
+			return out, errors.New("invalid BASE tag list (EOF)")
+		}
+		tagList := data[baseTagListOffset:]
+		count := int(binary.BigEndian.Uint16(tagList))
+		if len(tagList) < 2+4*count {
+			return out, errors.New("invalid BASE tag list (EOF)")
+		}
+		out.BaselineTags = make([]Tag, count)
+		for i := range out.BaselineTags {
+			out.BaselineTags[i] = Tag(binary.BigEndian.Uint32(tagList[2+4*i:]))
+		}
+	}
+
+	if baseScriptListOffset == 0 {
+		return out, errors.New("invalid BASE axis table (missing script list)")
+	}
+	if len(data) < int(baseScriptListOffset)+2 {
+		return out, errors.New("invalid BASE script list (EOF)")
+	}
+	scriptList := data[baseScriptListOffset:]
+	count := int(binary.BigEndian.Uint16(scriptList))
+	if len(scriptList) < 2+6*count {
+		return out, errors.New("invalid BASE script list (EOF)")
+	}
+	out.Scripts = make([]BaseScript, count)
+	for i := range out.Scripts {
+		out.Scripts[i].Tag = Tag(binary.BigEndian.Uint32(scriptList[2+6*i:]))
+		scriptOffset := binary.BigEndian.Uint16(scriptList[2+6*i+4:])
+		err = out.Scripts[i].parse(scriptList, scriptOffset, len(out.BaselineTags))
+		if err != nil {
+			return out, err
+		}
+	}
+
+	return out, nil
+}
+
+// data starts at the script list
+// `baselineCount` is used to sanitize the base values
+func (bs *BaseScript) parse(data []byte, offset uint16, baselineCount int) (err error) {
+	if len(data) < int(offset)+6 {
+		return errors.New("invalid BASE script table (EOF)")
+	}
+	data = data[offset:]
+	baseValuesOffset := binary.BigEndian.Uint16(data)
+	defaultMinMaxOffset := binary.BigEndian.Uint16(data[2:])
+	langSysCount := int(binary.BigEndian.Uint16(data[4:]))
+
+	if baseValuesOffset != 0 {
+		bs.DefaultBaselineIndex, bs.BaseCoords, err = parseBaseValues(data, baseValuesOffset)
+		if err != nil {
+			return err
+		}
+		if len(bs.BaseCoords) != baselineCount {
+			return fmt.Errorf("invalid BASE values table (%d values for %d baselines)", len(bs.BaseCoords), baselineCount)
+		}
+		if int(bs.DefaultBaselineIndex) >= baselineCount {
+			return fmt.Errorf("invalid BASE values table (default index %d for %d baselines)", bs.DefaultBaselineIndex, baselineCount)
+		}
+	}
+
+	if defaultMinMaxOffset != 0 {
+		var mm BaseMinMax
+		mm, err = parseBaseMinMax(data, defaultMinMaxOffset)
+		if err != nil {
+			return err
+		}
+		bs.DefaultMinMax = &mm
+	}
+
+	if len(data) < 6+6*langSysCount {
+		return errors.New("invalid BASE script table (EOF)")
+	}
+	bs.Languages = make([]BaseLangSys, langSysCount)
+	for i := range bs.Languages {
+		bs.Languages[i].Tag = Tag(binary.BigEndian.Uint32(data[6+6*i:]))
+		minMaxOffset := binary.BigEndian.Uint16(data[6+6*i+4:])
+		bs.Languages[i].MinMax, err = parseBaseMinMax(data, minMaxOffset)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseBaseValues(data []byte, offset uint16) (defaultIndex uint16, coords []BaseCoord, err error) {
+	if len(data) < int(offset)+4 {
+		return 0, nil, errors.New("invalid BASE values table (EOF)")
+	}
+	data = data[offset:]
+	defaultIndex = binary.BigEndian.Uint16(data)
+	count := int(binary.BigEndian.Uint16(data[2:]))
+	offsets, err := parseUint16s(data[4:], count)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid BASE values table: %s", err)
+	}
+	coords = make([]BaseCoord, count)
+	for i, off := range offsets {
+		coords[i], err = parseBaseCoord(data, off)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	return defaultIndex, coords, nil
+}
+
+func parseBaseMinMax(data []byte, offset uint16) (out BaseMinMax, err error) {
+	if len(data) < int(offset)+6 {
+		return out, errors.New("invalid BASE min max table (EOF)")
+	}
+	data = data[offset:]
+	minOffset := binary.BigEndian.Uint16(data)
+	maxOffset := binary.BigEndian.Uint16(data[2:])
+	featCount := int(binary.BigEndian.Uint16(data[4:]))
+
+	out.Min, err = parseBaseCoord(data, minOffset)
+	if err != nil {
+		return out, err
+	}
+	out.Max, err = parseBaseCoord(data, maxOffset)
+	if err != nil {
+		return out, err
+	}
+
+	if len(data) < 6+8*featCount {
+		return out, errors.New("invalid BASE min max table (EOF)")
+	}
+	out.Features = make([]BaseFeatMinMax, featCount)
+	for i := range out.Features {
+		out.Features[i].Tag = Tag(binary.BigEndian.Uint32(data[6+8*i:]))
+		minOffset = binary.BigEndian.Uint16(data[6+8*i+4:])
+		maxOffset = binary.BigEndian.Uint16(data[6+8*i+6:])
+		out.Features[i].Min, err = parseBaseCoord(data, minOffset)
+		if err != nil {
+			return out, err
+		}
+		out.Features[i].Max, err = parseBaseCoord(data, maxOffset)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// return nil for a NULL offset
+func parseBaseCoord(data []byte, offset uint16) (BaseCoord, error) {
+	if offset == 0 {
+		return nil, nil
+	}
+	if len(data) < int(offset)+4 {
+		return nil, errors.New("invalid BASE coordinate table (EOF)")
+	}
+	data = data[offset:]
+	format := binary.BigEndian.Uint16(data)
+	coordinate := int16(binary.BigEndian.Uint16(data[2:]))
+	switch format {
+	case 1:
+		return BaseCoordFormat1(coordinate), nil
+	case 2:
+		if len(data) < 8 {
+			return nil, errors.New("invalid BASE coordinate table format 2 (EOF)")
+		}
+		return BaseCoordFormat2{
+			Value:          coordinate,
+			ReferenceGlyph: GID(binary.BigEndian.Uint16(data[4:])),
+			BaseCoordPoint: binary.BigEndian.Uint16(data[6:]),
+		}, nil
+	case 3:
+		if len(data) < 6 {
+			return nil, errors.New("invalid BASE coordinate table format 3 (EOF)")
+		}
+		out := BaseCoordFormat3{Value: coordinate}
+		if deviceOffset := binary.BigEndian.Uint16(data[4:]); deviceOffset != 0 {
+			var err error
+			out.Device, err = parseDeviceTable(data, deviceOffset)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BASE coordinate table format 3: %s", err)
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("invalid BASE coordinate table format: %d", format)
+	}
+}
@@ -0,0 +1,120 @@
+package truetype
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/truetype"
+)
+
+func TestParseBASE(t *testing.T) {
+	file, err := testdata.Files.ReadFile("NotoSerifCJK-Regular.ttc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fonts, err := NewFontParsers(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := fonts[0].BASETable(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reference values are taken from fonttools
+	expTags := []Tag{MustNewTag("icfb"), MustNewTag("icft"), MustNewTag("ideo"), MustNewTag("romn")}
+	if !reflect.DeepEqual(base.Horizontal.BaselineTags, expTags) {
+		t.Fatalf("unexpected baseline tags %v", base.Horizontal.BaselineTags)
+	}
+	if !reflect.DeepEqual(base.Vertical.BaselineTags, expTags) {
+		t.Fatalf("unexpected baseline tags %v", base.Vertical.BaselineTags)
+	}
+	if L := len(base.Horizontal.Scripts); L != 7 {
+		t.Fatalf("expected 7 scripts, got %d", L)
+	}
+
+	latn := base.Horizontal.FindScript(MustNewTag("latn"))
+	if latn == nil || latn.DefaultBaselineIndex != 3 {
+		t.Fatalf("unexpected script record %v", latn)
+	}
+	expCoords := []BaseCoord{BaseCoordFormat1(-78), BaseCoordFormat1(838), BaseCoordFormat1(-120), BaseCoordFormat1(0)}
+	if !reflect.DeepEqual(latn.BaseCoords, expCoords) {
+		t.Fatalf("expected %v, got %v", expCoords, latn.BaseCoords)
+	}
+
+	// unknown scripts fallback to DFLT
+	arab := base.Horizontal.FindScript(MustNewTag("arab"))
+	if arab == nil || arab.Tag != tagDefaultScript || arab.DefaultBaselineIndex != 2 {
+		t.Fatalf("unexpected script record %v", arab)
+	}
+
+	if c := base.Vertical.Baseline(MustNewTag("icft"), MustNewTag("hani")); c == nil || c.Coordinate() != 958 {
+		t.Fatalf("unexpected baseline %v", c)
+	}
+	if c := base.Vertical.Baseline(MustNewTag("hang"), MustNewTag("hani")); c != nil {
+		t.Fatalf("unexpected baseline %v", c)
+	}
+	if _, _, ok := base.Horizontal.MinMax(MustNewTag("latn"), MustNewTag("ENG "), 0); ok {
+		t.Fatal("unexpected min max")
+	}
+}
+
+func TestParseBASEMinMax(t *testing.T) {
+	// build a BASE table with one horizontal axis,
+	// one script with two baselines and min/max extents
+	u16 := func(v uint16) []byte { return []byte{byte(v >> 8), byte(v)} }
+	var data []byte
+	add := func(vs ...interface{}) {
+		for _, v := range vs {
+			switch v := v.(type) {
+			case int:
+				data = append(data, u16(uint16(v))...)
+			case string:
+				data = append(data, v...)
+			}
+		}
+	}
+	add(1, 0, 8, 0)               // header, horizontal axis at 8
+	add(4, 14)                    // axis: tag list at 12, script list at 22
+	add(2, "hang", "romn")        // tag list
+	add(1, "deva", 8)             // script list: script at 30
+	add(12, 28, 1, "HIN ", 46)    // script: values at 42, min max at 58 and 76
+	add(0, 2, 8, 12)              // values: coordinates at 50 and 54
+	add(1, 600)                   // coordinate
+	add(1, 0)                     // coordinate
+	add(14, 0, 1, "abvm", 14, 14) // default min max: coordinate at 72
+	add(1, -200)                  // coordinate
+	add(6, 6, 0)                  // language min max: coordinate at 82
+	add(3, 800, 0)                // coordinate with no device
+
+	base, err := parseTableBASE(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !base.Vertical.IsEmpty() {
+		t.Fatal("expected empty vertical axis")
+	}
+	deva, hin, abvm := MustNewTag("deva"), MustNewTag("HIN "), MustNewTag("abvm")
+	if c := base.Horizontal.Baseline(MustNewTag("hang"), deva); c != BaseCoordFormat1(600) {
+		t.Fatalf("unexpected baseline %v", c)
+	}
+	if c := base.Horizontal.Baseline(MustNewTag("romn"), deva); c != BaseCoordFormat1(0) {
+		t.Fatalf("unexpected baseline %v", c)
+	}
+
+	min, max, ok := base.Horizontal.MinMax(deva, 0, 0)
+	if !ok || min != BaseCoordFormat1(-200) || max != nil {
+		t.Fatalf("unexpected default min max %v %v", min, max)
+	}
+	min, max, ok = base.Horizontal.MinMax(deva, 0, abvm)
+	if !ok || min != BaseCoordFormat1(-200) || max != BaseCoordFormat1(-200) {
+		t.Fatalf("unexpected feature min max %v %v", min, max)
+	}
+	min, max, ok = base.Horizontal.MinMax(deva, hin, abvm)
+	exp := BaseCoordFormat3{Value: 800}
+	if !ok || min != exp || max != exp {
+		t.Fatalf("unexpected language min max %v %v", min, max)
+	}
+}
@@ -101,6 +101,23 @@ func (t unicodeVariations) getGlyphVariant(r, selector rune) (GID, uint8) {
 	return 0, variantNotFound
 }
 
+var (
+	_ fonts.CmapRuneRanger = cmap4(nil)
+	_ fonts.CmapRuneRanger = cmap6or10{}
+	_ fonts.CmapRuneRanger = cmap12(nil)
+	_ fonts.CmapRuneRanger = cmap13(nil)
+)
+
+// appends the range [start, end] to `ranges`, merging it
+// with the last range if they are contiguous
+func appendRuneRange(ranges [][2]rune, start, end rune) [][2]rune {
+	if L := len(ranges); L != 0 && ranges[L-1][1]+1 == start {
+		ranges[L-1][1] = end
+		return ranges
+	}
+	return append(ranges, [2]rune{start, end})
+}
+
 type cmap0 = fonts.CmapSimple
 
 type cmap4 []cmapEntry16
@@ -163,18 +180,66 @@ func (s cmap4) Lookup(r rune) (GID, bool) {
 		} else if entry.end < c {
 			i = h + 1
 		} else if entry.indexes == nil {
-			return GID(c + entry.delta), true
+			glyph := c + entry.delta
+			return GID(glyph), glyph != 0
 		} else {
 			glyph := entry.indexes[c-entry.start]
 			if glyph == 0 {
 				return 0, false
 			}
-			return GID(glyph + entry.delta), true
+			glyph += entry.delta
+			return GID(glyph), glyph != 0
 		}
 	}
 	return 0, false
 }
 
+// returns true for the mandatory last segment, usually mapping 0xFFFF to
+// the missing glyph
+func (entry *cmapEntry16) isTerminator() bool { return entry.start == 0xFFFF }
+
+// RuneRanges implements fonts.CmapRuneRanger. As harfbuzz does when
+// collecting the runes of a font, the terminating 0xFFFF segment is ignored.
+func (s cmap4) RuneRanges(buf [][2]rune) [][2]rune {
+	for i := range s {
+		entry := &s[i]
+		if entry.isTerminator() {
+			continue
+		}
+		start, end := rune(entry.start), rune(entry.end)
+		if entry.indexes == nil {
+			// the rune resolving to glyph 0 is not mapped
+			if zero := rune(-entry.delta); start <= zero && zero <= end {
+				if start < zero {
+					buf = appendRuneRange(buf, start, zero-1)
+				}
+				if zero < end {
+					buf = appendRuneRange(buf, zero+1, end)
+				}
+			} else {
+				buf = appendRuneRange(buf, start, end)
+			}
+			continue
+		}
+		// zero indexes (or glyphs) are not mapped
+		isMapped := func(j int) bool {
+			glyph := entry.indexes[j]
+			return glyph != 0 && glyph+entry.delta != 0
+		}
+		for j := 0; j < len(entry.indexes); j++ {
+			if !isMapped(j) {
+				continue
+			}
+			k := j + 1
+			for ; k < len(entry.indexes) && isMapped(k); k++ {
+			}
+			buf = appendRuneRange(buf, start+rune(j), start+rune(k-1))
+			j = k
+		}
+	}
+	return buf
+}
+
 type cmap6or10 struct {
 	entries   []uint16
 	firstCode rune
@@ -212,6 +277,13 @@ func (s cmap6or10) Lookup(r rune) (GID, bool) {
 	return GID(s.entries[c]), true
 }
 
+func (s cmap6or10) RuneRanges(buf [][2]rune) [][2]rune {
+	if len(s.entries) == 0 {
+		return buf
+	}
+	return appendRuneRange(buf, s.firstCode, s.firstCode+rune(len(s.entries))-1)
+}
+
 type cmap12 []cmapEntry32
 
 type cmap12Iter struct {
@@ -256,6 +328,13 @@ func (s cmap12) Lookup(r rune) (GID, bool) {
 	return 0, false
 }
 
+func (s cmap12) RuneRanges(buf [][2]rune) [][2]rune {
+	for _, entry := range s {
+		buf = appendRuneRange(buf, rune(entry.start), rune(entry.end))
+	}
+	return buf
+}
+
 type cmap13 []cmapEntry32
 
 type cmap13Iter struct {
@@ -302,6 +381,13 @@ func (s cmap13) Lookup(r rune) (GID, bool) {
 	return 0, false
 }
 
+func (s cmap13) RuneRanges(buf [][2]rune) [][2]rune {
+	for _, entry := range s {
+		buf = appendRuneRange(buf, rune(entry.start), rune(entry.end))
+	}
+	return buf
+}
+
 // CmapID groups the platform and encoding of a Cmap subtable.
 type CmapID struct {
 	Platform PlatformID
@@ -90,6 +90,38 @@ func testCm(t *testing.T, cmap Cmap) {
 	if !reflect.DeepEqual(all, all2) {
 		t.Errorf("inconsistant compile functions for type %T", cmap)
 	}
+
+	if ranger, ok := cmap.(fonts.CmapRuneRanger); ok {
+		testRuneRanges(t, cmap, all, ranger.RuneRanges(nil))
+	}
+}
+
+// checks that `ranges` contains exactly the runes mapped by `cmap`
+func testRuneRanges(t *testing.T, cmap Cmap, all map[rune]GID, ranges [][2]rune) {
+	count := 0
+	for i, ra := range ranges {
+		if ra[0] > ra[1] || (i != 0 && ranges[i-1][1]+1 >= ra[0]) {
+			t.Fatalf("invalid ranges for type %T: %v", cmap, ranges)
+		}
+		for r := ra[0]; r <= ra[1]; r++ {
+			if _, ok := cmap.Lookup(r); !ok {
+				t.Fatalf("rune %d in ranges is not mapped for type %T", r, cmap)
+			}
+			count++
+		}
+	}
+	expected := 0
+	for r := range all {
+		if _, isCmap4 := cmap.(cmap4); isCmap4 && r == 0xFFFF { // terminating segment
+			continue
+		}
+		if _, ok := cmap.Lookup(r); ok {
+			expected++
+		}
+	}
+	if count != expected {
+		t.Fatalf("expected %d runes in ranges for type %T, got %d", expected, cmap, count)
+	}
 }
 
 func TestCmap(t *testing.T) {
@@ -269,3 +301,31 @@ func TestCmap12(t *testing.T) {
 		}
 	}
 }
+
+func TestCmap4RuneRanges(t *testing.T) {
+	cmap := cmap4{
+		{start: 'a', end: 'e', delta: uint16(0x10000 - 'c')},           // 'c' is mapped to glyph 0
+		{start: 'x', end: 'z', indexes: []gid{5, 0, 0xFFFF}, delta: 1}, // 'y' and 'z' are not mapped
+		{start: 0xFFFF, end: 0xFFFF, delta: 1},
+	}
+	for _, r := range []rune{'c', 'y', 'z', 0xFFFF} {
+		if _, ok := cmap.Lookup(r); ok {
+			t.Fatalf("rune %d should not be mapped", r)
+		}
+	}
+	if gid, ok := cmap.Lookup('d'); !ok || gid != 1 {
+		t.Fatalf("unexpected glyph %d", gid)
+	}
+	if exp, got := [][2]rune{{'a', 'b'}, {'d', 'e'}, {'x', 'x'}}, cmap.RuneRanges(nil); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+
+	// the terminating segment is ignored, even if mapped
+	cmap[2].delta = 0xFFFF
+	if gid, ok := cmap.Lookup(0xFFFF); !ok || gid != 0xFFFE {
+		t.Fatalf("unexpected glyph %d", gid)
+	}
+	if got := cmap.RuneRanges(nil); len(got) != 3 {
+		t.Fatalf("unexpected ranges %v", got)
+	}
+}
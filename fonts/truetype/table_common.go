@@ -229,6 +229,26 @@ func (cl CoverageList) Index(gi GID) (int, bool) {
 
 func (cl CoverageList) Size() int { return len(cl) }
 
+// returns the glyphs covered, sorted by coverage index
+func coverageGlyphs(cov Coverage) []GID {
+	switch cov := cov.(type) {
+	case CoverageList:
+		return cov
+	case CoverageRanges:
+		out := make([]GID, cov.Size())
+		for _, r := range cov {
+			for g := r.Start; g <= r.End; g++ {
+				if index := r.StartCoverage + int(g-r.Start); index < len(out) {
+					out[index] = g
+				}
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
 // func (cl coverageList) maxIndex() int { return len(cl) - 1 }
 
 func fetchCoverageList(buf []byte) (CoverageList, error) {
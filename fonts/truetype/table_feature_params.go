@@ -0,0 +1,136 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	tagSize            = MustNewTag("size")
+	tagDefaultLanguage = MustNewTag("dflt")
+)
+
+// FeatureParams is the optional data attached to some features.
+// It is either FeatureParamsSize, FeatureParamsStylisticSet or
+// FeatureParamsCharacterVariants.
+// See https://docs.microsoft.com/en-us/typography/opentype/spec/chapter2#featureparams
+type FeatureParams interface {
+	isFeatureParams()
+}
+
+func (FeatureParamsSize) isFeatureParams()              {}
+func (FeatureParamsStylisticSet) isFeatureParams()      {}
+func (FeatureParamsCharacterVariants) isFeatureParams() {}
+
+// FeatureParamsSize is used by the 'size' feature.
+// Sizes are expressed in decipoints.
+type FeatureParamsSize struct {
+	DesignSize uint16
+	// SubfamilyID identifies the fonts of a family sharing
+	// the same design but not the same size range.
+	SubfamilyID     uint16
+	SubfamilyNameID NameID
+	RangeStart      uint16 // exclusive
+	RangeEnd        uint16 // inclusive
+}
+
+// FeatureParamsStylisticSet is used by the 'ss01' to 'ss20' features.
+type FeatureParamsStylisticSet struct {
+	// UINameID is the 'name' table entry
+	// giving the user interface string for the feature.
+	UINameID NameID
+}
+
+// FeatureParamsCharacterVariants is used by the 'cv01' to 'cv99' features.
+// Each name ID is optional, a zero value meaning absent.
+type FeatureParamsCharacterVariants struct {
+	// Characters lists the Unicode code points for which the
+	// feature provides glyph variants.
+	Characters              []rune
+	FeatUILabelNameID       NameID
+	FeatUITooltipTextNameID NameID
+	SampleTextNameID        NameID
+	// NumNamedParameters is the number of named parameters, that is,
+	// the number of variants having a name, starting at `FirstParamUILabelNameID`.
+	NumNamedParameters      uint16
+	FirstParamUILabelNameID NameID
+}
+
+func isStylisticSet(tag Tag) bool {
+	return byte(tag>>24) == 's' && byte(tag>>16) == 's' && isDigit(byte(tag>>8)) && isDigit(byte(tag))
+}
+
+func isCharacterVariant(tag Tag) bool {
+	return byte(tag>>24) == 'c' && byte(tag>>16) == 'v' && isDigit(byte(tag>>8)) && isDigit(byte(tag))
+}
+
+func isDigit(b byte) bool { return '0' <= b && b <= '9' }
+
+// `data` starts at the base of `offset`; it returns a nil
+// FeatureParams for unsupported features
+func parseFeatureParams(data []byte, offset uint16, tag Tag) (FeatureParams, error) {
+	if len(data) < int(offset) {
+		return nil, errors.New("invalid feature params (EOF)")
+	}
+	data = data[offset:]
+	switch {
+	case tag == tagSize:
+		return parseFeatureParamsSize(data)
+	case isStylisticSet(tag):
+		if len(data) < 4 {
+			return nil, errors.New("invalid stylistic set feature params (EOF)")
+		}
+		return FeatureParamsStylisticSet{UINameID: NameID(binary.BigEndian.Uint16(data[2:]))}, nil
+	case isCharacterVariant(tag):
+		return parseFeatureParamsCharacterVariants(data)
+	default:
+		return nil, nil
+	}
+}
+
+func parseFeatureParamsSize(data []byte) (FeatureParams, error) {
+	if len(data) < 10 {
+		return nil, errors.New("invalid size feature params (EOF)")
+	}
+	out := FeatureParamsSize{
+		DesignSize:      binary.BigEndian.Uint16(data),
+		SubfamilyID:     binary.BigEndian.Uint16(data[2:]),
+		SubfamilyNameID: NameID(binary.BigEndian.Uint16(data[4:])),
+		RangeStart:      binary.BigEndian.Uint16(data[6:]),
+		RangeEnd:        binary.BigEndian.Uint16(data[8:]),
+	}
+	// see the sanity checks of the specification
+	if out.DesignSize == 0 {
+		return nil, errors.New("invalid size feature params (null design size)")
+	}
+	if out.SubfamilyID == 0 && out.SubfamilyNameID == 0 && out.RangeStart == 0 && out.RangeEnd == 0 {
+		return out, nil
+	}
+	if out.DesignSize < out.RangeStart || out.DesignSize > out.RangeEnd ||
+		out.SubfamilyNameID < 256 || out.SubfamilyNameID > 32767 {
+		return nil, errors.New("invalid size feature params")
+	}
+	return out, nil
+}
+
+func parseFeatureParamsCharacterVariants(data []byte) (FeatureParams, error) {
+	if len(data) < 14 {
+		return nil, errors.New("invalid character variants feature params (EOF)")
+	}
+	out := FeatureParamsCharacterVariants{
+		FeatUILabelNameID:       NameID(binary.BigEndian.Uint16(data[2:])),
+		FeatUITooltipTextNameID: NameID(binary.BigEndian.Uint16(data[4:])),
+		SampleTextNameID:        NameID(binary.BigEndian.Uint16(data[6:])),
+		NumNamedParameters:      binary.BigEndian.Uint16(data[8:]),
+		FirstParamUILabelNameID: NameID(binary.BigEndian.Uint16(data[10:])),
+	}
+	count := int(binary.BigEndian.Uint16(data[12:]))
+	if len(data) < 14+3*count {
+		return nil, errors.New("invalid character variants feature params (EOF)")
+	}
+	out.Characters = make([]rune, count)
+	for i := range out.Characters {
+		out.Characters[i] = parseUint24(data[14+3*i:])
+	}
+	return out, nil
+}
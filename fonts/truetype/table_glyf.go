@@ -140,17 +140,20 @@ type simpleGlyphData struct {
 	points []glyphContourPoint
 }
 
-// return all the contour points, without phantoms
-func (sg simpleGlyphData) getContourPoints() []contourPoint {
-	points := make([]contourPoint, len(sg.points))
+// appends all the contour points, without phantoms, to `buffer`
+func (sg simpleGlyphData) getContourPoints(buffer []contourPoint) []contourPoint {
+	start := len(buffer)
+	for _, p := range sg.points {
+		buffer = append(buffer, contourPoint{
+			SegmentPoint: fonts.SegmentPoint{X: float32(p.x), Y: float32(p.y)},
+			isOnCurve:    p.flag&flagOnCurve != 0,
+		})
+	}
+	points := buffer[start:]
 	for _, end := range sg.endPtsOfContours {
 		points[end].isEndPoint = true
 	}
-	for i, p := range sg.points {
-		points[i].X, points[i].Y = float32(p.x), float32(p.y)
-		points[i].isOnCurve = p.flag&flagOnCurve != 0
-	}
-	return points
+	return buffer
 }
 
 // returns the position after the read and the relative coordinate
@@ -0,0 +1,129 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	tagFpgm = MustNewTag("fpgm")
+	tagCvt  = MustNewTag("cvt ")
+	tagCvar = MustNewTag("cvar")
+)
+
+// hintingTables stores the tables used by the
+// TrueType bytecode interpreter.
+type hintingTables struct {
+	fpgm, prep []byte
+	cvt        []int16            // in font units
+	cvar       glyphVariationData // optional
+	maxp       maxpProfile
+}
+
+// maxpProfile is the version 1.0 part of the 'maxp' table,
+// used to size the interpreter memory
+type maxpProfile struct {
+	maxZones              uint16
+	maxTwilightPoints     uint16
+	maxStorage            uint16
+	maxFunctionDefs       uint16
+	maxInstructionDefs    uint16
+	maxStackElements      uint16
+	maxSizeOfInstructions uint16
+}
+
+func parseMaxpProfile(data []byte) (maxpProfile, error) {
+	if len(data) < 32 {
+		return maxpProfile{}, errors.New("invalid 'maxp' table profile (EOF)")
+	}
+	if version := binary.BigEndian.Uint32(data); version != 0x00010000 {
+		return maxpProfile{}, errors.New("unsupported 'maxp' table version")
+	}
+	return maxpProfile{
+		maxZones:              binary.BigEndian.Uint16(data[14:]),
+		maxTwilightPoints:     binary.BigEndian.Uint16(data[16:]),
+		maxStorage:            binary.BigEndian.Uint16(data[18:]),
+		maxFunctionDefs:       binary.BigEndian.Uint16(data[20:]),
+		maxInstructionDefs:    binary.BigEndian.Uint16(data[22:]),
+		maxStackElements:      binary.BigEndian.Uint16(data[24:]),
+		maxSizeOfInstructions: binary.BigEndian.Uint16(data[26:]),
+	}, nil
+}
+
+func parseTableCvt(data []byte) []int16 {
+	out := make([]int16, len(data)/2)
+	for i := range out {
+		out[i] = int16(binary.BigEndian.Uint16(data[2*i:]))
+	}
+	return out
+}
+
+func parseTableCvar(data []byte, axisCount, cvtCount int) (glyphVariationData, error) {
+	if len(data) < 4 {
+		return nil, errors.New("invalid 'cvar' table (EOF)")
+	}
+	if major := binary.BigEndian.Uint16(data); major != 1 {
+		return nil, errors.New("unsupported 'cvar' table version")
+	}
+	return parseOneGlyphVariationData(data, 0, true, axisCount, cvtCount)
+}
+
+// hintingTables loads the tables required for hinting.
+// The font must have TrueType outlines and a 'maxp' table version 1.0;
+// the 'fpgm', 'prep', 'cvt ' and 'cvar' tables are optional.
+func (pr *FontParser) hintingTables(fvar TableFvar) (out hintingTables, err error) {
+	buf, err := pr.GetRawTable(tagMaxp)
+	if err != nil {
+		return out, err
+	}
+	out.maxp, err = parseMaxpProfile(buf)
+	if err != nil {
+		return out, err
+	}
+
+	out.fpgm, _ = pr.GetRawTable(tagFpgm)
+	out.prep, _ = pr.GetRawTable(TagPrep)
+	if buf, err := pr.GetRawTable(tagCvt); err == nil {
+		out.cvt = parseTableCvt(buf)
+	}
+	if len(fvar.Axis) != 0 {
+		if buf, err := pr.GetRawTable(tagCvar); err == nil {
+			out.cvar, err = parseTableCvar(buf, len(fvar.Axis), len(out.cvt))
+			if err != nil {
+				return out, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// variedCvt returns the CVT values, in font units,
+// adjusted for the given normalized coordinates
+func (ht *hintingTables) variedCvt(coords []float32) []float32 {
+	out := make([]float32, len(ht.cvt))
+	for i, v := range ht.cvt {
+		out[i] = float32(v)
+	}
+	if len(coords) == 0 {
+		return out
+	}
+	for _, tuple := range ht.cvar {
+		scalar := tuple.calculateScalar(coords, nil)
+		if scalar == 0 {
+			continue
+		}
+		for i, delta := range tuple.deltas {
+			index := i
+			if tuple.pointNumbers != nil {
+				if i >= len(tuple.pointNumbers) {
+					break
+				}
+				index = int(tuple.pointNumbers[i])
+			}
+			if index < len(out) {
+				out[index] += float32(delta) * scalar
+			}
+		}
+	}
+	return out
+}
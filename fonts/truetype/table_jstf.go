@@ -0,0 +1,279 @@
+package truetype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// TableJSTF is the Justification table, which provides the lookups
+// used to shrink or extend the width of a line of text.
+// See https://docs.microsoft.com/en-us/typography/opentype/spec/jstf
+type TableJSTF struct {
+	// Scripts is sorted by tag.
+	Scripts []JstfScript
+}
+
+// FindScript looks for `script` and returns the corresponding record,
+// or nil if not found.
+func (t TableJSTF) FindScript(script Tag) *JstfScript {
+	// Scripts is sorted: binary search
+	low, high := 0, len(t.Scripts)
+	for low < high {
+		mid := low + (high-low)/2 // avoid overflow when computing mid
+		p := t.Scripts[mid].Tag
+		if script < p {
+			high = mid
+		} else if script > p {
+			low = mid + 1
+		} else {
+			return &t.Scripts[mid]
+		}
+	}
+	return nil
+}
+
+// JstfScript stores the justification data for one script.
+type JstfScript struct {
+	// ExtenderGlyphs lists the glyphs, sorted in ascending order, which
+	// may be inserted to extend a line (such as the Arabic kashida).
+	ExtenderGlyphs []GID
+	// DefaultLanguage is used when no specific language
+	// record matches. Its Tag is zero and it may be empty.
+	DefaultLanguage JstfLangSys
+	// Languages is sorted by tag.
+	Languages []JstfLangSys
+	Tag       Tag
+}
+
+// IsExtender returns `true` if `glyph` is one of the extender glyphs.
+func (js JstfScript) IsExtender(glyph GID) bool {
+	// ExtenderGlyphs is sorted: binary search
+	low, high := 0, len(js.ExtenderGlyphs)
+	for low < high {
+		mid := low + (high-low)/2 // avoid overflow when computing mid
+		p := js.ExtenderGlyphs[mid]
+		if glyph < p {
+			high = mid
+		} else if glyph > p {
+			low = mid + 1
+		} else {
+			return true
+		}
+	}
+	return false
+}
+
+// FindLanguage returns the justification data for `language`,
+// defaulting to `DefaultLanguage`.
+func (js JstfScript) FindLanguage(language Tag) JstfLangSys {
+	// Languages is sorted: binary search
+	low, high := 0, len(js.Languages)
+	for low < high {
+		mid := low + (high-low)/2 // avoid overflow when computing mid
+		p := js.Languages[mid].Tag
+		if language < p {
+			high = mid
+		} else if language > p {
+			low = mid + 1
+		} else {
+			return js.Languages[mid]
+		}
+	}
+	return js.DefaultLanguage
+}
+
+// JstfLangSys stores the justification suggestions for one language,
+// by order of preference.
+type JstfLangSys struct {
+	Priorities []JstfPriority
+	Tag        Tag
+}
+
+// JstfPriority is one justification suggestion.
+// The GSUB and GPOS slices store indices into the lookup list of the
+// corresponding table (which must be enabled or disabled), whereas the
+// Max slices store lookups private to the JSTF table, giving the maximum
+// shrinkage or extension allowed.
+type JstfPriority struct {
+	ShrinkageEnableGSUB  []uint16
+	ShrinkageDisableGSUB []uint16
+	ShrinkageEnableGPOS  []uint16
+	ShrinkageDisableGPOS []uint16
+	ShrinkageMax         []LookupGPOS
+
+	ExtensionEnableGSUB  []uint16
+	ExtensionDisableGSUB []uint16
+	ExtensionEnableGPOS  []uint16
+	ExtensionDisableGPOS []uint16
+	ExtensionMax         []LookupGPOS
+}
+
+func parseTableJSTF(data []byte) (out TableJSTF, err error) {
+	if len(data) < 6 {
+		return out, errors.New("invalid JSTF table (EOF)")
+	}
+	major := binary.BigEndian.Uint16(data)
+	minor := binary.BigEndian.Uint16(data[2:])
+	if major != 1 {
+		return out, fmt.Errorf("unsupported JSTF table version %d.%d", major, minor)
+	}
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 6+6*count {
+		return out, errors.New("invalid JSTF table (EOF)")
+	}
+	out.Scripts = make([]JstfScript, count)
+	for i := range out.Scripts {
+		out.Scripts[i].Tag = Tag(binary.BigEndian.Uint32(data[6+6*i:]))
+		offset := binary.BigEndian.Uint16(data[6+6*i+4:])
+		if err = out.Scripts[i].parse(data, offset); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+func (js *JstfScript) parse(data []byte, offset uint16) error {
+	if len(data) < int(offset)+6 {
+		return errors.New("invalid JSTF script table (EOF)")
+	}
+	data = data[offset:]
+	extenderOffset := binary.BigEndian.Uint16(data)
+	defaultOffset := binary.BigEndian.Uint16(data[2:])
+	count := int(binary.BigEndian.Uint16(data[4:]))
+
+	if extenderOffset != 0 {
+		if len(data) < int(extenderOffset)+2 {
+			return errors.New("invalid JSTF extender glyphs table (EOF)")
+		}
+		glyphCount := int(binary.BigEndian.Uint16(data[extenderOffset:]))
+		glyphs, err := parseUint16s(data[extenderOffset+2:], glyphCount)
+		if err != nil {
+			return err
+		}
+		js.ExtenderGlyphs = make([]GID, glyphCount)
+		for i, g := range glyphs {
+			js.ExtenderGlyphs[i] = GID(g)
+		}
+	}
+
+	var err error
+	if defaultOffset != 0 {
+		js.DefaultLanguage, err = parseJstfLangSys(data, defaultOffset)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(data) < 6+6*count {
+		return errors.New("invalid JSTF script table (EOF)")
+	}
+	js.Languages = make([]JstfLangSys, count)
+	for i := range js.Languages {
+		tag := Tag(binary.BigEndian.Uint32(data[6+6*i:]))
+		offset := binary.BigEndian.Uint16(data[6+6*i+4:])
+		js.Languages[i], err = parseJstfLangSys(data, offset)
+		if err != nil {
+			return err
+		}
+		js.Languages[i].Tag = tag
+	}
+	return nil
+}
+
+func parseJstfLangSys(data []byte, offset uint16) (out JstfLangSys, err error) {
+	if len(data) < int(offset)+2 {
+		return out, errors.New("invalid JSTF language table (EOF)")
+	}
+	data = data[offset:]
+	count := int(binary.BigEndian.Uint16(data))
+	offsets, err := parseUint16s(data[2:], count)
+	if err != nil {
+		return out, err
+	}
+	out.Priorities = make([]JstfPriority, count)
+	for i, offset := range offsets {
+		out.Priorities[i], err = parseJstfPriority(data, offset)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+func parseJstfPriority(data []byte, offset uint16) (out JstfPriority, err error) {
+	if len(data) < int(offset)+20 {
+		return out, errors.New("invalid JSTF priority table (EOF)")
+	}
+	data = data[offset:]
+	var offsets [10]uint16
+	for i := range offsets {
+		offsets[i] = binary.BigEndian.Uint16(data[2*i:])
+	}
+	modLists := [...]*[]uint16{
+		0: &out.ShrinkageEnableGSUB,
+		1: &out.ShrinkageDisableGSUB,
+		2: &out.ShrinkageEnableGPOS,
+		3: &out.ShrinkageDisableGPOS,
+		5: &out.ExtensionEnableGSUB,
+		6: &out.ExtensionDisableGSUB,
+		7: &out.ExtensionEnableGPOS,
+		8: &out.ExtensionDisableGPOS,
+	}
+	for i, list := range modLists {
+		if list == nil || offsets[i] == 0 {
+			continue
+		}
+		*list, err = parseJstfModList(data, offsets[i])
+		if err != nil {
+			return out, err
+		}
+	}
+	if offsets[4] != 0 {
+		out.ShrinkageMax, err = parseJstfMax(data, offsets[4])
+		if err != nil {
+			return out, err
+		}
+	}
+	if offsets[9] != 0 {
+		out.ExtensionMax, err = parseJstfMax(data, offsets[9])
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// returns a list of lookup indices
+func parseJstfModList(data []byte, offset uint16) ([]uint16, error) {
+	if len(data) < int(offset)+2 {
+		return nil, errors.New("invalid JSTF modification list (EOF)")
+	}
+	data = data[offset:]
+	count := int(binary.BigEndian.Uint16(data))
+	return parseUint16s(data[2:], count)
+}
+
+func parseJstfMax(data []byte, offset uint16) ([]LookupGPOS, error) {
+	if len(data) < int(offset)+2 {
+		return nil, errors.New("invalid JSTF max table (EOF)")
+	}
+	data = data[offset:]
+	count := int(binary.BigEndian.Uint16(data))
+	offsets, err := parseUint16s(data[2:], count)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]LookupGPOS, count)
+	for i, offset := range offsets {
+		header, err := parseLookup(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		out[i], err = header.parseGPOS(uint16(count))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package truetype
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSTF(t *testing.T) {
+	// build a JSTF table with one script, with extender glyphs,
+	// a default language with one priority, and an empty language
+	u16 := func(v uint16) []byte { return []byte{byte(v >> 8), byte(v)} }
+	var data []byte
+	add := func(vs ...interface{}) {
+		for _, v := range vs {
+			switch v := v.(type) {
+			case int:
+				data = append(data, u16(uint16(v))...)
+			case string:
+				data = append(data, v...)
+			}
+		}
+	}
+	add(1, 0, 1, "arab", 12)            // header, script at 12
+	add(12, 18, 1, "URD ", 74)          // script: extenders at 24, default at 30, language at 86
+	add(2, 5, 9)                        // extender glyphs
+	add(1, 4)                           // default language: priority at 34
+	add(0, 0, 0, 0, 0, 0, 0, 20, 0, 26) // priority: GPOS list at 54, max at 60
+	add(2, 3, 4)                        // GPOS extension enable list
+	add(1, 4)                           // max: lookup at 64
+	add(1, 0, 1, 8)                     // lookup: single positioning, subtable at 72
+	add(1, 8, 4, 100)                   // subtable: coverage at 80, X advance
+	add(1, 1, 5)                        // coverage
+	add(0)                              // language, no priority
+
+	jstf, err := parseTableJSTF(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jstf.FindScript(MustNewTag("latn")) != nil {
+		t.Fatal("unexpected script")
+	}
+	arab := jstf.FindScript(MustNewTag("arab"))
+	if arab == nil {
+		t.Fatal("missing script")
+	}
+	if !arab.IsExtender(5) || !arab.IsExtender(9) || arab.IsExtender(6) {
+		t.Fatalf("unexpected extender glyphs %v", arab.ExtenderGlyphs)
+	}
+
+	if lang := arab.FindLanguage(MustNewTag("URD ")); lang.Tag != MustNewTag("URD ") || len(lang.Priorities) != 0 {
+		t.Fatalf("unexpected language %v", lang)
+	}
+	lang := arab.FindLanguage(MustNewTag("FAR "))
+	if len(lang.Priorities) != 1 {
+		t.Fatalf("unexpected default language %v", lang)
+	}
+	prio := lang.Priorities[0]
+	if !reflect.DeepEqual(prio.ExtensionEnableGPOS, []uint16{3, 4}) || prio.ShrinkageEnableGSUB != nil {
+		t.Fatalf("unexpected priority %v", prio)
+	}
+	if len(prio.ExtensionMax) != 1 || prio.ShrinkageMax != nil {
+		t.Fatalf("unexpected priority %v", prio)
+	}
+	lookup := prio.ExtensionMax[0]
+	if lookup.Type != GPOSSingle || len(lookup.Subtables) != 1 {
+		t.Fatalf("unexpected lookup %v", lookup)
+	}
+	single, ok := lookup.Subtables[0].Data.(GPOSSingle1)
+	if !ok || single.Value.XAdvance != 100 {
+		t.Fatalf("unexpected subtable %v", lookup.Subtables[0].Data)
+	}
+}
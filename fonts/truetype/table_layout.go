@@ -133,8 +133,10 @@ type FeatureRecord struct {
 
 // Feature represents a glyph substitution or glyph positioning features.
 type Feature struct {
+	// Params is only parsed for the 'size', 'ssXX' and 'cvXX' features,
+	// and is nil if absent or invalid.
+	Params        FeatureParams
 	LookupIndices []uint16
-	paramsOffet   uint16
 }
 
 type LookupOptions struct {
@@ -336,8 +338,9 @@ func (t *TableLayout) parseScriptList(buf []byte) error {
 }
 
 // parseFeature parses a single Feature table. b expected to be the beginning of the feature
+// `tag` is used to interpret the feature parameters.
 // See https://www.microsoft.com/typography/otspec/chapter2.htm#featTbl
-func parseFeature(b []byte) (Feature, error) {
+func parseFeature(b []byte, tag Tag) (Feature, error) {
 	r := bytes.NewReader(b)
 
 	var feature struct {
@@ -353,7 +356,12 @@ func parseFeature(b []byte) (Feature, error) {
 		return Feature{}, fmt.Errorf("reading featureTable: %s", err)
 	}
 
-	return Feature{paramsOffet: feature.FeatureParams, LookupIndices: lookupIndices}, nil
+	out := Feature{LookupIndices: lookupIndices}
+	if feature.FeatureParams != 0 {
+		// invalid parameters are ignored
+		out.Params, _ = parseFeatureParams(b, feature.FeatureParams, tag)
+	}
+	return out, nil
 }
 
 // parseFeatureList parses the FeatureList.
@@ -382,10 +390,16 @@ func (t *TableLayout) parseFeatureList(buf []byte) error {
 		if len(b) < int(record.Offset) {
 			return io.ErrUnexpectedEOF
 		}
-		feature, err := parseFeature(b[record.Offset:])
+		feature, err := parseFeature(b[record.Offset:], record.Tag)
 		if err != nil {
 			return err
 		}
+		if record.Tag == tagSize && feature.Params == nil {
+			// some old fonts have the offset relative to the FeatureList
+			if paramsOffset := binary.BigEndian.Uint16(b[record.Offset:]); paramsOffset != 0 {
+				feature.Params, _ = parseFeatureParams(b, paramsOffset, tagSize)
+			}
+		}
 
 		t.Features[i] = FeatureRecord{Tag: record.Tag, Feature: feature}
 	}
@@ -395,7 +409,7 @@ func (t *TableLayout) parseFeatureList(buf []byte) error {
 
 // parseLookup parses a single Lookup table. b expected to be the beginning of LookupList.
 // See https://www.microsoft.com/typography/otspec/chapter2.htm#featTbl
-func (t *TableLayout) parseLookup(b []byte, lookupTableOffset uint16) (lookup, error) {
+func parseLookup(b []byte, lookupTableOffset uint16) (lookup, error) {
 	if int(lookupTableOffset) >= len(b) {
 		return lookup{}, io.ErrUnexpectedEOF
 	}
@@ -460,7 +474,7 @@ func (t *TableLayout) parseLookupList(buf []byte) ([]lookup, error) {
 			return nil, fmt.Errorf("reading lookupRecord[%d]: %s", i, err)
 		}
 
-		l, err := t.parseLookup(b, lookupTableOffset)
+		l, err := parseLookup(b, lookupTableOffset)
 		if err != nil {
 			return nil, err
 		}
@@ -622,7 +636,7 @@ func parseFeatureSubstitution(buf []byte) ([]FeatureSubstitution, error) {
 			return nil, io.ErrUnexpectedEOF
 		}
 		var err error
-		out[i].AlternateFeature, err = parseFeature(buf[alternateFeatureOffset:])
+		out[i].AlternateFeature, err = parseFeature(buf[alternateFeatureOffset:], 0)
 		if err != nil {
 			return nil, err
 		}
@@ -4,6 +4,10 @@
 package type1
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/benoitkugler/textlayout/fonts"
@@ -76,3 +80,90 @@ func (f AFMFont) CharSet() string {
 	}
 	return v.String()
 }
+
+// afmMetrics stores the metrics found in an .afm file,
+// resolved against the glyphs of a Font
+type afmMetrics struct {
+	advances map[fonts.GID]int
+	kerns    map[[2]fonts.GID]int16
+
+	capHeight, xHeight  Fl
+	ascender, descender Fl
+}
+
+// AttachAFM completes the font with the metrics found in `afm`,
+// which should be the metric file associated to the font.
+// Glyphs are matched by name, and the metrics from the .afm file
+// take precedence over the ones found in the charstrings:
+// advances, font bounding box, ascender and descender, cap and x heights and
+// kerning pairs are then available.
+func (f *Font) AttachAFM(afm AFMFont) {
+	names := make(map[string]fonts.GID, len(f.charstrings))
+	for gid, cs := range f.charstrings {
+		names[cs.name] = fonts.GID(gid)
+	}
+
+	metrics := &afmMetrics{
+		advances:  make(map[fonts.GID]int, len(afm.CharMetrics)),
+		kerns:     make(map[[2]fonts.GID]int16),
+		capHeight: afm.CapHeight,
+		xHeight:   Fl(afm.XHeight),
+		ascender:  afm.Ascender,
+		descender: afm.Descender,
+	}
+	for name, cm := range afm.CharMetrics {
+		if gid, ok := names[name]; ok {
+			metrics.advances[gid] = cm.Width
+		}
+	}
+	for first, pairs := range afm.KernPairs {
+		left, ok := names[first]
+		if !ok {
+			continue
+		}
+		for _, pair := range pairs {
+			if right, ok := names[pair.SndChar]; ok && pair.KerningDistance != 0 {
+				metrics.kerns[[2]fonts.GID{left, right}] = int16(pair.KerningDistance)
+			}
+		}
+	}
+
+	if afm.Llx != 0 || afm.Lly != 0 || afm.Urx != 0 || afm.Ury != 0 {
+		f.FontBBox = []Fl{afm.Llx, afm.Lly, afm.Urx, afm.Ury}
+	}
+	f.afm = metrics
+}
+
+// AttachSiblingAFM loads the .afm file with the same base name than
+// the font file `path` (with an .afm or .AFM extension), and attaches it to the font.
+// An error is returned if no such file is found, or if it is invalid.
+func (f *Font) AttachSiblingAFM(path string) error {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range [...]string{".afm", ".AFM"} {
+		file, err := os.Open(base + ext)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		afm, err := ParseAFMFile(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("invalid .afm file: %s", err)
+		}
+		f.AttachAFM(afm)
+		return nil
+	}
+	return fmt.Errorf("no .afm file found for %s", path)
+}
+
+// KernPair returns the kerning value between the two glyphs,
+// or zero, as defined by the .afm file attached with `AttachAFM`.
+// The value is expressed in glyph units and
+// is negative when glyphs should be closer.
+func (f *Font) KernPair(left, right fonts.GID) int16 {
+	if f.afm == nil {
+		return 0
+	}
+	return f.afm.kerns[[2]fonts.GID{left, right}]
+}
@@ -0,0 +1,112 @@
+package type1
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/type1"
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+func TestAttachAFM(t *testing.T) {
+	b, err := testdata.Files.ReadFile("CalligrapherRegular.pfb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := font.LineMetric(fonts.CapHeight); ok {
+		t.Fatal("unexpected cap height without .afm file")
+	}
+	a, _ := font.NominalGlyph('A')
+	v, _ := font.NominalGlyph('V')
+	if font.KernPair(a, v) != 0 {
+		t.Fatal("unexpected kerning without .afm file")
+	}
+
+	f, err := testdata.Files.Open("Times-Bold.afm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	afm, err := ParseAFMFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font.AttachAFM(afm)
+
+	if k := font.KernPair(a, v); k != -145 {
+		t.Fatalf("expected kerning -145, got %d", k)
+	}
+	if adv := font.HorizontalAdvance(a); adv != 722 {
+		t.Fatalf("expected advance 722, got %f", adv)
+	}
+	if h, _ := font.LineMetric(fonts.CapHeight); h != 676 {
+		t.Fatalf("unexpected cap height %f", h)
+	}
+	if h, _ := font.LineMetric(fonts.XHeight); h != 461 {
+		t.Fatalf("unexpected x height %f", h)
+	}
+	if exp := []Fl{-168, -218, 1000, 935}; !equalFloats(font.FontBBox, exp) {
+		t.Fatalf("expected bbox %v, got %v", exp, font.FontBBox)
+	}
+	extents, _ := font.FontHExtents()
+	if extents.Ascender != 683 || extents.Descender != -217 {
+		t.Fatalf("unexpected extents %v", extents)
+	}
+}
+
+func equalFloats(a, b []Fl) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSiblingAFM(t *testing.T) {
+	dir := t.TempDir()
+	for src, dst := range map[string]string{
+		"CalligrapherRegular.pfb": "font.pfb",
+		"Times-Bold.afm":          "font.afm",
+	} {
+		b, err := testdata.Files.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, dst), b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(filepath.Join(dir, "font.pfb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	font, err := Parse(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := font.LineMetric(fonts.CapHeight); ok {
+		t.Fatal("the sibling .afm file should only be loaded on demand")
+	}
+	if err = font.AttachSiblingAFM(file.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := font.LineMetric(fonts.CapHeight); !ok {
+		t.Fatal("expected metrics from the sibling .afm file")
+	}
+
+	if err = font.AttachSiblingAFM(filepath.Join(dir, "other.pfb")); err == nil {
+		t.Fatal("expected error for missing .afm file")
+	}
+}
@@ -0,0 +1,218 @@
+package type1
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
+	type1c "github.com/benoitkugler/textlayout/fonts/type1C"
+)
+
+// maximum number of stems in Type2 charstrings
+const maxType2Stems = 96
+
+// maximum number of stems per operator, so that
+// the argument stack (limited to 48) is never exceeded,
+// even with the optional width
+const stemsPerOperator = 23
+
+// type2Writer builds a Type2 charstring
+type type2Writer []byte
+
+func (w *type2Writer) int(v int32) {
+	switch {
+	case -107 <= v && v <= 107:
+		*w = append(*w, byte(v+139))
+	case 108 <= v && v <= 1131:
+		v -= 108
+		*w = append(*w, byte(v>>8+247), byte(v))
+	case -1131 <= v && v <= -108:
+		v = -v - 108
+		*w = append(*w, byte(v>>8+251), byte(v))
+	case -32768 <= v && v <= 32767:
+		*w = append(*w, 28, byte(v>>8), byte(v))
+	default: // 16.16 fixed number
+		*w = append(*w, 255, byte(v>>8), byte(v), 0, 0)
+	}
+}
+
+func (w *type2Writer) op(op byte) { *w = append(*w, op) }
+
+// stems writes the stems, whose positions are relative
+// to the previous stem, splitting the operator if needed
+func (w *type2Writer) stems(stems []ps.StemHint, op byte) {
+	for start := 0; start < len(stems); start += stemsPerOperator {
+		end := start + stemsPerOperator
+		if end > len(stems) {
+			end = len(stems)
+		}
+		var edge int32
+		for _, stem := range stems[start:end] {
+			w.int(stem.Pos - edge)
+			w.int(stem.Width)
+			edge = stem.Pos + stem.Width
+		}
+		w.op(op)
+	}
+}
+
+// sortStems returns the stems sorted by position (as required by Type2 charstrings),
+// and the mapping from old to new indices.
+func sortStems(stems []ps.StemHint) ([]ps.StemHint, []int) {
+	order := make([]int, len(stems))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return stems[order[i]].Pos < stems[order[j]].Pos })
+	sorted, newIndices := make([]ps.StemHint, len(stems)), make([]int, len(stems))
+	for newIndex, index := range order {
+		sorted[newIndex] = stems[index]
+		newIndices[index] = newIndex
+	}
+	return sorted, newIndices
+}
+
+// type2Charstring converts the outlines and hints of a glyph to a Type2 charstring.
+// `width` is written only if it is not nil.
+func type2Charstring(segments []fonts.Segment, hints ps.GlyphHints, width *int32) []byte {
+	var w type2Writer
+	if width != nil {
+		w.int(*width)
+	}
+
+	if len(hints.HStems)+len(hints.VStems) > maxType2Stems {
+		hints = ps.GlyphHints{}
+	}
+	hStems, hIndices := sortStems(hints.HStems)
+	vStems, vIndices := sortStems(hints.VStems)
+	// hint replacement requires hintmask operators
+	useMasks := len(hints.HintMasks) > 1
+	if useMasks {
+		w.stems(hStems, 18) // hstemhm
+		w.stems(vStems, 23) // vstemhm
+	} else {
+		w.stems(hStems, 1) // hstem
+		w.stems(vStems, 3) // vstem
+	}
+
+	writeMask := func(mask ps.HintMask) {
+		bits := make([]byte, (len(hStems)+len(vStems)+7)/8)
+		for _, i := range mask.HStems {
+			i = hIndices[i]
+			bits[i/8] |= 0x80 >> (i % 8)
+		}
+		for _, i := range mask.VStems {
+			i = vIndices[i] + len(hStems)
+			bits[i/8] |= 0x80 >> (i % 8)
+		}
+		w.op(19) // hintmask
+		w = append(w, bits...)
+	}
+
+	var (
+		currentX, currentY int32
+		mask, written      = 0, -1 // the first mask also applies to the previous segments
+	)
+	for i, segment := range segments {
+		for mask+1 < len(hints.HintMasks) && hints.HintMasks[mask+1].SegmentIndex <= i {
+			mask++
+		}
+		if useMasks && mask != written {
+			writeMask(hints.HintMasks[mask])
+			written = mask
+		}
+
+		for _, pt := range segment.ArgsSlice() {
+			x, y := int32(math.Round(float64(pt.X))), int32(math.Round(float64(pt.Y)))
+			w.int(x - currentX)
+			w.int(y - currentY)
+			currentX, currentY = x, y
+		}
+		switch segment.Op {
+		case fonts.SegmentOpMoveTo:
+			w.op(21) // rmoveto
+		case fonts.SegmentOpLineTo:
+			w.op(5) // rlineto
+		case fonts.SegmentOpCubeTo:
+			w.op(8) // rrcurveto
+		}
+	}
+	w.op(14) // endchar
+	return w
+}
+
+// ToCFF converts the font to the CFF format, re-encoding its
+// charstrings (including the stem hints) as Type2 charstrings.
+// The glyph order is preserved, but an empty .notdef glyph is
+// added as first glyph if needed.
+// The advances are rounded to integers.
+func (f *Font) ToCFF() (*type1c.Font, error) {
+	type glyph struct {
+		name     string
+		segments []fonts.Segment
+		hints    ps.GlyphHints
+		width    int32
+	}
+	var glyphs []glyph
+	if len(f.charstrings) == 0 || f.charstrings[0].name != Notdef {
+		glyphs = append(glyphs, glyph{name: Notdef})
+	}
+	for gid, cs := range f.charstrings {
+		segments, hints, err := f.LoadGlyphHints(fonts.GID(gid))
+		if err != nil {
+			return nil, fmt.Errorf("glyph %s: %s", cs.name, err)
+		}
+		width := int32(math.Round(float64(f.HorizontalAdvance(fonts.GID(gid)))))
+		glyphs = append(glyphs, glyph{name: cs.name, segments: segments, hints: hints, width: width})
+	}
+
+	// use the most common width as default
+	widthsCount := map[int32]int{}
+	for _, g := range glyphs {
+		widthsCount[g.width]++
+	}
+	var defaultWidth int32
+	for width, count := range widthsCount {
+		if c := widthsCount[defaultWidth]; count > c || (count == c && width < defaultWidth) {
+			defaultWidth = width
+		}
+	}
+
+	charstrings, names := make([][]byte, len(glyphs)), make([]string, len(glyphs))
+	for i, g := range glyphs {
+		names[i] = g.name
+		var width *int32
+		if g.width != defaultWidth {
+			width = &glyphs[i].width
+		}
+		charstrings[i] = type2Charstring(g.segments, g.hints, width)
+	}
+
+	desc := type1c.FontDescription{
+		PSInfo:   f.PSInfo,
+		Encoding: f.Encoding,
+		Private: type1c.PrivateDict{
+			BlueValues:    f.Hinting.BlueValues,
+			OtherBlues:    f.Hinting.OtherBlues,
+			StemSnapH:     f.Hinting.StemSnapH,
+			StemSnapV:     f.Hinting.StemSnapV,
+			StdHW:         f.Hinting.StdHW,
+			StdVW:         f.Hinting.StdVW,
+			BlueScale:     f.Hinting.BlueScale,
+			BlueShift:     f.Hinting.BlueShift,
+			BlueFuzz:      f.Hinting.BlueFuzz,
+			DefaultWidthX: float32(defaultWidth),
+		},
+		GlyphNames:  names,
+		Charstrings: charstrings,
+	}
+	if len(f.FontBBox) == 4 {
+		copy(desc.FontBBox[:], f.FontBBox)
+	}
+	if len(f.FontMatrix) == 6 {
+		desc.FontMatrix = f.FontMatrix
+	}
+	return type1c.NewFont(desc)
+}
@@ -0,0 +1,80 @@
+package type1
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/type1"
+	"github.com/benoitkugler/textlayout/fonts"
+	type1c "github.com/benoitkugler/textlayout/fonts/type1C"
+)
+
+func TestToCFF(t *testing.T) {
+	for _, filename := range []string{
+		"c0419bt_.pfb",
+		"CalligrapherRegular.pfb",
+		"Z003-MediumItalic.t1",
+	} {
+		b, err := testdata.Files.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		font, err := Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cff, err := font.ToCFF()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err = cff.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		back, err := type1c.Parse(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if back.NumGlyphs() != len(font.charstrings) {
+			t.Fatalf("%s: unexpected number of glyphs %d", filename, back.NumGlyphs())
+		}
+		if back.FamilyName != font.FamilyName || back.Upem() != font.Upem() {
+			t.Fatalf("%s: unexpected font info %v", filename, back.PSInfo)
+		}
+		if priv := back.PrivateDicts()[0]; !reflect.DeepEqual(priv.HintingParams(), font.Hinting) {
+			t.Fatalf("%s: unexpected hinting params %v", filename, priv.HintingParams())
+		}
+		for gid := 0; gid < len(font.charstrings); gid++ {
+			gid := fonts.GID(gid)
+			if back.GlyphName(gid) != font.GlyphName(gid) {
+				t.Fatalf("%s: unexpected glyph name %s", filename, back.GlyphName(gid))
+			}
+			exp, _, err := font.LoadGlyphHints(gid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := back.LoadGlyph(gid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(exp, got) {
+				t.Fatalf("%s: glyph %d: expected\n%v\ngot\n%v", filename, gid, exp, got)
+			}
+			if adv := back.HorizontalAdvance(gid); adv != font.HorizontalAdvance(gid) {
+				t.Fatalf("%s: glyph %d: unexpected advance %f", filename, gid, adv)
+			}
+		}
+
+		// hints are preserved
+		for gid := 0; gid < len(font.charstrings); gid++ {
+			_, hints, _ := font.LoadGlyphHints(fonts.GID(gid))
+			_, gotHints, _ := back.LoadGlyphHints(fonts.GID(gid))
+			if len(hints.HStems) != len(gotHints.HStems) || len(hints.VStems) != len(gotHints.VStems) {
+				t.Fatalf("%s: glyph %d: unexpected hints %v", filename, gid, gotHints)
+			}
+		}
+	}
+}
@@ -37,9 +37,9 @@ func (met *type1CharstringParser) Apply(op ps.PsOperator, state *ps.Machine) err
 	if !op.IsEscaped {
 		switch op.Operator {
 		case 1: // hstem
-			met.cs.Hstem(state)
+			met.cs.Type1Stem(state, met.leftBearing.Y, true)
 		case 3: // vstem
-			met.cs.Vstem(state)
+			met.cs.Type1Stem(state, met.leftBearing.X, false)
 		case 4: // vmoveto
 			if met.inFlex {
 				if state.ArgStack.Top < 1 {
@@ -75,6 +75,7 @@ func (met *type1CharstringParser) Apply(op ps.PsOperator, state *ps.Machine) err
 			// but does not place the point in the character path."
 			met.cs.CurrentPoint = ps.Point{X: met.leftBearing.X, Y: 0}
 		case 14: // endchar
+			met.cs.ClosePath()
 			return ps.ErrInterrupt
 		case 21: // rmoveto
 			if met.inFlex {
@@ -111,9 +112,9 @@ func (met *type1CharstringParser) Apply(op ps.PsOperator, state *ps.Machine) err
 		case 0: // dotsection
 			// just clear the stack
 		case 1: // vstem3
-			met.cs.Vstem(state)
-		case 2:
-			met.cs.Hstem(state)
+			met.cs.Type1Stem(state, met.leftBearing.X, false)
+		case 2: // hstem3
+			met.cs.Type1Stem(state, met.leftBearing.Y, true)
 		case 6: // seac
 			if state.ArgStack.Top < 5 {
 				return errors.New("invalid stack size for 'seac' in Type1 charstring")
@@ -166,7 +167,7 @@ func (met *type1CharstringParser) otherSub(state *ps.Machine) error {
 	nbArgs := state.ArgStack.Pop()
 	state.ArgStack.PopN(nbArgs)
 
-	// we only support the Flex feature
+	// we only support the Flex and hint replacement features
 	switch index {
 	case 0: // end flex
 		met.inFlex = false
@@ -209,6 +210,9 @@ func (met *type1CharstringParser) otherSub(state *ps.Machine) error {
 			return fmt.Errorf("invalid number of arguments for StartFlex other sub: %d", nbArgs)
 		}
 		// implemented in the moveto op codes
+	case 3: // hint replacement
+		// the subroutine index is left on the stack, for the following pop
+		met.cs.Type1HintReplacement()
 	default:
 		// not handled
 	}
@@ -2,10 +2,13 @@ package type1
 
 import (
 	"bytes"
+	"math"
+	"reflect"
 	"testing"
 
 	testdata "github.com/benoitkugler/textlayout-testdata/type1"
 	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
 )
 
 func TestParseMetrics(t *testing.T) {
@@ -42,3 +45,114 @@ func TestParseMetrics(t *testing.T) {
 		}
 	}
 }
+
+func TestHints(t *testing.T) {
+	b, err := testdata.Files.ReadFile("Z003-MediumItalic.t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := font.Hinting; h.StdHW != 48 || h.StdVW != 78 || len(h.BlueValues) != 6 || h.BlueScale != 0.039625 {
+		t.Fatalf("unexpected hinting values %v", h)
+	}
+	for gid := range font.charstrings {
+		if _, _, err = font.LoadGlyphHints(fonts.GID(gid)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 'H' uses hint replacement
+	gid, _ := font.NominalGlyph('H')
+	_, hints, err := font.LoadGlyphHints(gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []ps.StemHint{{Pos: 0, Width: 67}, {Pos: 553, Width: 20}, {Pos: -11, Width: 20}, {Pos: 626, Width: 52}}; !reflect.DeepEqual(hints.HStems, exp) {
+		t.Fatalf("expected %v, got %v", exp, hints.HStems)
+	}
+	exp := []ps.HintMask{
+		{SegmentIndex: 0, HStems: []int{0, 1}, VStems: []int{0, 1}},
+		{SegmentIndex: 29, HStems: []int{2, 3}, VStems: []int{0, 1}},
+	}
+	if !reflect.DeepEqual(hints.HintMasks, exp) {
+		t.Fatalf("expected %v, got %v", exp, hints.HintMasks)
+	}
+
+	b, err = testdata.Files.ReadFile("c0419bt_.pfb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err = Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gid, _ = font.NominalGlyph('H')
+	segments, hints, err := font.LoadGlyphHints(gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const ppem = 12
+	outline, err := font.HintedGlyphData(gid, ppem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the points on the stem edges are aligned on the pixel grid
+	for _, stem := range hints.HStems {
+		for i, seg := range segments {
+			for j, pt := range seg.ArgsSlice() {
+				if pt.Y != float32(stem.Pos) && pt.Y != float32(stem.Pos+stem.Width) {
+					continue
+				}
+				y := float64(outline.Segments[i].Args[j].Y) * ppem / 1000
+				if math.Abs(y-math.Round(y)) > 1e-4 {
+					t.Fatalf("point %v is not aligned on the pixel grid", outline.Segments[i].Args[j])
+				}
+			}
+		}
+	}
+}
+
+// closingPen stores the outlines and counts the closed contours
+type closingPen struct {
+	fonts.GlyphOutline
+	nbMoves, nbCloses int
+}
+
+func (p *closingPen) MoveTo(to fonts.SegmentPoint) {
+	p.nbMoves++
+	p.GlyphOutline.MoveTo(to)
+}
+
+func (p *closingPen) Close() { p.nbCloses++ }
+
+func TestDrawGlyph(t *testing.T) {
+	for _, filename := range filenamesBounds {
+		b, err := testdata.Files.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		font, err := Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range font.charstrings {
+			gid := fonts.GID(i)
+			var pen closingPen
+			if err = font.DrawGlyph(gid, &pen); err != nil {
+				t.Fatal(err)
+			}
+			if pen.nbMoves != pen.nbCloses {
+				t.Fatalf("glyph %d in %s: %d contours but %d closes", gid, filename, pen.nbMoves, pen.nbCloses)
+			}
+			if len(pen.Segments) != 0 && pen.Segments[0].Op != fonts.SegmentOpMoveTo {
+				t.Fatalf("glyph %d in %s: outline not starting with a move", gid, filename)
+			}
+			if exp := font.GlyphData(gid, 0, 0); !reflect.DeepEqual(exp, pen.GlyphOutline) {
+				t.Fatalf("glyph %d in %s: expected %v, got %v", gid, filename, exp, pen.GlyphOutline)
+			}
+		}
+	}
+}
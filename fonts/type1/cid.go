@@ -0,0 +1,629 @@
+package type1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	tk "github.com/benoitkugler/pstokenizer"
+	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
+)
+
+const headerCID = "%!PS-Adobe-3.0 Resource-CIDFont"
+
+var _ fonts.Face = (*CIDFont)(nil)
+var _ fonts.FaceCID = (*CIDFont)(nil)
+
+// CIDFont is a CID-keyed Type 1 font (CIDFontType 0), as defined in the
+// Adobe Technical Note #5014.
+// Its glyphs are selected by CIDs, which are also used as glyph indices.
+// Such fonts have no builtin character map: see SetCIDCmap
+// to use them for text.
+type CIDFont struct {
+	cmap fonts.CmapSimple // see SetCIDCmap
+	ros  fonts.CIDSystemInfo
+
+	FontBBox   []Fl
+	FontMatrix []Fl // often empty, since the font dicts provide their own matrix
+
+	fonts.PSInfo
+
+	charstrings []cidCharstring // indexed by CID
+	fontDicts   []cidFontDict   // the FDArray
+}
+
+type cidCharstring struct {
+	data []byte // decrypted, empty for CIDs without glyph
+	fd   int    // index into fontDicts
+}
+
+// cidFontDict is an entry of the FDArray,
+// with its subroutines decrypted
+type cidFontDict struct {
+	fontMatrix []Fl
+	subrs      [][]byte
+}
+
+// isCIDFont checks the header of a CID-keyed font file,
+// and seeks back to the start of `file`
+func isCIDFont(file fonts.Resource) bool {
+	var buf [len(headerCID)]byte
+	file.Seek(0, io.SeekStart)
+	_, err := io.ReadFull(file, buf[:])
+	file.Seek(0, io.SeekStart)
+	return err == nil && string(buf[:]) == headerCID
+}
+
+// ParseCIDFont parses a CID-keyed Type 1 font file (CIDFontType 0),
+// starting with %!PS-Adobe-3.0 Resource-CIDFont.
+func ParseCIDFont(file fonts.Resource) (*CIDFont, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	font, err := parseCIDFont(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CID-keyed font file: %s", err)
+	}
+	return font, nil
+}
+
+// the header of a CIDFont, pointing to the binary data
+type cidHeader struct {
+	cidMapOffset, fdBytes, gdBytes, cidCount int
+	fontDicts                                []cidFontDictHeader
+}
+
+type cidFontDictHeader struct {
+	fontMatrix                        []Fl
+	subrMapOffset, sdBytes, subrCount int
+	lenIV                             int
+}
+
+func parseCIDFont(data []byte) (*CIDFont, error) {
+	if !bytes.HasPrefix(data, []byte(headerCID)) {
+		return nil, errors.New("missing CIDFont header")
+	}
+
+	// the ASCII header ends with (Binary) <length> StartData or (Hex) <length> StartData
+	startData := bytes.Index(data, []byte("StartData"))
+	if startData == -1 {
+		return nil, errors.New("StartData not found")
+	}
+	fields := bytes.Fields(data[:startData])
+	if len(fields) < 2 {
+		return nil, errors.New("invalid StartData arguments")
+	}
+	length, err := strconv.Atoi(string(fields[len(fields)-1]))
+	if err != nil || length < 0 {
+		return nil, errors.New("invalid StartData length")
+	}
+	// "StartData" is followed by a single white space
+	binary := data[startData+len("StartData"):]
+	if len(binary) != 0 {
+		binary = binary[1:]
+	}
+	switch format := string(fields[len(fields)-2]); format {
+	case "(Binary)":
+		if length < len(binary) {
+			binary = binary[:length]
+		}
+	case "(Hex)":
+		if end := bytes.IndexByte(binary, '>'); end != -1 {
+			binary = binary[:end]
+		}
+		binary = hexToBinary(binary)
+	default:
+		return nil, fmt.Errorf("unsupported StartData format %s", format)
+	}
+
+	var (
+		out CIDFont
+		p   parser
+	)
+	p.lexer = newLexer(data[:startData])
+	header, err := p.parseCIDHeader(&out)
+	if err != nil {
+		return nil, err
+	}
+	if err = out.loadBinary(header, binary); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// parseCIDHeader reads the ASCII part of the font, ignoring the
+// PostScript code not related to the font dictionary.
+func (p *parser) parseCIDHeader(font *CIDFont) (out cidHeader, err error) {
+	out.fdBytes, out.gdBytes = -1, -1
+	for {
+		token := p.lexer.peekToken()
+		if token.Kind == 0 || token.Kind == tk.EOF {
+			break
+		}
+		if _, err = p.lexer.nextToken(); err != nil {
+			return out, err
+		}
+		if token.Kind != tk.Name {
+			continue
+		}
+
+		switch key := string(token.Value); key {
+		case "CIDSystemInfo":
+			var dict map[string][]tk.Token
+			dict, err = p.readSimpleDict()
+			if err != nil {
+				return out, err
+			}
+			font.ros = readCIDSystemInfo(dict)
+		case "FontInfo":
+			var dict map[string][]tk.Token
+			dict, err = p.readSimpleDict()
+			if err != nil {
+				return out, err
+			}
+			fontName := font.PSInfo.FontName
+			font.PSInfo = p.readFontInfo(dict)
+			font.PSInfo.FontName = fontName
+		case "FDArray":
+			out.fontDicts, err = p.readFDArray()
+			if err != nil {
+				return out, err
+			}
+		case "CIDFontName", "CIDFontType", "FontBBox", "FontMatrix",
+			"CIDMapOffset", "FDBytes", "GDBytes", "CIDCount":
+			var value []tk.Token
+			value, err = p.readValue()
+			if err != nil {
+				return out, err
+			}
+			// skip usages which are not definitions, like
+			// /CIDFontName currentdict /CIDFont defineresource pop
+			if t := p.lexer.peekToken(); !(t.IsOther("def") || t.IsOther("readonly") || t.IsOther("noaccess")) {
+				continue
+			}
+			if err = p.readDef(); err != nil {
+				return out, err
+			}
+			if len(value) == 0 {
+				return out, fmt.Errorf("missing value for key %s", key)
+			}
+			switch key {
+			case "CIDFontName":
+				font.PSInfo.FontName = string(value[0].Value)
+			case "CIDFontType":
+				if fontType, _ := value[0].Int(); fontType != 0 {
+					return out, fmt.Errorf("unsupported CIDFontType %d", fontType)
+				}
+			case "FontBBox":
+				font.FontBBox, err = p.arrayToNumbers(value)
+			case "FontMatrix":
+				font.FontMatrix, err = p.arrayToNumbers(value)
+			case "CIDMapOffset":
+				out.cidMapOffset, err = value[0].Int()
+			case "FDBytes":
+				out.fdBytes, err = value[0].Int()
+			case "GDBytes":
+				out.gdBytes, err = value[0].Int()
+			case "CIDCount":
+				out.cidCount, err = value[0].Int()
+			}
+			if err != nil {
+				return out, err
+			}
+		}
+	}
+
+	if out.fdBytes < 0 || out.fdBytes > 4 || out.gdBytes < 1 || out.gdBytes > 4 {
+		return out, fmt.Errorf("invalid FDBytes or GDBytes (%d, %d)", out.fdBytes, out.gdBytes)
+	}
+	if out.cidCount <= 0 || out.cidCount > 1<<16 {
+		return out, fmt.Errorf("invalid CIDCount %d", out.cidCount)
+	}
+	if len(out.fontDicts) == 0 {
+		return out, errors.New("missing FDArray")
+	}
+	return out, nil
+}
+
+func readCIDSystemInfo(dict map[string][]tk.Token) (out fonts.CIDSystemInfo) {
+	if v := dict["Registry"]; len(v) != 0 {
+		out.Registry = string(v[0].Value)
+	}
+	if v := dict["Ordering"]; len(v) != 0 {
+		out.Ordering = string(v[0].Value)
+	}
+	if v := dict["Supplement"]; len(v) != 0 {
+		out.Supplement, _ = v[0].Int()
+	}
+	return out
+}
+
+// Reads the /FDArray array, whose entries are font dictionaries.
+func (p *parser) readFDArray() ([]cidFontDictHeader, error) {
+	lengthT, err := p.read(tk.Integer)
+	if err != nil {
+		return nil, err
+	}
+	length, _ := lengthT.Int()
+	if length < 0 {
+		return nil, fmt.Errorf("invalid FDArray length %d", length)
+	}
+	if err = p.readWithName(tk.Other, "array"); err != nil {
+		return nil, err
+	}
+
+	out := make([]cidFontDictHeader, length)
+	for i := 0; i < length; i++ {
+		// premature end
+		if !p.lexer.peekToken().IsOther("dup") {
+			break
+		}
+		if err = p.readWithName(tk.Other, "dup"); err != nil {
+			return nil, err
+		}
+		indexT, err := p.read(tk.Integer)
+		if err != nil {
+			return nil, err
+		}
+		index, _ := indexT.Int()
+		if index < 0 || index >= length {
+			return nil, fmt.Errorf("out of range font dict index %d (for %d)", index, length)
+		}
+		out[index], err = p.readCIDFontDict()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.readPut(); err != nil {
+			return nil, err
+		}
+	}
+	err = p.readDef()
+	return out, err
+}
+
+// Reads one font dictionary of the FDArray, which is not terminated by "def".
+func (p *parser) readCIDFontDict() (cidFontDictHeader, error) {
+	out := cidFontDictHeader{lenIV: 4}
+	if _, err := p.read(tk.Integer); err != nil {
+		return out, err
+	}
+	if err := p.readWithName(tk.Other, "dict"); err != nil {
+		return out, err
+	}
+	if _, err := p.readMaybe(tk.Other, "dup"); err != nil {
+		return out, err
+	}
+	if err := p.readWithName(tk.Other, "begin"); err != nil {
+		return out, err
+	}
+
+	for {
+		token := p.lexer.peekToken()
+		if token.Kind == 0 || token.Kind == tk.EOF {
+			return out, errors.New("unexpected end of font dict")
+		}
+		if token.IsOther("currentdict") {
+			p.lexer.nextToken()
+			continue
+		}
+		if token.IsOther("end") {
+			p.lexer.nextToken()
+			break
+		}
+
+		keyT, err := p.read(tk.Name)
+		if err != nil {
+			return out, err
+		}
+		switch string(keyT.Value) {
+		case "Private":
+			dict, err := p.readSimpleDict()
+			if err != nil {
+				return out, err
+			}
+			for key, value := range dict {
+				if len(value) == 0 {
+					continue
+				}
+				switch key {
+				case "SubrMapOffset":
+					out.subrMapOffset, err = value[0].Int()
+				case "SDBytes":
+					out.sdBytes, err = value[0].Int()
+				case "SubrCount":
+					out.subrCount, err = value[0].Int()
+				case "lenIV":
+					out.lenIV, err = value[0].Int()
+				}
+				if err != nil {
+					return out, err
+				}
+			}
+		case "FontMatrix":
+			value, err := p.readDictValue()
+			if err != nil {
+				return out, err
+			}
+			out.fontMatrix, err = p.arrayToNumbers(value)
+			if err != nil {
+				return out, err
+			}
+		default:
+			if _, err = p.readDictValue(); err != nil {
+				return out, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// readOffset reads a big endian integer of 0 to 4 bytes
+func readOffset(b []byte) int {
+	var out int
+	for _, c := range b {
+		out = out<<8 | int(c)
+	}
+	return out
+}
+
+// loadBinary uses the CIDMap and the SubrMaps to
+// extract the charstrings and the subroutines.
+func (f *CIDFont) loadBinary(header cidHeader, binary []byte) error {
+	f.fontDicts = make([]cidFontDict, len(header.fontDicts))
+	for i, fd := range header.fontDicts {
+		f.fontDicts[i].fontMatrix = fd.fontMatrix
+		if fd.subrCount == 0 {
+			continue
+		}
+		if fd.sdBytes < 1 || fd.sdBytes > 4 || fd.subrCount < 0 {
+			return fmt.Errorf("invalid SubrMap in font dict %d", i)
+		}
+		end := fd.subrMapOffset + (fd.subrCount+1)*fd.sdBytes
+		if fd.subrMapOffset < 0 || end > len(binary) {
+			return errors.New("invalid SubrMap (EOF)")
+		}
+		subrMap := binary[fd.subrMapOffset:end]
+		subrs := make([][]byte, fd.subrCount)
+		for j := range subrs {
+			start, end := readOffset(subrMap[j*fd.sdBytes:(j+1)*fd.sdBytes]), readOffset(subrMap[(j+1)*fd.sdBytes:(j+2)*fd.sdBytes])
+			if start > end || end > len(binary) {
+				return fmt.Errorf("invalid subroutine offsets (%d, %d)", start, end)
+			}
+			// the data may be shared, so we copy it before decrypting
+			subrs[j] = decrypt(append([]byte(nil), binary[start:end]...), CHARSTRING_KEY, fd.lenIV)
+		}
+		f.fontDicts[i].subrs = subrs
+	}
+
+	entrySize := header.fdBytes + header.gdBytes
+	end := header.cidMapOffset + (header.cidCount+1)*entrySize
+	if header.cidMapOffset < 0 || end > len(binary) {
+		return errors.New("invalid CIDMap (EOF)")
+	}
+	cidMap := binary[header.cidMapOffset:end]
+	f.charstrings = make([]cidCharstring, header.cidCount)
+	for cid := range f.charstrings {
+		entry, next := cidMap[cid*entrySize:], cidMap[(cid+1)*entrySize:]
+		fd := readOffset(entry[:header.fdBytes])
+		start, end := readOffset(entry[header.fdBytes:entrySize]), readOffset(next[header.fdBytes:entrySize])
+		if start >= end { // no glyph for this CID
+			continue
+		}
+		if end > len(binary) {
+			return fmt.Errorf("invalid charstring offsets (%d, %d)", start, end)
+		}
+		if fd >= len(f.fontDicts) {
+			return fmt.Errorf("invalid font dict index %d", fd)
+		}
+		data := decrypt(append([]byte(nil), binary[start:end]...), CHARSTRING_KEY, header.fontDicts[fd].lenIV)
+		f.charstrings[cid] = cidCharstring{data: data, fd: fd}
+	}
+	return nil
+}
+
+// loadGlyph returns the outlines, the bounds and the advance of the glyph.
+func (f *CIDFont) loadGlyph(gid fonts.GID) ([]fonts.Segment, ps.PathBounds, int32, error) {
+	var out fonts.GlyphOutline
+	bounds, advance, err := f.drawGlyph(gid, &out)
+	if err != nil {
+		return nil, ps.PathBounds{}, 0, err
+	}
+	return out.Segments, bounds, advance, nil
+}
+
+// drawGlyph is the same as loadGlyph, but sends the outlines to `pen`
+func (f *CIDFont) drawGlyph(gid fonts.GID, pen fonts.Pen) (ps.PathBounds, int32, error) {
+	if int(gid) >= len(f.charstrings) || len(f.charstrings[gid].data) == 0 {
+		return ps.PathBounds{}, 0, errors.New("invalid glyph index")
+	}
+	charstring := f.charstrings[gid]
+
+	var (
+		psi    ps.Machine
+		parser type1CharstringParser
+	)
+	parser.cs.Pen = pen
+	err := psi.Run(charstring.data, f.fontDicts[charstring.fd].subrs, nil, &parser)
+	if err != nil {
+		return ps.PathBounds{}, 0, err
+	}
+	if parser.seac != nil {
+		return ps.PathBounds{}, 0, errors.New("seac operator is not supported in CID-keyed fonts")
+	}
+	return parser.cs.Bounds, parser.advance.X, nil
+}
+
+// NumGlyphs returns the number of glyphs in this font,
+// which is the CIDCount entry.
+func (f *CIDFont) NumGlyphs() int { return len(f.charstrings) }
+
+// CIDSystemInfo returns the character collection used by the font.
+func (f *CIDFont) CIDSystemInfo() (fonts.CIDSystemInfo, bool) { return f.ros, true }
+
+// CIDToGID returns the glyph selected by `cid`, which is `cid` itself,
+// or false if the font has no glyph for `cid`.
+func (f *CIDFont) CIDToGID(cid fonts.CID) (fonts.GID, bool) {
+	if int(cid) >= len(f.charstrings) || len(f.charstrings[cid].data) == 0 {
+		return 0, false
+	}
+	return fonts.GID(cid), true
+}
+
+// GIDToCID is the inverse of CIDToGID.
+func (f *CIDFont) GIDToCID(gid fonts.GID) (fonts.CID, bool) {
+	if int(gid) >= len(f.charstrings) || len(f.charstrings[gid].data) == 0 {
+		return 0, false
+	}
+	return fonts.CID(gid), true
+}
+
+// SetCIDCmap uses `cmap` to build the character map of the font, replacing the
+// current one. CIDs not supported by the font are ignored.
+func (f *CIDFont) SetCIDCmap(cmap fonts.CmapCID) {
+	f.cmap = make(fonts.CmapSimple, len(cmap))
+	for r, cid := range cmap {
+		if gid, ok := f.CIDToGID(cid); ok {
+			f.cmap[r] = gid
+		}
+	}
+}
+
+func (f *CIDFont) PostscriptInfo() (fonts.PSInfo, bool) { return f.PSInfo, true }
+
+func (f *CIDFont) PoscriptName() string { return f.PSInfo.FontName }
+
+func (f *CIDFont) LoadSummary() (fonts.FontSummary, error) {
+	isItalic, isBold, familyName, styleName := getStyle(f.PSInfo)
+	return fonts.FontSummary{
+		IsItalic:          isItalic,
+		IsBold:            isBold,
+		Familly:           familyName,
+		Style:             styleName,
+		HasScalableGlyphs: true,
+		HasBitmapGlyphs:   false,
+		HasColorGlyphs:    false,
+	}, nil
+}
+
+func (CIDFont) LoadBitmaps() []fonts.BitmapSize { return nil }
+
+// LoadMetrics returns the font itself.
+func (f *CIDFont) LoadMetrics() fonts.FaceMetrics { return f }
+
+// Upem uses the FontMatrix of the first font dict,
+// concatenated with the top-level FontMatrix, if any.
+func (f *CIDFont) Upem() uint16 {
+	matrix := f.FontMatrix
+	if len(f.fontDicts) != 0 && len(f.fontDicts[0].fontMatrix) >= 4 {
+		fdMatrix := f.fontDicts[0].fontMatrix
+		if len(matrix) >= 4 { // only the scaling factors are needed
+			matrix = []Fl{fdMatrix[0] * matrix[0], 0, 0, fdMatrix[3] * matrix[3]}
+		} else {
+			matrix = fdMatrix
+		}
+	}
+	return upemFromMatrix(matrix)
+}
+
+// GlyphName always returns an empty string, since glyphs
+// are identified by CIDs.
+func (f *CIDFont) GlyphName(gid fonts.GID) string { return "" }
+
+func (f *CIDFont) LineMetric(metric fonts.LineMetric) (float32, bool) {
+	switch metric {
+	case fonts.UnderlinePosition:
+		return float32(f.PSInfo.UnderlinePosition), true
+	case fonts.UnderlineThickness:
+		return float32(f.PSInfo.UnderlineThickness), true
+	default:
+		return 0, false
+	}
+}
+
+func (f *CIDFont) FontHExtents() (fonts.FontExtents, bool) {
+	var extents fonts.FontExtents
+	if len(f.FontBBox) < 4 {
+		return extents, false
+	}
+	// following freetype here
+	extents.Ascender = float32(f.FontBBox[3])
+	extents.Descender = float32(f.FontBBox[1])
+	extents.LineGap = float32(f.Upem()) * 1.2
+	if extents.LineGap < extents.Ascender-extents.Descender {
+		extents.LineGap = extents.Ascender - extents.Descender
+	}
+	return extents, true
+}
+
+// FontVExtents returns zero values.
+func (f *CIDFont) FontVExtents() (fonts.FontExtents, bool) {
+	return fonts.FontExtents{}, false
+}
+
+func (f *CIDFont) Cmap() (fonts.Cmap, fonts.CmapEncoding) {
+	return f.cmap, fonts.EncUnicode
+}
+
+func (f *CIDFont) NominalGlyph(ch rune) (fonts.GID, bool) {
+	out, ok := f.cmap[ch]
+	return out, ok
+}
+
+// HorizontalAdvance returns the advance of the glyph with index `index`
+// The return value is expressed in font units.
+// 0 is returned for invalid index values and for invalid
+// charstring glyph data.
+func (f *CIDFont) HorizontalAdvance(gid fonts.GID) float32 {
+	_, _, adv, err := f.loadGlyph(gid)
+	if err != nil {
+		return 0
+	}
+	return float32(adv)
+}
+
+func (f *CIDFont) VerticalAdvance(gid fonts.GID) float32 { return 0 }
+
+// GlyphHOrigin always return 0,0,true
+func (CIDFont) GlyphHOrigin(fonts.GID) (x, y int32, found bool) {
+	return 0, 0, true
+}
+
+// GlyphVOrigin always return 0,0,false
+func (CIDFont) GlyphVOrigin(fonts.GID) (x, y int32, found bool) {
+	return 0, 0, false
+}
+
+func (f *CIDFont) GlyphExtents(glyph fonts.GID, _, _ uint16) (fonts.GlyphExtents, bool) {
+	_, bbox, _, err := f.loadGlyph(glyph)
+	if err != nil {
+		return fonts.GlyphExtents{}, false
+	}
+	return bbox.ToExtents(), true
+}
+
+// GlyphData returns the outlines of the given glyph.
+// The returned value is either a fonts.GlyphOutline or nil if an error
+// occured.
+func (f *CIDFont) GlyphData(gid fonts.GID, _, _ uint16) fonts.GlyphData {
+	var out fonts.GlyphOutline
+	if err := f.DrawGlyph(gid, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+var _ fonts.FaceOutliner = (*CIDFont)(nil)
+
+// DrawGlyph sends the outlines of the given glyph to `pen`.
+func (f *CIDFont) DrawGlyph(gid fonts.GID, pen fonts.Pen) error {
+	_, _, err := f.drawGlyph(gid, pen)
+	return err
+}
@@ -0,0 +1,195 @@
+package type1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// encodeCharstring encodes integers (as int) and operators (as byte)
+// into a Type1 charstring
+func encodeCharstring(args ...interface{}) []byte {
+	var out []byte
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case byte:
+			out = append(out, arg)
+		case int:
+			switch {
+			case -107 <= arg && arg <= 107:
+				out = append(out, byte(arg+139))
+			case 108 <= arg && arg <= 1131:
+				arg -= 108
+				out = append(out, byte(arg>>8+247), byte(arg))
+			case -1131 <= arg && arg <= -108:
+				arg = -arg - 108
+				out = append(out, byte(arg>>8+251), byte(arg))
+			default:
+				panic("number not supported")
+			}
+		}
+	}
+	return out
+}
+
+// encryptCharstring is the inverse of decrypt, with a lenIV of 4
+func encryptCharstring(plain []byte) []byte {
+	const (
+		c1 uint16 = 52845
+		c2 uint16 = 22719
+	)
+	r := uint16(CHARSTRING_KEY)
+	out := make([]byte, 0, len(plain)+4)
+	for _, p := range append([]byte{0, 0, 0, 0}, plain...) {
+		c := p ^ byte(r>>8)
+		r = (uint16(c)+r)*c1 + c2
+		out = append(out, c)
+	}
+	return out
+}
+
+// buildCIDFont returns a CID-keyed font with 3 CIDs :
+// 0 is .notdef, 1 has no glyph and 2 is a square drawn by a subroutine.
+func buildCIDFont(asHex bool) []byte {
+	const (
+		hsbw, rmoveto, hlineto, vlineto, closepath = byte(13), byte(21), byte(6), byte(7), byte(9)
+		callsubr, ret, endchar                     = byte(10), byte(11), byte(14)
+	)
+	subr := encryptCharstring(encodeCharstring(400, hlineto, 500, vlineto, -400, hlineto, closepath, ret))
+	notdef := encryptCharstring(encodeCharstring(0, 250, hsbw, endchar))
+	square := encryptCharstring(encodeCharstring(50, 600, hsbw, 0, 0, rmoveto, 0, callsubr, endchar))
+
+	// CIDMap (4 entries of 3 bytes), SubrMap (2 entries of 2 bytes) and data
+	const dataStart = 4*3 + 2*2
+	subrStart := dataStart
+	notdefStart := subrStart + len(subr)
+	squareStart := notdefStart + len(notdef)
+	end := squareStart + len(square)
+
+	var binary []byte
+	for _, offset := range []int{notdefStart, squareStart, squareStart, end} {
+		binary = append(binary, 0, byte(offset>>8), byte(offset))
+	}
+	for _, offset := range []int{subrStart, notdefStart} {
+		binary = append(binary, byte(offset>>8), byte(offset))
+	}
+	binary = append(binary, subr...)
+	binary = append(binary, notdef...)
+	binary = append(binary, square...)
+
+	format, data := "(Binary)", binary
+	if asHex {
+		format, data = "(Hex)", []byte(hex.EncodeToString(binary)+">")
+	}
+
+	header := fmt.Sprintf(`%%!PS-Adobe-3.0 Resource-CIDFont
+%%%%DocumentNeededResources: ProcSet (CIDInit)
+%%%%BeginResource: CIDFont (Test-CID)
+/CIDInit /ProcSet findresource begin
+20 dict begin
+/CIDFontName /Test-CID def
+/CIDFontType 0 def
+/CIDSystemInfo 3 dict dup begin
+  /Registry (Adobe) def
+  /Ordering (Identity) def
+  /Supplement 0 def
+end def
+/FontBBox [0 -120 1000 880] def
+/FontInfo 3 dict dup begin
+  /FullName (Test CID Bold) def
+  /FamilyName (Test CID) def
+  /Weight (Bold) def
+end readonly def
+/CIDMapOffset 0 def
+/FDBytes 1 def
+/GDBytes 2 def
+/CIDCount 3 def
+/FDArray 1 array
+dup 0
+%%ADOBeginFontDict
+5 dict
+  begin
+  /FontName /Test-CID-Regular def
+  /FontType 1 def
+  /FontMatrix [0.001 0 0 0.001 0 0] def
+  /PaintType 0 def
+  /Private 6 dict dup begin
+    /MinFeature {16 16} def
+    /BlueValues [-12 0 500 512] def
+    /SubrMapOffset 12 def
+    /SDBytes 2 def
+    /SubrCount 1 def
+  end def
+  currentdict end
+%%ADOEndFontDict
+put
+def
+/CIDFontName currentdict /CIDFont defineresource pop
+end
+end
+%%%%BeginData: %d Binary Bytes
+%s %d StartData `, len(data), format, len(data))
+
+	out := append([]byte(header), data...)
+	return append(out, "\n%%EndData\n%%EndResource\n%%EOF\n"...)
+}
+
+func TestCIDFont(t *testing.T) {
+	for _, asHex := range []bool{false, true} {
+		faces, err := Load(bytes.NewReader(buildCIDFont(asHex)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		font, ok := faces[0].(*CIDFont)
+		if !ok {
+			t.Fatalf("unexpected font type %T", faces[0])
+		}
+
+		if font.PoscriptName() != "Test-CID" || font.NumGlyphs() != 3 || font.Upem() != 1000 {
+			t.Fatalf("unexpected font %s %d %d", font.PoscriptName(), font.NumGlyphs(), font.Upem())
+		}
+		if ros, _ := font.CIDSystemInfo(); ros != (fonts.CIDSystemInfo{Registry: "Adobe", Ordering: "Identity"}) {
+			t.Fatalf("unexpected ROS %v", ros)
+		}
+		summary, _ := font.LoadSummary()
+		if summary.Familly != "Test CID" || summary.Style != "Bold" || !summary.IsBold {
+			t.Fatalf("unexpected summary %v", summary)
+		}
+
+		if gid, ok := font.CIDToGID(2); !ok || gid != 2 {
+			t.Fatalf("unexpected glyph %d", gid)
+		}
+		if _, ok := font.CIDToGID(1); ok {
+			t.Fatal("unexpected glyph for CID 1")
+		}
+
+		if adv := font.HorizontalAdvance(0); adv != 250 {
+			t.Fatalf("unexpected advance %f", adv)
+		}
+		if adv := font.HorizontalAdvance(2); adv != 600 {
+			t.Fatalf("unexpected advance %f", adv)
+		}
+		exp := fonts.GlyphExtents{XBearing: 50, YBearing: 500, Width: 400, Height: -500}
+		if ext, _ := font.GlyphExtents(2, 0, 0); ext != exp {
+			t.Fatalf("expected %v, got %v", exp, ext)
+		}
+		if data := font.GlyphData(1, 0, 0); data != nil {
+			t.Fatalf("unexpected glyph data %v", data)
+		}
+
+		font.SetCIDCmap(fonts.CmapCID{'a': 2, 'b': 1, 'c': 4})
+		if gid, ok := font.NominalGlyph('a'); !ok || gid != 2 {
+			t.Fatalf("unexpected glyph %d", gid)
+		}
+		if _, ok := font.NominalGlyph('b'); ok {
+			t.Fatal("unexpected glyph for 'b'")
+		}
+	}
+
+	if _, err := ParseCIDFont(bytes.NewReader([]byte(headerCID + "\n/CIDCount 2 def\n(Binary) 0 StartData "))); err == nil {
+		t.Fatal("expected error for missing FDArray")
+	}
+}
@@ -0,0 +1,810 @@
+package type1
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	tk "github.com/benoitkugler/pstokenizer"
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/glyphsnames"
+)
+
+// CharCode is a character code read from a byte string,
+// according to the codespace ranges of a CMap.
+type CharCode struct {
+	Code    uint32
+	NbBytes uint8 // between 1 and 4
+}
+
+func newCharCode(b []byte) (CharCode, error) {
+	if len(b) == 0 || len(b) > 4 {
+		return CharCode{}, fmt.Errorf("invalid character code length %d", len(b))
+	}
+	return CharCode{Code: uint32(readOffset(b)), NbBytes: uint8(len(b))}, nil
+}
+
+func (c CharCode) less(other CharCode) bool {
+	if c.NbBytes != other.NbBytes {
+		return c.NbBytes < other.NbBytes
+	}
+	return c.Code < other.Code
+}
+
+// CMap maps character codes (of 1 to 4 bytes) to CIDs, or to Unicode strings
+// for ToUnicode CMaps, as defined in the Adobe Technical Note #5014 and
+// in the PDF specification (sections 9.7.5 and 9.10.3).
+type CMap struct {
+	Name          string
+	CIDSystemInfo fonts.CIDSystemInfo
+	WMode         int   // 0 for horizontal writing, 1 for vertical writing
+	UseCMap       *CMap // the CMap included with usecmap, or nil
+
+	codespaces []codespaceRange
+
+	cidChars     map[CharCode]fonts.CID
+	cidRanges    []cidRange // sorted
+	notdefChars  map[CharCode]fonts.CID
+	notdefRanges []cidRange // sorted
+
+	bfChars  map[CharCode][]rune
+	bfRanges []bfRange // sorted
+}
+
+type codespaceRange struct {
+	low, high []byte // with the same length
+}
+
+func (cs codespaceRange) contains(code []byte) bool {
+	if len(code) != len(cs.low) {
+		return false
+	}
+	// each byte is checked independently
+	for i, b := range code {
+		if b < cs.low[i] || b > cs.high[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type cidRange struct {
+	low, high CharCode // with the same length
+	cid       fonts.CID
+}
+
+type bfRange struct {
+	low, high CharCode // with the same length
+	// UTF-16BE encoded destination, incremented for each code
+	dst []byte
+	// if not nil, one destination for each code
+	dsts [][]rune
+}
+
+func (r bfRange) lookup(code CharCode) []rune {
+	offset := code.Code - r.low.Code
+	if r.dsts != nil {
+		if int(offset) >= len(r.dsts) {
+			return nil
+		}
+		return r.dsts[offset]
+	}
+	dst := append([]byte(nil), r.dst...)
+	// increment the last code unit
+	if n := len(dst); n >= 2 {
+		last := uint16(dst[n-2])<<8 | uint16(dst[n-1])
+		last += uint16(offset)
+		dst[n-2], dst[n-1] = byte(last>>8), byte(last)
+	} else if n == 1 {
+		dst[0] += byte(offset)
+	}
+	return decodeUTF16BE(dst)
+}
+
+// searchRange returns the index of the range containing `code`,
+// or -1. `low` and `high` return the bounds of the i-th range, sorted.
+func searchRange(n int, low, high func(i int) CharCode, code CharCode) int {
+	// first range with low > code
+	i := sort.Search(n, func(i int) bool { return code.less(low(i)) })
+	if i == 0 {
+		return -1
+	}
+	if l, h := low(i-1), high(i-1); l.NbBytes != code.NbBytes || code.Code > h.Code {
+		return -1
+	}
+	return i - 1
+}
+
+// if `increment` is false, all the codes of a range are mapped to the same CID,
+// as for notdef ranges
+func searchCIDRange(ranges []cidRange, code CharCode, increment bool) (fonts.CID, bool) {
+	i := searchRange(len(ranges), func(i int) CharCode { return ranges[i].low },
+		func(i int) CharCode { return ranges[i].high }, code)
+	if i == -1 {
+		return 0, false
+	}
+	if !increment {
+		return ranges[i].cid, true
+	}
+	return ranges[i].cid + fonts.CID(code.Code-ranges[i].low.Code), true
+}
+
+// CID returns the CID selected by `code`. The notdef mappings
+// are used if no CID is defined for `code`, and false is returned
+// if there is no notdef mapping either.
+func (c *CMap) CID(code CharCode) (fonts.CID, bool) {
+	if cid, ok := c.lookupCID(code); ok {
+		return cid, true
+	}
+	return c.lookupNotdef(code)
+}
+
+func (c *CMap) lookupCID(code CharCode) (fonts.CID, bool) {
+	if cid, ok := c.cidChars[code]; ok {
+		return cid, true
+	}
+	if cid, ok := searchCIDRange(c.cidRanges, code, true); ok {
+		return cid, true
+	}
+	if c.UseCMap != nil {
+		return c.UseCMap.lookupCID(code)
+	}
+	return 0, false
+}
+
+func (c *CMap) lookupNotdef(code CharCode) (fonts.CID, bool) {
+	if cid, ok := c.notdefChars[code]; ok {
+		return cid, true
+	}
+	if cid, ok := searchCIDRange(c.notdefRanges, code, false); ok {
+		return cid, true
+	}
+	if c.UseCMap != nil {
+		return c.UseCMap.lookupNotdef(code)
+	}
+	return 0, false
+}
+
+// Unicode returns the string mapped to `code` by the bfchar
+// and bfrange operators, as found in ToUnicode CMaps, or false.
+func (c *CMap) Unicode(code CharCode) ([]rune, bool) {
+	if rs, ok := c.bfChars[code]; ok {
+		return rs, true
+	}
+	i := searchRange(len(c.bfRanges), func(i int) CharCode { return c.bfRanges[i].low },
+		func(i int) CharCode { return c.bfRanges[i].high }, code)
+	if i != -1 {
+		if rs := c.bfRanges[i].lookup(code); rs != nil {
+			return rs, true
+		}
+	}
+	if c.UseCMap != nil {
+		return c.UseCMap.Unicode(code)
+	}
+	return nil, false
+}
+
+// returns the codespace ranges, including the ones of the parent CMaps
+func (c *CMap) codespaceRanges() []codespaceRange {
+	out := c.codespaces
+	if c.UseCMap != nil {
+		out = append(append([]codespaceRange(nil), out...), c.UseCMap.codespaceRanges()...)
+	}
+	return out
+}
+
+// defaultCodeLength is used when no codespace range is defined,
+// which happens in some (invalid) ToUnicode CMaps
+func (c *CMap) defaultCodeLength() int {
+	for code := range c.bfChars {
+		return int(code.NbBytes)
+	}
+	if len(c.bfRanges) != 0 {
+		return int(c.bfRanges[0].low.NbBytes)
+	}
+	for code := range c.cidChars {
+		return int(code.NbBytes)
+	}
+	if len(c.cidRanges) != 0 {
+		return int(c.cidRanges[0].low.NbBytes)
+	}
+	return 1
+}
+
+// CharCodes splits `text` into character codes, using the codespace ranges,
+// as described in the PDF specification (section 9.7.6.2).
+func (c *CMap) CharCodes(text []byte) []CharCode {
+	codespaces := c.codespaceRanges()
+	defaultLength := 0
+	if len(codespaces) == 0 {
+		defaultLength = c.defaultCodeLength()
+	}
+
+	var out []CharCode
+	for len(text) != 0 {
+		n := nextCodeLength(codespaces, text)
+		if defaultLength != 0 {
+			n = defaultLength
+		}
+		if n > len(text) {
+			n = len(text)
+		}
+		code, _ := newCharCode(text[:n])
+		out = append(out, code)
+		text = text[n:]
+	}
+	return out
+}
+
+func nextCodeLength(codespaces []codespaceRange, text []byte) int {
+	for n := 1; n <= 4 && n <= len(text); n++ {
+		for _, cs := range codespaces {
+			if cs.contains(text[:n]) {
+				return n
+			}
+		}
+	}
+	// no match : use the shortest range matching the first byte,
+	// or the shortest range
+	shortest, shortestFirst := 0, 0
+	for _, cs := range codespaces {
+		l := len(cs.low)
+		if shortest == 0 || l < shortest {
+			shortest = l
+		}
+		if text[0] >= cs.low[0] && text[0] <= cs.high[0] && (shortestFirst == 0 || l < shortestFirst) {
+			shortestFirst = l
+		}
+	}
+	if shortestFirst != 0 {
+		return shortestFirst
+	}
+	if shortest != 0 {
+		return shortest
+	}
+	return 1
+}
+
+// Runes decodes `text` using the Unicode mappings of the CMap.
+// Codes without mapping are ignored.
+func (c *CMap) Runes(text []byte) []rune {
+	var out []rune
+	for _, code := range c.CharCodes(text) {
+		rs, _ := c.Unicode(code)
+		out = append(out, rs...)
+	}
+	return out
+}
+
+// GlyphIndices decodes `text` into glyphs of the given CID-keyed font,
+// using the .notdef glyph (0) for codes without CID or for CIDs not
+// supported by the font.
+func (c *CMap) GlyphIndices(text []byte, font fonts.FaceCID) []fonts.GID {
+	codes := c.CharCodes(text)
+	out := make([]fonts.GID, len(codes))
+	for i, code := range codes {
+		if cid, ok := c.CID(code); ok {
+			out[i], _ = font.CIDToGID(cid)
+		}
+	}
+	return out
+}
+
+// UnicodeCmap returns the mapping from runes to CIDs defined by a Unicode-based
+// CMap, such as UniJIS-UTF16-H, which may be used with fonts.FaceCID.SetCIDCmap.
+// The encoding of the character codes (UCS2, UTF16, UTF32 or UTF8) is deduced
+// from the name of the CMap, and an error is returned if it is not a Unicode CMap.
+func (c *CMap) UnicodeCmap() (fonts.CmapCID, error) {
+	var decode func(code CharCode) (rune, bool)
+	switch name := c.Name; {
+	case strings.Contains(name, "UCS2") || strings.Contains(name, "UTF16"):
+		decode = func(code CharCode) (rune, bool) {
+			var buf [4]byte
+			putCharCode(buf[:], code)
+			rs := decodeUTF16BE(buf[:code.NbBytes])
+			if len(rs) != 1 || rs[0] == utf8.RuneError {
+				return 0, false
+			}
+			return rs[0], true
+		}
+	case strings.Contains(name, "UTF32"):
+		decode = func(code CharCode) (rune, bool) {
+			return rune(code.Code), code.NbBytes == 4 && utf8.ValidRune(rune(code.Code))
+		}
+	case strings.Contains(name, "UTF8"):
+		decode = func(code CharCode) (rune, bool) {
+			var buf [4]byte
+			putCharCode(buf[:], code)
+			r, size := utf8.DecodeRune(buf[:code.NbBytes])
+			return r, r != utf8.RuneError && size == int(code.NbBytes)
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a Unicode CMap", c.Name)
+	}
+
+	out := make(fonts.CmapCID)
+	c.addUnicodeMappings(out, decode)
+	return out, nil
+}
+
+func (c *CMap) addUnicodeMappings(out fonts.CmapCID, decode func(code CharCode) (rune, bool)) {
+	// the parent mappings are overridden
+	if c.UseCMap != nil {
+		c.UseCMap.addUnicodeMappings(out, decode)
+	}
+	for _, rg := range c.cidRanges {
+		for code := rg.low; code.Code <= rg.high.Code; code.Code++ {
+			if r, ok := decode(code); ok {
+				out[r] = rg.cid + fonts.CID(code.Code-rg.low.Code)
+			}
+			if code.Code == rg.high.Code { // avoid overflow
+				break
+			}
+		}
+	}
+	for code, cid := range c.cidChars {
+		if r, ok := decode(code); ok {
+			out[r] = cid
+		}
+	}
+}
+
+// putCharCode writes the bytes of `code` at the start of `dst`
+func putCharCode(dst []byte, code CharCode) {
+	for i := 0; i < int(code.NbBytes); i++ {
+		dst[i] = byte(code.Code >> (8 * (int(code.NbBytes) - 1 - i)))
+	}
+}
+
+// decodeUTF16BE decodes a UTF-16 (big endian) string.
+// A single byte is interpreted as a rune, as found in some invalid files.
+func decodeUTF16BE(b []byte) []rune {
+	if len(b) == 1 {
+		return []rune{rune(b[0])}
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return utf16.Decode(units)
+}
+
+// maximum level of nested usecmap
+const maxUseCMapDepth = 8
+
+// ParseCMap parses a CMap file, which is either a CMap resource
+// (like the predefined ones) or an embedded CMap (like a PDF ToUnicode stream).
+// CMaps included with the usecmap operator are resolved using PredefinedCMap.
+func ParseCMap(file io.Reader) (*CMap, error) {
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseCMap(data, 0)
+}
+
+func parseCMap(data []byte, depth int) (*CMap, error) {
+	p := parser{lexer: newLexer(data)}
+	out, err := p.parseCMap(depth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CMap file: %s", err)
+	}
+	return out, nil
+}
+
+func (p *parser) parseCMap(depth int) (*CMap, error) {
+	out := &CMap{
+		cidChars:    make(map[CharCode]fonts.CID),
+		notdefChars: make(map[CharCode]fonts.CID),
+		bfChars:     make(map[CharCode][]rune),
+	}
+	for {
+		token, err := p.lexer.nextToken()
+		if err != nil {
+			return nil, err
+		}
+		if token.Kind == 0 || token.Kind == tk.EOF {
+			break
+		}
+
+		switch token.Kind {
+		case tk.Name:
+			if p.lexer.peekToken().IsOther("usecmap") {
+				p.lexer.nextToken()
+				if depth >= maxUseCMapDepth {
+					return nil, errors.New("too many nested usecmap")
+				}
+				out.UseCMap, err = predefinedCMap(string(token.Value), depth+1)
+			} else {
+				err = p.readCMapEntry(string(token.Value), out)
+			}
+		case tk.Other:
+			switch string(token.Value) {
+			case "begincodespacerange":
+				err = p.readCodespaceRanges(out)
+			case "begincidrange":
+				out.cidRanges, err = p.readCIDRanges(out.cidRanges, "endcidrange")
+			case "beginnotdefrange":
+				out.notdefRanges, err = p.readCIDRanges(out.notdefRanges, "endnotdefrange")
+			case "begincidchar":
+				err = p.readCIDChars(out.cidChars, "endcidchar")
+			case "beginnotdefchar":
+				err = p.readCIDChars(out.notdefChars, "endnotdefchar")
+			case "beginbfrange":
+				err = p.readBFRanges(out)
+			case "beginbfchar":
+				err = p.readBFChars(out)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(out.cidRanges, func(i, j int) bool { return out.cidRanges[i].low.less(out.cidRanges[j].low) })
+	sort.SliceStable(out.notdefRanges, func(i, j int) bool { return out.notdefRanges[i].low.less(out.notdefRanges[j].low) })
+	sort.SliceStable(out.bfRanges, func(i, j int) bool { return out.bfRanges[i].low.less(out.bfRanges[j].low) })
+	return out, nil
+}
+
+// reads the value of the entries of the CMap dictionary we are interested in
+func (p *parser) readCMapEntry(key string, out *CMap) error {
+	switch key {
+	case "CIDSystemInfo":
+		var (
+			dict map[string][]tk.Token
+			err  error
+		)
+		if p.lexer.peekToken().Kind == tk.StartDic { // PDF syntax
+			dict, err = p.readInlineDict()
+			if err == nil {
+				err = p.readDef()
+			}
+		} else {
+			dict, err = p.readSimpleDict()
+		}
+		if err != nil {
+			return err
+		}
+		out.CIDSystemInfo = readCIDSystemInfo(dict)
+	case "CMapName", "WMode":
+		value, err := p.readValue()
+		if err != nil {
+			return err
+		}
+		// skip usages which are not definitions, like
+		// /CMapName currentdict /CMap defineresource pop
+		if t := p.lexer.peekToken(); !(t.IsOther("def") || t.IsOther("readonly") || t.IsOther("noaccess")) {
+			return nil
+		}
+		if err = p.readDef(); err != nil {
+			return err
+		}
+		if len(value) == 0 {
+			return fmt.Errorf("missing value for key %s", key)
+		}
+		if key == "CMapName" {
+			out.Name = string(value[0].Value)
+		} else {
+			out.WMode, _ = value[0].Int()
+		}
+	}
+	return nil
+}
+
+// Reads a dictionary written as << /Key value ... >>, whose values are simple.
+func (p *parser) readInlineDict() (map[string][]tk.Token, error) {
+	if _, err := p.read(tk.StartDic); err != nil {
+		return nil, err
+	}
+	dict := map[string][]tk.Token{}
+	for {
+		token := p.lexer.peekToken()
+		if token.Kind == tk.EndDic {
+			p.lexer.nextToken()
+			return dict, nil
+		}
+		if token.Kind == 0 || token.Kind == tk.EOF {
+			return nil, errors.New("unexpected end of dictionary")
+		}
+		keyT, err := p.read(tk.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.lexer.nextToken()
+		if err != nil {
+			return nil, err
+		}
+		dict[string(keyT.Value)] = []tk.Token{value}
+	}
+}
+
+// reads the bytes of a character code, as an hexadecimal string
+func (p *parser) readCode() ([]byte, error) {
+	token, err := p.read(tk.StringHex)
+	if err != nil {
+		return nil, err
+	}
+	return token.Value, nil
+}
+
+// reads two codes with the same length
+func (p *parser) readCodeRange() (low, high CharCode, err error) {
+	lowB, err := p.readCode()
+	if err != nil {
+		return low, high, err
+	}
+	highB, err := p.readCode()
+	if err != nil {
+		return low, high, err
+	}
+	if len(lowB) != len(highB) {
+		return low, high, fmt.Errorf("invalid code range <%x> <%x>", lowB, highB)
+	}
+	low, err = newCharCode(lowB)
+	if err != nil {
+		return low, high, err
+	}
+	high, err = newCharCode(highB)
+	return low, high, err
+}
+
+func (p *parser) readCID() (fonts.CID, error) {
+	token, err := p.read(tk.Integer)
+	if err != nil {
+		return 0, err
+	}
+	cid, err := token.Int()
+	if err != nil || cid < 0 || cid > 0xFFFF {
+		return 0, fmt.Errorf("invalid CID %s", token.Value)
+	}
+	return fonts.CID(cid), nil
+}
+
+func (p *parser) readCodespaceRanges(out *CMap) error {
+	for !p.lexer.peekToken().IsOther("endcodespacerange") {
+		low, err := p.readCode()
+		if err != nil {
+			return err
+		}
+		high, err := p.readCode()
+		if err != nil {
+			return err
+		}
+		if len(low) != len(high) || len(low) == 0 || len(low) > 4 {
+			return fmt.Errorf("invalid codespace range <%x> <%x>", low, high)
+		}
+		out.codespaces = append(out.codespaces, codespaceRange{low: low, high: high})
+	}
+	p.lexer.nextToken()
+	return nil
+}
+
+func (p *parser) readCIDRanges(ranges []cidRange, end string) ([]cidRange, error) {
+	for !p.lexer.peekToken().IsOther(end) {
+		low, high, err := p.readCodeRange()
+		if err != nil {
+			return nil, err
+		}
+		cid, err := p.readCID()
+		if err != nil {
+			return nil, err
+		}
+		if high.Code < low.Code {
+			return nil, fmt.Errorf("invalid code range %v %v", low, high)
+		}
+		ranges = append(ranges, cidRange{low: low, high: high, cid: cid})
+	}
+	p.lexer.nextToken()
+	return ranges, nil
+}
+
+func (p *parser) readCIDChars(chars map[CharCode]fonts.CID, end string) error {
+	for !p.lexer.peekToken().IsOther(end) {
+		b, err := p.readCode()
+		if err != nil {
+			return err
+		}
+		code, err := newCharCode(b)
+		if err != nil {
+			return err
+		}
+		chars[code], err = p.readCID()
+		if err != nil {
+			return err
+		}
+	}
+	p.lexer.nextToken()
+	return nil
+}
+
+// reads an UTF-16 string or a glyph name
+func (p *parser) readBFDestination() ([]rune, error) {
+	token, err := p.lexer.nextToken()
+	if err != nil {
+		return nil, err
+	}
+	switch token.Kind {
+	case tk.StringHex:
+		return decodeUTF16BE(token.Value), nil
+	case tk.Name:
+		r, _ := glyphsnames.GlyphToRune(string(token.Value))
+		return []rune{r}, nil
+	default:
+		return nil, fmt.Errorf("invalid bf destination %s", token.Kind)
+	}
+}
+
+func (p *parser) readBFRanges(out *CMap) error {
+	for !p.lexer.peekToken().IsOther("endbfrange") {
+		low, high, err := p.readCodeRange()
+		if err != nil {
+			return err
+		}
+		if high.Code < low.Code {
+			return fmt.Errorf("invalid code range %v %v", low, high)
+		}
+		rg := bfRange{low: low, high: high}
+		switch token := p.lexer.peekToken(); token.Kind {
+		case tk.StringHex:
+			p.lexer.nextToken()
+			rg.dst = token.Value
+		case tk.StartArray:
+			p.lexer.nextToken()
+			for p.lexer.peekToken().Kind != tk.EndArray {
+				dst, err := p.readBFDestination()
+				if err != nil {
+					return err
+				}
+				rg.dsts = append(rg.dsts, dst)
+			}
+			p.lexer.nextToken()
+			if rg.dsts == nil {
+				rg.dsts = [][]rune{}
+			}
+		default:
+			return fmt.Errorf("invalid bfrange destination %s", token.Kind)
+		}
+		out.bfRanges = append(out.bfRanges, rg)
+	}
+	p.lexer.nextToken()
+	return nil
+}
+
+func (p *parser) readBFChars(out *CMap) error {
+	for !p.lexer.peekToken().IsOther("endbfchar") {
+		b, err := p.readCode()
+		if err != nil {
+			return err
+		}
+		code, err := newCharCode(b)
+		if err != nil {
+			return err
+		}
+		out.bfChars[code], err = p.readBFDestination()
+		if err != nil {
+			return err
+		}
+	}
+	p.lexer.nextToken()
+	return nil
+}
+
+// CMapResourceDirs are the directories searched for the predefined CMap files
+// (see PredefinedCMap), before the builtin ones. A file may be stored directly in one of the directories,
+// or with the layout of https://github.com/adobe-type-tools/cmap-resources
+// (<dir>/<collection>/CMap/<name>) or of poppler-data (<dir>/<collection>/<name>).
+// For instance, "/usr/share/poppler/cMap" may be added to use the CMaps installed
+// with poppler-data.
+var CMapResourceDirs []string
+
+// the Unicode CMaps of the Adobe character collections,
+// compressed with gzip
+//
+//go:embed cmaps/*.gz
+var builtinCMaps embed.FS
+
+var predefinedCMaps = struct {
+	sync.Mutex
+	m map[string]*CMap
+}{m: map[string]*CMap{
+	"Identity-H": identityCMap("Identity-H", 0),
+	"Identity-V": identityCMap("Identity-V", 1),
+}}
+
+func identityCMap(name string, wMode int) *CMap {
+	return &CMap{
+		Name:          name,
+		CIDSystemInfo: fonts.CIDSystemInfo{Registry: "Adobe", Ordering: "Identity"},
+		WMode:         wMode,
+		codespaces:    []codespaceRange{{low: []byte{0, 0}, high: []byte{0xFF, 0xFF}}},
+		cidRanges:     []cidRange{{low: CharCode{0, 2}, high: CharCode{0xFFFF, 2}, cid: 0}},
+	}
+}
+
+// PredefinedCMap returns the predefined CMap with the given name.
+// Identity-H and Identity-V are builtin, as well as the Unicode CMaps
+// of the Adobe character collections (UniGB, UniCNS, UniJIS and UniKS), for the
+// UCS2 and UTF16 encodings and both writing modes (like UniGB-UTF16-H or UniJIS-UCS2-V).
+// The other ones must be provided as files, searched in CMapResourceDirs,
+// which also take precedence over the builtin CMaps.
+// The CMaps are cached and should not be modified.
+func PredefinedCMap(name string) (*CMap, error) { return predefinedCMap(name, 0) }
+
+func predefinedCMap(name string, depth int) (*CMap, error) {
+	predefinedCMaps.Lock()
+	cmap, ok := predefinedCMaps.m[name]
+	predefinedCMaps.Unlock()
+	if ok {
+		return cmap, nil
+	}
+
+	// the name is used in file paths and glob patterns
+	if name == "" || name[0] == '.' {
+		return nil, fmt.Errorf("invalid CMap name %s", name)
+	}
+	for _, c := range name {
+		if !(c == '-' || c == '_' || c == '.' || c == '+' ||
+			'0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return nil, fmt.Errorf("invalid CMap name %s", name)
+		}
+	}
+
+	data, err := readPredefinedCMap(name)
+	if err != nil {
+		return nil, err
+	}
+	cmap, err = parseCMap(data, depth)
+	if err != nil {
+		return nil, err
+	}
+	if cmap.Name == "" {
+		cmap.Name = name
+	}
+
+	predefinedCMaps.Lock()
+	predefinedCMaps.m[name] = cmap
+	predefinedCMaps.Unlock()
+	return cmap, nil
+}
+
+func readPredefinedCMap(name string) ([]byte, error) {
+	for _, dir := range CMapResourceDirs {
+		candidates := []string{filepath.Join(dir, name)}
+		for _, pattern := range [...]string{
+			filepath.Join(dir, "*", name),
+			filepath.Join(dir, "*", "CMap", name),
+		} {
+			matches, _ := filepath.Glob(pattern)
+			candidates = append(candidates, matches...)
+		}
+		for _, path := range candidates {
+			data, err := os.ReadFile(path)
+			if err == nil && bytes.Contains(data, []byte("begincmap")) {
+				return data, nil
+			}
+		}
+	}
+
+	file, err := builtinCMaps.Open("cmaps/" + name + ".gz")
+	if err != nil {
+		return nil, fmt.Errorf("predefined CMap %s not found", name)
+	}
+	defer file.Close()
+	r, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid builtin CMap %s: %s", name, err)
+	}
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,247 @@
+package type1
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+const sampleCMap = `%!PS-Adobe-3.0 Resource-CMap
+%%DocumentNeededResources: ProcSet (CIDInit)
+%%BeginResource: CMap (Test-RKSJ-H)
+/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo 3 dict dup begin
+  /Registry (Adobe) def
+  /Ordering (Japan1) def
+  /Supplement 2 def
+end def
+/CMapName /Test-RKSJ-H def
+/CMapType 1 def
+/WMode 0 def
+2 begincodespacerange
+  <00>   <80>
+  <8140> <9FFC>
+endcodespacerange
+1 beginnotdefrange
+<00> <1f> 231
+endnotdefrange
+2 begincidrange
+<20> <7e> 231
+<8140> <817e> 633
+endcidrange
+1 begincidchar
+<815f> 97
+endcidchar
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+%%EndResource
+%%EOF
+`
+
+const sampleToUnicode = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+3 beginbfchar
+<0003> <0020>
+<0010> <D835DC00>
+<0011> /fi
+endbfchar
+2 beginbfrange
+<0020> <0022> <0041>
+<0030> <0031> [<00660066> <0062>]
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`
+
+func TestParseCMap(t *testing.T) {
+	cmap, err := ParseCMap(strings.NewReader(sampleCMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmap.Name != "Test-RKSJ-H" || cmap.WMode != 0 ||
+		cmap.CIDSystemInfo != (fonts.CIDSystemInfo{Registry: "Adobe", Ordering: "Japan1", Supplement: 2}) {
+		t.Fatalf("unexpected CMap header %s %d %v", cmap.Name, cmap.WMode, cmap.CIDSystemInfo)
+	}
+
+	codes := cmap.CharCodes([]byte{'a', 0x81, 0x5f, 0x81, 0x41, 0x05, 0xA0})
+	exp := []CharCode{{'a', 1}, {0x815f, 2}, {0x8141, 2}, {0x05, 1}, {0xA0, 1}}
+	if !reflect.DeepEqual(codes, exp) {
+		t.Fatalf("expected %v, got %v", exp, codes)
+	}
+	for i, cid := range []fonts.CID{231 + 'a' - 0x20, 97, 634, 231} {
+		if got, ok := cmap.CID(codes[i]); !ok || got != cid {
+			t.Fatalf("code %v: expected CID %d, got %d", codes[i], cid, got)
+		}
+	}
+	if _, ok := cmap.CID(codes[4]); ok {
+		t.Fatal("unexpected CID for unmapped code")
+	}
+
+	toUnicode, err := ParseCMap(strings.NewReader(sampleToUnicode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toUnicode.CIDSystemInfo.Ordering != "UCS" {
+		t.Fatalf("unexpected ROS %v", toUnicode.CIDSystemInfo)
+	}
+	text := []byte{0, 0x21, 0, 3, 0, 0x10, 0, 0x11, 0, 0x30, 0, 0x31, 0, 0x22, 0, 0x40}
+	if runes := string(toUnicode.Runes(text)); runes != "B 𝐀ﬁffbC" {
+		t.Fatalf("unexpected text %s", runes)
+	}
+
+	for _, invalid := range []string{
+		"1 begincodespacerange <00> <FFFF> endcodespacerange",
+		"1 begincidrange <00> <FF> 100000 endcidrange",
+		"1 begincidrange <0000> <FF> 1 endcidrange",
+		"1 beginbfchar <00> 12 endbfchar",
+	} {
+		if _, err = ParseCMap(strings.NewReader(invalid)); err == nil {
+			t.Fatalf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestUseCMap(t *testing.T) {
+	cmap, err := ParseCMap(strings.NewReader(`/CMapName /Test-Identity-H def
+/Identity-H usecmap
+1 begincidchar
+<0001> 2
+endcidchar
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmap.UseCMap == nil || cmap.UseCMap.Name != "Identity-H" {
+		t.Fatal("missing parent CMap")
+	}
+
+	font, err := ParseCIDFont(bytes.NewReader(buildCIDFont(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gids := cmap.GlyphIndices([]byte{0, 2, 0, 1, 0, 0, 0, 5}, font)
+	if exp := []fonts.GID{2, 2, 0, 0}; !reflect.DeepEqual(gids, exp) {
+		t.Fatalf("expected %v, got %v", exp, gids)
+	}
+
+	if _, err = ParseCMap(strings.NewReader("/../../etc/passwd usecmap")); err == nil {
+		t.Fatal("expected error for invalid CMap name")
+	}
+}
+
+func TestPredefinedCMap(t *testing.T) {
+	for wMode, name := range []string{"Identity-H", "Identity-V"} {
+		cmap, err := PredefinedCMap(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cid, _ := cmap.CID(CharCode{0x1234, 2}); cid != 0x1234 {
+			t.Fatalf("unexpected CID %d", cid)
+		}
+		if cmap.WMode != wMode {
+			t.Fatalf("unexpected writing mode %d", cmap.WMode)
+		}
+	}
+
+	// Adobe cmap-resources layout
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Adobe-Test", "CMap"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	content := strings.NewReplacer("Test-RKSJ-H", "UniTest-UTF16-H",
+		"<8140> <9FFC>", "<0000> <D7FF> <D800DC00> <DBFFDFFF>",
+		"<8140> <817e> 633", "<00e9> <00ea> 633 <D835DC00> <D835DC00> 500").Replace(sampleCMap)
+	err := os.WriteFile(filepath.Join(dir, "Adobe-Test", "CMap", "UniTest-UTF16-H"), []byte(content), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(dirs []string) { CMapResourceDirs = dirs }(CMapResourceDirs)
+	CMapResourceDirs = []string{dir}
+
+	cmap, err := PredefinedCMap("UniTest-UTF16-H")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached, _ := PredefinedCMap("UniTest-UTF16-H"); cached != cmap {
+		t.Fatal("CMap should be cached")
+	}
+	runes, err := cmap.UnicodeCmap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runes['é'] != 633 || runes['ê'] != 634 || runes['𝐀'] != 500 || runes[' '] != 231 {
+		t.Fatalf("unexpected cmap %v", runes)
+	}
+
+	if _, err = PredefinedCMap("UniMissing-UTF16-H"); err == nil {
+		t.Fatal("expected error for missing CMap")
+	}
+	for _, name := range []string{"", ".", "../UniGB-UTF16-H"} {
+		if _, err = PredefinedCMap(name); err == nil {
+			t.Fatalf("expected error for invalid name %s", name)
+		}
+	}
+	identity, _ := PredefinedCMap("Identity-H")
+	if _, err = identity.UnicodeCmap(); err == nil {
+		t.Fatal("expected error for non Unicode CMap")
+	}
+}
+
+func TestBuiltinCMaps(t *testing.T) {
+	// no external resources are needed
+	defer func(dirs []string) { CMapResourceDirs = dirs }(CMapResourceDirs)
+	CMapResourceDirs = nil
+
+	for _, test := range []struct {
+		name     string
+		ordering string
+		wMode    int
+		r        rune
+		cid      fonts.CID
+	}{
+		{"UniGB-UTF16-H", "GB1", 0, 0xA4, 167},
+		{"UniGB-UCS2-H", "GB1", 0, 0xA4, 167},
+		{"UniCNS-UTF16-H", "CNS1", 0, 0xA5, 260},
+		{"UniJIS-UCS2-H", "Japan1", 0, 0x5C, 97},
+		{"UniJIS-UTF16-H", "Japan1", 0, 0xA0, 1},
+		{"UniJIS-UTF16-V", "Japan1", 1, 0x2190, 738},
+		{"UniKS-UTF16-H", "Korea1", 0, 0x2542, 550},
+		{"UniKS-UCS2-V", "Korea1", 1, 'A', 34},
+	} {
+		cmap, err := PredefinedCMap(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmap.CIDSystemInfo.Registry != "Adobe" || cmap.CIDSystemInfo.Ordering != test.ordering {
+			t.Fatalf("%s: unexpected CIDSystemInfo %v", test.name, cmap.CIDSystemInfo)
+		}
+		if cmap.WMode != test.wMode {
+			t.Fatalf("%s: unexpected writing mode %d", test.name, cmap.WMode)
+		}
+		runes, err := cmap.UnicodeCmap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runes[test.r] != test.cid {
+			t.Fatalf("%s: expected CID %d for %q, got %d", test.name, test.cid, test.r, runes[test.r])
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package type1
 
 import (
+	"errors"
 	"math"
 
 	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
 )
 
 // font metrics
@@ -11,11 +13,13 @@ import (
 var _ fonts.FaceMetrics = (*Font)(nil)
 
 // Upem reads the FontMatrix to extract the scaling factor (the maximum between x and y coordinates)
-func (f *Font) Upem() uint16 {
-	if len(f.FontMatrix) < 4 {
+func (f *Font) Upem() uint16 { return upemFromMatrix(f.FontMatrix) }
+
+func upemFromMatrix(fontMatrix []Fl) uint16 {
+	if len(fontMatrix) < 4 {
 		return 1000 // typical value for Type1 fonts
 	}
-	xx, yy := math.Abs(float64(f.FontMatrix[0])), math.Abs(float64(f.FontMatrix[3]))
+	xx, yy := math.Abs(float64(fontMatrix[0])), math.Abs(float64(fontMatrix[3]))
 	var (
 		upemX uint16 = 1000
 		upemY        = upemX
@@ -45,8 +49,18 @@ func (f *Font) LineMetric(metric fonts.LineMetric) (float32, bool) {
 		return float32(f.PSInfo.UnderlinePosition), true
 	case fonts.UnderlineThickness:
 		return float32(f.PSInfo.UnderlineThickness), true
-	default:
+	case fonts.CapHeight:
 		// CapHeight and XHeight are stored in .afm files
+		if f.afm == nil || f.afm.capHeight == 0 {
+			return 0, false
+		}
+		return f.afm.capHeight, true
+	case fonts.XHeight:
+		if f.afm == nil || f.afm.xHeight == 0 {
+			return 0, false
+		}
+		return f.afm.xHeight, true
+	default:
 		return 0, false
 	}
 }
@@ -60,6 +74,13 @@ func (f *Font) FontHExtents() (fonts.FontExtents, bool) {
 	// following freetype here
 	extents.Ascender = float32(yMax)
 	extents.Descender = float32(yMin)
+	// prefer the values from the .afm file, if any
+	if f.afm != nil && f.afm.ascender != 0 {
+		extents.Ascender = f.afm.ascender
+	}
+	if f.afm != nil && f.afm.descender != 0 {
+		extents.Descender = f.afm.descender
+	}
 
 	extents.LineGap = float32(f.Upem()) * 1.2
 	if extents.LineGap < extents.Ascender-extents.Descender {
@@ -84,9 +105,15 @@ func (f *Font) NominalGlyph(ch rune) (fonts.GID, bool) {
 
 // HorizontalAdvance returns the advance of the glyph with index `index`
 // The return value is expressed in font units.
+// The width from the attached .afm file is used if available.
 // 0 is returned for invalid index values and for invalid
 // charstring glyph data.
 func (f *Font) HorizontalAdvance(gid fonts.GID) float32 {
+	if f.afm != nil {
+		if adv, ok := f.afm.advances[gid]; ok {
+			return float32(adv)
+		}
+	}
 	_, _, adv, err := f.loadGlyph(gid, false)
 	if err != nil {
 		return 0
@@ -122,9 +149,51 @@ var _ fonts.FaceRenderer = (*Font)(nil)
 // The returned value is either a fonts.GlyphOutline or nil if an error
 // occured.
 func (f *Font) GlyphData(gid fonts.GID, _, _ uint16) fonts.GlyphData {
-	segments, _, _, err := f.loadGlyph(gid, false)
-	if err != nil {
+	var out fonts.GlyphOutline
+	if err := f.DrawGlyph(gid, &out); err != nil {
 		return nil
 	}
-	return fonts.GlyphOutline{Segments: segments}
+	return out
+}
+
+var _ fonts.FaceOutliner = (*Font)(nil)
+
+// DrawGlyph sends the outlines of the given glyph to `pen`.
+// Accented glyphs built with the seac operator are supported.
+func (f *Font) DrawGlyph(gid fonts.GID, pen fonts.Pen) error {
+	_, _, err := f.drawGlyph(gid, pen, false)
+	return err
+}
+
+// LoadGlyphHints returns the outlines and the stem hints of the given glyph.
+// Accented glyphs built with the seac operator are returned without hints.
+func (f *Font) LoadGlyphHints(gid fonts.GID) ([]fonts.Segment, ps.GlyphHints, error) {
+	if int(gid) >= len(f.charstrings) {
+		return nil, ps.GlyphHints{}, errors.New("invalid glyph index")
+	}
+	var (
+		psi    ps.Machine
+		parser type1CharstringParser
+	)
+	err := psi.Run(f.charstrings[gid].data, f.subrs, nil, &parser)
+	if err != nil {
+		return nil, ps.GlyphHints{}, err
+	}
+	if parser.seac != nil {
+		segments, _, _, err := f.loadGlyph(gid, false)
+		return segments, ps.GlyphHints{}, err
+	}
+	return parser.cs.Segments, parser.cs.Hints, nil
+}
+
+// HintedGlyphData returns the outline of the glyph, grid-fitted
+// for a size of `ppem` pixels per em, using the stem hints of the glyph
+// and the hinting values of the font.
+func (f *Font) HintedGlyphData(gid fonts.GID, ppem uint16) (fonts.GlyphOutline, error) {
+	segments, hints, err := f.LoadGlyphHints(gid)
+	if err != nil {
+		return fonts.GlyphOutline{}, err
+	}
+	gf := ps.NewGridFitter(f.Hinting, f.Upem(), ppem)
+	return fonts.GlyphOutline{Segments: gf.Fit(segments, hints)}, nil
 }
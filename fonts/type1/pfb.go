@@ -17,7 +17,15 @@ type loader struct{}
 
 // Load implements fonts.FontLoader. When the error is `nil`,
 // one (and only one) font is returned.
+// CID-keyed fonts are also supported, and returned as *CIDFont.
 func Load(file fonts.Resource) (fonts.Faces, error) {
+	if isCIDFont(file) {
+		f, err := ParseCIDFont(file)
+		if err != nil {
+			return nil, err
+		}
+		return fonts.Faces{f}, nil
+	}
 	f, err := Parse(file)
 	if err != nil {
 		return nil, err
@@ -26,7 +34,8 @@ func Load(file fonts.Resource) (fonts.Faces, error) {
 }
 
 // Parse parses an Adobe Type 1 (.pfb) font file.
-// See `ParseAFMFile` to read the associated Adobe font metric file.
+// See `Font.AttachSiblingAFM`, or `ParseAFMFile` and `Font.AttachAFM`, to complete
+// the font with an Adobe font metric file.
 func Parse(pfb fonts.Resource) (*Font, error) {
 	seg1, seg2, err := openPfb(pfb)
 	if err != nil {
@@ -58,6 +67,7 @@ type charstring struct {
 type Font struct {
 	Encoding *simpleencodings.Encoding
 	cmap     fonts.CmapSimple // see synthetizeCmap
+	afm      *afmMetrics      // optional, see AttachAFM
 
 	FontID      string
 	FontBBox    []Fl
@@ -69,6 +79,9 @@ type Font struct {
 
 	StrokeWidth Fl
 
+	// Hinting stores the values read from the Private dict.
+	Hinting ps.HintingParams
+
 	PaintType int
 	FontType  int
 	UniqueID  int
@@ -78,14 +91,14 @@ func (f *Font) PostscriptInfo() (fonts.PSInfo, bool) { return f.PSInfo, true }
 
 func (f *Font) PoscriptName() string { return f.PSInfo.FontName }
 
-func (f *Font) getStyle() (isItalic, isBold bool, familyName, styleName string) {
+func getStyle(info fonts.PSInfo) (isItalic, isBold bool, familyName, styleName string) {
 	// ported from freetype/src/type1/t1objs.c
 
 	// get style name -- be careful, some broken fonts only
 	// have a `/FontName' dictionary entry!
-	familyName = f.PSInfo.FamilyName
+	familyName = info.FamilyName
 	if familyName != "" {
-		full := f.PSInfo.FullName
+		full := info.FullName
 
 		theSame := true
 
@@ -115,14 +128,14 @@ func (f *Font) getStyle() (isItalic, isBold bool, familyName, styleName string)
 
 	styleName = strings.TrimSpace(styleName)
 	if styleName == "" {
-		styleName = strings.TrimSpace(f.PSInfo.Weight)
+		styleName = strings.TrimSpace(info.Weight)
 	}
 	if styleName == "" { // assume `Regular' style because we don't know better
 		styleName = "Regular"
 	}
 
-	isItalic = f.PSInfo.ItalicAngle != 0
-	isBold = f.PSInfo.Weight == "Bold" || f.PSInfo.Weight == "Black"
+	isItalic = info.ItalicAngle != 0
+	isBold = info.Weight == "Bold" || info.Weight == "Black"
 	return
 }
 
@@ -130,7 +143,7 @@ func (f *Font) getStyle() (isItalic, isBold bool, familyName, styleName string)
 func (f *Font) LoadMetrics() fonts.FaceMetrics { return f }
 
 func (f *Font) LoadSummary() (fonts.FontSummary, error) {
-	isItalic, isBold, familyName, styleName := f.getStyle()
+	isItalic, isBold, familyName, styleName := getStyle(f.PSInfo)
 	return fonts.FontSummary{
 		IsItalic:          isItalic,
 		IsBold:            isBold,
@@ -169,59 +182,68 @@ func (f *Font) synthetizeCmap() {
 	}
 }
 
-// loadGlyph returns the advance of the glyph with index `index`
+// loadGlyph returns the outlines, the bounds and the advance of the glyph with index `index`
 // The return value is expressed in font units.
 // An error is returned for invalid index values and for invalid
 // charstring glyph data.
 // inSeac is used to check for recursion in seac glyphs
 func (f *Font) loadGlyph(index fonts.GID, inSeac bool) ([]fonts.Segment, ps.PathBounds, int32, error) {
+	var out fonts.GlyphOutline
+	bounds, advance, err := f.drawGlyph(index, &out, inSeac)
+	if err != nil {
+		return nil, ps.PathBounds{}, 0, err
+	}
+	return out.Segments, bounds, advance, nil
+}
+
+// drawGlyph is the same as loadGlyph, but sends the outlines to `pen`
+func (f *Font) drawGlyph(index fonts.GID, pen fonts.Pen, inSeac bool) (ps.PathBounds, int32, error) {
 	if int(index) >= len(f.charstrings) {
-		return nil, ps.PathBounds{}, 0, errors.New("invalid glyph index")
+		return ps.PathBounds{}, 0, errors.New("invalid glyph index")
 	}
 
 	var (
 		psi    ps.Machine
 		parser type1CharstringParser
 	)
+	parser.cs.Pen = pen
 	err := psi.Run(f.charstrings[index].data, f.subrs, nil, &parser)
 	if err != nil {
-		return nil, ps.PathBounds{}, 0, err
+		return ps.PathBounds{}, 0, err
 	}
 	// handle the special case of seac glyph
 	if parser.seac != nil {
 		if inSeac {
-			return nil, ps.PathBounds{}, 0, errors.New("invalid nested seac operator")
+			return ps.PathBounds{}, 0, errors.New("invalid nested seac operator")
 		}
-		var (
-			bounds   ps.PathBounds
-			segments []fonts.Segment
-		)
-		segments, bounds, err = f.seacMetrics(*parser.seac)
+		bounds, err := f.drawSeac(*parser.seac, pen)
 		if err != nil {
-			return nil, ps.PathBounds{}, 0, err
+			return ps.PathBounds{}, 0, err
 		}
-		return segments, bounds, parser.advance.X, err
+		return bounds, parser.advance.X, err
 	}
-	return parser.cs.Segments, parser.cs.Bounds, parser.advance.X, err
+	return parser.cs.Bounds, parser.advance.X, err
 }
 
-func (f *Font) seacMetrics(seac seac) ([]fonts.Segment, ps.PathBounds, error) {
+func (f *Font) drawSeac(seac seac, pen fonts.Pen) (ps.PathBounds, error) {
 	aGlyph, err := f.glyphIndexFromStandardCode(seac.aCode)
 	if err != nil {
-		return nil, ps.PathBounds{}, err
+		return ps.PathBounds{}, err
 	}
 	bGlyph, err := f.glyphIndexFromStandardCode(seac.bCode)
 	if err != nil {
-		return nil, ps.PathBounds{}, err
+		return ps.PathBounds{}, err
 	}
-	segmentsBase, boundsBase, _, err := f.loadGlyph(bGlyph, true)
+	boundsBase, _, err := f.drawGlyph(bGlyph, pen, true)
 	if err != nil {
-		return nil, ps.PathBounds{}, err
+		return ps.PathBounds{}, err
 	}
 
-	segmentsAccent, boundsAccent, _, err := f.loadGlyph(aGlyph, true)
+	// the accent bounds are required before drawing it
+	var accent fonts.GlyphOutline
+	boundsAccent, _, err := f.drawGlyph(aGlyph, &accent, true)
 	if err != nil {
-		return nil, ps.PathBounds{}, err
+		return ps.PathBounds{}, err
 	}
 
 	// translate the accent
@@ -231,19 +253,19 @@ func (f *Font) seacMetrics(seac seac) ([]fonts.Segment, ps.PathBounds, error) {
 	boundsAccent.Min.Move(offsetOriginX, offsetOriginY)
 	boundsAccent.Max.Move(offsetOriginX, offsetOriginY)
 	offsetOriginXF, offsetOriginYF := float32(offsetOriginX), float32(offsetOriginY)
-	for i := range segmentsAccent {
-		argsSlice := segmentsAccent[i].ArgsSlice()
+	for i := range accent.Segments {
+		argsSlice := accent.Segments[i].ArgsSlice()
 		for j := range argsSlice {
 			argsSlice[j].Move(offsetOriginXF, offsetOriginYF)
 		}
 	}
+	accent.Draw(pen)
 
 	// union with the base
 	boundsBase.Enlarge(boundsAccent.Min)
 	boundsBase.Enlarge(boundsAccent.Max)
-	segmentsBase = append(segmentsBase, segmentsAccent...)
 
-	return segmentsBase, boundsBase, nil
+	return boundsBase, nil
 }
 
 func (f *Font) glyphIndexFromStandardCode(code int32) (fonts.GID, error) {
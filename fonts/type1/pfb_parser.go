@@ -12,6 +12,7 @@ import (
 
 	tk "github.com/benoitkugler/pstokenizer"
 	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
 	"github.com/benoitkugler/textlayout/fonts/simpleencodings"
 )
 
@@ -694,6 +695,9 @@ func (p *parser) parseBinary(bytes []byte, font *Font) error {
 
 	lenIV := 4 // number of random bytes at start of charstring
 
+	// default values
+	font.Hinting = ps.HintingParams{BlueScale: 0.039625, BlueShift: 7, BlueFuzz: 1}
+
 	for i := 0; i < length; i++ {
 		// premature end
 		if p.lexer.peekToken().Kind != tk.Name {
@@ -779,7 +783,7 @@ func (p *parser) parseBinary(bytes []byte, font *Font) error {
 			if err != nil {
 				return err
 			}
-			err = p.readPrivate(key.Value, vs)
+			p.readPrivate(font, key.Value, vs)
 		}
 
 		if err != nil {
@@ -809,54 +813,48 @@ func (p *parser) parseBinary(bytes []byte, font *Font) error {
 	return err
 }
 
-// Extracts values from the /Private dictionary.
-func (p *parser) readPrivate(key []byte, value []tk.Token) error {
-	// TODO: complete if needed
-	// 		 switch (key)
-	// 		 {
-	// 			 case "BlueValues":
-	// 				 font.blueValues = arrayToNumbers(value);
-	// 				 break;
-	// 			 case "OtherBlues":
-	// 				 font.otherBlues = arrayToNumbers(value);
-	// 				 break;
-	// 			 case "FamilyBlues":
-	// 				 font.familyBlues = arrayToNumbers(value);
-	// 				 break;
-	// 			 case "FamilyOtherBlues":
-	// 				 font.familyOtherBlues = arrayToNumbers(value);
-	// 				 break;
-	// 			 case "BlueScale":
-	// 				 font.blueScale = value[0].floatValue();
-	// 				 break;
-	// 			 case "BlueShift":
-	// 				 font.blueShift = value[0].intValue();
-	// 				 break;
-	// 			 case "BlueFuzz":
-	// 				 font.blueFuzz = value[0].intValue();
-	// 				 break;
-	// 			 case "StdHW":
-	// 				 font.stdHW = arrayToNumbers(value);
-	// 				 break;
-	// 			 case "StdVW":
-	// 				 font.stdVW = arrayToNumbers(value);
-	// 				 break;
-	// 			 case "StemSnapH":
-	// 				 font.stemSnapH = arrayToNumbers(value);
-	// 				 break;
-	// 			 case "StemSnapV":
-	// 				 font.stemSnapV = arrayToNumbers(value);
-	// 				 break;
-	// 			 case "ForceBold":
-	// 				 font.forceBold = value[0].booleanValue();
-	// 				 break;
-	// 			 case "LanguageGroup":
-	// 				 font.languageGroup = value[0].intValue();
-	// 				 break;
-	// 			 default:
-	// 				 break;
-	// 		 }
-	return nil
+// Extracts the hinting values from the /Private dictionary.
+// Invalid values are ignored.
+func (p *parser) readPrivate(font *Font, key []byte, value []tk.Token) {
+	readNumbers := func() []Fl {
+		numbers, _ := p.arrayToNumbers(value)
+		return numbers
+	}
+	readNumber := func() Fl {
+		if len(value) == 0 {
+			return 0
+		}
+		f, _ := value[0].Float()
+		return Fl(f)
+	}
+	readFirstNumber := func() Fl {
+		if numbers := readNumbers(); len(numbers) != 0 {
+			return numbers[0]
+		}
+		return 0
+	}
+
+	h := &font.Hinting
+	switch string(key) {
+	case "BlueValues":
+		h.BlueValues = readNumbers()
+	case "OtherBlues":
+		h.OtherBlues = readNumbers()
+	case "BlueScale":
+		h.BlueScale = readNumber()
+	case "BlueShift":
+		h.BlueShift = readNumber()
+	case "BlueFuzz":
+		h.BlueFuzz = readNumber()
+	case "StdHW":
+		h.StdHW = readFirstNumber()
+	case "StdVW":
+		h.StdVW = readFirstNumber()
+	case "StemSnapH":
+		h.StemSnapH = readNumbers()
+	case "StemSnapV":
+		h.StemSnapV = readNumbers()
+	}
 }
 
 // Reads the /Subrs array.
@@ -17,7 +17,8 @@ import (
 
 // var Loader fonts.FontLoader = loader{}
 
-// var _ fonts.Face = (*Font)(nil)
+var _ fonts.Face = (*Font)(nil)
+var _ fonts.FaceCID = (*Font)(nil)
 
 type loader struct{}
 
@@ -31,10 +32,16 @@ func Load(file fonts.Resource) ([]Font, error) {
 type Font struct {
 	userStrings userStrings
 	fdSelect    fdSelect // only valid for CIDFonts
-	charset     []uint16 // indexed by glyph ID
+	charset     []uint16 // indexed by glyph ID, storing CIDs for CIDFonts
 	Encoding    *simpleencodings.Encoding
 
-	cmap fonts.CmapSimple // see synthetizeCmap
+	cmap fonts.CmapSimple // see synthetizeCmap and SetCIDCmap
+
+	ros      fonts.CIDSystemInfo     // only valid for CIDFonts
+	cidToGID map[fonts.CID]fonts.GID // only valid for CIDFonts
+
+	fontBBox   [4]float32
+	fontMatrix []float32 // of length 6, including the matrix of the first font dict for CIDFonts
 
 	cidFontName string
 	charstrings [][]byte // indexed by glyph ID
@@ -43,6 +50,8 @@ type Font struct {
 	// array of length 1 for non CIDFonts
 	// For CIDFonts, it can be safely indexed by `fdSelect` output
 	localSubrs [][][]byte
+	// same indexing as `localSubrs`
+	privates []PrivateDict
 	fonts.PSInfo
 }
 
@@ -88,7 +97,8 @@ func parse(file fonts.Resource) ([]Font, error) {
 }
 
 // Type1 fonts have no natural notion of Unicode code points
-// We use a glyph names table to identify the most commonly used runes
+// We use a glyph names table to identify the most commonly used runes.
+// CIDFonts have no glyph names: see SetCIDCmap.
 func (f *Font) synthetizeCmap() {
 	f.cmap = make(map[rune]fonts.GID)
 	for gid := range f.charstrings {
@@ -103,6 +113,7 @@ func (f *Font) Cmap() (fonts.Cmap, fonts.CmapEncoding) {
 }
 
 // GlyphName returns the name of the glyph or an empty string if not found.
+// Glyphs of CIDFonts have no names.
 func (f *Font) GlyphName(glyph fonts.GID) string {
 	if f.fdSelect != nil || int(glyph) >= len(f.charset) {
 		return ""
@@ -250,7 +261,7 @@ func (f *Font) LoadSummary() (fonts.FontSummary, error) {
 	}, nil
 }
 
-// func (Font) LoadBitmaps() []fonts.BitmapSize { return nil }
-// func (f *Font) LoadMetrics() fonts.FaceMetrics {
-// 	return nil // TODO:
-// }
+func (Font) LoadBitmaps() []fonts.BitmapSize { return nil }
+
+// LoadMetrics returns the font itself.
+func (f *Font) LoadMetrics() fonts.FaceMetrics { return f }
@@ -10,6 +10,46 @@ import (
 // LoadGlyph parses the glyph charstring to compute segments and path bounds.
 // It returns an error if the glyph is invalid or if decoding the charstring fails.
 func (f *Font) LoadGlyph(glyph fonts.GID) ([]fonts.Segment, ps.PathBounds, error) {
+	loader, err := f.loadGlyph(glyph, nil)
+	return loader.cs.Segments, loader.cs.Bounds, err
+}
+
+// LoadGlyphHints is the same as LoadGlyph, but returns the
+// stem hints of the glyph instead of its bounds.
+func (f *Font) LoadGlyphHints(glyph fonts.GID) ([]fonts.Segment, ps.GlyphHints, error) {
+	loader, err := f.loadGlyph(glyph, nil)
+	return loader.cs.Segments, loader.cs.Hints, err
+}
+
+var _ fonts.FaceOutliner = (*Font)(nil)
+
+// DrawGlyph sends the outlines of the given glyph to `pen`,
+// without storing the segments.
+func (f *Font) DrawGlyph(glyph fonts.GID, pen fonts.Pen) error {
+	_, err := f.loadGlyph(glyph, pen)
+	return err
+}
+
+// HintedGlyphData returns the outline of the glyph, grid-fitted
+// for a size of `ppem` pixels per em, using the stem hints of the glyph
+// and the hinting values of its Private dict.
+func (f *Font) HintedGlyphData(glyph fonts.GID, ppem uint16) (fonts.GlyphOutline, error) {
+	segments, hints, err := f.LoadGlyphHints(glyph)
+	if err != nil {
+		return fonts.GlyphOutline{}, err
+	}
+	var params ps.HintingParams
+	if index, err := f.FontDictIndex(glyph); err == nil && int(index) < len(f.privates) {
+		params = f.privates[index].HintingParams()
+	}
+	gf := ps.NewGridFitter(params, f.Upem(), ppem)
+	return fonts.GlyphOutline{Segments: gf.Fit(segments, hints)}, nil
+}
+
+// loadGlyph runs the charstring of the glyph, returning the
+// loader storing the outlines, the hints and the advance width.
+// If `pen` is not nil, the outlines are sent to it instead.
+func (f *Font) loadGlyph(glyph fonts.GID, pen fonts.Pen) (type2CharstringHandler, error) {
 	var (
 		psi    ps.Machine
 		loader type2CharstringHandler
@@ -19,16 +59,23 @@ func (f *Font) LoadGlyph(glyph fonts.GID) ([]fonts.Segment, ps.PathBounds, error
 	if f.fdSelect != nil {
 		index, err = f.fdSelect.fontDictIndex(glyph)
 		if err != nil {
-			return nil, ps.PathBounds{}, err
+			return loader, err
 		}
 	}
 	if int(glyph) >= len(f.charstrings) {
-		return nil, ps.PathBounds{}, fmt.Errorf("invalid glyph index %d", glyph)
+		return loader, fmt.Errorf("invalid glyph index %d", glyph)
+	}
+
+	if int(index) < len(f.privates) {
+		priv := f.privates[index]
+		loader.nominalWidthX = int32(priv.NominalWidthX)
+		loader.width = int32(priv.DefaultWidthX)
 	}
 
+	loader.cs.Pen = pen
 	subrs := f.localSubrs[index]
 	err = psi.Run(f.charstrings[glyph], subrs, f.globalSubrs, &loader)
-	return loader.cs.Segments, loader.cs.Bounds, err
+	return loader, err
 }
 
 // type2CharstringHandler implements operators needed to fetch Type2 charstring metrics
@@ -51,7 +98,9 @@ func (met *type2CharstringHandler) Apply(op ps.PsOperator, state *ps.Machine) er
 		case 11: // return
 			return state.Return() // do not clear the arg stack
 		case 14: // endchar
-			if state.ArgStack.Top > 0 { // width is optional
+			// width is optional, and the 4 arguments of
+			// the deprecated seac form must not be taken for it
+			if state.ArgStack.Top&1 != 0 {
 				met.width = met.nominalWidthX + state.ArgStack.Vals[0]
 			}
 			met.cs.ClosePath()
@@ -76,8 +125,14 @@ func (met *type2CharstringHandler) Apply(op ps.PsOperator, state *ps.Machine) er
 			}
 			err = met.cs.Vmoveto(state)
 		case 1, 18: // hstem, hstemhm
+			if state.ArgStack.Top&1 != 0 { // width is optional
+				met.width = met.nominalWidthX + state.ArgStack.Vals[0]
+			}
 			met.cs.Hstem(state)
 		case 3, 23: // vstem, vstemhm
+			if state.ArgStack.Top&1 != 0 { // width is optional
+				met.width = met.nominalWidthX + state.ArgStack.Vals[0]
+			}
 			met.cs.Vstem(state)
 		case 19, 20: // hintmask, cntrmask
 			// variable number of arguments, but always even
@@ -85,7 +140,11 @@ func (met *type2CharstringHandler) Apply(op ps.PsOperator, state *ps.Machine) er
 			if state.ArgStack.Top&1 != 0 {
 				met.width = met.nominalWidthX + state.ArgStack.Vals[0]
 			}
-			met.cs.Hintmask(state)
+			if op.Operator == 19 {
+				met.cs.Hintmask(state)
+			} else {
+				met.cs.Cntrmask(state)
+			}
 			// the stack is managed by the previous call
 			return nil
 
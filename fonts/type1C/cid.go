@@ -0,0 +1,62 @@
+package type1c
+
+import (
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// IsCIDFont returns true for CID-keyed fonts, whose
+// glyphs are identified by CIDs rather than names.
+func (f *Font) IsCIDFont() bool { return f.fdSelect != nil }
+
+// CIDSystemInfo returns the character collection used by a CIDFont,
+// or false for other fonts.
+func (f *Font) CIDSystemInfo() (fonts.CIDSystemInfo, bool) {
+	return f.ros, f.IsCIDFont()
+}
+
+// CIDToGID returns the glyph selected by `cid`, or false if the font
+// is not a CIDFont or has no glyph for `cid`.
+func (f *Font) CIDToGID(cid fonts.CID) (fonts.GID, bool) {
+	gid, ok := f.cidToGID[cid]
+	return gid, ok
+}
+
+// GIDToCID returns the CID of the glyph, or false if the font
+// is not a CIDFont or if `gid` is invalid.
+func (f *Font) GIDToCID(gid fonts.GID) (fonts.CID, bool) {
+	if !f.IsCIDFont() || int(gid) >= len(f.charset) {
+		return 0, false
+	}
+	return fonts.CID(f.charset[gid]), true
+}
+
+// SetCIDCmap uses `cmap` to build the character map of a CIDFont, replacing the
+// current one. CIDs not supported by the font are ignored.
+// It does nothing for other fonts.
+func (f *Font) SetCIDCmap(cmap fonts.CmapCID) {
+	if !f.IsCIDFont() {
+		return
+	}
+	f.cmap = make(fonts.CmapSimple, len(cmap))
+	for r, cid := range cmap {
+		if gid, ok := f.cidToGID[cid]; ok {
+			f.cmap[r] = gid
+		}
+	}
+}
+
+// PrivateDicts returns the Private DICTs of the font : there is one
+// for each font DICT in CIDFonts, and only one for other fonts.
+// See FontDictIndex to select the one used by a glyph.
+// The returned slice should not be modified.
+func (f *Font) PrivateDicts() []PrivateDict { return f.privates }
+
+// FontDictIndex returns the index (in PrivateDicts) of the font DICT used by
+// the given glyph, which is always 0 for non CIDFonts.
+func (f *Font) FontDictIndex(glyph fonts.GID) (int, error) {
+	if f.fdSelect == nil {
+		return 0, nil
+	}
+	index, err := f.fdSelect.fontDictIndex(glyph)
+	return int(index), err
+}
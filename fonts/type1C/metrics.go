@@ -0,0 +1,125 @@
+package type1c
+
+import (
+	"math"
+
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// font metrics
+
+var defaultFontMatrix = []float32{0.001, 0, 0, 0.001, 0, 0}
+
+// multiplyMatrix returns the concatenation of `m1` and `m2`,
+// that is the transformation applying `m1` then `m2`
+func multiplyMatrix(m1, m2 []float32) []float32 {
+	return []float32{
+		m1[0]*m2[0] + m1[1]*m2[2],
+		m1[0]*m2[1] + m1[1]*m2[3],
+		m1[2]*m2[0] + m1[3]*m2[2],
+		m1[2]*m2[1] + m1[3]*m2[3],
+		m1[4]*m2[0] + m1[5]*m2[2] + m2[4],
+		m1[4]*m2[1] + m1[5]*m2[3] + m2[5],
+	}
+}
+
+// Upem reads the FontMatrix to extract the scaling factor (the maximum between x and y coordinates)
+func (f *Font) Upem() uint16 {
+	if len(f.fontMatrix) < 4 {
+		return 1000
+	}
+	xx, yy := math.Abs(float64(f.fontMatrix[0])), math.Abs(float64(f.fontMatrix[3]))
+	var (
+		upemX uint16 = 1000
+		upemY        = upemX
+	)
+	if xx != 0 {
+		upemX = uint16(math.Round(1 / xx))
+	}
+	if yy != 0 {
+		upemY = uint16(math.Round(1 / yy))
+	}
+	if upemX > upemY {
+		return upemX
+	}
+	return upemY
+}
+
+func (f *Font) LineMetric(metric fonts.LineMetric) (float32, bool) {
+	switch metric {
+	case fonts.UnderlinePosition:
+		return float32(f.PSInfo.UnderlinePosition), true
+	case fonts.UnderlineThickness:
+		return float32(f.PSInfo.UnderlineThickness), true
+	default:
+		return 0, false
+	}
+}
+
+func (f *Font) FontHExtents() (fonts.FontExtents, bool) {
+	var extents fonts.FontExtents
+	if f.fontBBox == [4]float32{} {
+		return extents, false
+	}
+	// following freetype here
+	extents.Ascender = f.fontBBox[3]
+	extents.Descender = f.fontBBox[1]
+	extents.LineGap = float32(f.Upem()) * 1.2
+	if extents.LineGap < extents.Ascender-extents.Descender {
+		extents.LineGap = extents.Ascender - extents.Descender
+	}
+	return extents, true
+}
+
+// FontVExtents returns zero values.
+func (f *Font) FontVExtents() (fonts.FontExtents, bool) {
+	return fonts.FontExtents{}, false
+}
+
+func (f *Font) NominalGlyph(ch rune) (fonts.GID, bool) {
+	out, ok := f.cmap[ch]
+	return out, ok
+}
+
+// HorizontalAdvance returns the advance of the glyph with index `index`
+// The return value is expressed in font units.
+// 0 is returned for invalid index values and for invalid
+// charstring glyph data.
+func (f *Font) HorizontalAdvance(gid fonts.GID) float32 {
+	loader, err := f.loadGlyph(gid, nil)
+	if err != nil {
+		return 0
+	}
+	return float32(loader.width)
+}
+
+func (f *Font) VerticalAdvance(gid fonts.GID) float32 { return 0 }
+
+// GlyphHOrigin always return 0,0,true
+func (Font) GlyphHOrigin(fonts.GID) (x, y int32, found bool) {
+	return 0, 0, true
+}
+
+// GlyphVOrigin always return 0,0,false
+func (Font) GlyphVOrigin(fonts.GID) (x, y int32, found bool) {
+	return 0, 0, false
+}
+
+func (f *Font) GlyphExtents(glyph fonts.GID, _, _ uint16) (fonts.GlyphExtents, bool) {
+	_, bbox, err := f.LoadGlyph(glyph)
+	if err != nil {
+		return fonts.GlyphExtents{}, false
+	}
+	return bbox.ToExtents(), true
+}
+
+// GlyphData returns the outlines of the given glyph.
+// The returned value is either a fonts.GlyphOutline or nil if an error
+// occured.
+func (f *Font) GlyphData(gid fonts.GID, _, _ uint16) fonts.GlyphData {
+	var out fonts.GlyphOutline
+	if err := f.DrawGlyph(gid, &out); err != nil {
+		return nil
+	}
+	return out
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/benoitkugler/textlayout/fonts"
 	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
@@ -158,15 +159,35 @@ func (p *cffParser) parse() ([]Font, error) {
 			return nil, err
 		}
 
+		out[i].fontBBox = topDict.fontBBox
+		out[i].fontMatrix = topDict.fontMatrix
+		if out[i].fontMatrix == nil {
+			out[i].fontMatrix = defaultFontMatrix
+		}
+
 		if !topDict.isCIDFont {
 			// Parse the Private DICT, whose location was found in the Top DICT.
-			var localSubrs [][]byte
-			localSubrs, err = p.parsePrivateDICT(topDict.privateDictOffset, topDict.privateDictLength)
+			var (
+				priv       PrivateDict
+				localSubrs [][]byte
+			)
+			priv, localSubrs, err = p.parsePrivateDICT(topDict.privateDictOffset, topDict.privateDictLength)
 			if err != nil {
 				return nil, err
 			}
+			out[i].privates = []PrivateDict{priv}
 			out[i].localSubrs = [][][]byte{localSubrs}
+			out[i].synthetizeCmap()
 		} else {
+			out[i].ros, err = topDict.toROS(strs)
+			if err != nil {
+				return nil, err
+			}
+			out[i].cidToGID = make(map[fonts.CID]fonts.GID, len(out[i].charset))
+			for gid, cid := range out[i].charset {
+				out[i].cidToGID[fonts.CID(cid)] = fonts.GID(gid)
+			}
+
 			// Parse the Font Dict Select data, whose location was found in the Top
 			// DICT.
 			out[i].fdSelect, err = p.parseFDSelect(topDict.fdSelect, numGlyphs)
@@ -179,17 +200,27 @@ func (p *cffParser) parse() ([]Font, error) {
 			if err = p.seek(topDict.fdArray); err != nil {
 				return nil, err
 			}
-			topDicts, err := p.parseTopDicts()
+			fontDicts, err := p.parseTopDicts()
 			if err != nil {
 				return nil, err
 			}
-			if len(topDicts) < indexExtent {
+			if len(fontDicts) < indexExtent {
 				return nil, fmt.Errorf("invalid number of font dicts: %d (for %d)",
-					len(topDicts), indexExtent)
+					len(fontDicts), indexExtent)
+			}
+			// the font matrix of the font dicts is concatenated
+			// with the one of the Top DICT, if any
+			if len(fontDicts) != 0 && fontDicts[0].fontMatrix != nil {
+				if topDict.fontMatrix == nil {
+					out[i].fontMatrix = fontDicts[0].fontMatrix
+				} else {
+					out[i].fontMatrix = multiplyMatrix(fontDicts[0].fontMatrix, topDict.fontMatrix)
+				}
 			}
-			multiSubrs := make([][][]byte, len(topDicts))
-			for i, topDict := range topDicts {
-				multiSubrs[i], err = p.parsePrivateDICT(topDict.privateDictOffset, topDict.privateDictLength)
+			multiSubrs := make([][][]byte, len(fontDicts))
+			out[i].privates = make([]PrivateDict, len(fontDicts))
+			for j, fontDict := range fontDicts {
+				out[i].privates[j], multiSubrs[j], err = p.parsePrivateDICT(fontDict.privateDictOffset, fontDict.privateDictLength)
 				if err != nil {
 					return nil, err
 				}
@@ -394,7 +425,7 @@ func (p *cffParser) parseEncoding(encodingOffset int32, numGlyphs uint16, charse
 			if err != nil {
 				return nil, err
 			}
-			for i := byte(0); i < nSupsBuf[0]; i++ {
+			for i := 0; i < int(nSupsBuf[0]); i++ {
 				code, sid := buf[3*i], be.Uint16(buf[3*i+1:])
 				encoding[code], err = strs.getString(sid)
 				if err != nil {
@@ -516,38 +547,37 @@ func (p *cffParser) parseFDSelect(offset int32, numGlyphs uint16) (fdSelect, err
 }
 
 // Parse Private DICT and the Local Subrs [Subroutines] INDEX
-func (p *cffParser) parsePrivateDICT(offset, length int32) ([][]byte, error) {
+func (p *cffParser) parsePrivateDICT(offset, length int32) (PrivateDict, [][]byte, error) {
+	// default values
+	priv := privateDict{PrivateDict: PrivateDict{BlueScale: 0.039625, BlueShift: 7, BlueFuzz: 1}}
 	if length == 0 {
-		return nil, nil
+		return priv.PrivateDict, nil, nil
 	}
 	if err := p.seek(offset); err != nil {
-		return nil, err
+		return priv.PrivateDict, nil, err
 	}
 	buf, err := p.read(int(length))
 	if err != nil {
-		return nil, err
+		return priv.PrivateDict, nil, err
 	}
-	var (
-		psi  ps.Machine
-		priv privateDict
-	)
+	var psi ps.Machine
 	if err = psi.Run(buf, nil, nil, &priv); err != nil {
-		return nil, err
+		return priv.PrivateDict, nil, err
 	}
 
 	if priv.subrsOffset == 0 {
-		return nil, nil
+		return priv.PrivateDict, nil, nil
 	}
 
 	// "The local subrs offset is relative to the beginning of the Private DICT data"
 	if err = p.seek(offset + priv.subrsOffset); err != nil {
-		return nil, errors.New("invalid local subroutines offset")
+		return priv.PrivateDict, nil, errors.New("invalid local subroutines offset")
 	}
 	subrs, err := p.parseIndex()
 	if err != nil {
-		return nil, err
+		return priv.PrivateDict, nil, err
 	}
-	return subrs, nil
+	return priv.PrivateDict, subrs, nil
 }
 
 // read returns the n bytes from p.offset and advances p.offset by n.
@@ -671,6 +701,10 @@ type topDictData struct {
 	fdSelect                                           int32
 	isCIDFont                                          bool
 	cidFontName                                        uint16
+	registry, ordering                                 uint16 // SIDs, only valid for CIDFonts
+	supplement                                         int32
+	fontBBox                                           [4]float32
+	fontMatrix                                         []float32 // nil if not specified
 	privateDictOffset                                  int32
 	privateDictLength                                  int32
 }
@@ -704,6 +738,20 @@ func (topDict topDictData) toInfo(strs userStrings) (out fonts.PSInfo, err error
 	return out, nil
 }
 
+// resolve the Registry and Ordering strings
+func (topDict topDictData) toROS(strs userStrings) (out fonts.CIDSystemInfo, err error) {
+	out.Registry, err = strs.getString(topDict.registry)
+	if err != nil {
+		return out, err
+	}
+	out.Ordering, err = strs.getString(topDict.ordering)
+	if err != nil {
+		return out, err
+	}
+	out.Supplement = int(topDict.supplement)
+	return out, nil
+}
+
 func (topDict *topDictData) Context() ps.PsContext { return ps.TopDict }
 
 func (topDict *topDictData) Apply(op ps.PsOperator, state *ps.Machine) error {
@@ -766,7 +814,13 @@ var topDictOperators = [2][]topDictOperator{
 			t.weight = s.ArgStack.Uint16()
 			return nil
 		}, +1 /*Weight*/},
-		5:  {topDictNoOp, -1 /*FontBBox*/},
+		5: {func(t *topDictData, s *ps.Machine) error {
+			if s.ArgStack.Top != 4 {
+				return fmt.Errorf("invalid FontBBox array length %d", s.ArgStack.Top)
+			}
+			copy(t.fontBBox[:], dictArray(s))
+			return nil
+		}, -1 /*FontBBox*/},
 		13: {topDictNoOp, +1 /*UniqueID*/},
 		14: {topDictNoOp, -1 /*XUID*/},
 		15: {func(t *topDictData, s *ps.Machine) error {
@@ -795,15 +849,15 @@ var topDictOperators = [2][]topDictOperator{
 			return nil
 		}, +1 /*isFixedPitch*/},
 		2: {func(t *topDictData, s *ps.Machine) error {
-			t.italicAngle = s.ArgStack.Float()
+			t.italicAngle = dictNumber(s.ArgStack.Vals[s.ArgStack.Top-1])
 			return nil
 		}, +1 /*ItalicAngle*/},
 		3: {func(t *topDictData, s *ps.Machine) error {
-			t.underlinePosition = s.ArgStack.Float()
+			t.underlinePosition = dictNumber(s.ArgStack.Vals[s.ArgStack.Top-1])
 			return nil
 		}, +1 /*UnderlinePosition*/},
 		4: {func(t *topDictData, s *ps.Machine) error {
-			t.underlineThickness = s.ArgStack.Float()
+			t.underlineThickness = dictNumber(s.ArgStack.Vals[s.ArgStack.Top-1])
 			return nil
 		}, +1 /*UnderlineThickness*/},
 		5: {topDictNoOp, +1 /*PaintType*/},
@@ -813,14 +867,23 @@ var topDictOperators = [2][]topDictOperator{
 			}
 			return nil
 		}, +1 /*CharstringType*/},
-		7:  {topDictNoOp, -1 /*FontMatrix*/},
+		7: {func(t *topDictData, s *ps.Machine) error {
+			if s.ArgStack.Top != 6 {
+				return fmt.Errorf("invalid FontMatrix array length %d", s.ArgStack.Top)
+			}
+			t.fontMatrix = dictArray(s)
+			return nil
+		}, -1 /*FontMatrix*/},
 		8:  {topDictNoOp, +1 /*StrokeWidth*/},
 		20: {topDictNoOp, +1 /*SyntheticBase*/},
 		21: {topDictNoOp, +1 /*PostScript*/},
 		22: {topDictNoOp, +1 /*BaseFontName*/},
 		23: {topDictNoOp, -2 /*BaseFontBlend*/},
-		30: {func(t *topDictData, _ *ps.Machine) error {
+		30: {func(t *topDictData, s *ps.Machine) error {
 			t.isCIDFont = true
+			t.registry = uint16(s.ArgStack.Vals[s.ArgStack.Top-3])
+			t.ordering = uint16(s.ArgStack.Vals[s.ArgStack.Top-2])
+			t.supplement = s.ArgStack.Vals[s.ArgStack.Top-1]
 			return nil
 		}, +3 /*ROS*/},
 		31: {topDictNoOp, +1 /*CIDFontVersion*/},
@@ -843,10 +906,49 @@ var topDictOperators = [2][]topDictOperator{
 	},
 }
 
+// PrivateDict exposes the hinting parameters and the default
+// widths of a font, as found in a Private DICT.
+// Delta encoded arrays (like BlueValues) are stored as absolute values.
+type PrivateDict struct {
+	BlueValues       []float32
+	OtherBlues       []float32
+	FamilyBlues      []float32
+	FamilyOtherBlues []float32
+	StemSnapH        []float32
+	StemSnapV        []float32
+
+	StdHW, StdVW float32
+
+	BlueScale float32
+	BlueShift float32
+	BlueFuzz  float32
+
+	ForceBold     bool
+	LanguageGroup int32
+
+	DefaultWidthX float32
+	NominalWidthX float32
+}
+
+// HintingParams returns the values used to grid-fit glyph outlines.
+func (p PrivateDict) HintingParams() ps.HintingParams {
+	return ps.HintingParams{
+		BlueValues: p.BlueValues,
+		OtherBlues: p.OtherBlues,
+		StdHW:      p.StdHW,
+		StdVW:      p.StdVW,
+		StemSnapH:  p.StemSnapH,
+		StemSnapV:  p.StemSnapV,
+		BlueScale:  p.BlueScale,
+		BlueShift:  p.BlueShift,
+		BlueFuzz:   p.BlueFuzz,
+	}
+}
+
 // privateDict contains fields specific to the Private DICT context.
 type privateDict struct {
-	subrsOffset                  int32
-	defaultWidthX, nominalWidthX int32
+	subrsOffset int32
+	PrivateDict
 }
 
 func (privateDict) Context() ps.PsContext { return ps.PrivateDict }
@@ -856,36 +958,96 @@ func (privateDict) Context() ps.PsContext { return ps.PrivateDict }
 func (priv *privateDict) Apply(op ps.PsOperator, state *ps.Machine) error {
 	if !op.IsEscaped { // 1-byte operators.
 		switch op.Operator {
-		case 6, 7, 8, 9: // "BlueValues" "OtherBlues" "FamilyBlues" "FamilyOtherBlues"
+		case 6: // "BlueValues"
+			priv.BlueValues = deltaArray(state)
 			return state.ArgStack.PopN(-2)
-		case 10, 11: // "StdHW" "StdVW"
-			return state.ArgStack.PopN(1)
-		case 20: // "defaultWidthX"
-			if state.ArgStack.Top < 1 {
-				return errors.New("invalid stack size for 'defaultWidthX' in private Dict charstring")
-			}
-			priv.defaultWidthX = state.ArgStack.Vals[state.ArgStack.Top-1]
-			return state.ArgStack.PopN(1)
-		case 21: // "nominalWidthX"
+		case 7: // "OtherBlues"
+			priv.OtherBlues = deltaArray(state)
+			return state.ArgStack.PopN(-2)
+		case 8: // "FamilyBlues"
+			priv.FamilyBlues = deltaArray(state)
+			return state.ArgStack.PopN(-2)
+		case 9: // "FamilyOtherBlues"
+			priv.FamilyOtherBlues = deltaArray(state)
+			return state.ArgStack.PopN(-2)
+		case 10, 11, 19, 20, 21: // "StdHW" "StdVW" "Subrs" "defaultWidthX" "nominalWidthX"
 			if state.ArgStack.Top < 1 {
-				return errors.New("invalid stack size for 'nominalWidthX' in private Dict charstring")
+				return fmt.Errorf("invalid stack size for %s in private Dict charstring", op)
 			}
-			priv.nominalWidthX = state.ArgStack.Vals[state.ArgStack.Top-1]
-			return state.ArgStack.PopN(1)
-		case 19: // "Subrs" pop 1
-			if state.ArgStack.Top < 1 {
-				return errors.New("invalid stack size for 'subrs' in private Dict charstring")
+			v := state.ArgStack.Vals[state.ArgStack.Top-1]
+			switch op.Operator {
+			case 10:
+				priv.StdHW = dictNumber(v)
+			case 11:
+				priv.StdVW = dictNumber(v)
+			case 19:
+				priv.subrsOffset = v
+			case 20:
+				priv.DefaultWidthX = dictNumber(v)
+			case 21:
+				priv.NominalWidthX = dictNumber(v)
 			}
-			priv.subrsOffset = state.ArgStack.Vals[state.ArgStack.Top-1]
 			return state.ArgStack.PopN(1)
 		}
 	} else { // 2-byte operators. The first byte is the escape byte.
 		switch op.Operator {
-		case 9, 10, 11, 14, 17, 18, 19: // "BlueScale" "BlueShift" "BlueFuzz" "ForceBold" "LanguageGroup" "ExpansionFactor" "initialRandomSeed"
+		case 9, 10, 11, 14, 17: // "BlueScale" "BlueShift" "BlueFuzz" "ForceBold" "LanguageGroup"
+			if state.ArgStack.Top < 1 {
+				return fmt.Errorf("invalid stack size for %s in private Dict charstring", op)
+			}
+			v := state.ArgStack.Vals[state.ArgStack.Top-1]
+			switch op.Operator {
+			case 9:
+				priv.BlueScale = dictNumber(v)
+			case 10:
+				priv.BlueShift = dictNumber(v)
+			case 11:
+				priv.BlueFuzz = dictNumber(v)
+			case 14:
+				priv.ForceBold = v == 1
+			case 17:
+				priv.LanguageGroup = v
+			}
 			return state.ArgStack.PopN(1)
-		case 12, 13: //  "StemSnapH"  "StemSnapV"
+		case 18, 19: // "ExpansionFactor" "initialRandomSeed"
+			return state.ArgStack.PopN(1)
+		case 12: //  "StemSnapH"
+			priv.StemSnapH = deltaArray(state)
+			return state.ArgStack.PopN(-2)
+		case 13: //  "StemSnapV"
+			priv.StemSnapV = deltaArray(state)
 			return state.ArgStack.PopN(-2)
 		}
 	}
 	return errors.New("invalid operand in private Dict charstring")
 }
+
+// dictNumber returns the value of a DICT operand.
+// The interpreter stores integers and the bits of real numbers in the same way:
+// since the bits of a real number encoding a small integer would be a denormal float,
+// we use the magnitude of the value to tell them apart.
+func dictNumber(v int32) float32 {
+	if -1<<23 < v && v < 1<<23 {
+		return float32(v)
+	}
+	return math.Float32frombits(uint32(v))
+}
+
+// dictArray returns the whole argument stack
+func dictArray(state *ps.Machine) []float32 {
+	out := make([]float32, state.ArgStack.Top)
+	for i, v := range state.ArgStack.Vals[:state.ArgStack.Top] {
+		out[i] = dictNumber(v)
+	}
+	return out
+}
+
+// deltaArray returns the whole argument stack,
+// resolving the delta encoding
+func deltaArray(state *ps.Machine) []float32 {
+	out := dictArray(state)
+	for i := 1; i < len(out); i++ {
+		out[i] += out[i-1]
+	}
+	return out
+}
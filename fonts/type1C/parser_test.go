@@ -3,12 +3,15 @@ package type1c
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"math/rand"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	testdata "github.com/benoitkugler/textlayout-testdata/type1C"
 	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
 )
 
 func TestParseCFF(t *testing.T) {
@@ -109,5 +112,131 @@ func TestCIDFont(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Println(len(font.localSubrs))
+
+	if !font.IsCIDFont() {
+		t.Fatal("expected CIDFont")
+	}
+	if ros, _ := font.CIDSystemInfo(); ros != (fonts.CIDSystemInfo{Registry: "Adobe", Ordering: "CNS1", Supplement: 7}) {
+		t.Fatalf("unexpected ROS %v", ros)
+	}
+	if len(font.PrivateDicts()) != 7 {
+		t.Fatalf("unexpected number of font dicts %d", len(font.PrivateDicts()))
+	}
+	for gid := 0; gid < font.NumGlyphs(); gid++ {
+		cid, ok := font.GIDToCID(fonts.GID(gid))
+		if !ok {
+			t.Fatalf("missing CID for glyph %d", gid)
+		}
+		if back, _ := font.CIDToGID(cid); back != fonts.GID(gid) {
+			t.Fatalf("inconsistent CID mapping for glyph %d", gid)
+		}
+		if fd, err := font.FontDictIndex(fonts.GID(gid)); err != nil || fd >= len(font.PrivateDicts()) {
+			t.Fatalf("invalid font dict for glyph %d", gid)
+		}
+	}
+
+	// CIDFonts have no builtin cmap
+	if _, ok := font.NominalGlyph('A'); ok {
+		t.Fatal("unexpected glyph")
+	}
+	cid, _ := font.GIDToCID(100)
+	font.SetCIDCmap(fonts.CmapCID{'A': cid, 'B': 0xFFFF})
+	if gid, ok := font.NominalGlyph('A'); !ok || gid != 100 {
+		t.Fatalf("unexpected glyph %d", gid)
+	}
+	if _, ok := font.NominalGlyph('B'); ok {
+		t.Fatal("unexpected glyph for unsupported CID")
+	}
+	if adv := font.HorizontalAdvance(100); adv <= 0 {
+		t.Fatalf("unexpected advance %f", adv)
+	}
+	if font.Upem() != 1000 {
+		t.Fatalf("unexpected upem %d", font.Upem())
+	}
+}
+
+func TestFaceMetrics(t *testing.T) {
+	b, err := testdata.Files.ReadFile("AAAPKB+SourceSansPro-Bold.cff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if font.IsCIDFont() || len(font.PrivateDicts()) != 1 {
+		t.Fatal("unexpected CIDFont")
+	}
+	if _, ok := font.CIDToGID(1); ok {
+		t.Fatal("unexpected CID mapping")
+	}
+	priv := font.PrivateDicts()[0]
+	if priv.DefaultWidthX != 528 || priv.NominalWidthX != 616 || priv.StdHW != 115 {
+		t.Fatalf("unexpected private dict %v", priv)
+	}
+	if exp := []float32{115, 126}; !reflect.DeepEqual(priv.StemSnapH, exp) {
+		t.Fatalf("expected %v, got %v", exp, priv.StemSnapH)
+	}
+
+	gid, ok := font.NominalGlyph('A')
+	if !ok || font.GlyphName(gid) != "A" {
+		t.Fatalf("unexpected glyph %d", gid)
+	}
+	if adv := font.HorizontalAdvance(gid); adv != 573 {
+		t.Fatalf("unexpected advance %f", adv)
+	}
+	if ext, ok := font.FontHExtents(); !ok || ext.Ascender != 1009 || ext.Descender != -316 {
+		t.Fatalf("unexpected extents %v", ext)
+	}
+	if pos, _ := font.LineMetric(fonts.UnderlinePosition); pos != -100 {
+		t.Fatalf("unexpected underline position %f", pos)
+	}
+	if _, ok := font.GlyphData(gid, 0, 0).(fonts.GlyphOutline); !ok {
+		t.Fatal("expected outline")
+	}
+}
+
+func TestHints(t *testing.T) {
+	b, err := testdata.Files.ReadFile("AAAPKB+SourceSansPro-Bold.cff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for gid := range font.charstrings {
+		if _, _, err := font.LoadGlyphHints(fonts.GID(gid)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gid, _ := font.NominalGlyph('H')
+	_, hints, err := font.LoadGlyphHints(gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []ps.StemHint{{Pos: 21, Width: -21}, {Pos: 272, Width: 129}, {Pos: 652, Width: -20}}; !reflect.DeepEqual(hints.HStems, exp) {
+		t.Fatalf("expected %v, got %v", exp, hints.HStems)
+	}
+	if exp := []ps.StemHint{{Pos: 77, Width: 147}, {Pos: 449, Width: 148}}; !reflect.DeepEqual(hints.VStems, exp) {
+		t.Fatalf("expected %v, got %v", exp, hints.VStems)
+	}
+
+	// all the points of 'H' are on horizontal edges
+	const ppem = 12
+	outline, err := font.HintedGlyphData(gid, ppem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seg := range outline.Segments {
+		for _, pt := range seg.ArgsSlice() {
+			y := float64(pt.Y) * ppem / 1000
+			if math.Abs(y-math.Round(y)) > 1e-4 {
+				t.Fatalf("point %v is not aligned on the pixel grid", pt)
+			}
+		}
+	}
 }
@@ -0,0 +1,299 @@
+package type1c
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	ps "github.com/benoitkugler/textlayout/fonts/psinterpreter"
+)
+
+// maximum nesting of subroutines calls, as per 5177.Type2.pdf, Appendix B
+const maxSubrsNesting = 10
+
+// subrCall is a call to a subroutine, found in a charstring,
+// whose index is given by the number stored in [start, end).
+type subrCall struct {
+	start, end int
+	index      int // unbiased
+	isGlobal   bool
+}
+
+// subrsUsage stores the calls found in the used subroutines,
+// indexed by (unbiased) subroutine index.
+type subrsUsage map[int][]subrCall
+
+// charstringScanner statically walks through Type2 charstrings,
+// to find the subroutines they use.
+type charstringScanner struct {
+	globalSubrs, localSubrs [][]byte
+	global, local           subrsUsage
+
+	nbStems int
+	depth   int // size of the argument stack
+	nesting int
+}
+
+// scan returns the subroutines calls found in `charstring`, and
+// whether the charstring ends (with the endchar operator).
+func (sc *charstringScanner) scan(charstring []byte) (calls []subrCall, ended bool, err error) {
+	// only integers are valid subroutine indices
+	lastNumber, lastNumberStart, lastNumberEnd := int32(0), -1, -1
+	for i := 0; i < len(charstring); {
+		b, start := charstring[i], i
+		switch {
+		case b == 28:
+			if i+3 > len(charstring) {
+				return nil, false, errors.New("invalid charstring number")
+			}
+			lastNumber = int32(int16(uint16(charstring[i+1])<<8 | uint16(charstring[i+2])))
+			i += 3
+		case 32 <= b && b <= 246:
+			lastNumber = int32(b) - 139
+			i++
+		case 247 <= b && b <= 254:
+			if i+2 > len(charstring) {
+				return nil, false, errors.New("invalid charstring number")
+			}
+			if b <= 250 {
+				lastNumber = (int32(b)-247)*256 + int32(charstring[i+1]) + 108
+			} else {
+				lastNumber = -(int32(b)-251)*256 - int32(charstring[i+1]) - 108
+			}
+			i += 2
+		case b == 255: // 16.16 fixed number
+			sc.depth++
+			i += 5
+			continue
+		}
+		if i != start { // integer
+			lastNumberStart, lastNumberEnd = start, i
+			sc.depth++
+			continue
+		}
+
+		i++
+		switch b {
+		case 1, 3, 18, 23: // hstem, vstem, hstemhm, vstemhm
+			sc.nbStems += sc.depth / 2
+		case 19, 20: // hintmask, cntrmask, with optional implicit vstem
+			sc.nbStems += sc.depth / 2
+			i += (sc.nbStems + 7) / 8
+		case 10, 29: // callsubr, callgsubr
+			if lastNumberEnd != start {
+				return nil, false, errors.New("unsupported computed subroutine index")
+			}
+			sc.depth--
+			call, ended, err := sc.call(lastNumber, b == 29)
+			if err != nil {
+				return nil, false, err
+			}
+			call.start, call.end = lastNumberStart, lastNumberEnd
+			calls = append(calls, call)
+			if ended {
+				return calls, true, nil
+			}
+			continue // the stack is not cleared
+		case 11: // return
+			return calls, false, nil
+		case 14: // endchar
+			return calls, true, nil
+		case 12:
+			if i >= len(charstring) {
+				return nil, false, errors.New("invalid escaped operator")
+			}
+			op := charstring[i]
+			i++
+			if 3 <= op && op <= 30 {
+				return nil, false, fmt.Errorf("unsupported arithmetic operator 12 %d", op)
+			}
+		}
+		sc.depth = 0
+	}
+	return calls, false, nil
+}
+
+// call scans the subroutine with the given (biased) index, also
+// returning whether the subroutine ends the glyph.
+func (sc *charstringScanner) call(biasedIndex int32, isGlobal bool) (subrCall, bool, error) {
+	subrs, usage := sc.localSubrs, sc.local
+	if isGlobal {
+		subrs, usage = sc.globalSubrs, sc.global
+	}
+	index := int(biasedIndex + ps.SubrBias(len(subrs)))
+	if index < 0 || index >= len(subrs) {
+		return subrCall{}, false, fmt.Errorf("invalid subroutine index %d (for length %d)", index, len(subrs))
+	}
+	if sc.nesting == maxSubrsNesting {
+		return subrCall{}, false, errors.New("maximum subroutine nesting reached")
+	}
+	sc.nesting++
+	defer func() { sc.nesting-- }()
+
+	// the subroutine must be scanned again even if already used,
+	// since the number of stems may differ
+	calls, ended, err := sc.scan(subrs[index])
+	if err != nil {
+		return subrCall{}, false, err
+	}
+	usage[index] = calls
+	return subrCall{index: index, isGlobal: isGlobal}, ended, nil
+}
+
+// renumbering maps the used subroutines to their new index.
+type renumbering struct {
+	newIndices map[int]int
+	bias       int32
+}
+
+func newRenumbering(usage subrsUsage) renumbering {
+	used := make([]int, 0, len(usage))
+	for index := range usage {
+		used = append(used, index)
+	}
+	sort.Ints(used)
+	out := renumbering{newIndices: make(map[int]int, len(used)), bias: ps.SubrBias(len(used))}
+	for newIndex, index := range used {
+		out.newIndices[index] = newIndex
+	}
+	return out
+}
+
+// subset returns the used subroutines, with their calls updated
+func (r renumbering) subset(subrs [][]byte, usage subrsUsage, global, local renumbering) [][]byte {
+	out := make([][]byte, len(r.newIndices))
+	for index, newIndex := range r.newIndices {
+		out[newIndex] = rewriteCalls(subrs[index], usage[index], global, local)
+	}
+	return out
+}
+
+// rewriteCalls returns a copy of `charstring` with the subroutine indices updated
+func rewriteCalls(charstring []byte, calls []subrCall, global, local renumbering) []byte {
+	out := make([]byte, 0, len(charstring))
+	last := 0
+	for _, call := range calls {
+		r := local
+		if call.isGlobal {
+			r = global
+		}
+		out = append(out, charstring[last:call.start]...)
+		out = appendCharstringInt(out, int32(r.newIndices[call.index])-r.bias)
+		last = call.end
+	}
+	return append(out, charstring[last:]...)
+}
+
+// appendCharstringInt encodes `v`, which must fit in an int16
+func appendCharstringInt(dst []byte, v int32) []byte {
+	d := dictWriter(dst)
+	d.int(v)
+	return d
+}
+
+// Subset returns a new font, restricted to the given glyphs, whose indices
+// in the returned font are their position in `glyphs`, shifted by one to make room for the .notdef glyph
+// (glyph 0 is always included, and should not be in `glyphs`).
+// Unused subroutines are removed and the remaining ones renumbered.
+//
+// An error is returned for charstrings computing subroutines indices at runtime.
+func (f *Font) Subset(glyphs []fonts.GID) (*Font, error) {
+	gids := append([]fonts.GID{0}, glyphs...)
+	nbFDs := len(f.localSubrs)
+	global := make(subrsUsage)
+	locals := make([]subrsUsage, nbFDs)
+	for i := range locals {
+		locals[i] = make(subrsUsage)
+	}
+
+	fds := make([]byte, len(gids))
+	calls := make([][]subrCall, len(gids))
+	for i, gid := range gids {
+		if int(gid) >= len(f.charstrings) {
+			return nil, fmt.Errorf("invalid glyph index %d", gid)
+		}
+		var fd byte
+		if f.fdSelect != nil {
+			var err error
+			fd, err = f.fdSelect.fontDictIndex(gid)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if int(fd) >= nbFDs {
+			return nil, fmt.Errorf("invalid font dict index %d", fd)
+		}
+		fds[i] = fd
+
+		sc := charstringScanner{
+			globalSubrs: f.globalSubrs, localSubrs: f.localSubrs[fd],
+			global: global, local: locals[fd],
+		}
+		var err error
+		calls[i], _, err = sc.scan(f.charstrings[gid])
+		if err != nil {
+			return nil, fmt.Errorf("glyph %d: %s", gid, err)
+		}
+	}
+
+	globalR := newRenumbering(global)
+	localRs := make([]renumbering, nbFDs)
+	for fd := range localRs {
+		localRs[fd] = newRenumbering(locals[fd])
+	}
+
+	// global subroutines calling local ones can't be renumbered
+	// if the local subroutines depend on the font dict
+	var globalLocalR renumbering
+	if nbFDs == 1 {
+		globalLocalR = localRs[0]
+	} else {
+		for _, subrCalls := range global {
+			for _, call := range subrCalls {
+				if !call.isGlobal {
+					return nil, errors.New("unsupported local subroutine call in global subroutine")
+				}
+			}
+		}
+	}
+
+	out := *f
+	out.globalSubrs = globalR.subset(f.globalSubrs, global, globalR, globalLocalR)
+	out.localSubrs = make([][][]byte, nbFDs)
+	for fd, r := range localRs {
+		out.localSubrs[fd] = r.subset(f.localSubrs[fd], locals[fd], globalR, r)
+	}
+	out.privates = append([]PrivateDict(nil), f.privates...)
+
+	out.charstrings = make([][]byte, len(gids))
+	out.charset = make([]uint16, len(gids))
+	for i, gid := range gids {
+		out.charstrings[i] = rewriteCalls(f.charstrings[gid], calls[i], globalR, localRs[fds[i]])
+		if int(gid) < len(f.charset) {
+			out.charset[i] = f.charset[gid]
+		}
+	}
+
+	if f.fdSelect != nil {
+		out.fdSelect = fdSelect0(fds)
+		out.cidToGID = make(map[fonts.CID]fonts.GID, len(gids))
+		for gid, cid := range out.charset {
+			out.cidToGID[fonts.CID(cid)] = fonts.GID(gid)
+		}
+		// keep the cmap set by SetCIDCmap, if any
+		newGIDs := make(map[fonts.GID]fonts.GID, len(gids))
+		for newGID, gid := range gids {
+			newGIDs[gid] = fonts.GID(newGID)
+		}
+		out.cmap = make(fonts.CmapSimple)
+		for r, gid := range f.cmap {
+			if newGID, ok := newGIDs[gid]; ok {
+				out.cmap[r] = newGID
+			}
+		}
+	} else {
+		out.synthetizeCmap()
+	}
+	return &out, nil
+}
@@ -0,0 +1,643 @@
+package type1c
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	"github.com/benoitkugler/textlayout/fonts/simpleencodings"
+)
+
+// dictOp is a DICT operator; escaped operators
+// are stored with the escape byte as high byte.
+type dictOp uint16
+
+const (
+	opVersion            dictOp = 0
+	opNotice             dictOp = 1
+	opFullName           dictOp = 2
+	opFamilyName         dictOp = 3
+	opWeight             dictOp = 4
+	opFontBBox           dictOp = 5
+	opBlueValues         dictOp = 6
+	opOtherBlues         dictOp = 7
+	opFamilyBlues        dictOp = 8
+	opFamilyOtherBlues   dictOp = 9
+	opStdHW              dictOp = 10
+	opStdVW              dictOp = 11
+	opCharset            dictOp = 15
+	opEncoding           dictOp = 16
+	opCharStrings        dictOp = 17
+	opPrivate            dictOp = 18
+	opSubrs              dictOp = 19
+	opDefaultWidthX      dictOp = 20
+	opNominalWidthX      dictOp = 21
+	opIsFixedPitch       dictOp = 12<<8 | 1
+	opItalicAngle        dictOp = 12<<8 | 2
+	opUnderlinePosition  dictOp = 12<<8 | 3
+	opUnderlineThickness dictOp = 12<<8 | 4
+	opFontMatrix         dictOp = 12<<8 | 7
+	opBlueScale          dictOp = 12<<8 | 9
+	opBlueShift          dictOp = 12<<8 | 10
+	opBlueFuzz           dictOp = 12<<8 | 11
+	opStemSnapH          dictOp = 12<<8 | 12
+	opStemSnapV          dictOp = 12<<8 | 13
+	opForceBold          dictOp = 12<<8 | 14
+	opLanguageGroup      dictOp = 12<<8 | 17
+	opROS                dictOp = 12<<8 | 30
+	opCIDCount           dictOp = 12<<8 | 34
+	opFDArray            dictOp = 12<<8 | 36
+	opFDSelect           dictOp = 12<<8 | 37
+	opCIDFontName        dictOp = 12<<8 | 38
+)
+
+// dictWriter serializes DICT data.
+type dictWriter []byte
+
+func (d *dictWriter) op(op dictOp) {
+	if op > 0xFF {
+		*d = append(*d, 12)
+	}
+	*d = append(*d, byte(op))
+}
+
+func (d *dictWriter) int(v int32) {
+	switch {
+	case -107 <= v && v <= 107:
+		*d = append(*d, byte(v+139))
+	case 108 <= v && v <= 1131:
+		v -= 108
+		*d = append(*d, byte(v>>8+247), byte(v))
+	case -1131 <= v && v <= -108:
+		v = -v - 108
+		*d = append(*d, byte(v>>8+251), byte(v))
+	case -32768 <= v && v <= 32767:
+		*d = append(*d, 28, byte(v>>8), byte(v))
+	default:
+		d.fixedInt(v)
+	}
+}
+
+// fixedInt always uses 5 bytes, so that offsets may be
+// patched without changing the size of the DICT
+func (d *dictWriter) fixedInt(v int32) {
+	*d = append(*d, 29, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// number writes `v` as an integer if possible, or as a real number
+func (d *dictWriter) number(v float32) {
+	if v == float32(math.Trunc(float64(v))) && -1<<23 < v && v < 1<<23 {
+		d.int(int32(v))
+		return
+	}
+	d.real(v)
+}
+
+// real encodes `v` using packed BCD nibbles
+func (d *dictWriter) real(v float32) {
+	s := strconv.FormatFloat(float64(v), 'G', -1, 32)
+	var nibbles []byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case '0' <= c && c <= '9':
+			nibbles = append(nibbles, c-'0')
+		case c == '.':
+			nibbles = append(nibbles, 0xa)
+		case c == 'E':
+			if i+1 < len(s) && s[i+1] == '-' {
+				nibbles = append(nibbles, 0xc)
+				i++
+			} else {
+				nibbles = append(nibbles, 0xb)
+				if i+1 < len(s) && s[i+1] == '+' {
+					i++
+				}
+			}
+		case c == '-':
+			nibbles = append(nibbles, 0xe)
+		}
+	}
+	nibbles = append(nibbles, 0xf)
+	if len(nibbles)%2 != 0 {
+		nibbles = append(nibbles, 0xf)
+	}
+	*d = append(*d, 30)
+	for i := 0; i < len(nibbles); i += 2 {
+		*d = append(*d, nibbles[i]<<4|nibbles[i+1])
+	}
+}
+
+func (d *dictWriter) numbers(vs []float32) {
+	for _, v := range vs {
+		d.number(v)
+	}
+}
+
+// deltas writes a delta encoded array
+func (d *dictWriter) deltas(vs []float32) {
+	var prev float32
+	for _, v := range vs {
+		d.number(v - prev)
+		prev = v
+	}
+}
+
+// appendIndex serializes an INDEX
+func appendIndex(dst []byte, items [][]byte) []byte {
+	dst = append(dst, byte(len(items)>>8), byte(len(items)))
+	if len(items) == 0 {
+		return dst
+	}
+	total := 1
+	for _, item := range items {
+		total += len(item)
+	}
+	offSize := offsetSize(total)
+	dst = append(dst, byte(offSize))
+	offset := 1
+	dst = appendOffset(dst, offset, offSize)
+	for _, item := range items {
+		offset += len(item)
+		dst = appendOffset(dst, offset, offSize)
+	}
+	for _, item := range items {
+		dst = append(dst, item...)
+	}
+	return dst
+}
+
+func offsetSize(maxOffset int) int {
+	switch {
+	case maxOffset <= 0xFF:
+		return 1
+	case maxOffset <= 0xFFFF:
+		return 2
+	case maxOffset <= 0xFFFFFF:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func appendOffset(dst []byte, offset, size int) []byte {
+	for i := size - 1; i >= 0; i-- {
+		dst = append(dst, byte(offset>>(8*i)))
+	}
+	return dst
+}
+
+// stringTable builds the String INDEX
+type stringTable struct {
+	sids map[string]uint16
+	user [][]byte
+}
+
+var stdStringsSIDs = func() map[string]uint16 {
+	out := make(map[string]uint16, len(stdStrings))
+	for sid, s := range stdStrings {
+		out[s] = uint16(sid)
+	}
+	return out
+}()
+
+func (st *stringTable) sid(s string) uint16 {
+	if sid, ok := stdStringsSIDs[s]; ok {
+		return sid
+	}
+	if sid, ok := st.sids[s]; ok {
+		return sid
+	}
+	if st.sids == nil {
+		st.sids = make(map[string]uint16)
+	}
+	sid := uint16(391 + len(st.user))
+	st.sids[s] = sid
+	st.user = append(st.user, []byte(s))
+	return sid
+}
+
+func (p PrivateDict) serialize(subrsOffset int32, hasSubrs bool) []byte {
+	var d dictWriter
+	if len(p.BlueValues) != 0 {
+		d.deltas(p.BlueValues)
+		d.op(opBlueValues)
+	}
+	if len(p.OtherBlues) != 0 {
+		d.deltas(p.OtherBlues)
+		d.op(opOtherBlues)
+	}
+	if len(p.FamilyBlues) != 0 {
+		d.deltas(p.FamilyBlues)
+		d.op(opFamilyBlues)
+	}
+	if len(p.FamilyOtherBlues) != 0 {
+		d.deltas(p.FamilyOtherBlues)
+		d.op(opFamilyOtherBlues)
+	}
+	if p.BlueScale != 0.039625 {
+		d.number(p.BlueScale)
+		d.op(opBlueScale)
+	}
+	if p.BlueShift != 7 {
+		d.number(p.BlueShift)
+		d.op(opBlueShift)
+	}
+	if p.BlueFuzz != 1 {
+		d.number(p.BlueFuzz)
+		d.op(opBlueFuzz)
+	}
+	if p.StdHW != 0 {
+		d.number(p.StdHW)
+		d.op(opStdHW)
+	}
+	if p.StdVW != 0 {
+		d.number(p.StdVW)
+		d.op(opStdVW)
+	}
+	if len(p.StemSnapH) != 0 {
+		d.deltas(p.StemSnapH)
+		d.op(opStemSnapH)
+	}
+	if len(p.StemSnapV) != 0 {
+		d.deltas(p.StemSnapV)
+		d.op(opStemSnapV)
+	}
+	if p.ForceBold {
+		d.int(1)
+		d.op(opForceBold)
+	}
+	if p.LanguageGroup != 0 {
+		d.int(p.LanguageGroup)
+		d.op(opLanguageGroup)
+	}
+	if p.DefaultWidthX != 0 {
+		d.number(p.DefaultWidthX)
+		d.op(opDefaultWidthX)
+	}
+	if p.NominalWidthX != 0 {
+		d.number(p.NominalWidthX)
+		d.op(opNominalWidthX)
+	}
+	if hasSubrs {
+		d.fixedInt(subrsOffset)
+		d.op(opSubrs)
+	}
+	return d
+}
+
+// offsets of the tables referenced by the Top DICT
+type topDictOffsets struct {
+	charset, encoding, charStrings int32
+	privateSize, privateOffset     int32
+	fdArray, fdSelect              int32
+}
+
+func (f *Font) serializeTopDict(st *stringTable, offsets topDictOffsets) []byte {
+	var d dictWriter
+	if f.IsCIDFont() {
+		d.int(int32(st.sid(f.ros.Registry)))
+		d.int(int32(st.sid(f.ros.Ordering)))
+		d.int(int32(f.ros.Supplement))
+		d.op(opROS)
+		if f.cidFontName != "" {
+			d.int(int32(st.sid(f.cidFontName)))
+			d.op(opCIDFontName)
+		}
+	}
+	for _, entry := range [...]struct {
+		value string
+		op    dictOp
+	}{
+		{f.PSInfo.Version, opVersion},
+		{f.PSInfo.Notice, opNotice},
+		{f.PSInfo.FullName, opFullName},
+		{f.PSInfo.FamilyName, opFamilyName},
+		{f.PSInfo.Weight, opWeight},
+	} {
+		if entry.value != "" {
+			d.int(int32(st.sid(entry.value)))
+			d.op(entry.op)
+		}
+	}
+	if f.PSInfo.IsFixedPitch {
+		d.int(1)
+		d.op(opIsFixedPitch)
+	}
+	if f.PSInfo.ItalicAngle != 0 {
+		d.int(int32(f.PSInfo.ItalicAngle))
+		d.op(opItalicAngle)
+	}
+	if f.PSInfo.UnderlinePosition != -100 {
+		d.int(int32(f.PSInfo.UnderlinePosition))
+		d.op(opUnderlinePosition)
+	}
+	if f.PSInfo.UnderlineThickness != 50 {
+		d.int(int32(f.PSInfo.UnderlineThickness))
+		d.op(opUnderlineThickness)
+	}
+	if f.fontBBox != [4]float32{} {
+		d.numbers(f.fontBBox[:])
+		d.op(opFontBBox)
+	}
+	if len(f.fontMatrix) == 6 && !equalMatrix(f.fontMatrix, defaultFontMatrix) {
+		d.numbers(f.fontMatrix)
+		d.op(opFontMatrix)
+	}
+	d.fixedInt(offsets.charset)
+	d.op(opCharset)
+	if !f.IsCIDFont() {
+		d.fixedInt(offsets.encoding)
+		d.op(opEncoding)
+	}
+	d.fixedInt(offsets.charStrings)
+	d.op(opCharStrings)
+	if f.IsCIDFont() {
+		d.int(int32(len(f.charstrings)))
+		d.op(opCIDCount)
+		d.fixedInt(offsets.fdArray)
+		d.op(opFDArray)
+		d.fixedInt(offsets.fdSelect)
+		d.op(opFDSelect)
+	} else {
+		d.fixedInt(offsets.privateSize)
+		d.fixedInt(offsets.privateOffset)
+		d.op(opPrivate)
+	}
+	return d
+}
+
+func equalMatrix(m1, m2 []float32) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+	for i := range m1 {
+		if m1[i] != m2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// serializeCharset uses the most compact format between 0 and 2
+func serializeCharset(charset []uint16) []byte {
+	if len(charset) <= 1 {
+		return []byte{0}
+	}
+	format0 := make([]byte, 1, 1+2*len(charset))
+	for _, sid := range charset[1:] {
+		format0 = append(format0, byte(sid>>8), byte(sid))
+	}
+
+	format2 := []byte{2}
+	for i := 1; i < len(charset); {
+		first, nLeft := charset[i], 0
+		for i+nLeft+1 < len(charset) && charset[i+nLeft+1] == first+uint16(nLeft)+1 && nLeft < 0xFFFF {
+			nLeft++
+		}
+		format2 = append(format2, byte(first>>8), byte(first), byte(nLeft>>8), byte(nLeft))
+		i += nLeft + 1
+	}
+	if len(format2) < len(format0) {
+		return format2
+	}
+	return format0
+}
+
+// serializeEncoding returns nil for the predefined encodings, or a custom encoding
+// using only supplements (which map codes to glyph names).
+func (f *Font) serializeEncoding(st *stringTable) (offset int32, data []byte) {
+	switch f.Encoding {
+	case nil, &simpleencodings.AdobeStandard:
+		return 0, nil
+	case &expertEncoding:
+		return 1, nil
+	}
+	data = []byte{0x80, 0, 0} // format 0 with supplements, no codes, nSups
+	nSups := 0
+	for code, name := range f.Encoding {
+		if name == "" || name == notdef || nSups == 0xFF {
+			continue
+		}
+		sid := st.sid(name)
+		data = append(data, byte(code), byte(sid>>8), byte(sid))
+		nSups++
+	}
+	data[2] = byte(nSups)
+	return -1, data
+}
+
+func (f *Font) serializeFDSelect() ([]byte, error) {
+	out := []byte{3, 0, 0}
+	nRanges, current := 0, -1
+	for gid := range f.charstrings {
+		fd, err := f.fdSelect.fontDictIndex(fonts.GID(gid))
+		if err != nil {
+			return nil, err
+		}
+		if int(fd) != current {
+			out = append(out, byte(gid>>8), byte(gid), fd)
+			current = int(fd)
+			nRanges++
+		}
+	}
+	out[1], out[2] = byte(nRanges>>8), byte(nRanges)
+	// sentinel
+	return append(out, byte(len(f.charstrings)>>8), byte(len(f.charstrings))), nil
+}
+
+// Write serializes the font in the CFF format, so that it may
+// be embedded in a PDF file (FontFile3) or read back with Parse.
+func (f *Font) Write(w io.Writer) error {
+	if len(f.charstrings) == 0 || len(f.charstrings) > 0xFFFF {
+		return fmt.Errorf("invalid number of glyphs %d", len(f.charstrings))
+	}
+	if len(f.localSubrs) != len(f.privates) {
+		return errors.New("inconsistent private dicts")
+	}
+	var st stringTable
+
+	// charset : resolve the glyph names for non CID fonts
+	charset := make([]uint16, len(f.charstrings))
+	for gid := range charset {
+		if f.IsCIDFont() {
+			if gid < len(f.charset) {
+				charset[gid] = f.charset[gid]
+			}
+			continue
+		}
+		if name := f.GlyphName(fonts.GID(gid)); gid != 0 && name != "" {
+			charset[gid] = st.sid(name)
+		}
+	}
+	charsetData := serializeCharset(charset)
+
+	var (
+		encodingOffset int32
+		encodingData   []byte
+		fdSelectData   []byte
+		err            error
+	)
+	if f.IsCIDFont() {
+		fdSelectData, err = f.serializeFDSelect()
+		if err != nil {
+			return err
+		}
+	} else {
+		encodingOffset, encodingData = f.serializeEncoding(&st)
+	}
+
+	// private dicts and the associated local subroutines
+	privates := make([][]byte, len(f.privates))
+	privateSizes := make([]int32, len(f.privates)) // without the subroutines
+	for i, priv := range f.privates {
+		hasSubrs := len(f.localSubrs[i]) != 0
+		// the Subrs offset is relative to the start of the Private DICT
+		privateSizes[i] = int32(len(priv.serialize(0, hasSubrs)))
+		dict := priv.serialize(privateSizes[i], hasSubrs)
+		if hasSubrs {
+			dict = appendIndex(dict, f.localSubrs[i])
+		}
+		privates[i] = dict
+	}
+
+	// the sizes of the DICTs do not depend on the offsets
+	name := f.fontName
+	if len(name) == 0 {
+		name = []byte(f.PSInfo.FontName)
+	}
+	nameIndex := appendIndex(nil, [][]byte{name})
+	topDictSize := len(f.serializeTopDict(&st, topDictOffsets{}))
+	topDictIndexSize := len(appendIndex(nil, [][]byte{make([]byte, topDictSize)}))
+	stringIndex := appendIndex(nil, st.user)
+	globalSubrsIndex := appendIndex(nil, f.globalSubrs)
+	charStringsIndex := appendIndex(nil, f.charstrings)
+
+	var offsets topDictOffsets
+	offset := int32(4 + len(nameIndex) + topDictIndexSize + len(stringIndex) + len(globalSubrsIndex))
+	if encodingData != nil {
+		encodingOffset = offset
+		offset += int32(len(encodingData))
+	}
+	offsets.encoding = encodingOffset
+	offsets.charset = offset
+	offset += int32(len(charsetData))
+	if f.IsCIDFont() {
+		offsets.fdSelect = offset
+		offset += int32(len(fdSelectData))
+	}
+	offsets.charStrings = offset
+	offset += int32(len(charStringsIndex))
+
+	var fdArrayIndex []byte
+	if f.IsCIDFont() {
+		// each font dict only stores its Private DICT, using 2*5+1 bytes
+		fds := make([][]byte, len(privates))
+		for i := range fds {
+			fds[i] = make([]byte, 2*5+1)
+		}
+		fdSize := len(appendIndex(nil, fds))
+		offsets.fdArray = offset
+		privateOffset := offset + int32(fdSize)
+		for i, private := range privates {
+			var d dictWriter
+			d.fixedInt(privateSizes[i])
+			d.fixedInt(privateOffset)
+			d.op(opPrivate)
+			fds[i] = d
+			privateOffset += int32(len(private))
+		}
+		fdArrayIndex = appendIndex(nil, fds)
+		if len(fdArrayIndex) != fdSize {
+			return errors.New("internal error: invalid FDArray size")
+		}
+	} else {
+		offsets.privateOffset = offset
+		offsets.privateSize = privateSizes[0]
+	}
+
+	topDict := f.serializeTopDict(&st, offsets)
+	if len(topDict) != topDictSize {
+		return errors.New("internal error: invalid Top DICT size")
+	}
+
+	out := []byte{1, 0, 4, 4} // header: version 1.0, header size, absolute offset size
+	out = append(out, nameIndex...)
+	out = appendIndex(out, [][]byte{topDict})
+	out = append(out, stringIndex...)
+	out = append(out, globalSubrsIndex...)
+	out = append(out, encodingData...)
+	out = append(out, charsetData...)
+	out = append(out, fdSelectData...)
+	out = append(out, charStringsIndex...)
+	out = append(out, fdArrayIndex...)
+	for _, private := range privates {
+		out = append(out, private...)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// notdef is the name of the glyph 0
+const notdef = ".notdef"
+
+// FontDescription provides the content of a (non CID) font
+// built from scratch by NewFont.
+type FontDescription struct {
+	fonts.PSInfo
+
+	FontBBox   [4]float32
+	FontMatrix []float32 // optional, defaulting to [0.001 0 0 0.001 0 0]
+	Private    PrivateDict
+	Encoding   *simpleencodings.Encoding // optional, defaulting to the Standard encoding
+
+	// GlyphNames and Charstrings are indexed by glyph index.
+	// The first glyph must be .notdef
+	GlyphNames  []string
+	Charstrings [][]byte // Type2 charstrings
+	Subrs       [][]byte // local subroutines, called by Charstrings
+}
+
+// NewFont builds a font from the given description, which may
+// then be serialized with Write.
+func NewFont(desc FontDescription) (*Font, error) {
+	if len(desc.Charstrings) == 0 || len(desc.Charstrings) != len(desc.GlyphNames) {
+		return nil, fmt.Errorf("invalid number of glyphs (%d charstrings for %d names)",
+			len(desc.Charstrings), len(desc.GlyphNames))
+	}
+	if desc.GlyphNames[0] != notdef {
+		return nil, fmt.Errorf("invalid first glyph %s", desc.GlyphNames[0])
+	}
+	if desc.FontMatrix == nil {
+		desc.FontMatrix = defaultFontMatrix
+	} else if len(desc.FontMatrix) != 6 {
+		return nil, fmt.Errorf("invalid font matrix length %d", len(desc.FontMatrix))
+	}
+	if desc.Encoding == nil {
+		desc.Encoding = &simpleencodings.AdobeStandard
+	}
+
+	var st stringTable
+	charset := make([]uint16, len(desc.GlyphNames))
+	for gid, name := range desc.GlyphNames[1:] {
+		charset[gid+1] = st.sid(name)
+	}
+
+	out := &Font{
+		userStrings: st.user,
+		charset:     charset,
+		Encoding:    desc.Encoding,
+		fontBBox:    desc.FontBBox,
+		fontMatrix:  desc.FontMatrix,
+		charstrings: desc.Charstrings,
+		fontName:    []byte(desc.FontName),
+		localSubrs:  [][][]byte{desc.Subrs},
+		privates:    []PrivateDict{desc.Private},
+		PSInfo:      desc.PSInfo,
+	}
+	out.synthetizeCmap()
+	return out, nil
+}
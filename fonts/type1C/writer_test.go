@@ -0,0 +1,210 @@
+package type1c
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	testdata "github.com/benoitkugler/textlayout-testdata/type1C"
+	"github.com/benoitkugler/textlayout/fonts"
+)
+
+// testFiles returns the standalone CFF files and the ones extracted from OpenType fonts
+func testFiles(t *testing.T) []string {
+	files := []string{
+		"AAAPKB+SourceSansPro-Bold.cff",
+		"AdobeMingStd-Light-Identity-H.cff",
+		"YPTQCA+CMR17.cff",
+	}
+	ttfs, err := testdata.Files.ReadDir("ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range ttfs {
+		files = append(files, filepath.Join("ttf", f.Name()))
+	}
+	return files
+}
+
+func loadTestFont(t *testing.T, file string) *Font {
+	b, err := testdata.Files.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return font
+}
+
+func writeAndParse(t *testing.T, font *Font) *Font {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := font.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	back, err := Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err, font.PSInfo.FamilyName)
+	}
+	return back
+}
+
+// assertSameGlyphs checks that glyph `gid1` in `f1` and `gid2` in `f2` are equal
+func assertSameGlyphs(t *testing.T, f1, f2 *Font, gid1, gid2 fonts.GID) {
+	t.Helper()
+	segs1, _, err := f1.LoadGlyph(gid1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segs2, _, err := f2.LoadGlyph(gid2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(segs1, segs2) {
+		t.Fatalf("glyph %d: different outlines", gid1)
+	}
+	if a1, a2 := f1.HorizontalAdvance(gid1), f2.HorizontalAdvance(gid2); a1 != a2 {
+		t.Fatalf("glyph %d: different advances %f %f", gid1, a1, a2)
+	}
+	if n1, n2 := f1.GlyphName(gid1), f2.GlyphName(gid2); n1 != n2 {
+		t.Fatalf("glyph %d: different names %s %s", gid1, n1, n2)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	for _, file := range testFiles(t) {
+		font := loadTestFont(t, file)
+		back := writeAndParse(t, font)
+
+		if back.PSInfo != font.PSInfo || back.cidFontName != font.cidFontName {
+			t.Fatalf("%s: different PS info %v %v", file, back.PSInfo, font.PSInfo)
+		}
+		if back.ros != font.ros || back.fontBBox != font.fontBBox ||
+			!reflect.DeepEqual(back.fontMatrix, font.fontMatrix) {
+			t.Fatalf("%s: different top dict", file)
+		}
+		if !reflect.DeepEqual(back.privates, font.privates) {
+			t.Fatalf("%s: different private dicts %v %v", file, back.privates, font.privates)
+		}
+		if !reflect.DeepEqual(back.Encoding, font.Encoding) || !reflect.DeepEqual(back.charset, font.charset) {
+			t.Fatalf("%s: different encoding or charset", file)
+		}
+		// check a sample of the glyphs
+		for gid := 0; gid < font.NumGlyphs(); gid += 1 + font.NumGlyphs()/100 {
+			assertSameGlyphs(t, font, back, fonts.GID(gid), fonts.GID(gid))
+			fd1, _ := font.FontDictIndex(fonts.GID(gid))
+			fd2, _ := back.FontDictIndex(fonts.GID(gid))
+			if fd1 != fd2 {
+				t.Fatalf("%s: different font dict for glyph %d", file, gid)
+			}
+		}
+	}
+}
+
+func TestDictNumbers(t *testing.T) {
+	for _, v := range []float32{0, 1, -1, 107, -107, 108, 1131, -1131, 1132, -1132, 32767, -32768, 100000, -100000,
+		0.5, -0.039625, 1e-7, 2.5e12, 0.001} {
+		var d dictWriter
+		d.number(v)
+		d.op(opStdHW)
+		p := cffParser{src: d}
+		priv, _, err := p.parsePrivateDICT(0, int32(len(d)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.StdHW != v {
+			t.Fatalf("expected %g, got %g", v, priv.StdHW)
+		}
+	}
+}
+
+func TestSubset(t *testing.T) {
+	for _, file := range testFiles(t) {
+		font := loadTestFont(t, file)
+		glyphs := []fonts.GID{3, 5, 10, fonts.GID(font.NumGlyphs() - 1)}
+		subset, err := font.Subset(glyphs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back := writeAndParse(t, subset)
+		if back.NumGlyphs() != len(glyphs)+1 {
+			t.Fatalf("unexpected number of glyphs %d", back.NumGlyphs())
+		}
+		assertSameGlyphs(t, font, back, 0, 0)
+		for i, gid := range glyphs {
+			assertSameGlyphs(t, font, back, gid, fonts.GID(i+1))
+			if font.IsCIDFont() {
+				cid1, _ := font.GIDToCID(gid)
+				cid2, _ := back.GIDToCID(fonts.GID(i + 1))
+				if cid1 != cid2 {
+					t.Fatalf("different CIDs %d %d", cid1, cid2)
+				}
+			}
+		}
+		if len(back.globalSubrs) > len(font.globalSubrs) {
+			t.Fatal("unexpected global subroutines")
+		}
+		for fd := range back.localSubrs {
+			if len(back.localSubrs[fd]) > len(font.localSubrs[fd]) {
+				t.Fatal("unexpected local subroutines")
+			}
+		}
+	}
+}
+
+func TestNewFont(t *testing.T) {
+	desc := FontDescription{
+		PSInfo:     fonts.PSInfo{FontName: "Test-Regular", FamilyName: "Test", UnderlinePosition: -100, UnderlineThickness: 50},
+		FontBBox:   [4]float32{0, 0, 500, 700},
+		Private:    PrivateDict{BlueValues: []float32{-10, 0, 700, 710}, BlueScale: 0.039625, BlueShift: 7, BlueFuzz: 1, StdVW: 80},
+		GlyphNames: []string{".notdef", "A", "myglyph"},
+		Charstrings: [][]byte{
+			{14},                                  // endchar
+			{139 + 100, 139, 21, 139 + 50, 6, 14}, // 100 0 rmoveto 50 hlineto endchar
+			{139 - 107, 10, 14},                   // 0 callsubr endchar (with bias)
+		},
+		Subrs: [][]byte{{139 + 10, 139 + 20, 21, 11}}, // 10 20 rmoveto return
+	}
+	font, err := NewFont(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back := writeAndParse(t, font)
+	if string(back.fontName) != "Test-Regular" || back.FamilyName != "Test" || back.GlyphName(2) != "myglyph" {
+		t.Fatalf("unexpected font %v", back.PSInfo)
+	}
+	if gid, _ := back.NominalGlyph('A'); gid != 1 {
+		t.Fatalf("unexpected glyph for A: %d", gid)
+	}
+	for gid := range desc.Charstrings {
+		assertSameGlyphs(t, font, back, fonts.GID(gid), fonts.GID(gid))
+	}
+
+	if _, err = NewFont(FontDescription{GlyphNames: []string{"A"}, Charstrings: [][]byte{{14}}}); err == nil {
+		t.Fatal("expected error for missing .notdef")
+	}
+}
+
+func TestEndcharWidth(t *testing.T) {
+	desc := FontDescription{
+		GlyphNames: []string{".notdef", "A", "Aacute"},
+		Private:    PrivateDict{DefaultWidthX: 500, NominalWidthX: 600},
+		Charstrings: [][]byte{
+			{139 + 100, 14},                              // 100 endchar
+			{139, 139, 139 + 65, 139 + 66, 14},           // 0 0 65 66 endchar (seac form, no width)
+			{139 + 50, 139, 139, 139 + 65, 139 + 66, 14}, // 50 0 0 65 66 endchar (seac form, with width)
+		},
+	}
+	font, err := NewFont(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for gid, exp := range []int{700, 500, 650} {
+		if adv := font.HorizontalAdvance(fonts.GID(gid)); adv != float32(exp) {
+			t.Fatalf("glyph %d: expected advance %d, got %f", gid, exp, adv)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package graphite
+
+// JustifyFlags specifies how the end of the segment
+// are handled by `Segment.Justify`.
+// The zero value means the segment is a complete line.
+type JustifyFlags uint8
+
+// JustifyEndInline indicates that the end of the segment is not the end of a line,
+// meaning that trailing whitespaces are also adjusted.
+const JustifyEndInline JustifyFlags = 1
+
+// total of the justification parameters for one level
+type justifyTotal struct {
+	numGlyphs int
+	tStretch  int
+	tShrink   int
+	tStep     int
+	tWeight   int
+}
+
+func (jt *justifyTotal) accumulate(s *Slot, seg *Segment, level uint8) {
+	jt.numGlyphs++
+	jt.tStretch += int(s.getJustify(seg, level, 0))
+	jt.tShrink += int(s.getJustify(seg, level, 1))
+	jt.tStep += int(s.getJustify(seg, level, 2))
+	jt.tWeight += int(s.getJustify(seg, level, 3))
+}
+
+func isWhitespace(r rune) bool {
+	switch r {
+	case 0x0009, 0x000A, 0x000B, 0x000C, 0x000D, 0x0020, 0x0085, 0x00A0,
+		0x1680, 0x180E, 0x2000, 0x2001, 0x2002, 0x2003, 0x2004, 0x2005,
+		0x2006, 0x2007, 0x2008, 0x2009, 0x200A, 0x2028, 0x2029, 0x202F,
+		0x205F, 0x3000:
+		return true
+	}
+	return false
+}
+
+// Justify adjusts the positions of the slots of the segment
+// so that its advance matches `width`, by distributing the difference
+// between the glyphs, according to the justification levels defined in the font.
+// If the font has no justification levels, the space is distributed among whitespaces
+// (or among all the glyphs if there is no whitespace).
+// The justification passes of the font are then applied.
+// `font` is optional and must match the one used to shape the segment;
+// `width` is expressed in the same unit as the positions of the slots.
+// The new advance is returned, and also stored in `seg.Advance`.
+func (seg *Segment) Justify(font *FontOptions, width float32, flags JustifyFlags) float32 {
+	if seg.First == nil {
+		return 0
+	}
+
+	pSlot := seg.First
+	var pFirst, pLast *Slot
+
+	end := seg.last
+	var currWidth float32
+	var scale float32 = 1
+	if font != nil {
+		scale = font.scale
+	}
+
+	if width < 0 && seg.silf.flags == 0 {
+		return width
+	}
+
+	reverse := (seg.dir&1 != 0) != seg.silf.isRTL && int(seg.silf.indexBidiPass) != len(seg.silf.passes)
+	if reverse {
+		seg.reverseSlots()
+		pFirst, pLast = pLast, pFirst
+	}
+	if pFirst == nil {
+		pFirst = pSlot
+	}
+	for !pFirst.isBase() {
+		pFirst = pFirst.parent
+	}
+	if pLast == nil {
+		pLast = seg.last
+	}
+	for !pLast.isBase() {
+		pLast = pLast.parent
+	}
+	base := pFirst.Position.X / scale
+	width = width / scale
+	if flags&JustifyEndInline == 0 {
+		// skip the trailing glyphs without outlines
+		for pLast != pFirst && pLast != nil {
+			var bbox rect
+			if glyph := seg.face.getGlyph(pLast.glyphID); glyph != nil {
+				bbox = glyph.bbox
+			}
+			if bbox.bl.X != 0 || bbox.bl.Y != 0 || bbox.tr.X != 0 || bbox.tr.Y == 0 {
+				break
+			}
+			pLast = pLast.prev
+		}
+	}
+
+	if pLast != nil {
+		end = pLast.sibling
+	}
+	if pFirst != nil {
+		pFirst = pFirst.sibling
+	}
+
+	icount := 0
+	numLevels := len(seg.silf.justificationLevels)
+	if numLevels == 0 {
+		// use whitespaces, or every glyph if no whitespace is found
+		for s := pSlot; s != nil && s != end; s = s.sibling {
+			c := seg.getCharInfo(s.Before)
+			if c != nil && isWhitespace(c.char) {
+				s.setJustify(seg, 0, 3, 1)
+				s.setJustify(seg, 0, 2, 1)
+				s.setJustify(seg, 0, 0, -1)
+				icount++
+			}
+		}
+		if icount == 0 {
+			for s := pSlot; s != nil && s != end; s = s.sibling {
+				s.setJustify(seg, 0, 3, 1)
+				s.setJustify(seg, 0, 2, 1)
+				s.setJustify(seg, 0, 0, -1)
+			}
+		}
+		numLevels++
+	}
+
+	stats := make([]justifyTotal, numLevels)
+	for s := pFirst; s != nil && s != end; s = s.sibling {
+		w := s.Position.X/scale + s.Advance.X - base
+		if w > currWidth {
+			currWidth = w
+		}
+		for j := range stats {
+			stats[j].accumulate(s, seg, uint8(j))
+		}
+		s.just = 0
+	}
+
+	startLevel := numLevels - 1
+	if width < 0 {
+		startLevel = -1
+	}
+	for i := startLevel; i >= 0; i-- {
+		level := uint8(i)
+		tWeight := stats[i].tWeight
+		if tWeight == 0 {
+			continue
+		}
+
+		for do := true; do; {
+			var residual float32
+			diff := width - currWidth
+			diffpw := diff / float32(tWeight)
+			tWeight = 0
+			for s := pFirst; s != nil && s != end; s = s.sibling { // don't include final glyph
+				w := s.getJustify(seg, level, 3)
+				pref := diffpw*float32(w) + residual
+				step := s.getJustify(seg, level, 2)
+				if step == 0 {
+					step = 1 // handle lazy font developers
+				}
+				if pref > 0 {
+					max := float32(uint16(s.getJustify(seg, level, 0)))
+					if i == 0 {
+						max -= s.just
+					}
+					if pref > max {
+						pref = max
+					} else {
+						tWeight += int(w)
+					}
+				} else {
+					max := float32(uint16(s.getJustify(seg, level, 1)))
+					if i == 0 {
+						max += s.just
+					}
+					if -pref > max {
+						pref = -max
+					} else {
+						tWeight += int(w)
+					}
+				}
+				actual := int(pref/float32(step)) * int(step)
+
+				if actual != 0 {
+					residual += diffpw*float32(w) - float32(actual)
+					if i == 0 {
+						s.just += float32(actual)
+					} else {
+						s.setJustify(seg, level, 4, int16(actual))
+					}
+				}
+			}
+			currWidth += diff - residual
+
+			errorAbs := int(residual)
+			if errorAbs < 0 {
+				errorAbs = -errorAbs
+			}
+			do = i == 0 && errorAbs > 0 && tWeight != 0
+		}
+	}
+
+	oldFirst, oldLast := seg.First, seg.last
+	if seg.silf.flags&1 != 0 {
+		pSlot = seg.addLineEnd(pSlot)
+		seg.First = pSlot
+		pLast = seg.addLineEnd(end)
+		seg.last = pLast
+	} else {
+		seg.First = pSlot
+		seg.last = pLast
+	}
+
+	// run justification passes
+	if seg.silf.indexJustPass != seg.silf.indexPosPass && (width >= 0 || seg.silf.flags&1 != 0) {
+		seg.silf.runGraphite(seg, seg.silf.indexJustPass, seg.silf.indexPosPass, false)
+	}
+
+	res := seg.positionSlots(font, pSlot, pLast, seg.dir != 0, true)
+
+	if seg.silf.flags&1 != 0 {
+		if seg.First != nil {
+			seg.delLineEnd(seg.First)
+		}
+		if seg.last != nil {
+			seg.delLineEnd(seg.last)
+		}
+	}
+	seg.First, seg.last = oldFirst, oldLast
+
+	if reverse {
+		seg.reverseSlots()
+	}
+
+	seg.Advance.X = res.X
+	return res.X
+}
+
+// inserts a line-end slot before `nSlot`, or at the end
+// of the segment if `nSlot` is nil
+func (seg *Segment) addLineEnd(nSlot *Slot) *Slot {
+	eSlot := seg.newSlot()
+	eSlot.setGlyph(seg, GID(seg.silf.lbGID))
+	if nSlot != nil {
+		eSlot.Next = nSlot
+		eSlot.prev = nSlot.prev
+		nSlot.prev = eSlot
+		eSlot.Before = nSlot.Before
+		if eSlot.prev != nil {
+			eSlot.After = eSlot.prev.After
+		} else {
+			eSlot.After = nSlot.Before
+		}
+	} else {
+		nSlot = seg.last
+		eSlot.prev = nSlot
+		nSlot.Next = eSlot
+		eSlot.After = eSlot.prev.After
+		eSlot.Before = nSlot.After
+	}
+	return eSlot
+}
+
+// removes a slot inserted by addLineEnd
+func (seg *Segment) delLineEnd(s *Slot) {
+	if nSlot := s.Next; nSlot != nil {
+		nSlot.prev = s.prev
+		if s.prev != nil {
+			s.prev.Next = nSlot
+		}
+	} else {
+		s.prev.Next = nil
+	}
+	seg.freeSlot(s)
+}
@@ -792,8 +792,12 @@ type passes struct {
 	attrSkipPasses     byte  // Glyph attribute of bitmap indicating key glyphs for pass optimization
 	attrCollision      byte  // Glyph attribute number for collision.flags attribute (several more collision attrs come after it...)
 
+	lbGID gid   // glyph ID for the line-break pseudo-glyph
+	flags uint8 // see silfSubtablePart1.Flags
+
 	indexBidiPass byte // (0xFF) means no bidi pass
 	indexPosPass  byte // index of the first positionning pass
+	indexJustPass byte // index of the first justification pass
 	hasCollision  bool
 	isRTL         bool
 }
@@ -844,6 +848,9 @@ func newPasses(silf *silfSubtable, numAttributes, numFeatures uint16) (out passe
 
 	out.indexBidiPass = silf.IBidi
 	out.indexPosPass = silf.IPos
+	out.indexJustPass = silf.IJust
+	out.lbGID = silf.lbGID
+	out.flags = silf.Flags
 	out.hasCollision = silf.Flags&0x20 != 0
 	// see the reference implementation for this switch
 	out.isRTL = (silf.Direction-1)&1 != 0
@@ -14,9 +14,10 @@ import (
 // Test shaping output against the reference graphite implementation
 
 type testOptions struct {
-	input []rune
-	// justification int // we dont support neither test this feature
-	offset int // zero for us
+	font          *FontOptions
+	input         []rune
+	justification int // in percent of the segment advance, 0 to disable
+	offset        int // zero for us
 }
 
 func lookup(map_ []*Slot, val *Slot) int {
@@ -36,10 +37,10 @@ func (opts testOptions) dumpSegment(seg *Segment) ([]byte, error) {
 	// 	int numSlots = gr_seg_n_slots(seg);
 	// #endif
 	//        size_t *map = new size_t [seg.length() + 1];
-	// if (opts.justification > 0){
-	// 	advanceWidth = gr_seg_justify(seg, gr_seg_first_slot(seg), sizedFont, gr_seg_advance_X(seg) * opts.justification / 100., gr_justCompleteLine, NULL, NULL);
-	// }else{
 	advanceWidth := seg.Advance.X
+	if opts.justification > 0 {
+		advanceWidth = seg.Justify(opts.font, seg.Advance.X*float32(opts.justification)/100, 0)
+	}
 	map_ := make([]*Slot, seg.NumGlyphs+1)
 	for slot, i := seg.First, 0; slot != nil; slot, i = slot.Next, i+1 {
 		map_[i] = slot
@@ -170,6 +171,10 @@ func (input shapingInput) String() string {
 }
 
 func (input shapingInput) testWithScale(t *testing.T, expected []byte, scale bool) error {
+	return input.testWithOptions(t, expected, scale, 0)
+}
+
+func (input shapingInput) testWithOptions(t *testing.T, expected []byte, scale bool, justification int) error {
 	face := loadGraphite(t, input.fontfile)
 
 	out := "Text codes\n"
@@ -202,7 +207,7 @@ func (input shapingInput) testWithScale(t *testing.T, expected []byte, scale boo
 		return fmt.Errorf("test %s: %s", input.name, err)
 	}
 
-	opts := testOptions{input: input.text}
+	opts := testOptions{font: font, input: input.text, justification: justification}
 	segString, err := opts.dumpSegment(seg)
 	if err != nil {
 		return fmt.Errorf("test %s: %s", input.name, err)
@@ -252,10 +257,6 @@ var referenceFonttestInput = []shapingInput{
 	{"general1", "general.ttf", "", []rune{0x0E01, 0x0062}, false},
 	{"piglatin1", "PigLatinBenchmark_v3.ttf", "", []rune{0x0068, 0x0065, 0x006C, 0x006C, 0x006F}, false},
 
-	// we dont support justification
-	// {"padauk12", "Padauk.ttf", []rune{0x0048, 0x0065, 0x006C,0x006C,0x006F,0x0020,0x004D,0x0075,0x006D -j 107}},
-	// {"charis6", "charis.t"",tf", []rune{0x0048, 0x0065, 0x006C,0x006C,0x006F,0x0020,0x004D,0x0075,0x006D -j 107}, false},
-
 	// {"scher5", "Scheherazadegr_noglyfs.t"",tf", []rune{0x0627, 0x0653, 0x06AF}, true},
 }
 
@@ -272,6 +273,25 @@ func TestShapeSegment(t *testing.T) {
 	}
 }
 
+// these inputs are justified to 107% of their advance
+var referenceJustifyInput = []shapingInput{
+	{"padauk12", "Padauk.ttf", "", []rune{0x0048, 0x0065, 0x006C, 0x006C, 0x006F, 0x0020, 0x004D, 0x0075, 0x006D}, false},
+	{"charis6", "charis.ttf", "", []rune{0x0048, 0x0065, 0x006C, 0x006C, 0x006F, 0x0020, 0x004D, 0x0075, 0x006D}, false},
+}
+
+func TestJustifySegment(t *testing.T) {
+	for _, input := range referenceJustifyInput {
+		expected, err := testdata.Files.ReadFile("shape_refs/" + input.name + ".log")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := input.testWithOptions(t, expected, true, 107); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // fail cases from TestReferenceShaping
 var fuzzTestInput = []shapingInput{
 	{name: "fuzz_0", fontfile: "MagyarLinLibertineG.ttf", text: []rune{0x0066, 0x0069}, features: "210=36", rtl: false},
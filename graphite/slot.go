@@ -767,7 +767,13 @@ type slotJustify struct {
 }
 
 func (sj *slotJustify) loadSlot(s *Slot, seg *Segment) {
-	sj.values = make([][numJustParams]int16, len(seg.silf.justificationLevels))
+	// always reserve storage for the first level,
+	// which is used by the default justification
+	levels := len(seg.silf.justificationLevels)
+	if levels == 0 {
+		levels = 1
+	}
+	sj.values = make([][numJustParams]int16, levels)
 	for i, justs := range seg.silf.justificationLevels {
 		v := &sj.values[i]
 		v[0] = seg.face.getGlyphAttr(s.glyphID, uint16(justs.AttrStretch))
@@ -100,6 +100,9 @@ type Buffer struct {
 	maxOps int // maximum operations allowed
 	maxLen int // maximum length allowed
 
+	// non nil after shaping with Graphite, required for justification
+	grSegment *graphiteSegment
+
 	serial       uint
 	idx          int                // Cursor into `info` and `pos` arrays
 	scratchFlags bufferScratchFlags /* Have space-fallback, etc. */
@@ -232,6 +235,7 @@ func (b *Buffer) Clear() {
 	b.Pos = b.Pos[:0]
 	b.clearContext(0)
 	b.clearContext(1)
+	b.grSegment = nil
 
 	b.serial = 0
 }
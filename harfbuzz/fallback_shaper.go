@@ -1,5 +1,7 @@
 package harfbuzz
 
+import tt "github.com/benoitkugler/textlayout/fonts/truetype"
+
 // ported from harfbuzz/src/hb-fallback-shape.cc Copyright © 2011  Google, Inc. Behdad Esfahbod
 
 var _ shaper = shaperFallback{}
@@ -13,7 +15,7 @@ func (shaperFallback) kind() shaperKind { return skFallback }
 func (shaperFallback) compile(props SegmentProperties, userFeatures []Feature) {
 }
 
-func (shaperFallback) shape(font *Font, buffer *Buffer, _ []Feature) {
+func (shaperFallback) shape(font *Font, buffer *Buffer, features []Feature) {
 	space, hasSpace := font.face.NominalGlyph(' ')
 
 	buffer.clearPositions()
@@ -39,4 +41,41 @@ func (shaperFallback) shape(font *Font, buffer *Buffer, _ []Feature) {
 	}
 
 	buffer.clearGlyphFlags(0)
+
+	if kerning, ok := font.face.(FaceKerning); ok && direction.isHorizontal() && isKerningEnabled(features) {
+		fallbackKern(kerning, font, buffer)
+	}
+}
+
+// isKerningEnabled returns false if the 'kern' feature
+// is globally disabled by the user
+func isKerningEnabled(features []Feature) bool {
+	kernTag := tt.NewTag('k', 'e', 'r', 'n')
+	enabled := true
+	for _, feature := range features {
+		if feature.Tag == kernTag && feature.Start == FeatureGlobalStart && feature.End == FeatureGlobalEnd {
+			enabled = feature.Value != 0
+		}
+	}
+	return enabled
+}
+
+// fallbackKern applies the kerning pairs to consecutive glyphs,
+// which are expected in visual order.
+func fallbackKern(kerning FaceKerning, font *Font, buffer *Buffer) {
+	info, pos := buffer.Info, buffer.Pos
+	for i := 1; i < len(info); i++ {
+		rawKern := kerning.KernPair(info[i-1].Glyph, info[i].Glyph)
+		if rawKern == 0 {
+			continue
+		}
+		kern := font.emScaleX(rawKern)
+		kern1 := kern >> 1
+		kern2 := kern - kern1
+		pos[i-1].XAdvance += kern1
+		pos[i].XAdvance += kern2
+		pos[i].XOffset += kern2
+
+		buffer.unsafeToBreak(i-1, i+1)
+	}
 }
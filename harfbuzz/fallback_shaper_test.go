@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
 )
 
 // ported from harfbuzz/test/api/test-shape.c  Copyright © 2011  Google, Inc. Behdad Esfahbod
@@ -73,3 +74,37 @@ func TestShape(t *testing.T) {
 	font.XScale = 100
 	testFont(t, font)
 }
+
+type dummyFaceKern struct {
+	dummyFaceShape
+}
+
+func (dummyFaceKern) KernPair(left, right fonts.GID) int16 {
+	if left == 1 && right == 2 { // Te
+		return -20
+	}
+	return 0
+}
+
+func TestShapeFallbackKern(t *testing.T) {
+	font := NewFont(dummyFaceKern{dummyFaceShape{xScale: 100}})
+	font.XScale = 100
+
+	shape := func(features []Feature) []GlyphPosition {
+		buffer := NewBuffer()
+		buffer.Props.Direction = LeftToRight
+		buffer.AddRunes([]rune("TesT"), 0, 4)
+		buffer.Shape(font, features)
+		return buffer.Pos
+	}
+
+	pos := shape(nil)
+	assertEqualInt(t, int(pos[0].XAdvance), 9)
+	assertEqualInt(t, int(pos[1].XAdvance), 5)
+	assertEqualInt(t, int(pos[1].XOffset), -1)
+	assertEqualInt(t, int(pos[2].XAdvance), 5)
+
+	pos = shape([]Feature{{Tag: tt.NewTag('k', 'e', 'r', 'n'), Value: 0, Start: FeatureGlobalStart, End: FeatureGlobalEnd}})
+	assertEqualInt(t, int(pos[0].XAdvance), 10)
+	assertEqualInt(t, int(pos[1].XAdvance), 6)
+}
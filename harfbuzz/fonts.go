@@ -43,6 +43,16 @@ type FaceOpentype interface {
 	VariationGlyph(ch, varSelector rune) (fonts.GID, bool)
 }
 
+// FaceKerning is an optional interface for faces providing kerning pairs
+// without Opentype layout tables, such as Type1 fonts with an .afm file.
+// It is used by the fallback shaper.
+type FaceKerning interface {
+	// KernPair return the kern value for the given pair, or zero.
+	// The value is expressed in glyph units and
+	// is negative when glyphs should be closer.
+	KernPair(left, right fonts.GID) int16
+}
+
 // Font is used internally as a light wrapper around the provided Face.
 //
 // While a font face is generally the in-memory representation of a static font file,
@@ -65,9 +75,13 @@ type Font struct {
 	faceUpem               int32                       // cached value of Face.Upem()
 
 	// Point size of the font. Set to zero to unset.
-	// This is used in AAT layout, when applying 'trak' table.
+	// This is used in AAT layout, and when applying the 'trak' table.
 	Ptem float32
 
+	// SyntheticTracking is the tracking, in font units, used for fonts
+	// without tracking data in their 'trak' table (see `Font.Tracking`).
+	SyntheticTracking float32
+
 	// Horizontal and vertical scale of the font.
 	// The resulting positions are computed with: fontUnit * Scale / faceUpem,
 	// where faceUpem is given by the face.
@@ -80,6 +94,14 @@ type Font struct {
 	// Is is used to select bitmap sizes and to perform some Opentype
 	// positionning.
 	XPpem, YPpem uint16
+
+	// SynthesizeVerticalMetrics enables synthesized vertical metrics
+	// for faces without vertical metrics (like fonts without 'vmtx' table),
+	// which is useful for CJK vertical text: the vertical advance is then the height
+	// of the horizontal line (ascender - descender), and the vertical origin is
+	// placed at the ascender, so that glyphs keep their position in the line.
+	// The default (false) matches the behavior of the reference implementation.
+	SynthesizeVerticalMetrics bool
 }
 
 // NewFont constructs a new font object from the specified face.
@@ -204,6 +226,10 @@ func (f *Font) GlyphHAdvance(glyph fonts.GID) Position {
 // Fetches the advance for a glyph ID in the font,
 // for vertical text segments.
 func (f *Font) getGlyphVAdvance(glyph fonts.GID) Position {
+	if f.useSynthesizedVerticalMetrics() {
+		extents := f.ExtentsForDirection(LeftToRight)
+		return -Position(extents.Ascender - extents.Descender)
+	}
 	adv := f.face.VerticalAdvance(glyph)
 	return f.emScalefY(adv)
 }
@@ -245,6 +271,10 @@ func (f *Font) getGlyphHOriginWithFallback(glyph fonts.GID) (Position, Position)
 }
 
 func (f *Font) getGlyphVOriginWithFallback(glyph fonts.GID) (Position, Position) {
+	if f.useSynthesizedVerticalMetrics() {
+		extents := f.ExtentsForDirection(LeftToRight)
+		return f.GlyphHAdvance(glyph) / 2, Position(extents.Ascender)
+	}
 	x, y, ok := f.face.GlyphVOrigin(glyph)
 	if !ok {
 		x, y, ok = f.face.GlyphHOrigin(glyph)
@@ -256,6 +286,16 @@ func (f *Font) getGlyphVOriginWithFallback(glyph fonts.GID) (Position, Position)
 	return x, y
 }
 
+// returns true if `SynthesizeVerticalMetrics` is set and
+// the face does not provide vertical metrics
+func (f *Font) useSynthesizedVerticalMetrics() bool {
+	if !f.SynthesizeVerticalMetrics {
+		return false
+	}
+	face, ok := f.face.(interface{ HasVerticalMetrics() bool })
+	return !ok || !face.HasVerticalMetrics()
+}
+
 func (f *Font) guessVOriginMinusHOrigin(glyph fonts.GID) (x, y Position) {
 	x = f.GlyphHAdvance(glyph) / 2
 	y = f.getHExtendsAscender()
@@ -403,7 +443,7 @@ func (f *Font) GetOTGlyphClass(glyph fonts.GID) uint32 {
 }
 
 // GetOTLigatureCarets fetches a list of the caret positions defined for a ligature glyph in the GDEF
-// table of the font (or nil if not found).
+// table of the font, or in the AAT 'lcar' table as a fallback (or nil if not found).
 func (f *Font) GetOTLigatureCarets(direction Direction, glyph fonts.GID) []Position {
 	if f.otTables == nil {
 		return nil
@@ -413,12 +453,12 @@ func (f *Font) GetOTLigatureCarets(direction Direction, glyph fonts.GID) []Posit
 
 	list := f.otTables.GDEF.LigatureCaretList
 	if list.Coverage == nil {
-		return nil
+		return f.getAATLigatureCarets(direction, glyph)
 	}
 
 	index, ok := list.Coverage.Index(glyph)
 	if !ok {
-		return nil
+		return f.getAATLigatureCarets(direction, glyph)
 	}
 
 	glyphCarets := list.LigCarets[index]
@@ -429,6 +469,219 @@ func (f *Font) GetOTLigatureCarets(direction Direction, glyph fonts.GID) []Posit
 	return out
 }
 
+// use the 'lcar' table
+func (f *Font) getAATLigatureCarets(direction Direction, glyph fonts.GID) []Position {
+	lcar := f.otTables.Lcar
+	carets := lcar.GetCarets(glyph)
+	if carets == nil {
+		return nil
+	}
+	out := make([]Position, len(carets))
+	for i, c := range carets {
+		if lcar.IsPointIndex {
+			x, y, _ := f.getGlyphContourPointForOrigin(glyph, uint16(c), direction)
+			if direction.isHorizontal() {
+				out[i] = x
+			} else {
+				out[i] = y
+			}
+		} else if direction.isHorizontal() {
+			out[i] = f.emScaleX(c)
+		} else {
+			out[i] = f.emScaleY(c)
+		}
+	}
+	return out
+}
+
+// OpticalBounds stores the amounts by which a glyph may protrude
+// beyond each of its sides, for optical margin alignment.
+// Positive values move the glyph outside of the text block.
+type OpticalBounds struct {
+	Left, Top, Right, Bottom Position
+}
+
+// GetAATOpticalBounds fetches the optical bounds of the glyph, defined
+// in the AAT 'opbd' table, or false if not found.
+// The table locates the optical edges of the glyph, either by deltas (in font units,
+// the Y axis going up) added to the sides of the advance box, or by control points
+// (the sides without a control point are not moved).
+// For both formats, the edges are resolved to protrusion amounts, which are positive
+// when the optical edge is inside the advance box.
+// Left and right (resp. top and bottom) values are scaled by `XScale` (resp. `YScale`).
+// See also `GetGlyphProtrusion`.
+func (f *Font) GetAATOpticalBounds(glyph fonts.GID) (OpticalBounds, bool) {
+	if f.otTables == nil {
+		return OpticalBounds{}, false
+	}
+	opbd := f.otTables.Opbd
+	bounds, ok := opbd.GetBounds(glyph)
+	if !ok {
+		return OpticalBounds{}, false
+	}
+
+	// the edges, relative to the horizontal (resp. vertical) origin,
+	// start as the sides of the advance box (vertical advances are negative)
+	hAdvance, vAdvance := f.GlyphHAdvance(glyph), f.getGlyphVAdvance(glyph)
+	left, right, top, bottom := Position(0), hAdvance, Position(0), vAdvance
+	if !opbd.IsPointIndex {
+		left += f.emScaleX(bounds.Left)
+		right += f.emScaleX(bounds.Right)
+		top += f.emScaleY(bounds.Top)
+		bottom += f.emScaleY(bounds.Bottom)
+	} else {
+		if x, _, ok := f.getScaledContourPoint(glyph, bounds.Left, LeftToRight); ok {
+			left = x
+		}
+		if x, _, ok := f.getScaledContourPoint(glyph, bounds.Right, LeftToRight); ok {
+			right = x
+		}
+		if _, y, ok := f.getScaledContourPoint(glyph, bounds.Top, TopToBottom); ok {
+			top = y
+		}
+		if _, y, ok := f.getScaledContourPoint(glyph, bounds.Bottom, TopToBottom); ok {
+			bottom = y
+		}
+	}
+
+	return OpticalBounds{
+		Left:   left,
+		Top:    -top,
+		Right:  hAdvance - right,
+		Bottom: bottom - vAdvance,
+	}, true
+}
+
+// returns the scaled position of the control point `index` (which may be -1 for no point),
+// relative to the glyph origin for `direction`
+func (f *Font) getScaledContourPoint(glyph fonts.GID, index int16, direction Direction) (x, y Position, ok bool) {
+	face, isOpentype := f.face.(FaceOpentype)
+	if !isOpentype || index < 0 {
+		return 0, 0, false
+	}
+	// contour points are in font units
+	x, y, ok = face.GetGlyphContourPoint(glyph, uint16(index))
+	if !ok {
+		return 0, 0, false
+	}
+	x, y = f.emScalefX(float32(x)), f.emScalefY(float32(y))
+	x, y = f.subtractGlyphOriginForDirection(glyph, direction, x, y)
+	return x, y, true
+}
+
+// GetAATMirroredGlyph returns the glyph to use in right to left
+// text in place of `glyph`, as defined by the AAT 'prop' table,
+// or false if not found.
+func (f *Font) GetAATMirroredGlyph(glyph fonts.GID) (fonts.GID, bool) {
+	if f.otTables == nil {
+		return 0, false
+	}
+	return f.otTables.Prop.MirroredGlyph(glyph)
+}
+
+var (
+	tagLeftBounds    = tt.MustNewTag("lfbd")
+	tagRightBounds   = tt.MustNewTag("rtbd")
+	tagOpticalBounds = tt.MustNewTag("opbd")
+)
+
+// GetGlyphProtrusion returns the amounts by which `glyph` may protrude
+// into the margin when it is placed at the start or at the end of a line
+// laid out in `direction`, for optical margin alignment.
+// Positive values move the glyph outside of the text block.
+// The values are read from the GPOS 'lfbd', 'rtbd' (or 'opbd') features
+// or, if the font has none of them, from the AAT 'opbd' table. They are scaled
+// by `XScale` (`YScale` for vertical directions), and are zero if the
+// font provides no data for the glyph.
+func (f *Font) GetGlyphProtrusion(glyph fonts.GID, direction Direction) (start, end Position) {
+	if f.otTables == nil {
+		return 0, 0
+	}
+	var ok bool
+	if direction.isHorizontal() {
+		start, end, ok = f.getGPOSProtrusion(glyph)
+	}
+	if !ok {
+		start, end = f.getAATProtrusion(glyph, direction)
+	}
+	if direction.isBackward() {
+		start, end = end, start
+	}
+	return start, end
+}
+
+// returns the left and right protrusions defined by the GPOS features,
+// or false if the font has no such features
+func (f *Font) getGPOSProtrusion(glyph fonts.GID) (left, right Position, ok bool) {
+	gpos := &f.otTables.GPOS
+	_, hasLeft := gpos.FindFeatureIndex(tagLeftBounds)
+	_, hasRight := gpos.FindFeatureIndex(tagRightBounds)
+	if hasLeft || hasRight {
+		left, _ = f.getGPOSFeatureProtrusion(glyph, tagLeftBounds)
+		_, right = f.getGPOSFeatureProtrusion(glyph, tagRightBounds)
+		return left, right, true
+	}
+	if _, has := gpos.FindFeatureIndex(tagOpticalBounds); has {
+		left, right = f.getGPOSFeatureProtrusion(glyph, tagOpticalBounds)
+		return left, right, true
+	}
+	return 0, 0, false
+}
+
+// sums the adjustments from the single positioning lookups of the feature
+func (f *Font) getGPOSFeatureProtrusion(glyph fonts.GID, feature tt.Tag) (left, right Position) {
+	gpos := &f.otTables.GPOS
+	for _, record := range gpos.Features {
+		if record.Tag != feature {
+			continue
+		}
+		for _, lookupIndex := range record.LookupIndices {
+			if int(lookupIndex) >= len(gpos.Lookups) {
+				continue
+			}
+			lookup := gpos.Lookups[lookupIndex]
+			if lookup.Type != tt.GPOSSingle {
+				continue
+			}
+			for _, subtable := range lookup.Subtables {
+				index, covered := subtable.Coverage.Index(glyph)
+				if !covered {
+					continue
+				}
+				var value tt.GPOSValueRecord
+				switch data := subtable.Data.(type) {
+				case tt.GPOSSingle1:
+					value = data.Value
+				case tt.GPOSSingle2:
+					if index >= len(data.Values) {
+						continue
+					}
+					value = data.Values[index]
+				default:
+					continue
+				}
+				// moving the glyph to the left (resp. reducing its advance)
+				// makes it protrude on the left (resp. right) side
+				left -= f.emScaleX(value.XPlacement)
+				right -= f.emScaleX(value.XAdvance - value.XPlacement)
+				break // only the first matching subtable applies
+			}
+		}
+		break
+	}
+	return left, right
+}
+
+// returns the left and right (or top and bottom) protrusions
+// defined by the AAT 'opbd' table
+func (f *Font) getAATProtrusion(glyph fonts.GID, direction Direction) (start, end Position) {
+	bounds, _ := f.GetAATOpticalBounds(glyph)
+	if direction.isHorizontal() {
+		return bounds.Left, bounds.Right
+	}
+	return bounds.Top, bounds.Bottom
+}
+
 // interpreted the CaretValue according to its format
 func (f *Font) getCaretValue(caret truetype.CaretValue, direction Direction, glyph fonts.GID, varStore truetype.VariationStore) Position {
 	switch caret := caret.(type) {
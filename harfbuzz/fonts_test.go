@@ -1,10 +1,13 @@
 package harfbuzz
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
 	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/language"
 )
 
 // ported from harfbuzz/test/api/test-font.c Copyright © 2011  Google, Inc. Behdad Esfahbod
@@ -268,3 +271,241 @@ func TestLigCarets(t *testing.T) {
 		t.Fatalf("for glyph %d, expected %v, got %v", 1023, expected, carets)
 	}
 }
+
+func TestAATQueries(t *testing.T) {
+	font := NewFont(openFontFileTT("ToyKern1.ttf"))
+
+	gid, ok := font.GetAATMirroredGlyph(11)
+	assert(t, ok)
+	assertEqualInt32(t, int32(gid), 12)
+	_, ok = font.GetAATMirroredGlyph(3)
+	assert(t, !ok)
+
+	// no 'opbd' nor 'lcar' table
+	_, ok = font.GetAATOpticalBounds(11)
+	assert(t, !ok)
+	assert(t, font.GetOTLigatureCarets(LeftToRight, 11) == nil)
+}
+
+func TestGlyphProtrusion(t *testing.T) {
+	font := NewFont(openFontFileTT("ToyKern1.ttf"))
+	font.XScale = font.faceUpem * 2
+
+	// no optical bounds data
+	start, end := font.GetGlyphProtrusion(11, LeftToRight)
+	assertEqualInt32(t, start, 0)
+	assertEqualInt32(t, end, 0)
+
+	tables := *font.otTables
+	tables.GPOS = tt.TableGPOS{
+		Lookups: []tt.LookupGPOS{
+			{Type: tt.GPOSSingle, Subtables: []tt.GPOSSubtable{
+				{
+					Coverage: tt.CoverageList{11, 12},
+					Data: tt.GPOSSingle2{
+						Format: tt.XPlacement | tt.XAdvance,
+						Values: []tt.GPOSValueRecord{{XPlacement: -50, XAdvance: -50}, {XPlacement: -20, XAdvance: -20}},
+					},
+				},
+			}},
+			{Type: tt.GPOSSingle, Subtables: []tt.GPOSSubtable{
+				{
+					Coverage: tt.CoverageList{11},
+					Data:     tt.GPOSSingle1{Format: tt.XAdvance, Value: tt.GPOSValueRecord{XAdvance: -30}},
+				},
+			}},
+		},
+	}
+	tables.GPOS.Features = []tt.FeatureRecord{
+		{Tag: tt.MustNewTag("lfbd"), Feature: tt.Feature{LookupIndices: []uint16{0}}},
+		{Tag: tt.MustNewTag("rtbd"), Feature: tt.Feature{LookupIndices: []uint16{1}}},
+	}
+	font.otTables = &tables
+
+	start, end = font.GetGlyphProtrusion(11, LeftToRight)
+	assertEqualInt32(t, start, 100)
+	assertEqualInt32(t, end, 60)
+	start, end = font.GetGlyphProtrusion(11, RightToLeft)
+	assertEqualInt32(t, start, 60)
+	assertEqualInt32(t, end, 100)
+	start, end = font.GetGlyphProtrusion(12, LeftToRight)
+	assertEqualInt32(t, start, 40)
+	assertEqualInt32(t, end, 0)
+	start, end = font.GetGlyphProtrusion(3, LeftToRight)
+	assertEqualInt32(t, start, 0)
+	assertEqualInt32(t, end, 0)
+}
+
+func TestSynthesizedVerticalMetrics(t *testing.T) {
+	font := NewFont(openFontFileTT("ToyKern1.ttf")) // no 'vmtx' table
+	font.XScale, font.YScale = font.faceUpem*2, font.faceUpem*2
+	extents := font.ExtentsForDirection(LeftToRight)
+
+	_, defaultAdvance := font.GlyphAdvanceForDirection(11, TopToBottom)
+	assertEqualInt32(t, defaultAdvance, -font.YScale)
+
+	font.SynthesizeVerticalMetrics = true
+	_, advance := font.GlyphAdvanceForDirection(11, TopToBottom)
+	assertEqualInt32(t, advance, -Position(extents.Ascender-extents.Descender))
+	x, y := font.getGlyphOriginForDirection(11, TopToBottom)
+	assertEqualInt32(t, x, font.GlyphHAdvance(11)/2)
+	assertEqualInt32(t, y, Position(extents.Ascender))
+}
+
+func TestBaseline(t *testing.T) {
+	face := openFontFileTT("AccanthisADFStdNo2-Regular.otf")
+	font := NewFont(face)
+	font.XScale, font.YScale = int32(face.Upem())*2, int32(face.Upem())*2
+
+	pos, ok := font.BaselineFromTable(BaselineIdeoEmboxBottomOrLeft, LeftToRight, language.Latin, "")
+	assert(t, ok)
+	assertEqualInt32(t, pos, -300)
+	pos, ok = font.BaselineFromTable(BaselineRoman, LeftToRight, language.Latin, "")
+	assert(t, ok)
+	assertEqualInt32(t, pos, 0)
+	_, ok = font.BaselineFromTable(BaselineHanging, LeftToRight, language.Latin, "")
+	assert(t, !ok)
+	_, ok = font.BaselineFromTable(BaselineRoman, TopToBottom, language.Latin, "")
+	assert(t, !ok)
+
+	// synthesized from the BASE table
+	assertEqualInt32(t, font.Baseline(BaselineIdeoEmboxTopOrRight, LeftToRight, language.Latin, ""), -300+font.YScale)
+	assertEqualInt32(t, font.Baseline(BaselineIdeoEmboxCentral, LeftToRight, language.Latin, ""), (-300+-300+font.YScale)/2)
+	assertEqualInt32(t, font.Baseline(BaselineHanging, LeftToRight, language.Latin, ""), font.YScale*6/10)
+
+	// synthesized from the font metrics
+	extents := font.ExtentsForDirection(TopToBottom)
+	assertEqualInt32(t, font.Baseline(BaselineIdeoEmboxTopOrRight, TopToBottom, language.Latin, ""), Position(extents.Ascender))
+	assertEqualInt32(t, font.Baseline(BaselineIdeoEmboxBottomOrLeft, TopToBottom, language.Latin, ""), Position(extents.Descender))
+
+	_, _, ok = font.BaselineMinMax(LeftToRight, language.Latin, "", 0)
+	assert(t, !ok)
+}
+
+func TestBaselineIdeographicFace(t *testing.T) {
+	font := NewFont(openFontFile("harfbuzz_reference/text-rendering-tests/fonts/FDArrayTest65535.otf"))
+	_, ok := font.BaselineFromTable(BaselineIdeoFaceTopOrRight, LeftToRight, language.Han, "")
+	assert(t, ok)
+
+	// remove the 'BASE' table
+	tables := *font.otTables
+	tables.BASE = tt.TableBASE{}
+	font.otTables = &tables
+	_, ok = font.BaselineFromTable(BaselineIdeoFaceTopOrRight, LeftToRight, language.Han, "")
+	assert(t, !ok)
+
+	// synthesized from the extents of U+2F00
+	glyph, ok := font.face.NominalGlyph(0x2F00)
+	assert(t, ok)
+	extents, ok := font.GlyphExtents(glyph)
+	assert(t, ok && extents.Height < 0)
+	assertEqualInt32(t, font.Baseline(BaselineIdeoFaceTopOrRight, LeftToRight, language.Han, ""), extents.YBearing)
+	assertEqualInt32(t, font.Baseline(BaselineIdeoFaceBottomOrLeft, LeftToRight, language.Han, ""), extents.YBearing+extents.Height)
+	assertEqualInt32(t, font.Baseline(BaselineIdeoFaceCentral, LeftToRight, language.Han, ""), extents.YBearing+extents.Height/2)
+
+	// synthesized from the embox for vertical text
+	top := font.Baseline(BaselineIdeoEmboxTopOrRight, TopToBottom, language.Han, "")
+	bottom := font.Baseline(BaselineIdeoEmboxBottomOrLeft, TopToBottom, language.Han, "")
+	assertEqualInt32(t, font.Baseline(BaselineIdeoFaceTopOrRight, TopToBottom, language.Han, ""), top+(bottom-top)/10)
+}
+
+// returns a minimal font file containing only the given table
+func singleTableFont(tag tt.Tag, table []byte) []byte {
+	out := []byte{0, 1, 0, 0, 0, 1, 0, 16, 0, 0, 0, 0} // version, numTables, searchRange...
+	out = append(out, byte(tag>>24), byte(tag>>16), byte(tag>>8), byte(tag))
+	out = append(out, 0, 0, 0, 0, 0, 0, 0, 28, 0, 0, byte(len(table)>>8), byte(len(table))) // checksum, offset, length
+	return append(out, table...)
+}
+
+// pointsFace overrides the contour points of a font
+type pointsFace struct {
+	*tt.Font
+	points map[uint16][2]int32
+}
+
+func (f pointsFace) GetGlyphContourPoint(_ fonts.GID, index uint16) (x, y int32, ok bool) {
+	p, ok := f.points[index]
+	return p[0], p[1], ok
+}
+
+// returns an 'opbd' table with the given bounds for glyphs 11 and 12
+func opbdTable(isPointIndex bool, bounds11, bounds12 [4]int16) []byte {
+	out := []byte{
+		0, 1, 0, 0, // version
+		0, 0, // format: distances
+		0, 6, 0, 4, 0, 2, 0, 8, 0, 1, 0, 0, // lookup format 6, two glyphs
+		0, 11, 0, 26, // glyph 11 -> offset 26
+		0, 12, 0, 34, // glyph 12 -> offset 34
+	}
+	if isPointIndex {
+		out[5] = 1
+	}
+	for _, v := range append(bounds11[:], bounds12[:]...) {
+		out = append(out, byte(uint16(v)>>8), byte(v))
+	}
+	return out
+}
+
+func withOpbd(t *testing.T, font *Font, opbd []byte) {
+	parser, err := tt.NewFontParser(bytes.NewReader(singleTableFont(tt.MustNewTag("opbd"), opbd)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := *font.otTables
+	tables.Opbd, err = parser.OpbdTable(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font.otTables = &tables
+}
+
+func TestAATOpticalBounds(t *testing.T) {
+	ttFont := openFontFileTT("ToyKern1.ttf")
+	font := NewFont(ttFont)
+	font.XScale, font.YScale = font.faceUpem*2, font.faceUpem*3
+
+	// left = -50, top = 10, right = 50, bottom = 20
+	withOpbd(t, font, opbdTable(false, [4]int16{-50, 10, 50, 20}, [4]int16{}))
+
+	// the deltas are added to the sides of the advance box, the Y axis going up
+	expected := OpticalBounds{Left: -100, Top: -30, Right: -100, Bottom: 60}
+	bounds, ok := font.GetAATOpticalBounds(11)
+	assert(t, ok)
+	if bounds != expected {
+		t.Fatalf("expected %v, got %v", expected, bounds)
+	}
+	bounds, ok = font.GetAATOpticalBounds(12)
+	assert(t, ok && bounds == OpticalBounds{})
+	_, ok = font.GetAATOpticalBounds(13)
+	assert(t, !ok)
+
+	start, end := font.GetGlyphProtrusion(11, LeftToRight)
+	assertEqualInt32(t, start, -100)
+	assertEqualInt32(t, end, -100)
+	start, end = font.GetGlyphProtrusion(11, TopToBottom)
+	assertEqualInt32(t, start, -30)
+	assertEqualInt32(t, end, 60)
+
+	// the same edges, given by control points, must be resolved to
+	// the same bounds (the font is not scaled, to use font units)
+	pointsFont := NewFont(pointsFace{Font: ttFont})
+	_, vOrigin := pointsFont.getGlyphVOriginWithFallback(11)
+	hAdvance, vAdvance := pointsFont.GlyphHAdvance(11), pointsFont.getGlyphVAdvance(11)
+	pointsFont.face = pointsFace{Font: ttFont, points: map[uint16][2]int32{
+		0: {-50, 0},
+		1: {hAdvance + 50, 0},
+		2: {0, vOrigin + 10},
+		3: {0, vOrigin + vAdvance + 20},
+	}}
+	withOpbd(t, pointsFont, opbdTable(true, [4]int16{0, 2, 1, 3}, [4]int16{-1, -1, -1, -1}))
+	expected = OpticalBounds{Left: -50, Top: -10, Right: -50, Bottom: 20}
+
+	bounds, ok = pointsFont.GetAATOpticalBounds(11)
+	assert(t, ok)
+	if bounds != expected {
+		t.Fatalf("expected %v, got %v", expected, bounds)
+	}
+	// sides without control point are not moved
+	bounds, ok = pointsFont.GetAATOpticalBounds(12)
+	assert(t, ok && bounds == OpticalBounds{})
+}
@@ -32,7 +32,15 @@ type GlyphPosition struct {
 	// glyph to which this attaches to, relative to current glyphs;
 	// negative for going back, positive for forward.
 	attachChain int16
-	attachType  uint8 // attachment type, irrelevant if attachChain is 0
+	attachType  uint8 // attachment type, irrelevant if attachChain and attachment are 0
+
+	// attachment is a copy of attachChain, saved when the attachment offsets
+	// are resolved (which clears attachChain), and used by the post-shaping
+	// adjustments (see Buffer.AdjustAdvance).
+	// It is a relative index, valid for the order of the shaped buffer:
+	// a function reordering, inserting or removing glyphs after
+	// shaping must update it.
+	attachment int16
 }
 
 // unicodeProp is a two-byte number. The low byte includes:
@@ -92,7 +92,36 @@ func (sh *shaperGraphite) shape(font *Font, buffer *Buffer, features []Feature)
 		return
 	}
 
-	clusters := make([]graphite2Cluster, len(buffer.Info))
+	// keep track of the segment, so that it may be justified later
+	inputClusters := make([]int, len(buffer.Info))
+	for i, info := range buffer.Info {
+		inputClusters[i] = info.Cluster
+	}
+	buffer.grSegment = &graphiteSegment{seg: seg, clusters: inputClusters}
+
+	segmentToBuffer(seg, font, buffer, inputClusters)
+}
+
+// graphiteSegment stores the result of the graphite shaping
+type graphiteSegment struct {
+	seg      *graphite.Segment
+	clusters []int     // the input clusters, one per character
+	features []Feature // the user features, used to apply the tracking again
+}
+
+// returns the scale used to convert graphite positions to `Position`
+func graphiteScale(font *Font) (xscale, yscale float32) {
+	upem := font.faceUpem
+	xscale = float32(font.XScale / upem)
+	yscale = float32(font.YScale / upem)
+	yscale *= yscale / xscale
+	return xscale, yscale
+}
+
+// segmentToBuffer fills `buffer` with the glyphs and positions of `seg`.
+// `inputClusters` are the clusters of the input characters.
+func segmentToBuffer(seg *graphite.Segment, font *Font, buffer *Buffer, inputClusters []int) {
+	clusters := make([]graphite2Cluster, len(inputClusters))
 	glyphs := make([]fonts.GID, seg.NumGlyphs)
 	if L := seg.NumGlyphs - len(buffer.Info); L > 0 {
 		// grow the storage
@@ -103,11 +132,8 @@ func (sh *shaperGraphite) shape(font *Font, buffer *Buffer, features []Feature)
 		buffer.Pos = buffer.Pos[:seg.NumGlyphs]
 	}
 
-	clusters[0].cluster = buffer.Info[0].Cluster
-	upem := font.faceUpem
-	xscale := float32(font.XScale / upem)
-	yscale := float32(font.YScale / upem)
-	yscale *= yscale / xscale
+	clusters[0].cluster = inputClusters[0]
+	xscale, yscale := graphiteScale(font)
 	var curradv float32
 	if buffer.Props.Direction.isBackward() {
 		curradv = seg.First.Position.X * xscale
@@ -130,7 +156,7 @@ func (sh *shaperGraphite) shape(font *Font, buffer *Buffer, features []Feature)
 		if is.CanInsertBefore() && clusters[ci].numChars != 0 && before >= clusters[ci].baseChar+clusters[ci].numChars {
 			c := &clusters[ci+1]
 			c.baseChar = clusters[ci].baseChar + clusters[ci].numChars
-			c.cluster = buffer.Info[c.baseChar].Cluster
+			c.cluster = inputClusters[c.baseChar]
 			c.numChars = before - c.baseChar
 			c.baseGlyph = ic
 			c.numGlyphs = 0
@@ -0,0 +1,355 @@
+package harfbuzz
+
+import (
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+// Justify adjusts the glyphs and positions of `b`, which must have been shaped
+// with `font`, so that the advance of the line matches `width`.
+//
+// For Graphite fonts, the justification defined by the font is used.
+// Since the glyphs are then generated again from the Graphite segment, the
+// adjustments made after shaping (like `AdjustAdvance` or `AddLetterSpacing`) are lost,
+// except for the tracking applied by `Shape` : they should be done after justifying.
+// Otherwise, the following strategies are tried in order, until `width` is reached :
+//   - the shrinkage (or extension) suggestions of the 'JSTF' table are applied,
+//     priority after priority. Only the lookups enabled by a suggestion are supported, since
+//     disabling a lookup would require to shape again. Also, the enabled GSUB lookups are
+//     only applied if they are single or alternate substitutions.
+//   - for horizontal text using the Arabic tatweel (kashida), tatweels are inserted
+//     after letters joining to the following one.
+//
+// It returns the new advance, which may differ from `width` if the target width can't be reached.
+// For vertical text, `width` and the returned value are the absolute value of the (negative)
+// vertical advance.
+func (b *Buffer) Justify(font *Font, width Position) Position {
+	if b.grSegment != nil {
+		return b.justifyGraphite(font, width)
+	}
+
+	advance := b.lineAdvance()
+	if len(b.Info) == 0 || advance == width {
+		return advance
+	}
+
+	if font.otTables != nil {
+		advance = b.justifyJSTF(font, width, advance)
+	}
+
+	if advance < width && b.Props.Direction.isHorizontal() && usesKashida(b.Props.Script) {
+		advance = b.insertKashidas(font, width, advance)
+	}
+
+	return advance
+}
+
+// returns the total advance of the glyphs, in the main direction
+func (b *Buffer) lineAdvance() Position {
+	var out Position
+	if b.Props.Direction.isHorizontal() {
+		for _, pos := range b.Pos {
+			out += pos.XAdvance
+		}
+	} else {
+		for _, pos := range b.Pos {
+			out -= pos.YAdvance
+		}
+	}
+	return out
+}
+
+func (b *Buffer) justifyGraphite(font *Font, width Position) Position {
+	xscale, _ := graphiteScale(font)
+	if xscale == 0 {
+		return b.lineAdvance()
+	}
+	gr := b.grSegment
+
+	// regenerating the buffer from the segment discards the tracking:
+	// justify the segment without it, and apply it again
+	tracked := b.lineAdvance()
+	segmentToBuffer(gr.seg, font, b, gr.clusters)
+	tracking := tracked - b.lineAdvance()
+
+	gr.seg.Justify(nil, float32(width-tracking)/xscale, 0)
+	segmentToBuffer(gr.seg, font, b, gr.clusters)
+	b.applyTracking(font, gr.features)
+	return b.lineAdvance()
+}
+
+// selects the JSTF language system matching the buffer properties
+func (b *Buffer) jstfLanguage(jstf tt.TableJSTF) (tt.JstfLangSys, bool) {
+	scriptTags, languageTags := NewOTTagsFromScriptAndLanguage(b.Props.Script, b.Props.Language)
+	var script *tt.JstfScript
+	for _, tag := range scriptTags {
+		if script = jstf.FindScript(tag); script != nil {
+			break
+		}
+	}
+	if script == nil {
+		script = jstf.FindScript(tagDefaultScript)
+	}
+	if script == nil {
+		return tt.JstfLangSys{}, false
+	}
+	for _, tag := range languageTags {
+		if lang := script.FindLanguage(tag); lang.Tag == tag {
+			return lang, true
+		}
+	}
+	return script.DefaultLanguage, true
+}
+
+// applies the JSTF priorities until `width` is reached,
+// and returns the new advance
+func (b *Buffer) justifyJSTF(font *Font, width, advance Position) Position {
+	langSys, ok := b.jstfLanguage(font.otTables.JSTF)
+	if !ok {
+		return advance
+	}
+
+	extend := advance < width
+	for _, prio := range langSys.Priorities {
+		enableGSUB, enableGPOS, max := prio.ShrinkageEnableGSUB, prio.ShrinkageEnableGPOS, prio.ShrinkageMax
+		if extend {
+			enableGSUB, enableGPOS, max = prio.ExtensionEnableGSUB, prio.ExtensionEnableGPOS, prio.ExtensionMax
+		}
+
+		b.applyJSTFSubstitutions(font, enableGSUB)
+
+		before := b.lineAdvance()
+		saved := append([]GlyphPosition(nil), b.Pos...)
+		b.applyJSTFPositionings(font, enableGPOS, max)
+		advance = b.lineAdvance()
+
+		if extend && advance >= width || !extend && advance <= width {
+			// the target is reached: only use the needed part
+			// of the adjustments
+			if advance != before {
+				interpolatePositions(saved, b.Pos, int64(width-before), int64(advance-before))
+			}
+			return b.lineAdvance()
+		}
+	}
+	return advance
+}
+
+// applies the GSUB lookups which only replace one glyph by another one,
+// and updates the advances accordingly
+func (b *Buffer) applyJSTFSubstitutions(font *Font, lookups []uint16) {
+	if len(lookups) == 0 {
+		return
+	}
+	c := newOtApplyContext(0, font, b)
+	c.recurseFunc = proxyGSUB.recurseFunc
+	// as for the lookups not attached to a feature, the global mask is used,
+	// so that alternate substitutions select the first alternate
+	c.setLookupMask(globalBitMask)
+	for _, lookupIndex := range lookups {
+		if int(lookupIndex) >= len(font.gsubAccels) {
+			continue
+		}
+		lookup := font.otTables.GSUB.Lookups[lookupIndex]
+		if lookup.Type != tt.GSUBSingle && lookup.Type != tt.GSUBAlternate {
+			continue
+		}
+
+		glyphs := make([]fonts.GID, len(b.Info))
+		for i, info := range b.Info {
+			glyphs[i] = info.Glyph
+		}
+
+		c.lookupIndex = lookupIndex
+		c.applyString(proxyGSUB, &font.gsubAccels[lookupIndex])
+
+		for i, info := range b.Info {
+			if info.Glyph == glyphs[i] {
+				continue
+			}
+			oldX, oldY := font.GlyphAdvanceForDirection(glyphs[i], b.Props.Direction)
+			newX, newY := font.GlyphAdvanceForDirection(info.Glyph, b.Props.Direction)
+			b.Pos[i].XAdvance += newX - oldX
+			b.Pos[i].YAdvance += newY - oldY
+		}
+	}
+}
+
+// applies the GPOS lookups from the font and the private JSTF lookups
+func (b *Buffer) applyJSTFPositionings(font *Font, lookups []uint16, max []tt.LookupGPOS) {
+	if len(lookups) == 0 && len(max) == 0 {
+		return
+	}
+	c := newOtApplyContext(1, font, b)
+	c.recurseFunc = proxyGPOS.recurseFunc
+	c.setLookupMask(globalBitMask)
+	for _, lookupIndex := range lookups {
+		if int(lookupIndex) >= len(font.gposAccels) {
+			continue
+		}
+		c.lookupIndex = lookupIndex
+		c.applyString(proxyGPOS, &font.gposAccels[lookupIndex])
+	}
+
+	// nested lookups are not supported for JSTF lookups
+	c.recurseFunc = func(*otApplyContext, uint16) bool { return false }
+	for _, lookup := range max {
+		var accel otLayoutLookupAccelerator
+		accel.init(lookupGPOS(lookup))
+		c.applyString(proxyGPOS, &accel)
+	}
+}
+
+// updates `pos` so that each position is moved from `saved`
+// by the ratio num/den
+func interpolatePositions(saved, pos []GlyphPosition, num, den int64) {
+	interpolate := func(from, to Position) Position {
+		return from + Position(int64(to-from)*num/den)
+	}
+	for i := range pos {
+		p, s := &pos[i], saved[i]
+		p.XAdvance = interpolate(s.XAdvance, p.XAdvance)
+		p.YAdvance = interpolate(s.YAdvance, p.YAdvance)
+		p.XOffset = interpolate(s.XOffset, p.XOffset)
+		p.YOffset = interpolate(s.YOffset, p.YOffset)
+	}
+}
+
+const tatweel = 0x0640
+
+// returns true for the scripts using U+0640 ARABIC TATWEEL
+func usesKashida(script language.Script) bool {
+	switch script {
+	case language.Adlam, language.Arabic, language.Hanifi_Rohingya, language.Mandaic,
+		language.Manichaean, language.Psalter_Pahlavi, language.Sogdian, language.Syriac:
+		return true
+	default:
+		return false
+	}
+}
+
+// returns the last (in logical order) non mark glyph of the cluster [start, end),
+// or -1 if not found
+func (b *Buffer) clusterLastLetter(start, end int) int {
+	if b.Props.Direction.isBackward() {
+		for i := start; i < end; i++ {
+			if !b.Info[i].isMark() {
+				return i
+			}
+		}
+	} else {
+		for i := end - 1; i >= start; i-- {
+			if !b.Info[i].isMark() {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// returns true if the letter, shaped with Arabic joining,
+// joins the following one (in logical order)
+func joinsFollowingLetter(info *GlyphInfo) bool {
+	switch info.complexAux {
+	case arabInit, arabMedi, arabMed2:
+		return true
+	default:
+		return false
+	}
+}
+
+// returns the buffer indices where a tatweel may be inserted,
+// in logical order
+func (b *Buffer) kashidaOpportunities() []int {
+	var out []int
+	backward := b.Props.Direction.isBackward()
+	iter, count := b.clusterIterator()
+	for start, end := iter.next(); start < count; start, end = iter.next() {
+		last := b.clusterLastLetter(start, end)
+		if last == -1 || b.Info[last].ligated() {
+			continue
+		}
+		// the letter must join the following one
+		if !joinsFollowingLetter(&b.Info[last]) {
+			continue
+		}
+		if backward {
+			out = append(out, start)
+		} else {
+			out = append(out, end)
+		}
+	}
+	if backward {
+		// restore the logical order
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+	return out
+}
+
+// inserts as many tatweels as possible without exceeding `width`,
+// and returns the new advance
+func (b *Buffer) insertKashidas(font *Font, width, advance Position) Position {
+	glyph, ok := font.face.NominalGlyph(tatweel)
+	if !ok {
+		return advance
+	}
+	kashidaAdvance := font.GlyphHAdvance(glyph)
+	if kashidaAdvance <= 0 {
+		return advance
+	}
+	opportunities := b.kashidaOpportunities()
+	total := int((width - advance) / kashidaAdvance)
+	if len(opportunities) == 0 || total == 0 {
+		return advance
+	}
+
+	// distribute the tatweels evenly, starting at the beginning of the text
+	counts := make(map[int]int, len(opportunities))
+	for i, index := range opportunities {
+		counts[index] = total / len(opportunities)
+		if i < total%len(opportunities) {
+			counts[index]++
+		}
+	}
+
+	info := make([]GlyphInfo, 0, len(b.Info)+total)
+	pos := make([]GlyphPosition, 0, len(b.Info)+total)
+	newIndices := make([]int, len(b.Info))
+	for i := 0; i <= len(b.Info); i++ {
+		if n := counts[i]; n != 0 {
+			// the tatweel belongs to the joining letter
+			ref := i - 1
+			if b.Props.Direction.isBackward() {
+				ref = i
+			}
+			kashida := b.Info[ref]
+			kashida.Glyph = glyph
+			kashida.codepoint = tatweel
+			kashida.setUnicodeProps(b)
+			kashida.glyphProps = tt.BaseGlyph
+			kashida.ligProps = 0
+			kashida.complexAux = arabMedi
+			for ; n > 0; n-- {
+				info = append(info, kashida)
+				pos = append(pos, GlyphPosition{XAdvance: kashidaAdvance})
+			}
+		}
+		if i < len(b.Info) {
+			newIndices[i] = len(info)
+			info = append(info, b.Info[i])
+			pos = append(pos, b.Pos[i])
+		}
+	}
+	// update the attachments, which are relative indices
+	for i, newIndex := range newIndices {
+		if j := b.attachedTo(i); j != -1 {
+			pos[newIndex].attachment = int16(newIndices[j] - newIndex)
+		}
+	}
+	b.Info, b.Pos = info, pos
+
+	return advance + Position(total)*kashidaAdvance
+}
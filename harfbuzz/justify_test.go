@@ -0,0 +1,206 @@
+package harfbuzz
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+func TestJustifyKashida(t *testing.T) {
+	face := openFontFileTT("NotoSansArabic.ttf")
+	font := NewFont(face)
+
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune("بسم الله"), 0, -1)
+	buffer.GuessSegmentProperties()
+	buffer.Shape(font, nil)
+
+	tatweelGlyph, ok := face.NominalGlyph(tatweel)
+	assert(t, ok)
+	kashidaAdvance := font.GlyphHAdvance(tatweelGlyph)
+
+	advance := buffer.lineAdvance()
+	nbGlyphs := len(buffer.Info)
+
+	// the width can't be reduced
+	assertEqualInt32(t, advance, buffer.Justify(font, advance-10))
+	assertEqualInt(t, nbGlyphs, len(buffer.Info))
+
+	newAdvance := buffer.Justify(font, advance+3*kashidaAdvance+kashidaAdvance/2)
+	assertEqualInt32(t, advance+3*kashidaAdvance, newAdvance)
+	assertEqualInt32(t, newAdvance, buffer.lineAdvance())
+	assertEqualInt(t, nbGlyphs+3, len(buffer.Info))
+
+	var count int
+	for _, info := range buffer.Info {
+		if info.Glyph == tatweelGlyph {
+			count++
+		}
+	}
+	assertEqualInt(t, 3, count)
+}
+
+func TestJustifyNoKashida(t *testing.T) {
+	face := openFontFile("perf_reference/fonts/Roboto-Regular.ttf")
+	font := NewFont(face)
+
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune("Hello world"), 0, -1)
+	buffer.Props.Script = language.Latin
+	buffer.GuessSegmentProperties()
+	buffer.Shape(font, nil)
+
+	advance := buffer.lineAdvance()
+	assertEqualInt32(t, advance, buffer.Justify(font, advance+1000))
+}
+
+func TestJustifyGraphite(t *testing.T) {
+	face := openFontFile("fonts/Simple-Graphite-Font.ttf")
+	font := NewFont(face)
+
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune("ab cab"), 0, -1)
+	buffer.Props.Direction = LeftToRight
+	buffer.Shape(font, nil)
+	assert(t, buffer.grSegment != nil)
+
+	advance := buffer.lineAdvance()
+	nbGlyphs := len(buffer.Info)
+
+	newAdvance := buffer.Justify(font, advance+500)
+	assertEqualInt32(t, advance+500, newAdvance)
+	assertEqualInt(t, nbGlyphs, len(buffer.Info))
+	assertEqualInt32(t, newAdvance, buffer.lineAdvance())
+}
+
+func TestJustifyGraphiteTracking(t *testing.T) {
+	face := openFontFile("fonts/Simple-Graphite-Font.ttf")
+	refFont, font := NewFont(face), NewFont(face)
+	font.SyntheticTracking = 100
+
+	shape := func(font *Font) *Buffer {
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune("ab cab"), 0, -1)
+		buffer.Props.Direction = LeftToRight
+		buffer.Shape(font, nil)
+		return buffer
+	}
+
+	ref := shape(refFont)
+	refAdvance := ref.lineAdvance()
+
+	buffer := shape(font)
+	tracking := buffer.lineAdvance() - refAdvance
+	assert(t, tracking > 0)
+
+	// the tracking is kept, and the width is still reached
+	width := buffer.lineAdvance() + 500
+	assertEqualInt32(t, width, buffer.Justify(font, width))
+	assertEqualInt32(t, width, buffer.lineAdvance())
+
+	assertEqualInt32(t, refAdvance+500, ref.Justify(refFont, refAdvance+500))
+	assertEqualInt(t, len(ref.Info), len(buffer.Info))
+	for i, pos := range buffer.Pos {
+		assertEqualInt32(t, ref.Pos[i].XOffset+50, pos.XOffset)
+		assertEqualInt32(t, ref.Pos[i].XAdvance+100, pos.XAdvance)
+	}
+}
+
+// returns a JSTF lookup adding `delta` to the advance of every glyph of the buffer
+func jstfAdvanceLookup(buffer *Buffer, delta int16) tt.LookupGPOS {
+	var coverage tt.CoverageList
+	for _, info := range buffer.Info {
+		coverage = append(coverage, info.Glyph)
+	}
+	sort.Slice(coverage, func(i, j int) bool { return coverage[i] < coverage[j] })
+	return tt.LookupGPOS{
+		Type: tt.GPOSSingle,
+		Subtables: []tt.GPOSSubtable{{
+			Coverage: coverage,
+			Data:     tt.GPOSSingle1{Format: tt.XAdvance, Value: tt.GPOSValueRecord{XAdvance: delta}},
+		}},
+	}
+}
+
+func withJSTF(font *Font, priorities ...tt.JstfPriority) {
+	tables := *font.otTables
+	tables.JSTF = tt.TableJSTF{Scripts: []tt.JstfScript{
+		{Tag: tagDefaultScript, DefaultLanguage: tt.JstfLangSys{Priorities: priorities}},
+	}}
+	font.otTables = &tables
+}
+
+func TestJustifyJSTF(t *testing.T) {
+	font := NewFont(openFontFile("perf_reference/fonts/Roboto-Regular.ttf"))
+
+	shape := func() ([]GlyphPosition, *Buffer) {
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune("abcd"), 0, -1)
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, nil)
+		return append([]GlyphPosition(nil), buffer.Pos...), buffer
+	}
+	ref, buffer := shape()
+	advance := buffer.lineAdvance()
+
+	withJSTF(font,
+		tt.JstfPriority{ // at most +20 per glyph, or -10 per glyph
+			ExtensionMax: []tt.LookupGPOS{jstfAdvanceLookup(buffer, 20)},
+			ShrinkageMax: []tt.LookupGPOS{jstfAdvanceLookup(buffer, -10)},
+		},
+		tt.JstfPriority{ // at most +100 per glyph, no shrinkage
+			ExtensionMax: []tt.LookupGPOS{jstfAdvanceLookup(buffer, 100)},
+		},
+	)
+
+	for _, test := range []struct {
+		width, expected Position
+		delta           Position // per glyph
+	}{
+		{advance + 40, advance + 40, 10},     // first priority, half used
+		{advance + 80, advance + 80, 20},     // first priority, fully used
+		{advance + 200, advance + 200, 50},   // first priority, then 30/100 of the second one
+		{advance + 1000, advance + 480, 120}, // all priorities are not enough
+		{advance - 20, advance - 20, -5},     // first priority, half used
+		{advance - 100, advance - 40, -10},   // no more shrinkage available
+	} {
+		_, buffer := shape()
+		assertEqualInt32(t, test.expected, buffer.Justify(font, test.width))
+		assertEqualInt32(t, test.expected, buffer.lineAdvance())
+		for i, pos := range buffer.Pos {
+			assertEqualInt32(t, ref[i].XAdvance+test.delta, pos.XAdvance)
+			assertEqualInt32(t, ref[i].XOffset, pos.XOffset)
+		}
+	}
+}
+
+func TestJustifyJSTFSubstitutions(t *testing.T) {
+	font := NewFont(openFontFile("perf_reference/fonts/Roboto-Regular.ttf"))
+
+	// lookup 1 of Roboto is a single substitution mapping
+	// lowercase letters to small capitals
+	smcp := uint16(1)
+	withJSTF(font, tt.JstfPriority{ExtensionEnableGSUB: []uint16{smcp}})
+
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune("abcd"), 0, -1)
+	buffer.GuessSegmentProperties()
+	buffer.Shape(font, nil)
+	glyphs := make([]fonts.GID, len(buffer.Info))
+	for i, info := range buffer.Info {
+		glyphs[i] = info.Glyph
+	}
+	advance := buffer.lineAdvance()
+
+	newAdvance := buffer.Justify(font, advance+1000)
+	var expected Position
+	for i, info := range buffer.Info {
+		assert(t, info.Glyph != glyphs[i])
+		assertEqualInt32(t, font.GlyphHAdvance(info.Glyph), buffer.Pos[i].XAdvance)
+		expected += buffer.Pos[i].XAdvance
+	}
+	assertEqualInt32(t, expected, newAdvance)
+}
@@ -1761,51 +1761,3 @@ func (dc *driverContextKerx4) transition(driver stateTableDriver, entry tt.AATSt
 		dc.mark = buffer.idx
 	}
 }
-
-func (sp *otShapePlan) aatLayoutTrack(font *Font, buffer *Buffer) {
-	trak := font.otTables.Trak
-
-	c := newAatApplyContext(sp, font, buffer)
-	c.applyTrak(trak)
-}
-
-func (c *aatApplyContext) applyTrak(trak tt.TableTrak) {
-	trakMask := c.plan.trakMask
-
-	ptem := c.font.Ptem
-	if ptem <= 0. {
-		return
-	}
-
-	buffer := c.buffer
-	if buffer.Props.Direction.isHorizontal() {
-		trackData := trak.Horizontal
-		tracking := int(trackData.GetTracking(ptem, 0))
-		advanceToAdd := c.font.emScalefX(float32(tracking))
-		offsetToAdd := c.font.emScalefX(float32(tracking / 2))
-
-		iter, count := buffer.graphemesIterator()
-		for start, _ := iter.next(); start < count; start, _ = iter.next() {
-			if buffer.Info[start].Mask&trakMask == 0 {
-				continue
-			}
-			buffer.Pos[start].XAdvance += advanceToAdd
-			buffer.Pos[start].XOffset += offsetToAdd
-		}
-
-	} else {
-		trackData := trak.Vertical
-		tracking := int(trackData.GetTracking(ptem, 0))
-		advanceToAdd := c.font.emScalefY(float32(tracking))
-		offsetToAdd := c.font.emScalefY(float32(tracking / 2))
-		iter, count := buffer.graphemesIterator()
-		for start, _ := iter.next(); start < count; start, _ = iter.next() {
-			if buffer.Info[start].Mask&trakMask == 0 {
-				continue
-			}
-			buffer.Pos[start].YAdvance += advanceToAdd
-			buffer.Pos[start].YOffset += offsetToAdd
-		}
-
-	}
-}
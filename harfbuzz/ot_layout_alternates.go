@@ -0,0 +1,143 @@
+package harfbuzz
+
+import (
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// GetOTGlyphAlternates fetches the alternates of `glyph`, as defined by
+// the Alternate Substitution subtables of the GSUB lookup at `lookupIndex`.
+// It returns nil if the lookup is invalid or does not cover `glyph`.
+// For a lookup used by a feature, the alternate at index `i` is selected
+// with the feature value `i+1`.
+func (f *Font) GetOTGlyphAlternates(lookupIndex uint16, glyph fonts.GID) []fonts.GID {
+	if f.otTables == nil || int(lookupIndex) >= len(f.otTables.GSUB.Lookups) {
+		return nil
+	}
+	for _, table := range f.otTables.GSUB.Lookups[lookupIndex].Subtables {
+		alternates, ok := table.Data.(tt.GSUBAlternate1)
+		if !ok {
+			continue
+		}
+		if index, ok := table.Coverage.Index(glyph); ok && index < len(alternates) {
+			return alternates[index]
+		}
+	}
+	return nil
+}
+
+// GlyphAlternates describes the alternates available
+// for one glyph of a shaped buffer.
+type GlyphAlternates struct {
+	// Feature is the feature providing the alternates.
+	Feature tt.Tag
+	// Index is the position of the glyph in the buffer.
+	Index int
+	// Default is the glyph used when the feature is disabled.
+	// It may differ from the shaped glyph if the feature
+	// is already enabled.
+	Default fonts.GID
+	// Alternates is selected by setting the value of `Feature`
+	// to the (one-based) index of the alternate.
+	Alternates []fonts.GID
+}
+
+// ClusterAlternates returns the alternate glyphs available
+// for the glyphs of the cluster `cluster`, from the features which
+// are enabled by `features` and implemented with Alternate Substitution lookups
+// (like 'salt', 'swsh' or 'cvXX').
+// `b` must have been shaped with `font` and `features`; to list the alternates
+// of a feature not applied to the whole text, its Start and End fields must
+// contain the cluster.
+func (b *Buffer) ClusterAlternates(font *Font, cluster int, features []Feature) []GlyphAlternates {
+	if font.otTables == nil {
+		return nil
+	}
+	gsub := &font.otTables.GSUB.TableLayout
+
+	scriptTags, languageTags := NewOTTagsFromScriptAndLanguage(b.Props.Script, b.Props.Language)
+	scriptIndex, _, _ := SelectScript(gsub, scriptTags)
+	languageIndex, _ := SelectLanguage(gsub, scriptIndex, languageTags)
+	variationsIndex := gsub.FindVariationIndex(font.varCoords())
+
+	var out []GlyphAlternates
+	for _, feature := range features {
+		if feature.Value == 0 || cluster < feature.Start || cluster >= feature.End {
+			continue
+		}
+		featureIndex := FindFeatureForLang(gsub, scriptIndex, languageIndex, feature.Tag)
+		for _, lookupIndex := range getFeatureLookupsWithVar(gsub, featureIndex, variationsIndex) {
+			for i, info := range b.Info {
+				if info.Cluster != cluster {
+					continue
+				}
+				nominal, _ := font.face.NominalGlyph(info.codepoint)
+				defaultGlyph, alternates := font.findAlternates(lookupIndex, info.Glyph, nominal)
+				if len(alternates) == 0 {
+					continue
+				}
+				out = append(out, GlyphAlternates{
+					Feature:    feature.Tag,
+					Index:      i,
+					Default:    defaultGlyph,
+					Alternates: alternates,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// returns the alternates of `glyph`, or of the glyph
+// for which `glyph` is an alternate, trying `nominal` first
+func (f *Font) findAlternates(lookupIndex uint16, glyph, nominal fonts.GID) (fonts.GID, []fonts.GID) {
+	if alternates := f.GetOTGlyphAlternates(lookupIndex, glyph); alternates != nil {
+		return glyph, alternates
+	}
+	if int(lookupIndex) >= len(f.otTables.GSUB.Lookups) {
+		return 0, nil
+	}
+	// the feature may have already been applied:
+	// look for the glyph in the alternate sets,
+	// starting with the glyph of the character, since
+	// a glyph may be an alternate of several glyphs
+	for _, alternate := range f.GetOTGlyphAlternates(lookupIndex, nominal) {
+		if alternate == glyph {
+			return nominal, f.GetOTGlyphAlternates(lookupIndex, nominal)
+		}
+	}
+	for _, table := range f.otTables.GSUB.Lookups[lookupIndex].Subtables {
+		alternates, ok := table.Data.(tt.GSUBAlternate1)
+		if !ok {
+			continue
+		}
+		for index, set := range alternates {
+			for _, alternate := range set {
+				if alternate != glyph {
+					continue
+				}
+				if defaultGlyph, ok := coverageGlyph(table.Coverage, index); ok {
+					return defaultGlyph, set
+				}
+			}
+		}
+	}
+	return 0, nil
+}
+
+// returns the glyph with coverage index `index`
+func coverageGlyph(cov tt.Coverage, index int) (fonts.GID, bool) {
+	switch cov := cov.(type) {
+	case tt.CoverageList:
+		if index < len(cov) {
+			return cov[index], true
+		}
+	case tt.CoverageRanges:
+		for _, r := range cov {
+			if r.StartCoverage <= index && index <= r.StartCoverage+int(r.End-r.Start) {
+				return r.Start + fonts.GID(index-r.StartCoverage), true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+package harfbuzz
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+func TestGetOTGlyphAlternates(t *testing.T) {
+	face := openFontFileTT("Raleway-v4020-Regular.otf")
+	font := NewFont(face)
+
+	a, _ := face.NominalGlyph('a')
+	if exp, got := []fonts.GID{502, 476, 459, 460}, font.GetOTGlyphAlternates(1, a); !reflect.DeepEqual(exp, got) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+	// not an alternate lookup
+	assert(t, font.GetOTGlyphAlternates(0, a) == nil)
+	// invalid lookup
+	assert(t, font.GetOTGlyphAlternates(1000, a) == nil)
+}
+
+func TestClusterAlternates(t *testing.T) {
+	face := openFontFileTT("Raleway-v4020-Regular.otf")
+	font := NewFont(face)
+	a, _ := face.NominalGlyph('a')
+	exp := []GlyphAlternates{{Feature: tt.MustNewTag("aalt"), Index: 0, Default: a, Alternates: []fonts.GID{502, 476, 459, 460}}}
+
+	shape := func(features []Feature) *Buffer {
+		buffer := NewBuffer()
+		buffer.AddRunes([]rune("ab"), 0, -1)
+		buffer.GuessSegmentProperties()
+		buffer.Shape(font, features)
+		return buffer
+	}
+
+	// the feature is disabled
+	buffer := shape(nil)
+	features := []Feature{{Tag: tt.MustNewTag("aalt"), Value: 1, Start: 0, End: 1}}
+	if got := buffer.ClusterAlternates(font, 0, features); !reflect.DeepEqual(exp, got) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+	// 'b' has no alternate, and is not in the feature range
+	assertEqualInt(t, 0, len(buffer.ClusterAlternates(font, 1, features)))
+	assertEqualInt(t, 0, len(buffer.ClusterAlternates(font, 0, nil)))
+
+	// the second alternate is selected
+	features[0].Value = 2
+	buffer = shape(features)
+	assert(t, buffer.Info[0].Glyph == 476)
+	if got := buffer.ClusterAlternates(font, 0, features); !reflect.DeepEqual(exp, got) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+}
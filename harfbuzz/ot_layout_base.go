@@ -0,0 +1,298 @@
+package harfbuzz
+
+import (
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+// ported from src/hb-ot-layout-base-table.hh, src/hb-ot-layout.cc Copyright © 2016 Elie Roux, 2018 Google, Inc. Ebrahim Byagowi
+
+// Baseline tags, as defined in the OpenType registry.
+// See https://docs.microsoft.com/en-us/typography/opentype/spec/baselinetags
+var (
+	// The baseline used by alphabetic scripts such as Latin, Cyrillic and Greek.
+	// In vertical writing mode, the alphabetic baseline for characters rotated 90 degrees clockwise.
+	BaselineRoman = tt.NewTag('r', 'o', 'm', 'n')
+	// The hanging baseline. In horizontal direction, this is the horizontal
+	// line from which syllables seem, to hang in Tibetan and other similar scripts.
+	// In vertical writing mode, for Tibetan (or some other similar script) characters
+	// rotated 90 degrees clockwise.
+	BaselineHanging = tt.NewTag('h', 'a', 'n', 'g')
+	// Ideographic character face bottom or left edge,
+	// if the direction is horizontal or vertical, respectively.
+	BaselineIdeoFaceBottomOrLeft = tt.NewTag('i', 'c', 'f', 'b')
+	// Ideographic character face top or right edge,
+	// if the direction is horizontal or vertical, respectively.
+	BaselineIdeoFaceTopOrRight = tt.NewTag('i', 'c', 'f', 't')
+	// The center of the ideographic character face.
+	BaselineIdeoFaceCentral = tt.NewTag('I', 'c', 'f', 'c')
+	// Ideographic em-box bottom or left edge,
+	// if the direction is horizontal or vertical, respectively.
+	BaselineIdeoEmboxBottomOrLeft = tt.NewTag('i', 'd', 'e', 'o')
+	// Ideographic em-box top or right edge baseline,
+	// if the direction is horizontal or vertical, respectively.
+	BaselineIdeoEmboxTopOrRight = tt.NewTag('i', 'd', 't', 'p')
+	// The center of the ideographic em-box.
+	BaselineIdeoEmboxCentral = tt.NewTag('I', 'd', 'c', 'e')
+	// The baseline about which mathematical characters are centered.
+	// In vertical writing mode when mathematical characters rotated 90 degrees clockwise,
+	// are centered.
+	BaselineMath = tt.NewTag('m', 'a', 't', 'h')
+)
+
+// returns the OpenType tags used to query the BASE table
+func baseTagsFromScriptAndLanguage(script language.Script, lang language.Language) (scriptTag, languageTag tt.Tag) {
+	scriptTags, languageTags := NewOTTagsFromScriptAndLanguage(script, lang)
+	scriptTag, languageTag = tagDefaultScript, tagDefaultLanguage
+	if len(scriptTags) != 0 {
+		scriptTag = scriptTags[len(scriptTags)-1]
+	}
+	if len(languageTags) != 0 {
+		languageTag = languageTags[len(languageTags)-1]
+	}
+	return scriptTag, languageTag
+}
+
+func (f *Font) baseAxis(direction Direction) tt.BaseAxis {
+	if f.otTables == nil {
+		return tt.BaseAxis{}
+	}
+	if direction.isHorizontal() {
+		return f.otTables.BASE.Horizontal
+	}
+	return f.otTables.BASE.Vertical
+}
+
+// interpret the BaseCoord according to its format
+func (f *Font) getBaseCoord(coord tt.BaseCoord, direction Direction) Position {
+	switch coord := coord.(type) {
+	case tt.BaseCoordFormat3:
+		varStore := f.otTables.BASE.VariationStore
+		if direction.isHorizontal() {
+			return f.emScaleY(coord.Value) + f.getYDelta(varStore, coord.Device)
+		}
+		return f.emScaleX(coord.Value) + f.getXDelta(varStore, coord.Device)
+	default:
+		// for format 2, the contour point is only relevant
+		// for hinted fonts and is ignored
+		if direction.isHorizontal() {
+			return f.emScaleY(coord.Coordinate())
+		}
+		return f.emScaleX(coord.Coordinate())
+	}
+}
+
+// getBaseline fetches a baseline value from the BASE table,
+// returning false if not found.
+func (f *Font) getBaseline(baseline tt.Tag, direction Direction, scriptTag tt.Tag) (Position, bool) {
+	coord := f.baseAxis(direction).Baseline(baseline, scriptTag)
+	if coord == nil {
+		return 0, false
+	}
+	return f.getBaseCoord(coord, direction), true
+}
+
+// BaselineFromTable fetches the given baseline (see the Baseline constants)
+// from the font 'BASE' table, for the given `script` and `lang`.
+// It returns false if the font has no 'BASE' table or if it does not
+// define the baseline.
+// The returned position is scaled, and is an Y coordinate (resp. X coordinate) for
+// horizontal (resp. vertical) text.
+func (f *Font) BaselineFromTable(baseline tt.Tag, direction Direction, script language.Script, lang language.Language) (Position, bool) {
+	scriptTag, _ := baseTagsFromScriptAndLanguage(script, lang)
+	return f.getBaseline(baseline, direction, scriptTag)
+}
+
+// Baseline is the same as `BaselineFromTable`, but synthesizes the baseline
+// when it is missing from the 'BASE' table, following
+// https://www.w3.org/TR/css-inline-3/#baseline-synthesis-fonts
+func (f *Font) Baseline(baseline tt.Tag, direction Direction, script language.Script, lang language.Language) Position {
+	scriptTag, _ := baseTagsFromScriptAndLanguage(script, lang)
+	return f.getBaselineWithFallback(baseline, direction, script, scriptTag)
+}
+
+func (f *Font) getBaselineWithFallback(baseline tt.Tag, direction Direction, script language.Script, scriptTag tt.Tag) Position {
+	if coord, ok := f.getBaseline(baseline, direction, scriptTag); ok {
+		return coord
+	}
+
+	// synthesize missing baselines
+	switch baseline {
+	case BaselineRoman:
+		return 0
+	case BaselineMath:
+		var (
+			glyph fonts.GID
+			ok    bool
+		)
+		if direction.isHorizontal() {
+			glyph, ok = f.face.NominalGlyph(0x2212)
+			if !ok {
+				glyph, ok = f.face.NominalGlyph('-')
+			}
+		}
+		if ok {
+			if extents, ok := f.GlyphExtents(glyph); ok {
+				return extents.YBearing + extents.Height/2
+			}
+		}
+		return f.xHeightWithFallback() / 2
+	case BaselineIdeoFaceTopOrRight, BaselineIdeoFaceBottomOrLeft:
+		if direction.isHorizontal() {
+			// use the extents of a full ideographic glyph
+			glyph, ok := f.face.NominalGlyph(0x2F00)
+			if !ok {
+				glyph, ok = f.face.NominalGlyph(0x3013)
+			}
+			if ok {
+				if extents, ok := f.GlyphExtents(glyph); ok {
+					if baseline == BaselineIdeoFaceTopOrRight {
+						return extents.YBearing
+					}
+					return extents.YBearing + extents.Height
+				}
+			}
+		}
+		emboxTop := f.getBaselineWithFallback(BaselineIdeoEmboxTopOrRight, direction, script, scriptTag)
+		emboxBottom := f.getBaselineWithFallback(BaselineIdeoEmboxBottomOrLeft, direction, script, scriptTag)
+		if baseline == BaselineIdeoFaceTopOrRight {
+			return emboxTop + (emboxBottom-emboxTop)/10
+		}
+		return emboxBottom + (emboxTop-emboxBottom)/10
+	case BaselineIdeoEmboxTopOrRight:
+		if coord, ok := f.getBaseline(BaselineIdeoEmboxBottomOrLeft, direction, scriptTag); ok {
+			if direction.isHorizontal() {
+				return coord + f.YScale
+			}
+			return coord + f.XScale
+		}
+		return Position(f.ExtentsForDirection(direction).Ascender)
+	case BaselineIdeoEmboxBottomOrLeft:
+		if coord, ok := f.getBaseline(BaselineIdeoEmboxTopOrRight, direction, scriptTag); ok {
+			if direction.isHorizontal() {
+				return coord - f.YScale
+			}
+			return coord - f.XScale
+		}
+		return Position(f.ExtentsForDirection(direction).Descender)
+	case BaselineHanging:
+		if !direction.isHorizontal() {
+			return f.XScale * 6 / 10
+		}
+		if ch := hangingBaselineCharacter(script); ch != 0 {
+			if glyph, ok := f.face.NominalGlyph(ch); ok {
+				if extents, ok := f.GlyphExtents(glyph); ok {
+					return extents.YBearing
+				}
+			}
+		}
+		return f.YScale * 6 / 10
+	case BaselineIdeoEmboxCentral:
+		top := f.getBaselineWithFallback(BaselineIdeoEmboxTopOrRight, direction, script, scriptTag)
+		bottom := f.getBaselineWithFallback(BaselineIdeoEmboxBottomOrLeft, direction, script, scriptTag)
+		return (top + bottom) / 2
+	case BaselineIdeoFaceCentral:
+		top := f.getBaselineWithFallback(BaselineIdeoFaceTopOrRight, direction, script, scriptTag)
+		bottom := f.getBaselineWithFallback(BaselineIdeoFaceBottomOrLeft, direction, script, scriptTag)
+		return (top + bottom) / 2
+	default:
+		return 0
+	}
+}
+
+// returns the scaled x-height, using 'x' glyph extents as fallback
+func (f *Font) xHeightWithFallback() Position {
+	if xHeight, ok := f.LineMetric(fonts.XHeight); ok {
+		return xHeight
+	}
+	if glyph, ok := f.face.NominalGlyph('x'); ok {
+		if extents, ok := f.GlyphExtents(glyph); ok {
+			return extents.YBearing
+		}
+	}
+	return f.YScale / 2
+}
+
+// returns a character whose top is aligned on the hanging baseline,
+// or 0 for scripts not using such a baseline.
+// Keep in sync with BASE table baseline selection.
+func hangingBaselineCharacter(script language.Script) rune {
+	switch script {
+	// Unicode-1.1 additions
+	case language.Bengali:
+		return 0x0995
+	case language.Devanagari:
+		return 0x0915
+	case language.Gujarati:
+		return 0x0A95
+	case language.Gurmukhi:
+		return 0x0A15
+	// Unicode-2.0 additions
+	case language.Tibetan:
+		return 0x0F40
+	// Unicode-4.0 additions
+	case language.Limbu:
+		return 0x1901
+	// Unicode-4.1 additions
+	case language.Syloti_Nagri:
+		return 0xA807
+	// Unicode-5.0 additions
+	case language.Phags_Pa:
+		return 0xA840
+	// Unicode-5.2 additions
+	case language.Samaritan:
+		return 0x0800
+	// Unicode-6.0 additions
+	case language.Brahmi:
+		return 0x11005
+	// Unicode-6.1 additions
+	case language.Sharada:
+		return 0x11191
+	// Unicode-7.0 additions
+	case language.Modi:
+		return 0x1160E
+	case language.Siddham:
+		return 0x1158E
+	case language.Tirhuta:
+		return 0x1148F
+	// Unicode-9.0 additions
+	case language.Marchen:
+		return 0x11C72
+	case language.Newa:
+		return 0x1140E
+	// Unicode-10.0 additions
+	case language.Soyombo:
+		return 0x11A5C
+	case language.Zanabazar_Square:
+		return 0x11A0B
+	// Unicode-12.0 additions
+	case language.Nandinagari:
+		return 0x119A2
+	// Unicode-13.0 additions
+	case language.Dives_Akuru:
+		return 0x1190C
+	default:
+		return 0
+	}
+}
+
+// BaselineMinMax fetches the minimum and maximum extents
+// for the given script, language and feature, from the font 'BASE' table.
+// `feature` may be zero to select the default extents.
+// It returns false if not found. When only one of the extents is defined
+// by the font, the other one is returned as zero.
+func (f *Font) BaselineMinMax(direction Direction, script language.Script, lang language.Language, feature tt.Tag) (min, max Position, ok bool) {
+	scriptTag, languageTag := baseTagsFromScriptAndLanguage(script, lang)
+	minCoord, maxCoord, ok := f.baseAxis(direction).MinMax(scriptTag, languageTag, feature)
+	if !ok {
+		return 0, 0, false
+	}
+	if minCoord != nil {
+		min = f.getBaseCoord(minCoord, direction)
+	}
+	if maxCoord != nil {
+		max = f.getBaseCoord(maxCoord, direction)
+	}
+	return min, max, true
+}
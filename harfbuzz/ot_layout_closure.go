@@ -0,0 +1,433 @@
+package harfbuzz
+
+import (
+	"sort"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+// ported from src/hb-ot-layout.cc, hb-ot-layout-gsubgpos.hh, hb-ot-layout-gsub-table.hh
+// Copyright © 2007,2008,2009,2010  Red Hat, Inc. 2010,2012  Google, Inc.  Behdad Esfahbod
+
+const (
+	// maximum number of passes over the lookups when computing a closure
+	closureMaxStages = 32
+	// maximum number of lookups visited when computing a closure
+	maxLookupVisitCount = 35000
+)
+
+// CollectFeatures returns the sorted indices of the features of `table`
+// matching the given scripts, languages and features tags.
+// A nil slice of tags means that all the scripts (or languages, or features) are selected.
+// The required features of the selected language systems are included,
+// as long as they match `features`.
+func CollectFeatures(table *tt.TableLayout, scripts, languages, features []tt.Tag) []uint16 {
+	set := map[uint16]bool{}
+	collectLangSys := func(ls tt.LangSys) {
+		if ls.RequiredFeatureIndex != 0xFFFF && int(ls.RequiredFeatureIndex) < len(table.Features) {
+			if hasTag(features, table.Features[ls.RequiredFeatureIndex].Tag) {
+				set[ls.RequiredFeatureIndex] = true
+			}
+		}
+		for _, index := range ls.Features {
+			if int(index) < len(table.Features) && hasTag(features, table.Features[index].Tag) {
+				set[index] = true
+			}
+		}
+	}
+	collectScript := func(script tt.Script) {
+		if languages == nil { // all languages
+			if script.DefaultLanguage != nil {
+				collectLangSys(*script.DefaultLanguage)
+			}
+			for _, ls := range script.Languages {
+				collectLangSys(ls)
+			}
+			return
+		}
+		for _, tag := range languages {
+			if index := script.FindLanguage(tag); index != -1 {
+				collectLangSys(script.Languages[index])
+			}
+		}
+	}
+
+	if scripts == nil { // all scripts
+		for _, script := range table.Scripts {
+			collectScript(script)
+		}
+	} else {
+		for _, tag := range scripts {
+			if index := table.FindScript(tag); index != -1 {
+				collectScript(table.Scripts[index])
+			}
+		}
+	}
+
+	return sortedIndices(set)
+}
+
+// CollectLookups returns the sorted indices of the lookups of `table` used by
+// the features selected by `scripts`, `languages` and `features` (see `CollectFeatures`).
+// The lookups used by the feature variations of the selected features are also included.
+// Nested lookups are not included : see `ClosureLookups`.
+func CollectLookups(table *tt.TableLayout, scripts, languages, features []tt.Tag) []uint16 {
+	featureIndices := CollectFeatures(table, scripts, languages, features)
+	set := map[uint16]bool{}
+	for _, featureIndex := range featureIndices {
+		for _, lookupIndex := range table.Features[featureIndex].LookupIndices {
+			set[lookupIndex] = true
+		}
+	}
+	for _, variation := range table.FeatureVariations {
+		for _, sub := range variation.FeatureSubstitutions {
+			if !hasIndex(featureIndices, sub.FeatureIndex) {
+				continue
+			}
+			for _, lookupIndex := range sub.AlternateFeature.LookupIndices {
+				set[lookupIndex] = true
+			}
+		}
+	}
+	return sortedIndices(set)
+}
+
+// ClosureLookups returns the sorted indices of `lookups`, completed by the
+// lookups which may be reached from them, through (chained) contextual substitutions.
+// Invalid indices are ignored.
+func ClosureLookups(gsub *tt.TableGSUB, lookups []uint16) []uint16 {
+	set := map[uint16]bool{}
+	var visit func(lookupIndex uint16)
+	visit = func(lookupIndex uint16) {
+		if int(lookupIndex) >= len(gsub.Lookups) || set[lookupIndex] {
+			return
+		}
+		set[lookupIndex] = true
+		for _, table := range gsub.Lookups[lookupIndex].Subtables {
+			for _, nested := range nestedLookups(table) {
+				visit(nested.LookupIndex)
+			}
+		}
+	}
+	for _, lookupIndex := range lookups {
+		visit(lookupIndex)
+	}
+	return sortedIndices(set)
+}
+
+// returns the lookups referenced by a contextual subtable
+func nestedLookups(table tt.GSUBSubtable) []tt.SequenceLookup {
+	var out []tt.SequenceLookup
+	switch data := table.Data.(type) {
+	case tt.GSUBContext1:
+		for _, rules := range data {
+			for _, rule := range rules {
+				out = append(out, rule.Lookups...)
+			}
+		}
+	case tt.GSUBContext2:
+		for _, rules := range data.SequenceSets {
+			for _, rule := range rules {
+				out = append(out, rule.Lookups...)
+			}
+		}
+	case tt.GSUBContext3:
+		out = data.SequenceLookups
+	case tt.GSUBChainedContext1:
+		for _, rules := range data {
+			for _, rule := range rules {
+				out = append(out, rule.Lookups...)
+			}
+		}
+	case tt.GSUBChainedContext2:
+		for _, rules := range data.SequenceSets {
+			for _, rule := range rules {
+				out = append(out, rule.Lookups...)
+			}
+		}
+	case tt.GSUBChainedContext3:
+		out = data.SequenceLookups
+	}
+	return out
+}
+
+// LookupsSubstituteClosure adds to `glyphs` all the glyphs which may be
+// produced by the substitutions of the given GSUB `lookups`, when applied on
+// a text using only glyphs from `glyphs`.
+// The nested lookups of contextual substitutions are followed.
+// The closure is an over-approximation : a context is considered
+// as matched as soon as each of its items intersects `glyphs`.
+func LookupsSubstituteClosure(gsub *tt.TableGSUB, lookups []uint16, glyphs map[fonts.GID]struct{}) {
+	c := closureContext{
+		gsub:             gsub,
+		glyphs:           glyphs,
+		output:           map[fonts.GID]struct{}{},
+		doneLookups:      map[uint16]int{},
+		nestingLevelLeft: maxNestingLevel,
+	}
+	for stage := 0; stage < closureMaxStages; stage++ {
+		glyphsLength := len(glyphs)
+		for _, lookupIndex := range lookups {
+			c.closeLookup(lookupIndex)
+			c.flush()
+		}
+		if glyphsLength == len(glyphs) {
+			break
+		}
+	}
+}
+
+type closureContext struct {
+	gsub   *tt.TableGSUB
+	glyphs map[fonts.GID]struct{}
+	// glyphs added while iterating over `glyphs`
+	output map[fonts.GID]struct{}
+	// number of glyphs when the lookup was last visited,
+	// used to avoid infinite recursion
+	doneLookups      map[uint16]int
+	nestingLevelLeft int
+	lookupCount      int
+}
+
+func (c *closureContext) flush() {
+	for g := range c.output {
+		c.glyphs[g] = struct{}{}
+	}
+	c.output = map[fonts.GID]struct{}{}
+}
+
+// returns `true` if the lookup has already been visited with the
+// current set of glyphs, or if too many lookups have been visited
+func (c *closureContext) isLookupDone(lookupIndex uint16) bool {
+	if c.lookupCount > maxLookupVisitCount {
+		return true
+	}
+	c.lookupCount++
+	if count, has := c.doneLookups[lookupIndex]; has && count == len(c.glyphs) {
+		return true
+	}
+	c.doneLookups[lookupIndex] = len(c.glyphs)
+	return false
+}
+
+func (c *closureContext) closeLookup(lookupIndex uint16) {
+	if int(lookupIndex) >= len(c.gsub.Lookups) || c.isLookupDone(lookupIndex) {
+		return
+	}
+	for _, table := range c.gsub.Lookups[lookupIndex].Subtables {
+		c.closeSubtable(table)
+	}
+}
+
+func (c *closureContext) recurse(lookups []tt.SequenceLookup) {
+	if c.nestingLevelLeft == 0 {
+		return
+	}
+	c.nestingLevelLeft--
+	for _, lookup := range lookups {
+		c.closeLookup(lookup.LookupIndex)
+	}
+	c.nestingLevelLeft++
+}
+
+func (c *closureContext) has(glyph fonts.GID) bool {
+	_, has := c.glyphs[glyph]
+	return has
+}
+
+func (c *closureContext) add(glyph fonts.GID) { c.output[glyph] = struct{}{} }
+
+// returns `true` if all the glyphs are in the set
+func (c *closureContext) intersectsGlyphs(glyphs []uint16) bool {
+	for _, g := range glyphs {
+		if !c.has(fonts.GID(g)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *closureContext) intersectsCoverage(cov tt.Coverage) bool {
+	for g := range c.glyphs {
+		if _, ok := cov.Index(g); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// returns `true` if all the coverages intersect the set
+func (c *closureContext) intersectsCoverages(covs []tt.Coverage) bool {
+	for _, cov := range covs {
+		if !c.intersectsCoverage(cov) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns `true` if one glyph of the set has class `klass`;
+// glyphs not covered by `class` have class 0
+func (c *closureContext) intersectsClass(class tt.Class, klass uint16) bool {
+	for g := range c.glyphs {
+		var gClass uint32
+		if class != nil {
+			gClass, _ = class.ClassID(g)
+		}
+		if gClass == uint32(klass) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns `true` if all the classes intersect the set
+func (c *closureContext) intersectsClasses(class tt.Class, classes []uint16) bool {
+	for _, klass := range classes {
+		if !c.intersectsClass(class, klass) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *closureContext) closeSubtable(table tt.GSUBSubtable) {
+	switch data := table.Data.(type) {
+	case tt.GSUBSingle1:
+		for g := range c.glyphs {
+			if _, ok := table.Coverage.Index(g); ok {
+				c.add(fonts.GID(uint16(int(g) + int(data))))
+			}
+		}
+	case tt.GSUBSingle2:
+		for g := range c.glyphs {
+			if index, ok := table.Coverage.Index(g); ok && index < len(data) {
+				c.add(data[index])
+			}
+		}
+	case tt.GSUBMultiple1:
+		for g := range c.glyphs {
+			if index, ok := table.Coverage.Index(g); ok && index < len(data) {
+				for _, sub := range data[index] {
+					c.add(sub)
+				}
+			}
+		}
+	case tt.GSUBAlternate1:
+		for g := range c.glyphs {
+			if index, ok := table.Coverage.Index(g); ok && index < len(data) {
+				for _, sub := range data[index] {
+					c.add(sub)
+				}
+			}
+		}
+	case tt.GSUBLigature1:
+		for g := range c.glyphs {
+			if index, ok := table.Coverage.Index(g); ok && index < len(data) {
+				for _, lig := range data[index] {
+					if c.intersectsGlyphs(lig.Components) {
+						c.add(lig.Glyph)
+					}
+				}
+			}
+		}
+	case tt.GSUBContext1:
+		for g := range c.glyphs {
+			if index, ok := table.Coverage.Index(g); ok && index < len(data) {
+				for _, rule := range data[index] {
+					if c.intersectsGlyphs(rule.Input) {
+						c.recurse(rule.Lookups)
+					}
+				}
+			}
+		}
+	case tt.GSUBContext2:
+		if !c.intersectsCoverage(table.Coverage) {
+			return
+		}
+		for klass, rules := range data.SequenceSets {
+			if !c.intersectsClass(data.Class, uint16(klass)) {
+				continue
+			}
+			for _, rule := range rules {
+				if c.intersectsClasses(data.Class, rule.Input) {
+					c.recurse(rule.Lookups)
+				}
+			}
+		}
+	case tt.GSUBContext3:
+		if c.intersectsCoverages(data.Coverages) {
+			c.recurse(data.SequenceLookups)
+		}
+	case tt.GSUBChainedContext1:
+		for g := range c.glyphs {
+			if index, ok := table.Coverage.Index(g); ok && index < len(data) {
+				for _, rule := range data[index] {
+					if c.intersectsGlyphs(rule.Backtrack) && c.intersectsGlyphs(rule.Input) &&
+						c.intersectsGlyphs(rule.Lookahead) {
+						c.recurse(rule.Lookups)
+					}
+				}
+			}
+		}
+	case tt.GSUBChainedContext2:
+		if !c.intersectsCoverage(table.Coverage) {
+			return
+		}
+		for klass, rules := range data.SequenceSets {
+			if !c.intersectsClass(data.InputClass, uint16(klass)) {
+				continue
+			}
+			for _, rule := range rules {
+				if c.intersectsClasses(data.BacktrackClass, rule.Backtrack) &&
+					c.intersectsClasses(data.InputClass, rule.Input) &&
+					c.intersectsClasses(data.LookaheadClass, rule.Lookahead) {
+					c.recurse(rule.Lookups)
+				}
+			}
+		}
+	case tt.GSUBChainedContext3:
+		if c.intersectsCoverages(data.Backtrack) && c.intersectsCoverages(data.Input) &&
+			c.intersectsCoverages(data.Lookahead) {
+			c.recurse(data.SequenceLookups)
+		}
+	case tt.GSUBReverseChainedContext1:
+		if !c.intersectsCoverages(data.Backtrack) || !c.intersectsCoverages(data.Lookahead) {
+			return
+		}
+		for g := range c.glyphs {
+			if index, ok := table.Coverage.Index(g); ok && index < len(data.Substitutes) {
+				c.add(data.Substitutes[index])
+			}
+		}
+	}
+}
+
+// returns `true` if `tags` is nil or contains `tag`
+func hasTag(tags []tt.Tag, tag tt.Tag) bool {
+	if tags == nil {
+		return true
+	}
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// `indices` is sorted
+func hasIndex(indices []uint16, index uint16) bool {
+	i := sort.Search(len(indices), func(i int) bool { return indices[i] >= index })
+	return i < len(indices) && indices[i] == index
+}
+
+func sortedIndices(set map[uint16]bool) []uint16 {
+	out := make([]uint16, 0, len(set))
+	for index := range set {
+		out = append(out, index)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,82 @@
+package harfbuzz
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/fonts"
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+func TestCollectLookups(t *testing.T) {
+	face := openFontFile("perf_reference/fonts/Amiri-Regular.ttf")
+	gsub := &NewFont(face).otTables.GSUB
+
+	liga := tt.MustNewTag("liga")
+	lookups := CollectLookups(&gsub.TableLayout, nil, nil, []tt.Tag{liga})
+	if exp := []uint16{207}; !reflect.DeepEqual(lookups, exp) {
+		t.Fatalf("expected %v, got %v", exp, lookups)
+	}
+	if got := ClosureLookups(gsub, lookups); !reflect.DeepEqual(got, lookups) {
+		t.Fatalf("expected %v, got %v", lookups, got)
+	}
+
+	// unknown script
+	lookups = CollectLookups(&gsub.TableLayout, []tt.Tag{tt.MustNewTag("xxxx")}, nil, nil)
+	assertEqualInt(t, 0, len(lookups))
+
+	for _, index := range CollectFeatures(&gsub.TableLayout, nil, nil, []tt.Tag{liga}) {
+		assert(t, gsub.Features[index].Tag == liga)
+	}
+}
+
+func TestLookupsSubstituteClosure(t *testing.T) {
+	face := openFontFile("perf_reference/fonts/Amiri-Regular.ttf")
+	gsub := &NewFont(face).otTables.GSUB
+
+	lookups := CollectLookups(&gsub.TableLayout, nil, nil, []tt.Tag{tt.MustNewTag("liga")})
+	f, _ := face.NominalGlyph('f')
+	i, _ := face.NominalGlyph('i')
+	glyphs := map[fonts.GID]struct{}{f: {}, i: {}}
+	LookupsSubstituteClosure(gsub, lookups, glyphs)
+
+	var names []string
+	for _, g := range []fonts.GID{f, i, 781, 782, 784} {
+		_, ok := glyphs[g]
+		assert(t, ok)
+		names = append(names, face.GlyphName(g))
+	}
+	assertEqualInt(t, 5, len(glyphs))
+	if exp := []string{"f", "i", "f_f", "f_i", "f_f_i"}; !reflect.DeepEqual(names, exp) {
+		t.Fatalf("expected %v, got %v", exp, names)
+	}
+}
+
+func TestLookupsSubstituteClosureCycle(t *testing.T) {
+	// lookup 0 recursively calls itself and lookup 1,
+	// which replaces 1 by 2, and 2 by 3
+	gsub := &tt.TableGSUB{
+		Lookups: []tt.LookupGSUB{
+			{Type: tt.GSUBContext, Subtables: []tt.GSUBSubtable{{
+				Coverage: tt.CoverageList{1, 2, 3},
+				Data: tt.GSUBContext3{
+					Coverages:       []tt.Coverage{tt.CoverageList{1, 2, 3}},
+					SequenceLookups: []tt.SequenceLookup{{LookupIndex: 0}, {LookupIndex: 1}},
+				},
+			}}},
+			{Type: tt.GSUBSingle, Subtables: []tt.GSUBSubtable{{
+				Coverage: tt.CoverageList{1, 2},
+				Data:     tt.GSUBSingle1(1),
+			}}},
+		},
+	}
+	glyphs := map[fonts.GID]struct{}{1: {}}
+	LookupsSubstituteClosure(gsub, []uint16{0}, glyphs)
+	if exp := (map[fonts.GID]struct{}{1: {}, 2: {}, 3: {}}); !reflect.DeepEqual(glyphs, exp) {
+		t.Fatalf("expected %v, got %v", exp, glyphs)
+	}
+
+	if exp, got := []uint16{0, 1}, ClosureLookups(gsub, []uint16{0, 5}); !reflect.DeepEqual(exp, got) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+}
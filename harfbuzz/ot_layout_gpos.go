@@ -22,6 +22,7 @@ func positionStartGPOS(buffer *Buffer) {
 	for i := range buffer.Pos {
 		buffer.Pos[i].attachChain = 0
 		buffer.Pos[i].attachType = 0
+		buffer.Pos[i].attachment = 0
 	}
 }
 
@@ -34,10 +35,12 @@ func propagateAttachmentOffsets(pos []GlyphPosition, i int, direction Direction)
 	}
 
 	pos[i].attachChain = 0
+	// kept for the post-shaping adjustments
+	pos[i].attachment = chain
 
 	j := i + int(chain)
 
-	if j >= len(pos) {
+	if j < 0 || j >= len(pos) {
 		return
 	}
 
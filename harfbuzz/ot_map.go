@@ -127,10 +127,13 @@ func (mb *otMapBuilder) enableFeature(tag tt.Tag)  { mb.enableFeatureExt(tag, ff
 func (mb *otMapBuilder) addFeature(tag tt.Tag)     { mb.addFeatureExt(tag, ffNone, 1) }
 func (mb *otMapBuilder) disableFeature(tag tt.Tag) { mb.addFeatureExt(tag, ffGLOBAL, 0) }
 
-func (mb *otMapBuilder) compile(m *otMap, key otShapePlanKey) {
-	const globalBitShift = 8*4 - 1
-	const globalBitMask = 1 << globalBitShift
+// the bit used for the global features, set on all the glyphs
+const (
+	globalBitShift = 8*4 - 1
+	globalBitMask  = 1 << globalBitShift
+)
 
+func (mb *otMapBuilder) compile(m *otMap, key otShapePlanKey) {
 	m.globalMask = globalBitMask
 
 	var (
@@ -73,7 +73,7 @@ func (planner *otShapePlanner) compile(plan *otShapePlan, key otShapePlanKey) {
 	plan.hasFrac = plan.fracMask != 0 || (plan.numrMask != 0 && plan.dnomMask != 0)
 
 	plan.rtlmMask = plan.map_.getMask1(tt.NewTag('r', 't', 'l', 'm'))
-	plan.hasVert = plan.map_.getMask1(tt.NewTag('v', 'e', 'r', 't')) != 0
+	plan.hasVert = plan.map_.getMask1(tt.NewTag('v', 'e', 'r', 't')) != 0 || plan.map_.getMask1(tt.NewTag('v', 'r', 't', '2')) != 0
 
 	kernTag := tt.NewTag('v', 'k', 'r', 'n')
 	if planner.props.Direction.isHorizontal() {
@@ -82,8 +82,6 @@ func (planner *otShapePlanner) compile(plan *otShapePlan, key otShapePlanKey) {
 
 	plan.kernMask, _ = plan.map_.getMask(kernTag)
 	plan.requestedKerning = plan.kernMask != 0
-	plan.trakMask, _ = plan.map_.getMask(tt.NewTag('t', 'r', 'a', 'k'))
-	plan.requestedTracking = plan.trakMask != 0
 
 	hasGposKern := plan.map_.getFeatureIndex(1, kernTag) != NoFeatureIndex
 	disableGpos := plan.shaper.gposTag() != 0 && plan.shaper.gposTag() != plan.map_.chosenScript[1]
@@ -133,9 +131,6 @@ func (planner *otShapePlanner) compile(plan *otShapePlan, key otShapePlanKey) {
 	if plan.applyMorx {
 		plan.adjustMarkPositioningWhenZeroing = false
 	}
-
-	// currently we always apply trak.
-	plan.applyTrak = plan.requestedTracking && !planner.tables.Trak.IsEmpty()
 }
 
 type otShapePlan struct {
@@ -150,10 +145,8 @@ type otShapePlan struct {
 	dnomMask GlyphMask
 	rtlmMask GlyphMask
 	kernMask GlyphMask
-	trakMask GlyphMask
 
 	hasFrac                          bool
-	requestedTracking                bool
 	requestedKerning                 bool
 	hasVert                          bool
 	hasGposMark                      bool
@@ -167,7 +160,6 @@ type otShapePlan struct {
 	applyKern         bool
 	applyKerx         bool
 	applyMorx         bool
-	applyTrak         bool
 }
 
 func (sp *otShapePlan) init0(tables *tt.LayoutTables, props SegmentProperties, userFeatures []Feature, otKey otShapePlanKey) {
@@ -200,10 +192,6 @@ func (sp *otShapePlan) position(font *Font, buffer *Buffer) {
 	} else if sp.applyFallbackKern {
 		sp.otApplyFallbackKern(font, buffer)
 	}
-
-	if sp.applyTrak {
-		sp.aatLayoutTrack(font, buffer)
-	}
 }
 
 var (
@@ -228,6 +216,19 @@ var (
 	}
 )
 
+// verticalFeature returns the feature used to select the vertical alternates,
+// that is 'vert', or 'vrt2' for fonts only providing the latter.
+func (planner *otShapePlanner) verticalFeature() tt.Tag {
+	vert, vrt2 := tt.NewTag('v', 'e', 'r', 't'), tt.NewTag('v', 'r', 't', '2')
+	gsub := &planner.tables.GSUB
+	if _, hasVert := gsub.FindFeatureIndex(vert); !hasVert {
+		if _, hasVrt2 := gsub.FindFeatureIndex(vrt2); hasVrt2 {
+			return vrt2
+		}
+	}
+	return vert
+}
+
 func (planner *otShapePlanner) collectFeatures(userFeatures []Feature) {
 	map_ := &planner.map_
 
@@ -251,11 +252,6 @@ func (planner *otShapePlanner) collectFeatures(userFeatures []Feature) {
 	/* Random! */
 	map_.enableFeatureExt(tt.NewTag('r', 'a', 'n', 'd'), ffRandom, otMapMaxValue)
 
-	/* Tracking.  We enable dummy feature here just to allow disabling
-	* AAT 'trak' table using features.
-	* https://github.com/harfbuzz/harfbuzz/issues/1303 */
-	map_.enableFeatureExt(tt.NewTag('t', 'r', 'a', 'k'), ffHasFallback, 1)
-
 	map_.enableFeature(tt.NewTag('H', 'a', 'r', 'f')) /* Considered required. */
 	map_.enableFeature(tt.NewTag('H', 'A', 'R', 'F')) /* Considered discretionary. */
 
@@ -277,7 +273,7 @@ func (planner *otShapePlanner) collectFeatures(userFeatures []Feature) {
 		 * matter which script/langsys it is listed (or not) under.
 		 * See various bugs referenced from:
 		 * https://github.com/harfbuzz/harfbuzz/issues/63 */
-		map_.enableFeatureExt(tt.NewTag('v', 'e', 'r', 't'), ffGlobalSearch, 1)
+		map_.enableFeatureExt(planner.verticalFeature(), ffGlobalSearch, 1)
 	}
 
 	for _, f := range userFeatures {
@@ -705,6 +701,10 @@ func (c *otContext) position() {
 
 	if c.buffer.Props.Direction.isBackward() {
 		c.buffer.Reverse()
+		// attachments are relative indices
+		for i := range c.buffer.Pos {
+			c.buffer.Pos[i].attachment = -c.buffer.Pos[i].attachment
+		}
 	}
 }
 
@@ -25,9 +25,23 @@ import (
 //
 // It also depends on the properties of the segment of text : the `Props`
 // field of the buffer must be set before calling `Shape`.
+//
+// Finally, the tracking of the font (see `Font.Tracking`) is applied,
+// unless disabled by the 'trak' feature. The optional ligatures
+// are not used for the clusters where tracking is applied.
 func (b *Buffer) Shape(font *Font, features []Feature) {
+	b.grSegment = nil
+	userFeatures := features
+	if font.trackingUnits(b.Props.Direction) != 0 {
+		// optional ligatures are disabled when tracking is applied
+		features = trackingFeatures(features)
+	}
 	shapePlan := newShapePlanCached(font, b.Props, features, font.varCoords())
 	shapePlan.execute(font, b, features)
+	if b.grSegment != nil {
+		b.grSegment.features = userFeatures
+	}
+	b.applyTracking(font, userFeatures)
 }
 
 type shaperKind uint8
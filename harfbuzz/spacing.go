@@ -0,0 +1,225 @@
+package harfbuzz
+
+// This file implements post-shaping adjustments, which
+// preserve the attachments (marks and cursive connections) resolved
+// during shaping. They rely on the order of the shaped buffer, which
+// must not be modified before calling them.
+
+// returns the pen shift in the main direction of the buffer,
+// for an advance increased by `delta`
+func (b *Buffer) penShift(delta Position) (dx, dy Position) {
+	if b.Props.Direction.isHorizontal() {
+		return delta, 0
+	}
+	// vertical advances are negative
+	return 0, -delta
+}
+
+// returns the glyph the glyph at `index` is attached to,
+// or -1 if it is not attached
+func (b *Buffer) attachedTo(index int) int {
+	chain := b.Pos[index].attachment
+	if chain == 0 {
+		return -1
+	}
+	j := index + int(chain)
+	if j < 0 || j >= len(b.Pos) {
+		return -1
+	}
+	return j
+}
+
+// returns the glyph on which the glyph at `index` is positioned,
+// by following its mark attachments, or `index` itself
+func (b *Buffer) markBase(index int) int {
+	for range b.Pos { // protect against cycles
+		j := b.attachedTo(index)
+		if j == -1 || b.Pos[index].attachType&attachTypeMark == 0 {
+			break
+		}
+		index = j
+	}
+	return index
+}
+
+// addAdvances adds deltas[i] to the advance of the glyph i, with the same
+// semantics as AdjustAdvance, but in a single pass over the buffer.
+// `deltas` must have the same length as the buffer.
+func (b *Buffer) addAdvances(deltas []Position) {
+	// shifts[i] is the pen shift applied to the glyph i
+	shifts := make([]Position, len(b.Pos))
+	var shift Position
+	for i, delta := range deltas {
+		shifts[i] = shift
+		if delta == 0 {
+			continue
+		}
+		dx, dy := b.penShift(delta)
+		b.Pos[i].XAdvance += dx
+		b.Pos[i].YAdvance += dy
+		shift += delta
+	}
+
+	// marks keep their position relative to their base glyph
+	for i := range b.Pos {
+		base := b.markBase(i)
+		if base == i || shifts[base] == shifts[i] {
+			continue
+		}
+		dx, dy := b.penShift(shifts[base] - shifts[i])
+		b.Pos[i].XOffset += dx
+		b.Pos[i].YOffset += dy
+	}
+}
+
+// AdjustAdvance adds `delta` to the advance of the glyph at `index`,
+// in the main direction of the (already shaped) buffer, so that positive values
+// always increase the space between glyphs (for vertical text, the YAdvance is decreased).
+//
+// Marks attached to a glyph on the other side of the adjusted advance have their offsets
+// updated, so that they keep their position relative to their base glyph.
+//
+// Since the whole buffer is inspected, adjusting each glyph with AdjustAdvance
+// is quadratic: see AddLetterSpacing for a common adjustment done in one pass.
+func (b *Buffer) AdjustAdvance(index int, delta Position) {
+	dx, dy := b.penShift(delta)
+	b.Pos[index].XAdvance += dx
+	b.Pos[index].YAdvance += dy
+
+	for i := range b.Pos {
+		base := b.markBase(i)
+		if base == i {
+			continue
+		}
+		// the glyphs after `index` are moved by the advance
+		moved, baseMoved := i > index, base > index
+		if moved && !baseMoved {
+			b.Pos[i].XOffset -= dx
+			b.Pos[i].YOffset -= dy
+		} else if !moved && baseMoved {
+			b.Pos[i].XOffset += dx
+			b.Pos[i].YOffset += dy
+		}
+	}
+}
+
+// AdjustOffset moves the glyph at `index` of the (already shaped) buffer by (dx, dy),
+// and propagates the move to the glyphs attached to it, directly or not:
+// marks are moved by (dx, dy), whereas glyphs connected by a cursive attachment
+// are only moved in the cross-stream direction (that is, by dy for horizontal text).
+func (b *Buffer) AdjustOffset(index int, dx, dy Position) {
+	horizontal := b.Props.Direction.isHorizontal()
+	for i := range b.Pos {
+		ix, iy := dx, dy
+		j := i
+		for range b.Pos { // protect against cycles
+			if j == index {
+				break
+			}
+			next := b.attachedTo(j)
+			if next == -1 {
+				break
+			}
+			if b.Pos[j].attachType&attachTypeCursive != 0 {
+				// only the cross-stream offset is propagated
+				if horizontal {
+					ix = 0
+				} else {
+					iy = 0
+				}
+			}
+			j = next
+		}
+		if j != index {
+			continue
+		}
+		b.Pos[i].XOffset += ix
+		b.Pos[i].YOffset += iy
+	}
+}
+
+// returns true if a glyph of one of the ranges is attached
+// to a glyph of the other one
+func (b *Buffer) areAttached(start1, end1, start2, end2 int) bool {
+	isIn := func(i, start, end int) bool { return start <= i && i < end }
+	for i := start1; i < end1; i++ {
+		if isIn(b.attachedTo(i), start2, end2) {
+			return true
+		}
+	}
+	for i := start2; i < end2; i++ {
+		if isIn(b.attachedTo(i), start1, end1) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns true if the cluster [start, end) ends with
+// a letter joining to the following one (in logical order),
+// for the scripts using Arabic joining
+func (b *Buffer) joinsFollowingCluster(start, end int) bool {
+	if !b.Props.Direction.isHorizontal() || !usesKashida(b.Props.Script) {
+		return false
+	}
+	last := b.clusterLastLetter(start, end)
+	return last != -1 && joinsFollowingLetter(&b.Info[last])
+}
+
+// AddLetterSpacing inserts `spacing` after each cluster of the (already shaped) buffer,
+// with the semantics of the CSS `letter-spacing` property: the spacing is added at the end
+// (in logical order) of each cluster, including the last one.
+//
+// No spacing is inserted inside a cluster (and thus inside a ligature), between two clusters
+// linked by an attachment (like a cursive connection), nor between joining letters
+// for scripts using Arabic joining.
+// For vertical text, `spacing` is the amount of space added in the (downward) main direction.
+func (b *Buffer) AddLetterSpacing(spacing Position) {
+	if spacing == 0 || len(b.Pos) == 0 {
+		return
+	}
+	backward := b.Props.Direction.isBackward()
+
+	// the advance deltas are accumulated and applied in one pass
+	deltas := make([]Position, len(b.Pos))
+
+	// clusters in buffer (visual) order
+	var clusters [][2]int
+	iter, count := b.clusterIterator()
+	for start, end := iter.next(); start < count; start, end = iter.next() {
+		clusters = append(clusters, [2]int{start, end})
+	}
+
+	for ci, cluster := range clusters {
+		// find the cluster following `cluster` in logical order
+		next := ci + 1
+		if backward {
+			next = ci - 1
+		}
+		if b.joinsFollowingCluster(cluster[0], cluster[1]) {
+			continue
+		}
+		if 0 <= next && next < len(clusters) && b.areAttached(cluster[0], cluster[1], clusters[next][0], clusters[next][1]) {
+			continue
+		}
+
+		if !backward {
+			deltas[cluster[1]-1] += spacing
+		} else if next >= 0 {
+			// the space is on the visual left (or top) of the cluster
+			deltas[clusters[next][1]-1] += spacing
+		} else {
+			// no glyph precedes the cluster: move all the glyphs instead
+			dx, dy := b.penShift(spacing)
+			for i := range b.Pos {
+				b.Pos[i].XOffset += dx
+				b.Pos[i].YOffset += dy
+			}
+			last := len(b.Pos) - 1
+			b.Pos[last].XAdvance += dx
+			b.Pos[last].YAdvance += dy
+		}
+	}
+
+	b.addAdvances(deltas)
+}
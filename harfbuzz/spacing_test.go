@@ -0,0 +1,91 @@
+package harfbuzz
+
+import (
+	"testing"
+
+	"github.com/benoitkugler/textlayout/language"
+)
+
+// returns the absolute horizontal position of each glyph
+func glyphsXPositions(b *Buffer) []Position {
+	out := make([]Position, len(b.Pos))
+	var pen Position
+	for i, pos := range b.Pos {
+		out[i] = pen + pos.XOffset
+		pen += pos.XAdvance
+	}
+	return out
+}
+
+func TestAdjustAdvanceMarks(t *testing.T) {
+	font := NewFont(openFontFile("perf_reference/fonts/Roboto-Regular.ttf"))
+
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune("ax́b"), 0, -1)
+	buffer.Props.Script = language.Latin
+	buffer.GuessSegmentProperties()
+	buffer.Shape(font, nil)
+	assertEqualInt(t, len(buffer.Pos), 4)
+	// the acute is attached to the x
+	assertEqualInt(t, buffer.attachedTo(2), 1)
+
+	before := glyphsXPositions(buffer)
+	buffer.AdjustAdvance(1, 100)
+	after := glyphsXPositions(buffer)
+	assertEqualInt32(t, after[0], before[0])
+	assertEqualInt32(t, after[1], before[1])
+	assertEqualInt32(t, after[2], before[2]) // the mark stays on its base
+	assertEqualInt32(t, after[3], before[3]+100)
+
+	buffer.AdjustOffset(1, 10, 20)
+	assertEqualInt32(t, glyphsXPositions(buffer)[2], before[2]+10)
+	assertEqualInt32(t, buffer.Pos[1].YOffset, 20)
+	assertEqualInt32(t, buffer.Pos[0].XOffset, 0)
+}
+
+func TestAddLetterSpacing(t *testing.T) {
+	font := NewFont(openFontFile("perf_reference/fonts/Roboto-Regular.ttf"))
+
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune("ax́b"), 0, -1)
+	buffer.Props.Script = language.Latin
+	buffer.GuessSegmentProperties()
+	buffer.Shape(font, nil)
+
+	before := glyphsXPositions(buffer)
+	advance := buffer.lineAdvance()
+	buffer.AddLetterSpacing(50)
+	after := glyphsXPositions(buffer)
+	assertEqualInt32(t, buffer.lineAdvance(), advance+3*50)
+	assertEqualInt32(t, after[1], before[1]+50)
+	assertEqualInt32(t, after[2], before[2]+50) // no spacing inside a cluster
+	assertEqualInt32(t, after[3], before[3]+2*50)
+
+	// vertical text
+	buffer = NewBuffer()
+	buffer.AddRunes([]rune("ab"), 0, -1)
+	buffer.Props = SegmentProperties{Direction: TopToBottom, Script: language.Latin}
+	buffer.Shape(font, nil)
+	advance = buffer.lineAdvance()
+	buffer.AddLetterSpacing(50)
+	assertEqualInt32(t, buffer.lineAdvance(), advance+2*50)
+}
+
+func TestAddLetterSpacingArabic(t *testing.T) {
+	font := NewFont(openFontFileTT("NotoSansArabic.ttf"))
+
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune("بسم"), 0, -1)
+	buffer.GuessSegmentProperties()
+	buffer.Shape(font, nil)
+
+	before := glyphsXPositions(buffer)
+	advance := buffer.lineAdvance()
+	buffer.AddLetterSpacing(50)
+	after := glyphsXPositions(buffer)
+	// the letters are joined: spacing is only added at the end, that is on the left
+	assertEqualInt32(t, buffer.lineAdvance(), advance+50)
+	for i := range before {
+		assertEqualInt32(t, after[i], before[i]+50)
+	}
+}
@@ -0,0 +1,131 @@
+package harfbuzz
+
+import (
+	"sort"
+
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+)
+
+var tagTracking = tt.NewTag('t', 'r', 'a', 'k')
+
+// returns the tracking in font units, see `Tracking`
+func (f *Font) trackingUnits(direction Direction) int {
+	if f.otTables != nil && f.Ptem > 0 {
+		trackData := f.otTables.Trak.Horizontal
+		if direction.isVertical() {
+			trackData = f.otTables.Trak.Vertical
+		}
+		if len(trackData.Entries) != 0 {
+			return int(trackData.GetTracking(f.Ptem, 0))
+		}
+	}
+	return int(f.SyntheticTracking)
+}
+
+// Tracking returns the space added after each cluster of a text laid out
+// in `direction`, and scaled by `XScale` (`YScale` for vertical text).
+// Positive values increase the space between glyphs.
+//
+// The value is read from the normal track of the AAT 'trak' table, for the size `Ptem`
+// (which must be set). If the font has no tracking data for `direction`,
+// `SyntheticTracking` is used instead.
+func (f *Font) Tracking(direction Direction) Position {
+	tracking := f.trackingUnits(direction)
+	if direction.isVertical() {
+		return f.emScalefY(float32(tracking))
+	}
+	return f.emScalefX(float32(tracking))
+}
+
+// returns true if the tracking is not disabled by
+// a 'trak' feature for the given cluster
+func trackingEnabled(features []Feature, cluster int) bool {
+	enabled := true
+	for _, feature := range features {
+		if feature.Tag == tagTracking && feature.Start <= cluster && cluster < feature.End {
+			enabled = feature.Value != 0
+		}
+	}
+	return enabled
+}
+
+// returns the features used to disable the optional ligatures
+// on the cluster ranges where tracking is applied, followed by `features`
+func trackingFeatures(features []Feature) []Feature {
+	// the cluster boundaries where the tracking may be toggled
+	bounds := []int{FeatureGlobalStart}
+	for _, feature := range features {
+		if feature.Tag == tagTracking {
+			bounds = append(bounds, feature.Start, feature.End)
+		}
+	}
+	sort.Ints(bounds)
+
+	var tracked [][2]int // ranges [start, end) of the tracked clusters
+	for i, start := range bounds {
+		end := FeatureGlobalEnd
+		if i+1 < len(bounds) {
+			end = bounds[i+1]
+		}
+		if start >= end || !trackingEnabled(features, start) {
+			continue
+		}
+		if L := len(tracked); L != 0 && tracked[L-1][1] == start {
+			tracked[L-1][1] = end
+		} else {
+			tracked = append(tracked, [2]int{start, end})
+		}
+	}
+	if len(tracked) == 0 {
+		return features
+	}
+
+	// user features come last, so that they have precedence
+	out := make([]Feature, 0, 2*len(tracked)+len(features))
+	for _, ra := range tracked {
+		out = append(out,
+			Feature{Tag: tt.NewTag('l', 'i', 'g', 'a'), Value: 0, Start: ra[0], End: ra[1]},
+			Feature{Tag: tt.NewTag('c', 'l', 'i', 'g'), Value: 0, Start: ra[0], End: ra[1]},
+		)
+	}
+	return append(out, features...)
+}
+
+// applyTracking adds the tracking to each cluster of the shaped buffer,
+// splitting it on both sides of the glyphs.
+// Tracking may be disabled for some clusters using the 'trak' feature.
+//
+// Contrary to the reference implementation, which only adjusts the first glyph
+// of each grapheme, the tracking is added to the advance of the last glyph
+// (in visual order) of each cluster, and all the glyphs of the cluster are moved
+// by half the tracking, so that the glyphs of a cluster (like a base and
+// its marks, or ligature components) keep their relative positions.
+func (b *Buffer) applyTracking(font *Font, features []Feature) {
+	tracking := font.trackingUnits(b.Props.Direction)
+	if tracking == 0 {
+		return
+	}
+	var advanceToAdd, offsetToAdd Position
+	if b.Props.Direction.isVertical() {
+		advanceToAdd, offsetToAdd = font.emScalefY(float32(tracking)), font.emScalefY(float32(tracking/2))
+	} else {
+		advanceToAdd, offsetToAdd = font.emScalefX(float32(tracking)), font.emScalefX(float32(tracking/2))
+	}
+	dx, dy := b.penShift(offsetToAdd)
+	deltas := make([]Position, len(b.Pos))
+
+	// the buffer is in visual order, so that adding the tracking
+	// symmetrically does not depend on the direction
+	iter, count := b.clusterIterator()
+	for start, end := iter.next(); start < count; start, end = iter.next() {
+		if !trackingEnabled(features, b.Info[start].Cluster) {
+			continue
+		}
+		deltas[end-1] = advanceToAdd
+		for i := start; i < end; i++ {
+			b.Pos[i].XOffset += dx
+			b.Pos[i].YOffset += dy
+		}
+	}
+	b.addAdvances(deltas)
+}
@@ -0,0 +1,112 @@
+package harfbuzz
+
+import (
+	"testing"
+
+	tt "github.com/benoitkugler/textlayout/fonts/truetype"
+	"github.com/benoitkugler/textlayout/language"
+)
+
+func TestTrackingTable(t *testing.T) {
+	font := NewFont(openFontFileTT("ToyTrak.ttf"))
+	assertEqualInt32(t, font.Tracking(LeftToRight), 0) // Ptem is not set
+
+	font.Ptem = 1
+	assertEqualInt32(t, font.Tracking(LeftToRight), 200)
+	font.Ptem = 96
+	assertEqualInt32(t, font.Tracking(LeftToRight), -100)
+	// no vertical data
+	assertEqualInt32(t, font.Tracking(TopToBottom), 0)
+	font.SyntheticTracking = 20
+	assertEqualInt32(t, font.Tracking(TopToBottom), 20)
+	assertEqualInt32(t, font.Tracking(LeftToRight), -100)
+}
+
+func shapeString(font *Font, s string, props SegmentProperties, features []Feature) *Buffer {
+	buffer := NewBuffer()
+	buffer.AddRunes([]rune(s), 0, -1)
+	buffer.Props = props
+	buffer.Shape(font, features)
+	return buffer
+}
+
+func TestSyntheticTracking(t *testing.T) {
+	font := NewFont(openFontFile("perf_reference/fonts/Roboto-Regular.ttf"))
+	latin := SegmentProperties{Direction: LeftToRight, Script: language.Latin, Language: language.NewLanguage("en")}
+
+	ref := shapeString(font, "fin", latin, nil)
+	assertEqualInt(t, len(ref.Info), 2) // 'fi' ligature
+
+	font.SyntheticTracking = 100
+	buffer := shapeString(font, "fin", latin, nil)
+	// ligatures are disabled
+	assertEqualInt(t, len(buffer.Info), 3)
+	for _, pos := range buffer.Pos {
+		assertEqualInt32(t, pos.XOffset, 50)
+	}
+	// ligatures may be enabled again
+	buffer = shapeString(font, "fin", latin, []Feature{{Tag: tt.NewTag('l', 'i', 'g', 'a'), Value: 1, Start: FeatureGlobalStart, End: FeatureGlobalEnd}})
+	assertEqualInt(t, len(buffer.Info), 2)
+	assertEqualInt32(t, buffer.lineAdvance(), ref.lineAdvance()+2*100)
+
+	// disable tracking for the second cluster
+	buffer = shapeString(font, "abc", latin, []Feature{{Tag: tagTracking, Value: 0, Start: 1, End: 2}})
+	assertEqualInt32(t, buffer.Pos[0].XOffset, 50)
+	assertEqualInt32(t, buffer.Pos[1].XOffset, 0)
+	assertEqualInt32(t, buffer.Pos[2].XOffset, 50)
+
+	// right to left and vertical text
+	font.SyntheticTracking = 0
+	refRTL := shapeString(font, "abc", SegmentProperties{Direction: RightToLeft, Script: language.Latin}, nil)
+	refTTB := shapeString(font, "abc", SegmentProperties{Direction: TopToBottom, Script: language.Latin}, nil)
+	font.SyntheticTracking = 100
+	buffer = shapeString(font, "abc", SegmentProperties{Direction: RightToLeft, Script: language.Latin}, nil)
+	assertEqualInt32(t, buffer.lineAdvance(), refRTL.lineAdvance()+3*100)
+	buffer = shapeString(font, "abc", SegmentProperties{Direction: TopToBottom, Script: language.Latin}, nil)
+	assertEqualInt32(t, buffer.lineAdvance(), refTTB.lineAdvance()+3*100)
+	for i, pos := range buffer.Pos {
+		assertEqualInt32(t, pos.YOffset, refTTB.Pos[i].YOffset-50)
+	}
+}
+
+func TestTrackingLigatures(t *testing.T) {
+	font := NewFont(openFontFile("perf_reference/fonts/Roboto-Regular.ttf"))
+	font.SyntheticTracking = 100
+	latin := SegmentProperties{Direction: LeftToRight, Script: language.Latin, Language: language.NewLanguage("en")}
+
+	// tracking disabled for the whole text: ligatures are kept
+	buffer := shapeString(font, "fin", latin, []Feature{{Tag: tagTracking, Value: 0, Start: FeatureGlobalStart, End: FeatureGlobalEnd}})
+	assertEqualInt(t, len(buffer.Info), 2)
+	for _, pos := range buffer.Pos {
+		assertEqualInt32(t, pos.XOffset, 0)
+	}
+
+	// tracking disabled for the first clusters only
+	buffer = shapeString(font, "fifi", latin, []Feature{{Tag: tagTracking, Value: 0, Start: 0, End: 2}})
+	assertEqualInt(t, len(buffer.Info), 3)
+	assertEqualInt(t, buffer.Info[0].Cluster, 0)
+	assertEqualInt(t, buffer.Info[1].Cluster, 2)
+	assertEqualInt(t, buffer.Info[2].Cluster, 3)
+}
+
+func TestTrackingCluster(t *testing.T) {
+	font := NewFont(openFontFile("perf_reference/fonts/Roboto-Regular.ttf"))
+	latin := SegmentProperties{Direction: LeftToRight, Script: language.Latin, Language: language.NewLanguage("en")}
+	const text = "x́a" // no precomposed glyph for the first cluster
+
+	ref := shapeString(font, text, latin, nil)
+	assertEqualInt(t, len(ref.Info), 3)
+	assertEqualInt(t, ref.Info[1].Cluster, 0)
+
+	font.SyntheticTracking = 100
+	buffer := shapeString(font, text, latin, nil)
+	assertEqualInt(t, len(buffer.Info), 3)
+	// the tracking is added after the last glyph of the cluster,
+	// and the whole cluster is centered
+	for i, pos := range buffer.Pos {
+		assertEqualInt32(t, pos.XOffset, ref.Pos[i].XOffset+50)
+	}
+	assertEqualInt32(t, buffer.Pos[0].XAdvance, ref.Pos[0].XAdvance)
+	assertEqualInt32(t, buffer.Pos[1].XAdvance, ref.Pos[1].XAdvance+100)
+	assertEqualInt32(t, buffer.Pos[2].XAdvance, ref.Pos[2].XAdvance+100)
+}
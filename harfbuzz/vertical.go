@@ -0,0 +1,85 @@
+package harfbuzz
+
+import (
+	"unicode"
+
+	ucd "github.com/benoitkugler/textlayout/unicodedata"
+)
+
+// VerticalRun is a part of a vertical text, whose
+// runes share the same orientation.
+type VerticalRun struct {
+	// Props are the properties to use when shaping the run.
+	// Their direction is vertical for upright runs, and
+	// horizontal for sideways runs.
+	Props SegmentProperties
+
+	// Start and End are the indices of the run in the input text,
+	// End being excluded.
+	Start, End int
+
+	// Sideways is true if the run is laid out horizontally,
+	// in which case the resulting glyphs must be rotated
+	// by 90 degrees clockwise when rendering.
+	Sideways bool
+}
+
+// SplitVerticalRun splits `text`, which is laid out in the vertical direction
+// `props.Direction`, into runs of upright and sideways runes, according to
+// the Unicode Vertical_Orientation property (see https://www.unicode.org/reports/tr50/).
+// Upright runs keep `props`, and will be shaped with the vertical features
+// (such as 'vert'), whereas sideways runs use the horizontal direction of the script
+// (reversed for `BottomToTop`).
+// Combining marks, joiners and variation selectors follow the orientation of their base.
+// An horizontal direction is not supported and returns a single run.
+func SplitVerticalRun(text []rune, props SegmentProperties) []VerticalRun {
+	if len(text) == 0 {
+		return nil
+	}
+	if !props.Direction.isVertical() {
+		return []VerticalRun{{Props: props, Start: 0, End: len(text)}}
+	}
+
+	sidewaysProps := props
+	sidewaysProps.Direction = getHorizontalDirection(props.Script)
+	if sidewaysProps.Direction == 0 {
+		sidewaysProps.Direction = LeftToRight
+	}
+	if props.Direction.isBackward() {
+		sidewaysProps.Direction = sidewaysProps.Direction.Reverse()
+	}
+
+	var (
+		out     []VerticalRun
+		current = VerticalRun{Sideways: !ucd.LookupVerticalOrientation(text[0]).IsUpright()}
+	)
+	for i, r := range text[1:] {
+		if isOrientationExtender(r) {
+			continue
+		}
+		sideways := !ucd.LookupVerticalOrientation(r).IsUpright()
+		if sideways != current.Sideways {
+			current.End = i + 1
+			out = append(out, current)
+			current = VerticalRun{Start: i + 1, Sideways: sideways}
+		}
+	}
+	current.End = len(text)
+	out = append(out, current)
+
+	for i, run := range out {
+		if run.Sideways {
+			out[i].Props = sidewaysProps
+		} else {
+			out[i].Props = props
+		}
+	}
+	return out
+}
+
+// returns true for the runes which do not start a new
+// orientation run
+func isOrientationExtender(r rune) bool {
+	return unicode.Is(unicode.M, r) || unicode.Is(unicode.Variation_Selector, r) ||
+		r == 0x200D // Zero Width Joiner
+}
@@ -0,0 +1,37 @@
+package harfbuzz
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/benoitkugler/textlayout/language"
+)
+
+func TestSplitVerticalRun(t *testing.T) {
+	props := SegmentProperties{Direction: TopToBottom, Script: language.Han}
+	sideways := SegmentProperties{Direction: LeftToRight, Script: language.Han}
+
+	text := []rune("日本語「ABĆ」2021年")
+	runs := SplitVerticalRun(text, props)
+	expected := []VerticalRun{
+		{Props: props, Start: 0, End: 4},
+		{Props: sideways, Start: 4, End: 8, Sideways: true},
+		{Props: props, Start: 8, End: 9},
+		{Props: sideways, Start: 9, End: 13, Sideways: true},
+		{Props: props, Start: 13, End: 14},
+	}
+	if !reflect.DeepEqual(runs, expected) {
+		t.Fatalf("expected %v, got %v", expected, runs)
+	}
+
+	props.Direction = BottomToTop
+	runs = SplitVerticalRun([]rune("ab"), props)
+	assertEqualInt(t, len(runs), 1)
+	assert(t, runs[0].Sideways && runs[0].Props.Direction == RightToLeft)
+
+	runs = SplitVerticalRun(text, sideways)
+	assertEqualInt(t, len(runs), 1)
+	assert(t, !runs[0].Sideways && runs[0].End == len(text))
+
+	assert(t, SplitVerticalRun(nil, props) == nil)
+}
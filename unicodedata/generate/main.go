@@ -60,7 +60,7 @@ func main() {
 	mirrors, err := parseMirroring(b)
 	check(err)
 
-	dms, compEx := parseXML("ucd.nounihan.grouped.zip")
+	dms, compEx, verticalOrientations := parseXML("ucd.nounihan.grouped.zip")
 
 	b, err = ioutil.ReadFile("ArabicShaping.txt")
 	check(err)
@@ -163,6 +163,9 @@ func main() {
 	process("../sentenceBreak.go", func(w io.Writer) {
 		generateSTermProperty(sentenceBreaks, w)
 	})
+	process("../verticalOrientation.go", func(w io.Writer) {
+		generateVerticalOrientation(verticalOrientations, w)
+	})
 	process("../../language/scripts_table.go", func(w io.Writer) {
 		generateScriptLookupTable(scriptsRanges, scriptNames, w)
 	})
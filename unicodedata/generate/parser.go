@@ -251,6 +251,7 @@ type group struct {
 	Dm        string `xml:"dm,attr"`
 	Dt        string `xml:"dt,attr"`
 	CompEx    string `xml:"Comp_Ex,attr"`
+	Vo        string `xml:"vo,attr"`
 	Chars     []char `xml:"char"`
 	Reserved  []char `xml:"reserved"`
 	NonChar   []char `xml:"noncharacter"`
@@ -264,9 +265,12 @@ type char struct {
 	Dm      string `xml:"dm,attr"`
 	Dt      string `xml:"dt,attr"`
 	CompEx  string `xml:"Comp_Ex,attr"`
+	Vo      string `xml:"vo,attr"`
 }
 
-func parseXML(filename string) (map[rune][]rune, map[rune]bool) {
+// parseXML returns the canonical decompositions, the composition exclusions
+// and the runes for each Vertical_Orientation value
+func parseXML(filename string) (map[rune][]rune, map[rune]bool, map[string][]rune) {
 	f, err := zip.OpenReader(filename)
 	check(err)
 	if len(f.File) != 1 {
@@ -289,8 +293,26 @@ func parseXML(filename string) (map[rune][]rune, map[rune]bool) {
 
 	dms := map[rune][]rune{}
 	compEx := map[rune]bool{}
+	vos := map[string][]rune{}
 	handleRunes := func(l []char, gr group) {
 		for _, ch := range l {
+			if ch.Vo == "" {
+				ch.Vo = gr.Vo
+			}
+			if ch.Cp != "" {
+				ru, err := strconv.ParseInt(ch.Cp, 16, 32)
+				check(err)
+				vos[ch.Vo] = append(vos[ch.Vo], rune(ru))
+			} else {
+				firstRune, err := strconv.ParseInt(ch.FirstCp, 16, 32)
+				check(err)
+				lastRune, err := strconv.ParseInt(ch.LastCp, 16, 32)
+				check(err)
+				for ru := firstRune; ru <= lastRune; ru++ {
+					vos[ch.Vo] = append(vos[ch.Vo], rune(ru))
+				}
+			}
+
 			if ch.Dm == "" {
 				ch.Dm = gr.Dm
 			}
@@ -340,7 +362,7 @@ func parseXML(filename string) (map[rune][]rune, map[rune]bool) {
 		delete(dms, rune(i))
 	}
 
-	return dms, compEx
+	return dms, compEx, vos
 }
 
 // return the joining type and joining group
@@ -378,6 +378,23 @@ func generateSTermProperty(datas map[string][]rune, w io.Writer) {
 	fmt.Fprintf(w, "var %s = %s\n\n", className, s)
 }
 
+var verticalOrientations = [...][2]string{
+	{"U", "Upright"},
+	{"Tu", "TransformedUpright"},
+	{"Tr", "TransformedRotated"},
+}
+
+// the default value R is not stored
+func generateVerticalOrientation(datas map[string][]rune, w io.Writer) {
+	fmt.Fprint(w, header)
+	for _, class := range verticalOrientations {
+		table := rangetable.New(datas[class[0]]...)
+		s := printTable(table, false)
+		fmt.Fprintf(w, "// Vertical_Orientation: %s\n", class[0])
+		fmt.Fprintf(w, "var Vertical%s = %s\n\n", class[1], s)
+	}
+}
+
 func generateEmojisTest(sequences [][]rune, w io.Writer) {
 	fmt.Fprintln(w, `package harfbuzz
 
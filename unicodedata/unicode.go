@@ -57,6 +57,45 @@ func LookupBreakClass(ch rune) *unicode.RangeTable {
 	return BreakXX
 }
 
+// VerticalOrientation is the Vertical_Orientation property of a rune,
+// as defined by https://www.unicode.org/reports/tr50/,
+// which gives its default orientation in vertical text.
+type VerticalOrientation uint8
+
+const (
+	// Displayed sideways, rotated 90 degrees clockwise (R)
+	OrientationRotated VerticalOrientation = iota
+	// Displayed upright, with the same glyph as in horizontal text (U)
+	OrientationUpright
+	// Displayed upright, with a specific vertical glyph if available,
+	// falling back to the horizontal glyph (Tu)
+	OrientationTransformedUpright
+	// Displayed upright, with a specific vertical glyph if available,
+	// falling back to the rotated horizontal glyph (Tr)
+	OrientationTransformedRotated
+)
+
+// IsUpright returns `true` if the rune should be laid out
+// upright in vertical text, that is for all the values but `OrientationRotated`.
+// Note that runes with `OrientationTransformedRotated` rely on the font
+// providing a vertical alternate glyph.
+func (vo VerticalOrientation) IsUpright() bool { return vo != OrientationRotated }
+
+// LookupVerticalOrientation returns the Vertical_Orientation property of the rune,
+// defaulting to `OrientationRotated`.
+func LookupVerticalOrientation(ch rune) VerticalOrientation {
+	switch {
+	case unicode.Is(VerticalUpright, ch):
+		return OrientationUpright
+	case unicode.Is(VerticalTransformedUpright, ch):
+		return OrientationTransformedUpright
+	case unicode.Is(VerticalTransformedRotated, ch):
+		return OrientationTransformedRotated
+	default:
+		return OrientationRotated
+	}
+}
+
 // LookupMirrorChar finds the mirrored equivalent of a character as defined in
 // the file BidiMirroring.txt of the Unicode Character Database available at
 // http://www.unicode.org/Public/UNIDATA/BidiMirroring.txt.
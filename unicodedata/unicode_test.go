@@ -77,3 +77,28 @@ func TestUnicodeNormalization(t *testing.T) {
 	assertDecompose(0xCE31, true, 0xCE20, 0x11B8)
 	assertDecompose(0xCE20, true, 0x110E, 0x1173)
 }
+
+func TestVerticalOrientation(t *testing.T) {
+	for _, test := range []struct {
+		r        rune
+		expected VerticalOrientation
+	}{
+		{'a', OrientationRotated},
+		{'1', OrientationRotated},
+		{'中', OrientationUpright},
+		{'あ', OrientationUpright},
+		{'한', OrientationUpright},
+		{'。', OrientationTransformedUpright},
+		{'ぁ', OrientationTransformedUpright},
+		{'「', OrientationTransformedRotated},
+		{'ー', OrientationTransformedRotated},
+		{0x1F600, OrientationUpright}, // emoji
+	} {
+		if got := LookupVerticalOrientation(test.r); got != test.expected {
+			t.Errorf("rune %U: expected %d, got %d", test.r, test.expected, got)
+		}
+	}
+	if LookupVerticalOrientation('a').IsUpright() || !LookupVerticalOrientation('「').IsUpright() {
+		t.Error("unexpected IsUpright")
+	}
+}
@@ -0,0 +1,169 @@
+package unicodedata
+
+import "unicode"
+
+// Code generated by generate/main.go DO NOT EDIT.
+
+// Vertical_Orientation: U
+var VerticalUpright = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00a7, Hi: 0x00a9, Stride: 2},
+		{Lo: 0x00ae, Hi: 0x00b1, Stride: 3},
+		{Lo: 0x00bc, Hi: 0x00be, Stride: 1},
+		{Lo: 0x00d7, Hi: 0x00f7, Stride: 32},
+		{Lo: 0x02ea, Hi: 0x02eb, Stride: 1},
+		{Lo: 0x1100, Hi: 0x11ff, Stride: 1},
+		{Lo: 0x1401, Hi: 0x167f, Stride: 1},
+		{Lo: 0x18b0, Hi: 0x18ff, Stride: 1},
+		{Lo: 0x2016, Hi: 0x2020, Stride: 10},
+		{Lo: 0x2021, Hi: 0x2030, Stride: 15},
+		{Lo: 0x2031, Hi: 0x203b, Stride: 10},
+		{Lo: 0x203c, Hi: 0x2042, Stride: 6},
+		{Lo: 0x2047, Hi: 0x2049, Stride: 1},
+		{Lo: 0x2051, Hi: 0x2065, Stride: 20},
+		{Lo: 0x20dd, Hi: 0x20e0, Stride: 1},
+		{Lo: 0x20e2, Hi: 0x20e4, Stride: 1},
+		{Lo: 0x2100, Hi: 0x2101, Stride: 1},
+		{Lo: 0x2103, Hi: 0x2109, Stride: 1},
+		{Lo: 0x210f, Hi: 0x2113, Stride: 4},
+		{Lo: 0x2114, Hi: 0x2116, Stride: 2},
+		{Lo: 0x2117, Hi: 0x211e, Stride: 7},
+		{Lo: 0x211f, Hi: 0x2123, Stride: 1},
+		{Lo: 0x2125, Hi: 0x2129, Stride: 2},
+		{Lo: 0x212e, Hi: 0x2135, Stride: 7},
+		{Lo: 0x2136, Hi: 0x213f, Stride: 1},
+		{Lo: 0x2145, Hi: 0x214a, Stride: 1},
+		{Lo: 0x214c, Hi: 0x214d, Stride: 1},
+		{Lo: 0x214f, Hi: 0x2189, Stride: 1},
+		{Lo: 0x218c, Hi: 0x218f, Stride: 1},
+		{Lo: 0x221e, Hi: 0x2234, Stride: 22},
+		{Lo: 0x2235, Hi: 0x2300, Stride: 203},
+		{Lo: 0x2301, Hi: 0x2307, Stride: 1},
+		{Lo: 0x230c, Hi: 0x231f, Stride: 1},
+		{Lo: 0x2324, Hi: 0x2328, Stride: 1},
+		{Lo: 0x232b, Hi: 0x237d, Stride: 82},
+		{Lo: 0x237e, Hi: 0x239a, Stride: 1},
+		{Lo: 0x23be, Hi: 0x23cd, Stride: 1},
+		{Lo: 0x23cf, Hi: 0x23d1, Stride: 2},
+		{Lo: 0x23d2, Hi: 0x23db, Stride: 1},
+		{Lo: 0x23e2, Hi: 0x2422, Stride: 1},
+		{Lo: 0x2424, Hi: 0x24ff, Stride: 1},
+		{Lo: 0x25a0, Hi: 0x2619, Stride: 1},
+		{Lo: 0x2620, Hi: 0x2767, Stride: 1},
+		{Lo: 0x2776, Hi: 0x2793, Stride: 1},
+		{Lo: 0x2b12, Hi: 0x2b2f, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b59, Stride: 1},
+		{Lo: 0x2b97, Hi: 0x2bb8, Stride: 33},
+		{Lo: 0x2bb9, Hi: 0x2bd1, Stride: 1},
+		{Lo: 0x2bd3, Hi: 0x2beb, Stride: 1},
+		{Lo: 0x2bf0, Hi: 0x2bff, Stride: 1},
+		{Lo: 0x2e50, Hi: 0x2e51, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x3000, Stride: 1},
+		{Lo: 0x3003, Hi: 0x3007, Stride: 1},
+		{Lo: 0x3012, Hi: 0x3013, Stride: 1},
+		{Lo: 0x3020, Hi: 0x302f, Stride: 1},
+		{Lo: 0x3031, Hi: 0x3040, Stride: 1},
+		{Lo: 0x3042, Hi: 0x304a, Stride: 2},
+		{Lo: 0x304b, Hi: 0x3062, Stride: 1},
+		{Lo: 0x3064, Hi: 0x3082, Stride: 1},
+		{Lo: 0x3084, Hi: 0x3088, Stride: 2},
+		{Lo: 0x3089, Hi: 0x308d, Stride: 1},
+		{Lo: 0x308f, Hi: 0x3094, Stride: 1},
+		{Lo: 0x3097, Hi: 0x309a, Stride: 1},
+		{Lo: 0x309d, Hi: 0x309f, Stride: 1},
+		{Lo: 0x30a2, Hi: 0x30aa, Stride: 2},
+		{Lo: 0x30ab, Hi: 0x30c2, Stride: 1},
+		{Lo: 0x30c4, Hi: 0x30e2, Stride: 1},
+		{Lo: 0x30e4, Hi: 0x30e8, Stride: 2},
+		{Lo: 0x30e9, Hi: 0x30ed, Stride: 1},
+		{Lo: 0x30ef, Hi: 0x30f4, Stride: 1},
+		{Lo: 0x30f7, Hi: 0x30fb, Stride: 1},
+		{Lo: 0x30fd, Hi: 0x3126, Stride: 1},
+		{Lo: 0x3128, Hi: 0x31ef, Stride: 1},
+		{Lo: 0x3200, Hi: 0x32fe, Stride: 1},
+		{Lo: 0x3358, Hi: 0x337a, Stride: 1},
+		{Lo: 0x3380, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xa960, Hi: 0xa97f, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7ff, Stride: 1},
+		{Lo: 0xe000, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe10, Hi: 0xfe1f, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe48, Stride: 1},
+		{Lo: 0xfe53, Hi: 0xfe57, Stride: 1},
+		{Lo: 0xfe5f, Hi: 0xfe62, Stride: 1},
+		{Lo: 0xfe67, Hi: 0xfe6f, Stride: 1},
+		{Lo: 0xff02, Hi: 0xff07, Stride: 1},
+		{Lo: 0xff0a, Hi: 0xff0b, Stride: 1},
+		{Lo: 0xff0f, Hi: 0xff19, Stride: 1},
+		{Lo: 0xff20, Hi: 0xff3a, Stride: 1},
+		{Lo: 0xff3c, Hi: 0xff40, Stride: 2},
+		{Lo: 0xff41, Hi: 0xff5a, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe2, Stride: 1},
+		{Lo: 0xffe4, Hi: 0xffe7, Stride: 1},
+		{Lo: 0xfff0, Hi: 0xfff8, Stride: 1},
+		{Lo: 0xfffc, Hi: 0xfffd, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x10980, Hi: 0x1099f, Stride: 1},
+		{Lo: 0x11580, Hi: 0x115ff, Stride: 1},
+		{Lo: 0x11a00, Hi: 0x11aaf, Stride: 1},
+		{Lo: 0x13000, Hi: 0x1343f, Stride: 1},
+		{Lo: 0x14400, Hi: 0x1467f, Stride: 1},
+		{Lo: 0x16fe0, Hi: 0x18d8f, Stride: 1},
+		{Lo: 0x1b000, Hi: 0x1b2ff, Stride: 1},
+		{Lo: 0x1d000, Hi: 0x1d1ff, Stride: 1},
+		{Lo: 0x1d2e0, Hi: 0x1d37f, Stride: 1},
+		{Lo: 0x1d800, Hi: 0x1daaf, Stride: 1},
+		{Lo: 0x1f000, Hi: 0x1f1ff, Stride: 1},
+		{Lo: 0x1f202, Hi: 0x1f7ff, Stride: 1},
+		{Lo: 0x1f900, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x2fffd, Stride: 1},
+		{Lo: 0x30000, Hi: 0x3fffd, Stride: 1},
+		{Lo: 0xf0000, Hi: 0xffffd, Stride: 1},
+		{Lo: 0x100000, Hi: 0x10fffd, Stride: 1},
+	},
+	LatinOffset: 4,
+}
+
+// Vertical_Orientation: Tu
+var VerticalTransformedUpright = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x3001, Hi: 0x3002, Stride: 1},
+		{Lo: 0x3041, Hi: 0x3049, Stride: 2},
+		{Lo: 0x3063, Hi: 0x3083, Stride: 32},
+		{Lo: 0x3085, Hi: 0x3087, Stride: 2},
+		{Lo: 0x308e, Hi: 0x3095, Stride: 7},
+		{Lo: 0x3096, Hi: 0x309b, Stride: 5},
+		{Lo: 0x309c, Hi: 0x30a1, Stride: 5},
+		{Lo: 0x30a3, Hi: 0x30a9, Stride: 2},
+		{Lo: 0x30c3, Hi: 0x30e3, Stride: 32},
+		{Lo: 0x30e5, Hi: 0x30e7, Stride: 2},
+		{Lo: 0x30ee, Hi: 0x30f5, Stride: 7},
+		{Lo: 0x30f6, Hi: 0x3127, Stride: 49},
+		{Lo: 0x31f0, Hi: 0x31ff, Stride: 1},
+		{Lo: 0x32ff, Hi: 0x3357, Stride: 1},
+		{Lo: 0x337b, Hi: 0x337f, Stride: 1},
+		{Lo: 0xfe50, Hi: 0xfe52, Stride: 1},
+		{Lo: 0xff01, Hi: 0xff0c, Stride: 11},
+		{Lo: 0xff0e, Hi: 0xff1f, Stride: 17},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f200, Hi: 0x1f201, Stride: 1},
+	},
+}
+
+// Vertical_Orientation: Tr
+var VerticalTransformedRotated = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x2329, Hi: 0x232a, Stride: 1},
+		{Lo: 0x3008, Hi: 0x3011, Stride: 1},
+		{Lo: 0x3014, Hi: 0x301f, Stride: 1},
+		{Lo: 0x3030, Hi: 0x30a0, Stride: 112},
+		{Lo: 0x30fc, Hi: 0xfe59, Stride: 52573},
+		{Lo: 0xfe5a, Hi: 0xfe5e, Stride: 1},
+		{Lo: 0xff08, Hi: 0xff09, Stride: 1},
+		{Lo: 0xff1a, Hi: 0xff1b, Stride: 1},
+		{Lo: 0xff3b, Hi: 0xff3f, Stride: 2},
+		{Lo: 0xff5b, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe3, Hi: 0xffe3, Stride: 1},
+	},
+}